@@ -12,8 +12,12 @@ import (
 func main() {
 	router := gin.Default()
 	handlers := handlers.NewHandler()
+	auditMiddleware := middleware.NewAuditMiddleware(handlers.AuditSnapshot)
 	middleware := middleware.NewAuthMiddleware()
 
+	// Start delivering audit events the broker missed in background
+	go auditMiddleware.RunRetry()
+
 	auth := router.Group("api/v1/auth")
 	{
 		auth.POST("/register", handlers.RegisterUser)
@@ -32,15 +36,47 @@ func main() {
 		// User routes
 		api.GET("/profile", handlers.GetUserProfile)
 		api.PUT("/profile", handlers.UpdateUserProfile)
+
+		// Notification preference routes
+		api.GET("/notifications/preferences", handlers.GetNotificationPreferences)
+		api.PUT("/notifications/preferences", handlers.UpdateNotificationPreferences)
 		
 		// Product routes for authenticated users
 		api.GET("/products", handlers.ListProducts)
+		api.GET("/products/search", handlers.SearchProducts)
 		api.GET("/products/:id", handlers.GetProduct)
+		api.GET("/products/:id/price-history", handlers.GetPriceHistory)
+		api.GET("/products/:id/reviews", handlers.ListReviews)
+		api.POST("/products/:id/reviews", handlers.CreateReview)
+		api.GET("/categories", handlers.ListCategories)
+		api.GET("/categories/:id", handlers.GetCategory)
+
+		// Order routes
+		api.POST("/orders", handlers.CreateOrder)
+		api.GET("/orders", handlers.ListOrders)
+		api.GET("/orders/:id", handlers.GetOrder)
+
+		// Coupon routes
+		api.POST("/coupons/evaluate", handlers.EvaluateCoupon)
+	}
+
+	// Cart routes work for anonymous sessions too; checkout needs a login
+	cart := router.Group("api/v1/cart")
+	cart.Use(middleware.OptionalAuthMiddleware())
+	{
+		cart.GET("", handlers.GetCart)
+		cart.DELETE("", handlers.ClearCart)
+		cart.POST("/items", handlers.AddCartItem)
+		cart.PUT("/items/:productId", handlers.UpdateCartItem)
+		cart.DELETE("/items/:productId", handlers.RemoveCartItem)
+		cart.POST("/checkout", handlers.CheckoutCart)
 	}
 
 	// Admin-only routes
 	admin := router.Group("api/v1/admin")
 	admin.Use(middleware.AuthMiddleware(true))
+	// Audit runs before the role check so denied attempts are recorded too
+	admin.Use(auditMiddleware.Audit())
 	admin.Use(middleware.RequireRole("admin"))
 	{
 		// User management
@@ -49,10 +85,52 @@ func main() {
 		
 		// Product management
 		admin.POST("/products", handlers.CreateProduct)
+		admin.POST("/products/import", handlers.ImportProducts)
+		admin.GET("/products/export", handlers.ExportProducts)
 		admin.PUT("/products/:id", handlers.UpdateProduct)
+		admin.PATCH("/products/:id", handlers.PatchProduct)
 		admin.DELETE("/products/:id", handlers.DeleteProduct)
+		admin.GET("/products/deleted", handlers.ListDeletedProducts)
+		admin.POST("/products/:id/restore", handlers.RestoreProduct)
+		admin.GET("/products/:id/history", handlers.GetProductHistory)
+		admin.PUT("/products/:id/stock", handlers.AdjustStock)
+		admin.PUT("/products/:id/categories", handlers.SetProductCategories)
+		admin.PUT("/products/:id/tags", handlers.SetProductTags)
+		admin.POST("/products/:id/images", handlers.UploadProductImage)
+		admin.DELETE("/products/:id/images/:imageId", handlers.DeleteProductImage)
+		admin.POST("/products/:id/variants", handlers.CreateVariant)
+		admin.PUT("/products/:id/variants/:variantId", handlers.UpdateVariant)
+		admin.DELETE("/products/:id/variants/:variantId", handlers.DeleteVariant)
+		admin.GET("/products/:id/price-schedules", handlers.ListPriceSchedules)
+		admin.POST("/products/:id/price-schedules", handlers.CreatePriceSchedule)
+		admin.DELETE("/products/:id/price-schedules/:scheduleId", handlers.DeletePriceSchedule)
+		admin.GET("/products/:id/reviews", handlers.ListAllReviews)
+
+		// Review moderation
+		admin.PUT("/reviews/:id", handlers.ModerateReview)
+		admin.DELETE("/reviews/:id", handlers.DeleteReview)
+
+		// Category management
+		admin.POST("/categories", handlers.CreateCategory)
+		admin.PUT("/categories/:id", handlers.UpdateCategory)
+		admin.DELETE("/categories/:id", handlers.DeleteCategory)
+
+		// Payments
+		admin.GET("/payments/:id", handlers.GetPayment)
+
+		// Coupons
+		admin.POST("/coupons", handlers.CreateCoupon)
+		admin.GET("/coupons", handlers.ListCoupons)
+		admin.GET("/coupons/:id", handlers.GetCoupon)
+		admin.DELETE("/coupons/:id", handlers.DeactivateCoupon)
+
+		// Audit log
+		admin.GET("/audit", handlers.ListAuditEvents)
 	}
 
+	// Product images are public so storefronts can embed them
+	router.GET("/media/*filepath", handlers.GetMedia)
+
 	// health and ready
 	router.GET("/health", handlers.Health)
 
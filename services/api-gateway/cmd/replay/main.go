@@ -0,0 +1,64 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/lucas/gokafka/api-gateway/internal/recorder"
+	"github.com/lucas/gokafka/shared/utils"
+)
+
+// replay resends a recorded session against the services and prints how the
+// new replies differ from the recorded ones. Redacted fields are replayed as
+// "[REDACTED]", so requests that depend on them (e.g. login) will differ.
+func main() {
+	dir := flag.String("dir", utils.GetEnvOrDefault("RECORD_DIR", "recordings"), "directory holding recorded sessions")
+	session := flag.String("session", "", "session ID to replay (default: all sessions)")
+	requestType := flag.String("type", "", "only replay requests of this type")
+	topic := flag.String("topic", "api-gateway-topic", "topic the services consume requests from")
+	broker := flag.String("broker", utils.GetEnvOrDefault("KAFKA_BROKERS", "localhost:9092"), "Kafka broker address")
+	timeout := flag.Duration("timeout", 10*time.Second, "time to wait for each reply")
+	ignore := flag.String("ignore", strings.Join(recorder.DefaultIgnoreFields, ","), "comma-separated fields excluded from the diff")
+	flag.Parse()
+
+	entries, err := recorder.ReadEntries(*dir, *session)
+	if err != nil {
+		log.Fatalf("Failed to load recordings: %v", err)
+	}
+
+	replayer := recorder.NewReplayer(*broker, *topic, *timeout, strings.Split(*ignore, ","))
+	defer replayer.Close()
+
+	var replayed, mismatched, failed int
+	for _, entry := range entries {
+		if *requestType != "" && entry.Request.Type != *requestType {
+			continue
+		}
+		replayed++
+
+		result := replayer.Replay(entry)
+		switch {
+		case result.Err != nil:
+			failed++
+			fmt.Printf("FAIL  %s %s: %v\n", entry.Request.Type, entry.Request.CorrelationID, result.Err)
+		case len(result.Diffs) > 0:
+			mismatched++
+			fmt.Printf("DIFF  %s %s\n", entry.Request.Type, entry.Request.CorrelationID)
+			for _, diff := range result.Diffs {
+				fmt.Printf("      %s\n", diff)
+			}
+		default:
+			fmt.Printf("OK    %s %s\n", entry.Request.Type, entry.Request.CorrelationID)
+		}
+	}
+
+	fmt.Printf("\nreplayed %d requests: %d matched, %d differed, %d failed\n",
+		replayed, replayed-mismatched-failed, mismatched, failed)
+	if mismatched > 0 || failed > 0 {
+		os.Exit(1)
+	}
+}
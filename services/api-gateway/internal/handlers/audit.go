@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	sharedModels "github.com/lucas/gokafka/shared/models"
+)
+
+// AuditSnapshot fetches the current state of an admin target so the audit
+// trail can record what a change replaced
+func (h *Handler) AuditSnapshot(targetType, targetID string) string {
+	var req SendRequest
+	switch targetType {
+	case "products":
+		id, err := strconv.Atoi(targetID)
+		if err != nil {
+			return ""
+		}
+		req = SendRequest{
+			Type:    "get-product-by-id",
+			Payload: sharedModels.GetProductRequest{ID: id},
+			Key:     "product-get",
+			ReplyTo: "product-service-topic",
+		}
+	case "categories":
+		id, err := strconv.Atoi(targetID)
+		if err != nil {
+			return ""
+		}
+		req = SendRequest{
+			Type:    "get-category",
+			Payload: sharedModels.GetCategoryRequest{ID: id},
+			Key:     "category-get",
+			ReplyTo: "product-service-topic",
+		}
+	case "reviews":
+		id, err := strconv.Atoi(targetID)
+		if err != nil {
+			return ""
+		}
+		req = SendRequest{
+			Type:    "get-review",
+			Payload: sharedModels.GetReviewRequest{ID: id},
+			Key:     "review-get",
+			ReplyTo: "product-service-topic",
+		}
+	case "coupons":
+		id, err := strconv.Atoi(targetID)
+		if err != nil {
+			return ""
+		}
+		req = SendRequest{
+			Type:    "get-coupon",
+			Payload: sharedModels.GetCouponRequest{ID: id},
+			Key:     "coupon-get",
+			ReplyTo: "promotion-service-topic",
+		}
+	case "users":
+		req = SendRequest{
+			Type:    "get-by-id",
+			Payload: sharedModels.GetProfileRequest{ID: targetID},
+			Key:     "user-get",
+			ReplyTo: "user-service-topic",
+		}
+	default:
+		return ""
+	}
+	req.Timeout = 5 * time.Second
+
+	messaging := NewMessagingService(h)
+	resp, err := messaging.SendAndWait(req)
+	if err != nil || !resp.Success {
+		return ""
+	}
+
+	// Unwrap {"status": ..., "data": ...} envelopes to the entity itself
+	var envelope struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(resp.Data), &envelope); err == nil && len(envelope.Data) > 0 {
+		return string(envelope.Data)
+	}
+	return resp.Data
+}
+
+// ListAuditEvents handles listing audit events with optional filters
+func (h *Handler) ListAuditEvents(c *gin.Context) {
+	limit := 0
+	if limitStr := c.Query("limit"); limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+	}
+
+	for _, param := range []string{"from", "to"} {
+		if value := c.Query(param); value != "" {
+			if _, err := time.Parse(time.RFC3339, value); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + param + " timestamp, expected RFC3339"})
+				return
+			}
+		}
+	}
+
+	req := sharedModels.ListAuditEventsRequest{
+		ActorID:    c.Query("actor_id"),
+		Action:     c.Query("action"),
+		TargetType: c.Query("target_type"),
+		TargetID:   c.Query("target_id"),
+		Outcome:    c.Query("outcome"),
+		From:       c.Query("from"),
+		To:         c.Query("to"),
+		Cursor:     c.Query("cursor"),
+		Limit:      limit,
+	}
+
+	// Send request to audit service
+	messaging := NewMessagingService(h)
+	resp, err := messaging.SendAndWait(SendRequest{
+		Type:    "list-audit-events",
+		Payload: req,
+		Key:     "audit-list",
+		ReplyTo: "audit-service-topic",
+		Timeout: 10 * time.Second,
+	})
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	responseHandler := NewResponseHandler(c)
+	responseHandler.HandleServiceResponse(resp, "Audit events retrieved successfully")
+}
@@ -90,6 +90,9 @@ func (h *Handler) LoginUser(c *gin.Context) {
 			return
 		}
 
+		// Carry over anything added to the cart before logging in
+		h.mergeCartOnLogin(c, loginResponse.Data.ID)
+
 		c.JSON(http.StatusOK, gin.H{
 			"message": "Login successful",
 			"token":   loginResponse.Token,
@@ -0,0 +1,289 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	sharedModels "github.com/lucas/gokafka/shared/models"
+)
+
+// CartSessionHeader carries the anonymous cart session. The gateway issues a
+// new session in the response when a request has neither a token nor a
+// session, and merges the session's cart into the user's cart on login.
+const CartSessionHeader = "X-Cart-Session"
+
+// checkoutTimeout covers cart pricing plus order creation
+const checkoutTimeout = 30 * time.Second
+
+// cartSession returns the caller's valid cart session ID, if any
+func cartSession(c *gin.Context) string {
+	sessionID := c.GetHeader(CartSessionHeader)
+	if _, err := uuid.Parse(sessionID); err != nil {
+		return ""
+	}
+	return sessionID
+}
+
+// cartOwner identifies whose cart a request targets: the authenticated user,
+// or else the anonymous session, which is created when missing
+func cartOwner(c *gin.Context) (userID string, sessionID string) {
+	if id, ok := c.Get("user_id"); ok {
+		if userIDStr, isString := id.(string); isString && userIDStr != "" {
+			return userIDStr, ""
+		}
+	}
+
+	sessionID = cartSession(c)
+	if sessionID == "" {
+		sessionID = uuid.NewString()
+	}
+	c.Header(CartSessionHeader, sessionID)
+	return "", sessionID
+}
+
+// cartVariantID reads the optional variant_id query parameter that picks
+// the line of a product's variant; 0 means the product itself
+func cartVariantID(c *gin.Context) (int, bool) {
+	value := c.Query("variant_id")
+	if value == "" {
+		return 0, true
+	}
+	variantID, err := strconv.Atoi(value)
+	if err != nil || variantID <= 0 {
+		return 0, false
+	}
+	return variantID, true
+}
+
+// GetCart returns the caller's cart at current prices
+func (h *Handler) GetCart(c *gin.Context) {
+	// Initialize helper services
+	respHandler := NewResponseHandler(c)
+	messaging := NewMessagingService(h)
+
+	userID, sessionID := cartOwner(c)
+
+	// Send request to cart service
+	resp, err := messaging.SendAndWait(SendRequest{
+		Type:    "get-cart",
+		Payload: sharedModels.CartRequest{UserID: userID, SessionID: sessionID},
+		Key:     "cart-get",
+		ReplyTo: "cart-service-topic",
+	})
+	if err != nil {
+		respHandler.HandleError(http.StatusInternalServerError, "Failed to send message to cart service", err.Error())
+		return
+	}
+
+	respHandler.HandleServiceResponse(resp, "Cart retrieved successfully")
+}
+
+// AddCartItem adds a product to the caller's cart
+func (h *Handler) AddCartItem(c *gin.Context) {
+	// Initialize helper services
+	validator := NewValidator(c)
+	respHandler := NewResponseHandler(c)
+	messaging := NewMessagingService(h)
+
+	// Parse and validate request
+	var itemReq sharedModels.CartItemRequest
+	if err := validator.BindJSON(&itemReq); err != nil {
+		return
+	}
+	if itemReq.ProductID <= 0 {
+		respHandler.HandleError(http.StatusBadRequest, "product_id is required")
+		return
+	}
+	if itemReq.Quantity == 0 {
+		itemReq.Quantity = 1
+	}
+
+	itemReq.UserID, itemReq.SessionID = cartOwner(c)
+
+	// Send request to cart service
+	resp, err := messaging.SendAndWait(SendRequest{
+		Type:    "add-cart-item",
+		Payload: itemReq,
+		Key:     "cart-add-item",
+		ReplyTo: "cart-service-topic",
+	})
+	if err != nil {
+		respHandler.HandleError(http.StatusInternalServerError, "Failed to send message to cart service", err.Error())
+		return
+	}
+
+	respHandler.HandleServiceResponse(resp, "Item added to cart")
+}
+
+// UpdateCartItem sets the quantity of a product in the caller's cart, or of
+// the variant named by the variant_id query parameter
+func (h *Handler) UpdateCartItem(c *gin.Context) {
+	// Initialize helper services
+	validator := NewValidator(c)
+	respHandler := NewResponseHandler(c)
+	messaging := NewMessagingService(h)
+
+	// Parse product ID from URL parameter
+	productID, err := strconv.Atoi(c.Param("productId"))
+	if err != nil {
+		respHandler.HandleError(http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+	variantID, ok := cartVariantID(c)
+	if !ok {
+		respHandler.HandleError(http.StatusBadRequest, "Invalid variant ID")
+		return
+	}
+
+	// Parse and validate request
+	var updateData struct {
+		Quantity int `json:"quantity"`
+	}
+	if err := validator.BindJSON(&updateData); err != nil {
+		return
+	}
+
+	itemReq := sharedModels.CartItemRequest{ProductID: productID, VariantID: variantID, Quantity: updateData.Quantity}
+	itemReq.UserID, itemReq.SessionID = cartOwner(c)
+
+	// Send request to cart service
+	resp, err := messaging.SendAndWait(SendRequest{
+		Type:    "update-cart-item",
+		Payload: itemReq,
+		Key:     "cart-update-item",
+		ReplyTo: "cart-service-topic",
+	})
+	if err != nil {
+		respHandler.HandleError(http.StatusInternalServerError, "Failed to send message to cart service", err.Error())
+		return
+	}
+
+	respHandler.HandleServiceResponse(resp, "Cart item updated")
+}
+
+// RemoveCartItem removes a product, or the variant named by the variant_id
+// query parameter, from the caller's cart
+func (h *Handler) RemoveCartItem(c *gin.Context) {
+	// Initialize helper services
+	respHandler := NewResponseHandler(c)
+	messaging := NewMessagingService(h)
+
+	// Parse product ID from URL parameter
+	productID, err := strconv.Atoi(c.Param("productId"))
+	if err != nil {
+		respHandler.HandleError(http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+	variantID, ok := cartVariantID(c)
+	if !ok {
+		respHandler.HandleError(http.StatusBadRequest, "Invalid variant ID")
+		return
+	}
+
+	itemReq := sharedModels.CartItemRequest{ProductID: productID, VariantID: variantID}
+	itemReq.UserID, itemReq.SessionID = cartOwner(c)
+
+	// Send request to cart service
+	resp, err := messaging.SendAndWait(SendRequest{
+		Type:    "remove-cart-item",
+		Payload: itemReq,
+		Key:     "cart-remove-item",
+		ReplyTo: "cart-service-topic",
+	})
+	if err != nil {
+		respHandler.HandleError(http.StatusInternalServerError, "Failed to send message to cart service", err.Error())
+		return
+	}
+
+	respHandler.HandleServiceResponse(resp, "Item removed from cart")
+}
+
+// ClearCart empties the caller's cart
+func (h *Handler) ClearCart(c *gin.Context) {
+	// Initialize helper services
+	respHandler := NewResponseHandler(c)
+	messaging := NewMessagingService(h)
+
+	userID, sessionID := cartOwner(c)
+
+	// Send request to cart service
+	resp, err := messaging.SendAndWait(SendRequest{
+		Type:    "clear-cart",
+		Payload: sharedModels.CartRequest{UserID: userID, SessionID: sessionID},
+		Key:     "cart-clear",
+		ReplyTo: "cart-service-topic",
+	})
+	if err != nil {
+		respHandler.HandleError(http.StatusInternalServerError, "Failed to send message to cart service", err.Error())
+		return
+	}
+
+	respHandler.HandleServiceResponse(resp, "Cart cleared")
+}
+
+// CheckoutCart places an order for the contents of the caller's cart
+func (h *Handler) CheckoutCart(c *gin.Context) {
+	// Initialize helper services
+	validator := NewValidator(c)
+	respHandler := NewResponseHandler(c)
+	messaging := NewMessagingService(h)
+
+	// Checkout needs an account; anonymous carts are merged on login
+	userID, ok := c.Get("user_id")
+	userIDStr, isString := userID.(string)
+	if !ok || !isString {
+		respHandler.HandleError(http.StatusUnauthorized, "Login required to check out")
+		return
+	}
+
+	// Parse and validate request
+	var checkoutReq sharedModels.CheckoutCartRequest
+	if err := validator.BindJSON(&checkoutReq); err != nil {
+		return
+	}
+	checkoutReq.UserID = userIDStr
+
+	// Send request to cart service
+	resp, err := messaging.SendAndWait(SendRequest{
+		Type:    "checkout-cart",
+		Payload: checkoutReq,
+		Key:     "cart-checkout",
+		ReplyTo: "cart-service-topic",
+		Timeout: checkoutTimeout,
+	})
+	if err != nil {
+		respHandler.HandleError(http.StatusInternalServerError, "Failed to send message to cart service", err.Error())
+		return
+	}
+
+	respHandler.HandleServiceResponse(resp, "Order accepted")
+}
+
+// mergeCartOnLogin moves the caller's anonymous cart into their user cart.
+// Failures are logged only; they must not break the login.
+func (h *Handler) mergeCartOnLogin(c *gin.Context, userID string) {
+	sessionID := cartSession(c)
+	if sessionID == "" {
+		return
+	}
+
+	messaging := NewMessagingService(h)
+	resp, err := messaging.SendAndWait(SendRequest{
+		Type:    "merge-cart",
+		Payload: sharedModels.MergeCartRequest{UserID: userID, SessionID: sessionID},
+		Key:     "cart-merge",
+		ReplyTo: "cart-service-topic",
+		Timeout: 5 * time.Second,
+	})
+	if err != nil {
+		log.Printf("Failed to merge cart for user %s: %v", userID, err)
+		return
+	}
+	if !resp.Success {
+		log.Printf("Failed to merge cart for user %s: %s", userID, resp.Error)
+	}
+}
@@ -0,0 +1,236 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	sharedModels "github.com/lucas/gokafka/shared/models"
+)
+
+// Category handlers
+
+// ListCategories handles listing all categories. Clients build the tree
+// from each category's parent_id.
+func (h *Handler) ListCategories(c *gin.Context) {
+	// Send request to product service
+	messaging := NewMessagingService(h)
+	resp, err := messaging.SendAndWait(SendRequest{
+		Type:    "list-categories",
+		Payload: "",
+		Key:     "category-list",
+		ReplyTo: "product-service-topic",
+		Timeout: 10 * time.Second,
+	})
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	responseHandler := NewResponseHandler(c)
+	responseHandler.HandleServiceResponse(resp, "Categories retrieved successfully")
+}
+
+// GetCategory handles getting a category by ID
+func (h *Handler) GetCategory(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category ID"})
+		return
+	}
+
+	// Send request to product service
+	messaging := NewMessagingService(h)
+	resp, err := messaging.SendAndWait(SendRequest{
+		Type:    "get-category",
+		Payload: sharedModels.GetCategoryRequest{ID: id},
+		Key:     "category-get",
+		ReplyTo: "product-service-topic",
+		Timeout: 10 * time.Second,
+	})
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	responseHandler := NewResponseHandler(c)
+	responseHandler.HandleServiceResponse(resp, "Category retrieved successfully")
+}
+
+// CreateCategory handles category creation
+func (h *Handler) CreateCategory(c *gin.Context) {
+	// Parse and validate request
+	var req sharedModels.CreateCategoryRequest
+	validator := NewValidator(c)
+	if err := validator.BindJSON(&req); err != nil {
+		return
+	}
+
+	if err := validator.ValidateRequired(map[string]interface{}{
+		"name": req.Name,
+	}); err != nil {
+		return
+	}
+
+	// Send request to product service
+	messaging := NewMessagingService(h)
+	resp, err := messaging.SendAndWait(SendRequest{
+		Type:    "create-category",
+		Payload: req,
+		Key:     "category-create",
+		ReplyTo: "product-service-topic",
+		Timeout: 10 * time.Second,
+	})
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	responseHandler := NewResponseHandler(c)
+	responseHandler.HandleServiceResponse(resp, "Category created successfully")
+}
+
+// UpdateCategory handles renaming and moving a category. A null or missing
+// parent_id moves it to the top level.
+func (h *Handler) UpdateCategory(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category ID"})
+		return
+	}
+
+	// Parse and validate request
+	var req sharedModels.UpdateCategoryRequest
+	validator := NewValidator(c)
+	if err := validator.BindJSON(&req); err != nil {
+		return
+	}
+	req.ID = id
+
+	if err := validator.ValidateRequired(map[string]interface{}{
+		"name": req.Name,
+	}); err != nil {
+		return
+	}
+
+	// Send request to product service
+	messaging := NewMessagingService(h)
+	resp, err := messaging.SendAndWait(SendRequest{
+		Type:    "update-category",
+		Payload: req,
+		Key:     "category-update",
+		ReplyTo: "product-service-topic",
+		Timeout: 10 * time.Second,
+	})
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	responseHandler := NewResponseHandler(c)
+	responseHandler.HandleServiceResponse(resp, "Category updated successfully")
+}
+
+// DeleteCategory handles deleting a category without subcategories
+func (h *Handler) DeleteCategory(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category ID"})
+		return
+	}
+
+	// Send request to product service
+	messaging := NewMessagingService(h)
+	resp, err := messaging.SendAndWait(SendRequest{
+		Type:    "delete-category",
+		Payload: sharedModels.DeleteCategoryRequest{ID: id},
+		Key:     "category-delete",
+		ReplyTo: "product-service-topic",
+		Timeout: 10 * time.Second,
+	})
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	responseHandler := NewResponseHandler(c)
+	responseHandler.HandleServiceResponse(resp, "Category deleted successfully")
+}
+
+// SetProductCategories handles replacing the categories of a product
+func (h *Handler) SetProductCategories(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	// Parse and validate request
+	var req sharedModels.SetProductCategoriesRequest
+	validator := NewValidator(c)
+	if err := validator.BindJSON(&req); err != nil {
+		return
+	}
+	req.ID = id
+
+	// Send request to product service
+	messaging := NewMessagingService(h)
+	resp, err := messaging.SendAndWait(SendRequest{
+		Type:    "set-product-categories",
+		Payload: req,
+		Key:     "product-categories",
+		ReplyTo: "product-service-topic",
+		Timeout: 10 * time.Second,
+		Actor:   c.GetString("user_id"),
+	})
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	responseHandler := NewResponseHandler(c)
+	responseHandler.HandleServiceResponse(resp, "Product categories updated successfully")
+}
+
+// SetProductTags handles replacing the tags of a product
+func (h *Handler) SetProductTags(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	// Parse and validate request
+	var req sharedModels.SetProductTagsRequest
+	validator := NewValidator(c)
+	if err := validator.BindJSON(&req); err != nil {
+		return
+	}
+	req.ID = id
+
+	// Send request to product service
+	messaging := NewMessagingService(h)
+	resp, err := messaging.SendAndWait(SendRequest{
+		Type:    "set-product-tags",
+		Payload: req,
+		Key:     "product-tags",
+		ReplyTo: "product-service-topic",
+		Timeout: 10 * time.Second,
+		Actor:   c.GetString("user_id"),
+	})
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	responseHandler := NewResponseHandler(c)
+	responseHandler.HandleServiceResponse(resp, "Product tags updated successfully")
+}
@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	sharedModels "github.com/lucas/gokafka/shared/models"
+)
+
+// evaluateCouponTimeout covers the product lookups promotion-service makes
+// before it replies
+const evaluateCouponTimeout = 15 * time.Second
+
+// EvaluateCoupon prices items for the caller and shows what a coupon would
+// take off, without redeeming it
+func (h *Handler) EvaluateCoupon(c *gin.Context) {
+	// Initialize helper services
+	validator := NewValidator(c)
+	respHandler := NewResponseHandler(c)
+	messaging := NewMessagingService(h)
+
+	// Get user ID from context (set by middleware)
+	userID, ok := c.Get("user_id")
+	userIDStr, isString := userID.(string)
+	if !ok || !isString {
+		respHandler.HandleError(http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	// Parse and validate request
+	var evaluateReq sharedModels.EvaluateCouponRequest
+	if err := validator.BindJSON(&evaluateReq); err != nil {
+		return
+	}
+	if err := validator.ValidateRequired(map[string]interface{}{
+		"code": evaluateReq.Code,
+	}); err != nil {
+		return
+	}
+	if len(evaluateReq.Items) == 0 {
+		respHandler.HandleError(http.StatusBadRequest, "items is required")
+		return
+	}
+
+	// Per-user limits apply to the authenticated user
+	evaluateReq.UserID = userIDStr
+
+	// Send request to promotion service
+	resp, err := messaging.SendAndWait(SendRequest{
+		Type:    "evaluate-coupon",
+		Payload: evaluateReq,
+		Key:     "coupon-evaluate",
+		ReplyTo: "promotion-service-topic",
+		Timeout: evaluateCouponTimeout,
+	})
+	if err != nil {
+		respHandler.HandleError(http.StatusInternalServerError, "Failed to send message to promotion service", err.Error())
+		return
+	}
+
+	respHandler.HandleServiceResponse(resp, "Coupon evaluated successfully")
+}
+
+// Coupon management handlers
+
+// CreateCoupon handles creating a coupon
+func (h *Handler) CreateCoupon(c *gin.Context) {
+	// Parse and validate request
+	var req sharedModels.CreateCouponRequest
+	validator := NewValidator(c)
+	if err := validator.BindJSON(&req); err != nil {
+		return
+	}
+
+	if err := validator.ValidateRequired(map[string]interface{}{
+		"code": req.Code,
+		"type": req.Type,
+	}); err != nil {
+		return
+	}
+
+	// Send request to promotion service
+	messaging := NewMessagingService(h)
+	resp, err := messaging.SendAndWait(SendRequest{
+		Type:    "create-coupon",
+		Payload: req,
+		Key:     "coupon-create",
+		ReplyTo: "promotion-service-topic",
+		Timeout: 10 * time.Second,
+		Actor:   c.GetString("user_id"),
+	})
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	responseHandler := NewResponseHandler(c)
+	responseHandler.HandleServiceResponse(resp, "Coupon created successfully")
+}
+
+// ListCoupons handles listing coupons; ?active=true leaves out deactivated
+// ones
+func (h *Handler) ListCoupons(c *gin.Context) {
+	activeOnly := false
+	if active := c.Query("active"); active != "" {
+		var err error
+		activeOnly, err = strconv.ParseBool(active)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid active filter"})
+			return
+		}
+	}
+
+	// Send request to promotion service
+	messaging := NewMessagingService(h)
+	resp, err := messaging.SendAndWait(SendRequest{
+		Type:    "list-coupons",
+		Payload: sharedModels.ListCouponsRequest{ActiveOnly: activeOnly},
+		Key:     "coupon-list",
+		ReplyTo: "promotion-service-topic",
+		Timeout: 10 * time.Second,
+	})
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	responseHandler := NewResponseHandler(c)
+	responseHandler.HandleServiceResponse(resp, "Coupons retrieved successfully")
+}
+
+// GetCoupon handles getting a coupon with its redemption count
+func (h *Handler) GetCoupon(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid coupon ID"})
+		return
+	}
+
+	// Send request to promotion service
+	messaging := NewMessagingService(h)
+	resp, err := messaging.SendAndWait(SendRequest{
+		Type:    "get-coupon",
+		Payload: sharedModels.GetCouponRequest{ID: id},
+		Key:     "coupon-get",
+		ReplyTo: "promotion-service-topic",
+		Timeout: 10 * time.Second,
+	})
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	responseHandler := NewResponseHandler(c)
+	responseHandler.HandleServiceResponse(resp, "Coupon retrieved successfully")
+}
+
+// DeactivateCoupon handles deactivating a coupon. Coupons are never
+// deleted so past orders keep pointing at them.
+func (h *Handler) DeactivateCoupon(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid coupon ID"})
+		return
+	}
+
+	// Send request to promotion service
+	messaging := NewMessagingService(h)
+	resp, err := messaging.SendAndWait(SendRequest{
+		Type:    "deactivate-coupon",
+		Payload: sharedModels.DeactivateCouponRequest{ID: id},
+		Key:     "coupon-deactivate",
+		ReplyTo: "promotion-service-topic",
+		Timeout: 10 * time.Second,
+		Actor:   c.GetString("user_id"),
+	})
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	responseHandler := NewResponseHandler(c)
+	responseHandler.HandleServiceResponse(resp, "Coupon deactivated successfully")
+}
@@ -4,11 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
+	"net/http/httputil"
 	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/lucas/gokafka/api-gateway/internal/cache"
+	"github.com/lucas/gokafka/api-gateway/internal/recorder"
 	"github.com/lucas/gokafka/shared/utils"
 	"github.com/segmentio/kafka-go"
 )
@@ -19,6 +21,8 @@ type Handler struct {
 	responseChans map[string]chan []byte
 	mu            sync.Mutex
 	blacklist     *cache.TokenBlacklist
+	recorder      *recorder.Recorder
+	productProxy  *httputil.ReverseProxy
 }
 
 func NewHandler() *Handler {
@@ -38,6 +42,42 @@ func NewHandler() *Handler {
 			Topic:   "product-service-topic",
 			GroupID: "api-gateway-group",
 		}),
+		// notification-service
+		kafka.NewReader(kafka.ReaderConfig{
+			Brokers: []string{broker},
+			Topic:   "notification-service-topic",
+			GroupID: "api-gateway-group",
+		}),
+		// audit-service
+		kafka.NewReader(kafka.ReaderConfig{
+			Brokers: []string{broker},
+			Topic:   "audit-service-topic",
+			GroupID: "api-gateway-group",
+		}),
+		// order-service
+		kafka.NewReader(kafka.ReaderConfig{
+			Brokers: []string{broker},
+			Topic:   "order-service-topic",
+			GroupID: "api-gateway-group",
+		}),
+		// cart-service
+		kafka.NewReader(kafka.ReaderConfig{
+			Brokers: []string{broker},
+			Topic:   "cart-service-topic",
+			GroupID: "api-gateway-group",
+		}),
+		// payment-service
+		kafka.NewReader(kafka.ReaderConfig{
+			Brokers: []string{broker},
+			Topic:   "payment-service-topic",
+			GroupID: "api-gateway-group",
+		}),
+		// promotion-service
+		kafka.NewReader(kafka.ReaderConfig{
+			Brokers: []string{broker},
+			Topic:   "promotion-service-topic",
+			GroupID: "api-gateway-group",
+		}),
 		// add new readers here
 	}
 	h := &Handler{
@@ -49,6 +89,8 @@ func NewHandler() *Handler {
 		readers:       readers,
 		responseChans: make(map[string]chan []byte),
 		blacklist:     cache.NewTokenBlacklist(),
+		recorder:      recorder.NewRecorder(),
+		productProxy:  newProductProxy(),
 	}
 	for _, reader := range h.readers {
 		go h.listenResponses(reader)
@@ -80,7 +122,7 @@ func (h *Handler) listenResponses(reader *kafka.Reader) {
 
 func (h *Handler) Health(c *gin.Context) {
 	// Send health check to all the services
-	services := []string{"user-service", "product-service"} // add new services here
+	services := []string{"user-service", "product-service", "notification-service", "audit-service", "order-service", "cart-service", "payment-service", "promotion-service"} // add new services here
 	var wg sync.WaitGroup
 	responses := make([]map[string]interface{}, len(services))
 	errors := make([]string, len(services))
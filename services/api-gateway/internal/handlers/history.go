@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	sharedModels "github.com/lucas/gokafka/shared/models"
+)
+
+// GetProductHistory handles listing a product's changes for admins, newest
+// first. Pages are requested with limit and cursor, like ListProducts.
+func (h *Handler) GetProductHistory(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	limit := 0
+	if limitStr := c.Query("limit"); limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+	}
+
+	req := sharedModels.ProductHistoryRequest{
+		ID:     id,
+		Limit:  limit,
+		Cursor: c.Query("cursor"),
+	}
+
+	messaging := NewMessagingService(h)
+	resp, err := messaging.SendAndWait(SendRequest{
+		Type:    "get-product-history",
+		Payload: req,
+		Key:     "product-history",
+		ReplyTo: "product-service-topic",
+		Timeout: 10 * time.Second,
+	})
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	responseHandler := NewResponseHandler(c)
+	responseHandler.HandleServiceResponse(resp, "Product history retrieved successfully")
+}
+
+// GetPriceHistory handles a product's price over time. The interval query
+// parameter picks day, week or month buckets; from and to bound the range
+// and take RFC3339 timestamps or dates.
+func (h *Handler) GetPriceHistory(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	req := sharedModels.PriceHistoryRequest{
+		ID:       id,
+		Interval: c.Query("interval"),
+		From:     c.Query("from"),
+		To:       c.Query("to"),
+	}
+
+	messaging := NewMessagingService(h)
+	resp, err := messaging.SendAndWait(SendRequest{
+		Type:    "get-price-history",
+		Payload: req,
+		Key:     "product-price-history",
+		ReplyTo: "product-service-topic",
+		Timeout: 10 * time.Second,
+	})
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	responseHandler := NewResponseHandler(c)
+	responseHandler.HandleServiceResponse(resp, "Price history retrieved successfully")
+}
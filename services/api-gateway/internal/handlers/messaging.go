@@ -9,6 +9,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/lucas/gokafka/api-gateway/internal/recorder"
 	sharedModels "github.com/lucas/gokafka/shared/models"
 	"github.com/segmentio/kafka-go"
 )
@@ -32,6 +33,7 @@ type SendRequest struct {
 	Key     string      // Kafka message key
 	ReplyTo string      // Topic to reply to
 	Timeout time.Duration
+	Actor   string // ID of the user the request is made for, if the service records it
 }
 
 // SendResponse represents the response from a Kafka request
@@ -40,6 +42,7 @@ type SendResponse struct {
 	Success       bool
 	Data          string
 	Error         string
+	Code          string
 }
 
 // SendAndWait sends a Kafka message and waits for a response
@@ -72,10 +75,19 @@ func (ms *MessagingService) SendAndWait(req SendRequest) (*SendResponse, error)
 		CorrelationID: correlationID,
 		ReplyTo:       req.ReplyTo,
 		Payload:       string(payloadBytes),
+		Actor:         req.Actor,
 	}
 
 	log.Printf("Sending message with correlationID: %s and type: %s", correlationID, req.Type)
 
+	// Record the exchange when traffic recording is enabled
+	startedAt := time.Now()
+	entry := recorder.Entry{RecordedAt: startedAt.UTC(), Key: req.Key, Request: kafkaReq}
+	defer func() {
+		entry.DurationMs = time.Since(startedAt).Milliseconds()
+		ms.handler.recorder.Record(entry)
+	}()
+
 	// Send message to service via Kafka
 	reqBytes, _ := json.Marshal(kafkaReq)
 	err = ms.handler.writer.WriteMessages(context.Background(),
@@ -85,6 +97,7 @@ func (ms *MessagingService) SendAndWait(req SendRequest) (*SendResponse, error)
 		},
 	)
 	if err != nil {
+		entry.Error = err.Error()
 		return nil, fmt.Errorf("failed to send message: %w", err)
 	}
 
@@ -99,17 +112,21 @@ func (ms *MessagingService) SendAndWait(req SendRequest) (*SendResponse, error)
 	case resp := <-replyChan:
 		var respObj sharedModels.Response
 		if err := json.Unmarshal(resp, &respObj); err != nil {
+			entry.Error = err.Error()
 			return nil, fmt.Errorf("invalid response format: %w", err)
 		}
+		entry.Response = &respObj
 
 		return &SendResponse{
 			CorrelationID: respObj.CorrelationID,
 			Success:       respObj.Success,
 			Data:          respObj.Data,
 			Error:         respObj.Error,
+			Code:          respObj.Code,
 		}, nil
 
 	case <-time.After(timeout):
+		entry.Error = "timeout waiting for response from service"
 		return nil, fmt.Errorf("timeout waiting for response from service")
 	}
 }
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	sharedModels "github.com/lucas/gokafka/shared/models"
+)
+
+// GetNotificationPreferences returns the caller's notification preferences
+func (h *Handler) GetNotificationPreferences(c *gin.Context) {
+	// Initialize helper services
+	respHandler := NewResponseHandler(c)
+	messaging := NewMessagingService(h)
+
+	// Get user ID from context (set by middleware)
+	userID, ok := c.Get("user_id")
+	userIDStr, isString := userID.(string)
+	if !ok || !isString {
+		respHandler.HandleError(http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	// Send request to notification service
+	resp, err := messaging.SendAndWait(SendRequest{
+		Type:    "get-notification-preferences",
+		Payload: sharedModels.GetNotificationPreferencesRequest{UserID: userIDStr},
+		Key:     "notification-preferences-get",
+		ReplyTo: "notification-service-topic",
+	})
+	if err != nil {
+		respHandler.HandleError(http.StatusInternalServerError, "Failed to send message to notification service", err.Error())
+		return
+	}
+
+	respHandler.HandleServiceResponse(resp, "Notification preferences retrieved successfully")
+}
+
+// UpdateNotificationPreferences changes the caller's notification preferences
+func (h *Handler) UpdateNotificationPreferences(c *gin.Context) {
+	// Initialize helper services
+	validator := NewValidator(c)
+	respHandler := NewResponseHandler(c)
+	messaging := NewMessagingService(h)
+
+	// Get user ID from context (set by middleware)
+	userID, ok := c.Get("user_id")
+	userIDStr, isString := userID.(string)
+	if !ok || !isString {
+		respHandler.HandleError(http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	// Parse and validate request
+	var updateReq sharedModels.UpdateNotificationPreferencesRequest
+	if err := validator.BindJSON(&updateReq); err != nil {
+		return
+	}
+
+	// Users may only change their own preferences
+	updateReq.UserID = userIDStr
+
+	// Send request to notification service
+	resp, err := messaging.SendAndWait(SendRequest{
+		Type:    "update-notification-preferences",
+		Payload: updateReq,
+		Key:     "notification-preferences-update",
+		ReplyTo: "notification-service-topic",
+	})
+	if err != nil {
+		respHandler.HandleError(http.StatusInternalServerError, "Failed to send message to notification service", err.Error())
+		return
+	}
+
+	respHandler.HandleServiceResponse(resp, "Notification preferences updated successfully")
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	sharedModels "github.com/lucas/gokafka/shared/models"
+)
+
+// createOrderTimeout covers the user and product lookups order-service makes
+// before it replies
+const createOrderTimeout = 30 * time.Second
+
+// CreateOrder places an order for the caller. The reply carries the pending
+// order; checkout completes in the background.
+func (h *Handler) CreateOrder(c *gin.Context) {
+	// Initialize helper services
+	validator := NewValidator(c)
+	respHandler := NewResponseHandler(c)
+	messaging := NewMessagingService(h)
+
+	// Get user ID from context (set by middleware)
+	userID, ok := c.Get("user_id")
+	userIDStr, isString := userID.(string)
+	if !ok || !isString {
+		respHandler.HandleError(http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	// Parse and validate request
+	var createReq sharedModels.CreateOrderRequest
+	if err := validator.BindJSON(&createReq); err != nil {
+		return
+	}
+	if len(createReq.Items) == 0 {
+		respHandler.HandleError(http.StatusBadRequest, "items is required")
+		return
+	}
+
+	// Orders are always placed for the authenticated user
+	createReq.UserID = userIDStr
+
+	// Send request to order service
+	resp, err := messaging.SendAndWait(SendRequest{
+		Type:    "create-order",
+		Payload: createReq,
+		Key:     "order-create",
+		ReplyTo: "order-service-topic",
+		Timeout: createOrderTimeout,
+	})
+	if err != nil {
+		respHandler.HandleError(http.StatusInternalServerError, "Failed to send message to order service", err.Error())
+		return
+	}
+
+	respHandler.HandleServiceResponse(resp, "Order accepted")
+}
+
+// GetOrder returns one of the caller's orders
+func (h *Handler) GetOrder(c *gin.Context) {
+	// Initialize helper services
+	respHandler := NewResponseHandler(c)
+	messaging := NewMessagingService(h)
+
+	// Get user ID from context (set by middleware)
+	userID, ok := c.Get("user_id")
+	userIDStr, isString := userID.(string)
+	if !ok || !isString {
+		respHandler.HandleError(http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	// Send request to order service
+	resp, err := messaging.SendAndWait(SendRequest{
+		Type:    "get-order",
+		Payload: sharedModels.GetOrderRequest{ID: c.Param("id"), UserID: userIDStr},
+		Key:     "order-get",
+		ReplyTo: "order-service-topic",
+	})
+	if err != nil {
+		respHandler.HandleError(http.StatusInternalServerError, "Failed to send message to order service", err.Error())
+		return
+	}
+
+	respHandler.HandleServiceResponse(resp, "Order retrieved successfully")
+}
+
+// ListOrders returns the caller's orders
+func (h *Handler) ListOrders(c *gin.Context) {
+	// Initialize helper services
+	respHandler := NewResponseHandler(c)
+	messaging := NewMessagingService(h)
+
+	// Get user ID from context (set by middleware)
+	userID, ok := c.Get("user_id")
+	userIDStr, isString := userID.(string)
+	if !ok || !isString {
+		respHandler.HandleError(http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	// Send request to order service
+	resp, err := messaging.SendAndWait(SendRequest{
+		Type:    "list-orders",
+		Payload: sharedModels.ListOrdersRequest{UserID: userIDStr},
+		Key:     "order-list",
+		ReplyTo: "order-service-topic",
+	})
+	if err != nil {
+		respHandler.HandleError(http.StatusInternalServerError, "Failed to send message to order service", err.Error())
+		return
+	}
+
+	respHandler.HandleServiceResponse(resp, "Orders retrieved successfully")
+}
@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	sharedModels "github.com/lucas/gokafka/shared/models"
+)
+
+// GetPayment returns a payment by ID for admins
+func (h *Handler) GetPayment(c *gin.Context) {
+	// Initialize helper services
+	respHandler := NewResponseHandler(c)
+	messaging := NewMessagingService(h)
+
+	// Send request to payment service
+	resp, err := messaging.SendAndWait(SendRequest{
+		Type:    "get-payment",
+		Payload: sharedModels.GetPaymentRequest{ID: c.Param("id")},
+		Key:     "payment-get",
+		ReplyTo: "payment-service-topic",
+	})
+	if err != nil {
+		respHandler.HandleError(http.StatusInternalServerError, "Failed to send message to payment service", err.Error())
+		return
+	}
+
+	respHandler.HandleServiceResponse(resp, "Payment retrieved successfully")
+}
@@ -1,14 +1,84 @@
 package handlers
 
 import (
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	sharedModels "github.com/lucas/gokafka/shared/models"
 )
 
+// AcceptCurrencyHeader asks for product prices in a currency, like the
+// currency query parameter, which takes precedence
+const AcceptCurrencyHeader = "Accept-Currency"
+
+// productETag derives a strong ETag from the version of the product in a
+// successful product-service response, or "" if it has none
+func productETag(resp *SendResponse) string {
+	var envelope struct {
+		Data struct {
+			Version int `json:"version"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(resp.Data), &envelope); err != nil || envelope.Data.Version <= 0 {
+		return ""
+	}
+	return fmt.Sprintf(`"%d"`, envelope.Data.Version)
+}
+
+// parseIfMatch reads the product version from an If-Match header holding a
+// single ETag as returned by GetProduct
+func parseIfMatch(value string) (int, error) {
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(value, "W/") {
+		return 0, fmt.Errorf("If-Match must be a strong ETag")
+	}
+	version, err := strconv.Atoi(strings.Trim(value, `"`))
+	if err != nil || version <= 0 {
+		return 0, fmt.Errorf("If-Match must be an ETag returned by GET /products/:id")
+	}
+	return version, nil
+}
+
+// requestedCurrency returns the display currency asked for by the client, or
+// "" for the products' own currencies. Only the first entry of an
+// Accept-Currency list is honoured.
+func requestedCurrency(c *gin.Context) (string, error) {
+	currency := c.Query("currency")
+	if currency == "" {
+		currency, _, _ = strings.Cut(c.GetHeader(AcceptCurrencyHeader), ",")
+		currency, _, _ = strings.Cut(currency, ";")
+	}
+
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if currency == "" {
+		return "", nil
+	}
+	if err := sharedModels.ValidateCurrency(currency); err != nil {
+		return "", err
+	}
+	return currency, nil
+}
+
+// priceParam parses an optional price query parameter such as 9.99 or
+// "9.99 EUR". It writes a 400 response and returns false when invalid.
+func priceParam(c *gin.Context, param string) (*sharedModels.Money, bool) {
+	value := c.Query(param)
+	if value == "" {
+		return nil, true
+	}
+	price, err := sharedModels.ParseMoney(value, sharedModels.DefaultCurrency)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + param + ": " + err.Error()})
+		return nil, false
+	}
+	return &price, true
+}
+
 // Product CRUD handlers
 
 // CreateProduct handles product creation
@@ -27,7 +97,7 @@ func (h *Handler) CreateProduct(c *gin.Context) {
 		return
 	}
 
-	if req.Price <= 0 {
+	if !req.Price.IsPositive() {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Price must be greater than 0"})
 		return
 	}
@@ -40,6 +110,7 @@ func (h *Handler) CreateProduct(c *gin.Context) {
 		Key:     "product-create",
 		ReplyTo: "product-service-topic",
 		Timeout: 10 * time.Second,
+		Actor:   c.GetString("user_id"),
 	})
 
 	if err != nil {
@@ -61,8 +132,15 @@ func (h *Handler) GetProduct(c *gin.Context) {
 		return
 	}
 
+	currency, err := requestedCurrency(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("Vary", AcceptCurrencyHeader)
+
 	// Create request
-	req := sharedModels.GetProductRequest{ID: id}
+	req := sharedModels.GetProductRequest{ID: id, Currency: currency}
 
 	// Send request to product service
 	messaging := NewMessagingService(h)
@@ -79,17 +157,92 @@ func (h *Handler) GetProduct(c *gin.Context) {
 		return
 	}
 
+	if resp.Success {
+		if etag := productETag(resp); etag != "" {
+			c.Header("ETag", etag)
+		}
+	}
 	responseHandler := NewResponseHandler(c)
 	responseHandler.HandleServiceResponse(resp, "Product retrieved successfully")
 }
 
-// ListProducts handles listing all products
+// ListProducts handles listing one page of products
 func (h *Handler) ListProducts(c *gin.Context) {
+	h.listProducts(c, false, "Products retrieved successfully")
+}
+
+// ListDeletedProducts handles listing soft deleted products for admins. It
+// takes the same query parameters as ListProducts.
+func (h *Handler) ListDeletedProducts(c *gin.Context) {
+	h.listProducts(c, true, "Deleted products retrieved successfully")
+}
+
+// listProducts lists live or soft deleted products filtered by the query
+// parameters
+func (h *Handler) listProducts(c *gin.Context, deleted bool, successMessage string) {
+	currency, err := requestedCurrency(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("Vary", AcceptCurrencyHeader)
+
+	limit := 0
+	if limitStr := c.Query("limit"); limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+	}
+
+	for _, param := range []string{"created_after", "created_before"} {
+		if value := c.Query(param); value != "" {
+			if _, err := time.Parse(time.RFC3339, value); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + param + " timestamp, expected RFC3339"})
+				return
+			}
+		}
+	}
+
+	minPrice, ok := priceParam(c, "min_price")
+	if !ok {
+		return
+	}
+	maxPrice, ok := priceParam(c, "max_price")
+	if !ok {
+		return
+	}
+
+	categoryID := 0
+	if categoryStr := c.Query("category"); categoryStr != "" {
+		categoryID, err = strconv.Atoi(categoryStr)
+		if err != nil || categoryID <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category"})
+			return
+		}
+	}
+
+	req := sharedModels.ListProductsRequest{
+		Currency:      currency,
+		Limit:         limit,
+		Cursor:        c.Query("cursor"),
+		Sort:          c.Query("sort"),
+		MinPrice:      minPrice,
+		MaxPrice:      maxPrice,
+		NamePrefix:    c.Query("name_prefix"),
+		CategoryID:    categoryID,
+		Tag:           c.Query("tag"),
+		CreatedAfter:  c.Query("created_after"),
+		CreatedBefore: c.Query("created_before"),
+		Deleted:       deleted,
+	}
+
 	// Send request to product service
 	messaging := NewMessagingService(h)
 	resp, err := messaging.SendAndWait(SendRequest{
 		Type:    "list-products",
-		Payload: "",
+		Payload: req,
 		Key:     "product-list",
 		ReplyTo: "product-service-topic",
 		Timeout: 10 * time.Second,
@@ -101,10 +254,58 @@ func (h *Handler) ListProducts(c *gin.Context) {
 	}
 
 	responseHandler := NewResponseHandler(c)
-	responseHandler.HandleServiceResponse(resp, "Products retrieved successfully")
+	responseHandler.HandleServiceResponse(resp, successMessage)
 }
 
-// UpdateProduct handles product updates
+// SearchProducts handles full-text product search
+func (h *Handler) SearchProducts(c *gin.Context) {
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Search query q is required"})
+		return
+	}
+
+	currency, err := requestedCurrency(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("Vary", AcceptCurrencyHeader)
+
+	req := sharedModels.SearchProductsRequest{Query: query, Currency: currency}
+	for param, target := range map[string]*int{"limit": &req.Limit, "offset": &req.Offset} {
+		if value := c.Query(param); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + param})
+				return
+			}
+			*target = n
+		}
+	}
+
+	// Send request to product service
+	messaging := NewMessagingService(h)
+	resp, err := messaging.SendAndWait(SendRequest{
+		Type:    "search-products",
+		Payload: req,
+		Key:     "product-search",
+		ReplyTo: "product-service-topic",
+		Timeout: 10 * time.Second,
+	})
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	responseHandler := NewResponseHandler(c)
+	responseHandler.HandleServiceResponse(resp, "Products found")
+}
+
+// UpdateProduct handles product updates. The If-Match header must carry the
+// ETag of the product the update is based on; if the product has changed
+// since, the update is rejected with 412 and the client should fetch it again.
 func (h *Handler) UpdateProduct(c *gin.Context) {
 	// Parse product ID from URL parameter
 	idStr := c.Param("id")
@@ -114,11 +315,16 @@ func (h *Handler) UpdateProduct(c *gin.Context) {
 		return
 	}
 
+	version, ok := requireIfMatch(c)
+	if !ok {
+		return
+	}
+
 	// Parse and validate request
 	var updateData struct {
-		Name        string  `json:"name"`
-		Description string  `json:"description"`
-		Price       float64 `json:"price"`
+		Name        string             `json:"name"`
+		Description string             `json:"description"`
+		Price       sharedModels.Money `json:"price"`
 	}
 	validator := NewValidator(c)
 	if err := validator.BindJSON(&updateData); err != nil {
@@ -132,7 +338,7 @@ func (h *Handler) UpdateProduct(c *gin.Context) {
 		return
 	}
 
-	if updateData.Price <= 0 {
+	if !updateData.Price.IsPositive() {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Price must be greater than 0"})
 		return
 	}
@@ -140,6 +346,7 @@ func (h *Handler) UpdateProduct(c *gin.Context) {
 	// Create update request
 	req := sharedModels.UpdateProductRequest{
 		ID:          id,
+		Version:     version,
 		Name:        updateData.Name,
 		Description: updateData.Description,
 		Price:       updateData.Price,
@@ -153,6 +360,7 @@ func (h *Handler) UpdateProduct(c *gin.Context) {
 		Key:     "product-update",
 		ReplyTo: "product-service-topic",
 		Timeout: 10 * time.Second,
+		Actor:   c.GetString("user_id"),
 	})
 
 	if err != nil {
@@ -160,6 +368,96 @@ func (h *Handler) UpdateProduct(c *gin.Context) {
 		return
 	}
 
+	handleProductUpdateResponse(c, resp)
+}
+
+// PatchProduct handles partial product updates. The body is a JSON Merge
+// Patch (RFC 7396) of name, description and price: members that are left out
+// keep their value and a null description clears it. If-Match works as for
+// UpdateProduct.
+func (h *Handler) PatchProduct(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	switch c.ContentType() {
+	case "application/merge-patch+json", "application/json":
+	default:
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Content-Type must be application/merge-patch+json"})
+		return
+	}
+
+	version, ok := requireIfMatch(c)
+	if !ok {
+		return
+	}
+
+	patch, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+		return
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(patch, &fields); err != nil || fields == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Patch must be a JSON object"})
+		return
+	}
+
+	req := sharedModels.PatchProductRequest{
+		ID:      id,
+		Version: version,
+		Patch:   patch,
+	}
+
+	messaging := NewMessagingService(h)
+	resp, err := messaging.SendAndWait(SendRequest{
+		Type:    "patch-product",
+		Payload: req,
+		Key:     "product-patch",
+		ReplyTo: "product-service-topic",
+		Timeout: 10 * time.Second,
+		Actor:   c.GetString("user_id"),
+	})
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	handleProductUpdateResponse(c, resp)
+}
+
+// requireIfMatch reads the product version from the If-Match header,
+// responding 428 if it is missing
+func requireIfMatch(c *gin.Context) (int, bool) {
+	ifMatch := c.GetHeader("If-Match")
+	if ifMatch == "" {
+		c.JSON(http.StatusPreconditionRequired, gin.H{"error": "If-Match header is required"})
+		return 0, false
+	}
+	version, err := parseIfMatch(ifMatch)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return 0, false
+	}
+	return version, true
+}
+
+// handleProductUpdateResponse answers an update with the product's new
+// ETag, or 412 if it was changed by someone else
+func handleProductUpdateResponse(c *gin.Context, resp *SendResponse) {
+	if resp.Code == sharedModels.ErrorCodeVersionConflict {
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": resp.Error})
+		return
+	}
+	if resp.Success {
+		if etag := productETag(resp); etag != "" {
+			c.Header("ETag", etag)
+		}
+	}
 	responseHandler := NewResponseHandler(c)
 	responseHandler.HandleServiceResponse(resp, "Product updated successfully")
 }
@@ -185,6 +483,7 @@ func (h *Handler) DeleteProduct(c *gin.Context) {
 		Key:     "product-delete",
 		ReplyTo: "product-service-topic",
 		Timeout: 10 * time.Second,
+		Actor:   c.GetString("user_id"),
 	})
 
 	if err != nil {
@@ -195,3 +494,75 @@ func (h *Handler) DeleteProduct(c *gin.Context) {
 	responseHandler := NewResponseHandler(c)
 	responseHandler.HandleServiceResponse(resp, "Product deleted successfully")
 }
+
+// RestoreProduct handles restoring a soft deleted product
+func (h *Handler) RestoreProduct(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	// Send request to product service
+	messaging := NewMessagingService(h)
+	resp, err := messaging.SendAndWait(SendRequest{
+		Type:    "restore-product",
+		Payload: sharedModels.RestoreProductRequest{ID: id},
+		Key:     "product-restore",
+		ReplyTo: "product-service-topic",
+		Timeout: 10 * time.Second,
+		Actor:   c.GetString("user_id"),
+	})
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	responseHandler := NewResponseHandler(c)
+	responseHandler.HandleServiceResponse(resp, "Product restored successfully")
+}
+
+// AdjustStock handles admin stock adjustments. The body carries either a
+// relative "delta" or an absolute "quantity".
+func (h *Handler) AdjustStock(c *gin.Context) {
+	// Parse product ID from URL parameter
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	// Parse and validate request
+	var req sharedModels.AdjustStockRequest
+	validator := NewValidator(c)
+	if err := validator.BindJSON(&req); err != nil {
+		return
+	}
+	req.ID = id
+
+	if req.Quantity == nil && req.Delta == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "delta or quantity is required"})
+		return
+	}
+
+	// Send request to product service
+	messaging := NewMessagingService(h)
+	resp, err := messaging.SendAndWait(SendRequest{
+		Type:    "adjust-stock",
+		Payload: req,
+		Key:     "product-stock",
+		ReplyTo: "product-service-topic",
+		Timeout: 10 * time.Second,
+		Actor:   c.GetString("user_id"),
+	})
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	responseHandler := NewResponseHandler(c)
+	responseHandler.HandleServiceResponse(resp, "Stock adjusted successfully")
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lucas/gokafka/shared/utils"
+)
+
+// newProductProxy forwards image uploads, media downloads and catalogue
+// import and export to product-service over HTTP; files of that size do not
+// fit in Kafka messages
+func newProductProxy() *httputil.ReverseProxy {
+	rawURL := utils.GetEnvOrDefault("PRODUCT_SERVICE_URL", "http://product-service:8082")
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		log.Fatalf("Invalid PRODUCT_SERVICE_URL %q: %v", rawURL, err)
+	}
+
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	// Flush immediately so exports stream instead of buffering
+	proxy.FlushInterval = -1
+	proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		log.Printf("Product service proxy error for %s %s: %v", r.Method, r.URL.Path, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte(`{"error":"Product service unavailable"}`))
+	}
+	return proxy
+}
+
+// proxyToProductService sends the request to path on product-service
+func (h *Handler) proxyToProductService(c *gin.Context, path string) {
+	c.Request.URL.Path = path
+	c.Request.URL.RawPath = ""
+	h.productProxy.ServeHTTP(c.Writer, c.Request)
+}
+
+// UploadProductImage handles POST /admin/products/:id/images. The image is
+// sent as the multipart field "image".
+func (h *Handler) UploadProductImage(c *gin.Context) {
+	h.proxyToProductService(c, "/products/"+url.PathEscape(c.Param("id"))+"/images")
+}
+
+// DeleteProductImage handles DELETE /admin/products/:id/images/:imageId
+func (h *Handler) DeleteProductImage(c *gin.Context) {
+	h.proxyToProductService(c, "/products/"+url.PathEscape(c.Param("id"))+
+		"/images/"+url.PathEscape(c.Param("imageId")))
+}
+
+// GetMedia serves uploaded images and thumbnails
+func (h *Handler) GetMedia(c *gin.Context) {
+	h.proxyToProductService(c, "/media"+c.Param("filepath"))
+}
+
+// ImportProducts handles POST /admin/products/import. The body is a CSV or
+// NDJSON file; the response reports the outcome of every row.
+func (h *Handler) ImportProducts(c *gin.Context) {
+	h.proxyToProductService(c, "/products/import")
+}
+
+// ExportProducts handles GET /admin/products/export?format=csv|ndjson,
+// streaming the whole catalogue
+func (h *Handler) ExportProducts(c *gin.Context) {
+	h.proxyToProductService(c, "/products/export")
+}
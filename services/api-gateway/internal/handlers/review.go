@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	sharedModels "github.com/lucas/gokafka/shared/models"
+)
+
+// Review handlers
+
+// CreateReview handles posting the caller's review of a product. Each user
+// reviews a product once.
+func (h *Handler) CreateReview(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	// Get user ID from context (set by middleware)
+	userID, ok := c.Get("user_id")
+	userIDStr, isString := userID.(string)
+	if !ok || !isString {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	// Parse and validate request
+	var req sharedModels.CreateReviewRequest
+	validator := NewValidator(c)
+	if err := validator.BindJSON(&req); err != nil {
+		return
+	}
+	if err := validator.ValidateRequired(map[string]interface{}{
+		"text": req.Text,
+	}); err != nil {
+		return
+	}
+	if req.Rating < 1 || req.Rating > 5 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Rating must be between 1 and 5"})
+		return
+	}
+
+	// Reviews are always posted by the authenticated user
+	req.ProductID = id
+	req.UserID = userIDStr
+
+	// Send request to product service
+	messaging := NewMessagingService(h)
+	resp, err := messaging.SendAndWait(SendRequest{
+		Type:    "create-review",
+		Payload: req,
+		Key:     "review-create",
+		ReplyTo: "product-service-topic",
+		Timeout: 10 * time.Second,
+	})
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	responseHandler := NewResponseHandler(c)
+	responseHandler.HandleServiceResponse(resp, "Review created successfully")
+}
+
+// ListReviews handles listing a product's published reviews, newest first
+func (h *Handler) ListReviews(c *gin.Context) {
+	h.listReviews(c, sharedModels.ReviewStatusPublished)
+}
+
+// ListAllReviews handles listing a product's reviews for moderators. The
+// status query parameter narrows them to published or hidden ones.
+func (h *Handler) ListAllReviews(c *gin.Context) {
+	h.listReviews(c, c.Query("status"))
+}
+
+// listReviews sends a request for a page of reviews in status; pages are
+// requested with limit and cursor, like ListProducts
+func (h *Handler) listReviews(c *gin.Context, status string) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	limit := 0
+	if limitStr := c.Query("limit"); limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+	}
+
+	req := sharedModels.ListReviewsRequest{
+		ProductID: id,
+		Status:    status,
+		Limit:     limit,
+		Cursor:    c.Query("cursor"),
+	}
+
+	messaging := NewMessagingService(h)
+	resp, err := messaging.SendAndWait(SendRequest{
+		Type:    "list-reviews",
+		Payload: req,
+		Key:     "review-list",
+		ReplyTo: "product-service-topic",
+		Timeout: 10 * time.Second,
+	})
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	responseHandler := NewResponseHandler(c)
+	responseHandler.HandleServiceResponse(resp, "Reviews retrieved successfully")
+}
+
+// ModerateReview handles hiding a review or publishing it again. The body
+// is {"status": "hidden"} or {"status": "published"}.
+func (h *Handler) ModerateReview(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid review ID"})
+		return
+	}
+
+	// Parse and validate request
+	var req sharedModels.ModerateReviewRequest
+	validator := NewValidator(c)
+	if err := validator.BindJSON(&req); err != nil {
+		return
+	}
+	if err := validator.ValidateRequired(map[string]interface{}{
+		"status": req.Status,
+	}); err != nil {
+		return
+	}
+	req.ID = id
+
+	// Send request to product service
+	messaging := NewMessagingService(h)
+	resp, err := messaging.SendAndWait(SendRequest{
+		Type:    "moderate-review",
+		Payload: req,
+		Key:     "review-moderate",
+		ReplyTo: "product-service-topic",
+		Timeout: 10 * time.Second,
+		Actor:   c.GetString("user_id"),
+	})
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	responseHandler := NewResponseHandler(c)
+	responseHandler.HandleServiceResponse(resp, "Review moderated successfully")
+}
+
+// DeleteReview handles removing a review for good
+func (h *Handler) DeleteReview(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid review ID"})
+		return
+	}
+
+	// Send request to product service
+	messaging := NewMessagingService(h)
+	resp, err := messaging.SendAndWait(SendRequest{
+		Type:    "delete-review",
+		Payload: sharedModels.DeleteReviewRequest{ID: id},
+		Key:     "review-delete",
+		ReplyTo: "product-service-topic",
+		Timeout: 10 * time.Second,
+		Actor:   c.GetString("user_id"),
+	})
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	responseHandler := NewResponseHandler(c)
+	responseHandler.HandleServiceResponse(resp, "Review deleted successfully")
+}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	sharedModels "github.com/lucas/gokafka/shared/models"
+)
+
+// Price schedule handlers
+
+// CreatePriceSchedule handles scheduling a price for a product. starts_at
+// and ends_at are RFC3339 timestamps; without ends_at the price becomes the
+// product's regular price once it starts.
+func (h *Handler) CreatePriceSchedule(c *gin.Context) {
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	// Parse and validate request
+	var req sharedModels.CreatePriceScheduleRequest
+	validator := NewValidator(c)
+	if err := validator.BindJSON(&req); err != nil {
+		return
+	}
+	req.ProductID = productID
+
+	if err := validator.ValidateRequired(map[string]interface{}{
+		"starts_at": req.StartsAt,
+	}); err != nil {
+		return
+	}
+
+	if !req.Price.IsPositive() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Price must be greater than 0"})
+		return
+	}
+
+	// Send request to product service
+	messaging := NewMessagingService(h)
+	resp, err := messaging.SendAndWait(SendRequest{
+		Type:    "create-price-schedule",
+		Payload: req,
+		Key:     "price-schedule-create",
+		ReplyTo: "product-service-topic",
+		Timeout: 10 * time.Second,
+		Actor:   c.GetString("user_id"),
+	})
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	responseHandler := NewResponseHandler(c)
+	responseHandler.HandleServiceResponse(resp, "Price schedule created successfully")
+}
+
+// ListPriceSchedules handles listing a product's price schedules, including
+// ended ones
+func (h *Handler) ListPriceSchedules(c *gin.Context) {
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	// Send request to product service
+	messaging := NewMessagingService(h)
+	resp, err := messaging.SendAndWait(SendRequest{
+		Type:    "list-price-schedules",
+		Payload: sharedModels.ListPriceSchedulesRequest{ProductID: productID},
+		Key:     "price-schedule-list",
+		ReplyTo: "product-service-topic",
+		Timeout: 10 * time.Second,
+	})
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	responseHandler := NewResponseHandler(c)
+	responseHandler.HandleServiceResponse(resp, "Price schedules retrieved successfully")
+}
+
+// DeletePriceSchedule handles cancelling a price schedule; deleting an
+// active schedule ends it early
+func (h *Handler) DeletePriceSchedule(c *gin.Context) {
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+	scheduleID, err := strconv.Atoi(c.Param("scheduleId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid price schedule ID"})
+		return
+	}
+
+	// Send request to product service
+	messaging := NewMessagingService(h)
+	resp, err := messaging.SendAndWait(SendRequest{
+		Type:    "delete-price-schedule",
+		Payload: sharedModels.DeletePriceScheduleRequest{ID: scheduleID, ProductID: productID},
+		Key:     "price-schedule-delete",
+		ReplyTo: "product-service-topic",
+		Timeout: 10 * time.Second,
+		Actor:   c.GetString("user_id"),
+	})
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	responseHandler := NewResponseHandler(c)
+	responseHandler.HandleServiceResponse(resp, "Price schedule deleted successfully")
+}
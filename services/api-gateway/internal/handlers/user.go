@@ -3,6 +3,8 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	shared "github.com/lucas/gokafka/shared/models"
@@ -71,7 +73,62 @@ func (h *Handler) GetUserProfile(c *gin.Context) {
 }
 
 func (h *Handler) UpdateUserProfile(c *gin.Context) {
-	c.JSON(http.StatusNotImplemented, gin.H{"error": "method not implemented"})
+	// Initialize helper services
+	validator := NewValidator(c)
+	respHandler := NewResponseHandler(c)
+	messaging := NewMessagingService(h)
+
+	// Get user ID from context (set by middleware)
+	userID, exists := c.Get("user_id")
+	if !exists {
+		respHandler.HandleError(http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	userIDStr, ok := userID.(string)
+	if !ok {
+		respHandler.HandleError(http.StatusInternalServerError, "Invalid user ID format")
+		return
+	}
+
+	// Parse and validate request
+	var updateData struct {
+		FirstName string `json:"first_name"`
+		LastName  string `json:"last_name"`
+	}
+	if err := validator.BindJSON(&updateData); err != nil {
+		return
+	}
+
+	// Validate required fields
+	if err := validator.ValidateRequired(map[string]interface{}{
+		"FirstName": updateData.FirstName,
+		"LastName":  updateData.LastName,
+	}); err != nil {
+		return
+	}
+
+	// Users may only update their own profile
+	updateReq := shared.UpdateProfileRequest{
+		ID:        userIDStr,
+		FirstName: updateData.FirstName,
+		LastName:  updateData.LastName,
+	}
+
+	// Send request to user service
+	resp, err := messaging.SendAndWait(SendRequest{
+		Type:    "update-user-profile",
+		Payload: updateReq,
+		Key:     "user-update",
+		ReplyTo: "user-service-topic",
+	})
+	if err != nil {
+		respHandler.HandleError(http.StatusInternalServerError, "Failed to send message to user service", err.Error())
+		return
+	}
+
+	// Handle service response
+	respHandler.HandleServiceResponse(resp, "User profile updated successfully")
 }
 
 func (h *Handler) ListUserProfiles(c *gin.Context) {
@@ -87,10 +144,38 @@ func (h *Handler) ListUserProfiles(c *gin.Context) {
 		return
 	}
 
+	limit := 0
+	if limitStr := c.Query("limit"); limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			respHandler.HandleError(http.StatusBadRequest, "Invalid limit")
+			return
+		}
+	}
+
+	for _, param := range []string{"created_after", "created_before"} {
+		if value := c.Query(param); value != "" {
+			if _, err := time.Parse(time.RFC3339, value); err != nil {
+				respHandler.HandleError(http.StatusBadRequest, "Invalid "+param+" timestamp, expected RFC3339")
+				return
+			}
+		}
+	}
+
+	req := shared.ListUsersRequest{
+		Search:        c.Query("search"),
+		Role:          c.Query("role"),
+		CreatedAfter:  c.Query("created_after"),
+		CreatedBefore: c.Query("created_before"),
+		Cursor:        c.Query("cursor"),
+		Limit:         limit,
+	}
+
 	// Send request to user service
 	resp, err := messaging.SendAndWait(SendRequest{
 		Type:    "list-user-profiles",
-		Payload: "",
+		Payload: req,
 		Key:     "get-user-profile",
 		ReplyTo: "user-service-topic",
 	})
@@ -108,22 +193,25 @@ func (h *Handler) ListUserProfiles(c *gin.Context) {
 			return
 		}
 
-		// Return the list of user profiles
-		var profiles []gin.H
+		// Return the list of user profiles; an empty page is an empty list
+		profiles := make([]gin.H, 0, len(profileRes.Data))
 		for _, userData := range profileRes.Data {
 			profiles = append(profiles, gin.H{
 				"id":         userData.ID,
 				"email":      userData.Email,
 				"first_name": userData.FirstName,
 				"last_name":  userData.LastName,
+				"role":       userData.Role,
 				"created_at": userData.CreatedAt,
 				"updated_at": userData.UpdatedAt,
 			})
 		}
 
 		c.JSON(http.StatusOK, gin.H{
-			"message": "User profiles retrieved successfully",
-			"data":    profiles,
+			"message":     "User profiles retrieved successfully",
+			"data":        profiles,
+			"total":       profileRes.Total,
+			"next_cursor": profileRes.NextCursor,
 		})
 
 	} else {
@@ -134,5 +222,34 @@ func (h *Handler) ListUserProfiles(c *gin.Context) {
 }
 
 func (h *Handler) DeleteUserProfile(c *gin.Context) {
-	c.JSON(http.StatusNotImplemented, gin.H{"error": "method not implemented"})
+	// Initialize helper services
+	respHandler := NewResponseHandler(c)
+	messaging := NewMessagingService(h)
+
+	targetID := c.Param("id")
+	if targetID == "" {
+		respHandler.HandleError(http.StatusBadRequest, "User ID is required")
+		return
+	}
+
+	// Prevent admins from locking themselves out
+	if userID, _ := c.Get("user_id"); userID == targetID {
+		respHandler.HandleError(http.StatusBadRequest, "Admins cannot delete their own account")
+		return
+	}
+
+	// Send request to user service
+	resp, err := messaging.SendAndWait(SendRequest{
+		Type:    "delete-user",
+		Payload: shared.DeleteUserRequest{ID: targetID},
+		Key:     "user-delete",
+		ReplyTo: "user-service-topic",
+	})
+	if err != nil {
+		respHandler.HandleError(http.StatusInternalServerError, "Failed to send message to user service", err.Error())
+		return
+	}
+
+	// Handle service response
+	respHandler.HandleServiceResponse(resp, "User deleted successfully")
 }
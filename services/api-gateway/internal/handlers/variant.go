@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	sharedModels "github.com/lucas/gokafka/shared/models"
+)
+
+// Variant handlers
+
+// CreateVariant handles adding a variant to a product. Omitting price keeps
+// the product price.
+func (h *Handler) CreateVariant(c *gin.Context) {
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	// Parse and validate request
+	var req sharedModels.CreateVariantRequest
+	validator := NewValidator(c)
+	if err := validator.BindJSON(&req); err != nil {
+		return
+	}
+	req.ProductID = productID
+
+	if err := validator.ValidateRequired(map[string]interface{}{
+		"sku": req.SKU,
+	}); err != nil {
+		return
+	}
+
+	if req.Price != nil && !req.Price.IsPositive() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Price must be greater than 0"})
+		return
+	}
+
+	// Send request to product service
+	messaging := NewMessagingService(h)
+	resp, err := messaging.SendAndWait(SendRequest{
+		Type:    "create-variant",
+		Payload: req,
+		Key:     "variant-create",
+		ReplyTo: "product-service-topic",
+		Timeout: 10 * time.Second,
+		Actor:   c.GetString("user_id"),
+	})
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	responseHandler := NewResponseHandler(c)
+	responseHandler.HandleServiceResponse(resp, "Variant created successfully")
+}
+
+// UpdateVariant handles replacing a variant. A null or missing price makes
+// the variant use the product price.
+func (h *Handler) UpdateVariant(c *gin.Context) {
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+	variantID, err := strconv.Atoi(c.Param("variantId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid variant ID"})
+		return
+	}
+
+	// Parse and validate request
+	var req sharedModels.UpdateVariantRequest
+	validator := NewValidator(c)
+	if err := validator.BindJSON(&req); err != nil {
+		return
+	}
+	req.ID = variantID
+	req.ProductID = productID
+
+	if err := validator.ValidateRequired(map[string]interface{}{
+		"sku": req.SKU,
+	}); err != nil {
+		return
+	}
+
+	if req.Price != nil && !req.Price.IsPositive() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Price must be greater than 0"})
+		return
+	}
+
+	// Send request to product service
+	messaging := NewMessagingService(h)
+	resp, err := messaging.SendAndWait(SendRequest{
+		Type:    "update-variant",
+		Payload: req,
+		Key:     "variant-update",
+		ReplyTo: "product-service-topic",
+		Timeout: 10 * time.Second,
+		Actor:   c.GetString("user_id"),
+	})
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	responseHandler := NewResponseHandler(c)
+	responseHandler.HandleServiceResponse(resp, "Variant updated successfully")
+}
+
+// DeleteVariant handles removing a variant from a product
+func (h *Handler) DeleteVariant(c *gin.Context) {
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+	variantID, err := strconv.Atoi(c.Param("variantId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid variant ID"})
+		return
+	}
+
+	// Send request to product service
+	messaging := NewMessagingService(h)
+	resp, err := messaging.SendAndWait(SendRequest{
+		Type:    "delete-variant",
+		Payload: sharedModels.DeleteVariantRequest{ID: variantID, ProductID: productID},
+		Key:     "variant-delete",
+		ReplyTo: "product-service-topic",
+		Timeout: 10 * time.Second,
+		Actor:   c.GetString("user_id"),
+	})
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	responseHandler := NewResponseHandler(c)
+	responseHandler.HandleServiceResponse(resp, "Variant deleted successfully")
+}
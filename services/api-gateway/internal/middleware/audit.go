@@ -0,0 +1,216 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	sharedModels "github.com/lucas/gokafka/shared/models"
+	"github.com/lucas/gokafka/shared/utils"
+	"github.com/segmentio/kafka-go"
+)
+
+// Audit constants
+const (
+	maxAuditBodyBytes   = 64 * 1024
+	auditPublishTimeout = 2 * time.Second
+	auditRetryInterval  = 30 * time.Second
+	AuditOutcomeSuccess = "success"
+	AuditOutcomeFailure = "failure"
+	// auditSnapshotRole is the role a caller needs before the audit fetches
+	// a snapshot; anyone else is denied by RequireRole anyway
+	auditSnapshotRole = "admin"
+)
+
+// auditVerbs maps HTTP methods to the verb used in audit actions
+var auditVerbs = map[string]string{
+	http.MethodGet:    "read",
+	http.MethodPost:   "create",
+	http.MethodPut:    "update",
+	http.MethodPatch:  "patch",
+	http.MethodDelete: "delete",
+}
+
+// SnapshotFunc returns the current JSON state of an admin target, or an
+// empty string when it cannot be fetched
+type SnapshotFunc func(targetType, targetID string) string
+
+type AuditMiddleware struct {
+	writer   *kafka.Writer
+	spool    *auditSpool
+	snapshot SnapshotFunc
+}
+
+func NewAuditMiddleware(snapshot SnapshotFunc) *AuditMiddleware {
+	broker := utils.GetEnvOrDefault("KAFKA_BROKERS", "localhost:9092")
+
+	// Each record is flushed on its own instead of waiting for a batch to
+	// fill, so the synchronous write only costs a broker round trip
+	return &AuditMiddleware{
+		writer: &kafka.Writer{
+			Addr:         kafka.TCP(broker),
+			Topic:        sharedModels.AuditEventsTopic,
+			Balancer:     &kafka.Hash{},
+			RequiredAcks: kafka.RequireAll,
+			BatchTimeout: 10 * time.Millisecond,
+		},
+		spool:    newAuditSpool(utils.GetEnvOrDefault("AUDIT_SPOOL_FILE", DefaultAuditSpoolFile)),
+		snapshot: snapshot,
+	}
+}
+
+// bodyCaptureWriter keeps a bounded copy of the response body
+type bodyCaptureWriter struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+func (w bodyCaptureWriter) Write(b []byte) (int, error) {
+	if remaining := maxAuditBodyBytes - w.body.Len(); remaining > 0 {
+		if len(b) > remaining {
+			w.body.Write(b[:remaining])
+		} else {
+			w.body.Write(b)
+		}
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Audit emits an audit event for every request it wraps. It must run after
+// AuthMiddleware so the actor is known.
+func (am *AuditMiddleware) Audit() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		record := sharedModels.AuditRecord{
+			ID:         uuid.NewString(),
+			OccurredAt: time.Now().UTC().Format(time.RFC3339Nano),
+			ActorID:    c.GetString("user_id"),
+			ActorEmail: c.GetString("user_email"),
+			ActorRole:  c.GetString("user_role"),
+			Method:     c.Request.Method,
+			Path:       c.FullPath(),
+			TargetType: auditTargetType(c.FullPath()),
+			TargetID:   c.Param("id"),
+			ClientIP:   c.ClientIP(),
+		}
+		record.Action = record.TargetType + "." + auditVerbs[record.Method]
+
+		// Capture the state being changed before the handler runs. Callers
+		// without the admin role are denied before any change is made, so
+		// their attempt is recorded without the round trip.
+		if record.TargetID != "" && record.Method != http.MethodGet && am.snapshot != nil &&
+			record.ActorRole == auditSnapshotRole {
+			record.Before = am.snapshot(record.TargetType, record.TargetID)
+		}
+
+		writer := bodyCaptureWriter{ResponseWriter: c.Writer, body: &bytes.Buffer{}}
+		c.Writer = writer
+
+		c.Next()
+
+		record.StatusCode = c.Writer.Status()
+		record.Outcome = AuditOutcomeSuccess
+		if record.StatusCode >= http.StatusBadRequest {
+			record.Outcome = AuditOutcomeFailure
+		}
+		if record.Method != http.MethodGet {
+			record.After = auditResponseData(writer.body.Bytes())
+		}
+
+		am.publish(record)
+	}
+}
+
+// auditTargetType extracts the resource from an admin route, e.g.
+// "/api/v1/admin/products/:id" -> "products"
+func auditTargetType(fullPath string) string {
+	_, rest, found := strings.Cut(fullPath, "/admin/")
+	if !found {
+		return "unknown"
+	}
+	resource, _, _ := strings.Cut(rest, "/")
+	if resource == "" {
+		return "unknown"
+	}
+	return resource
+}
+
+// auditResponseData keeps the "data" field of a JSON response, falling back
+// to the whole body
+func auditResponseData(body []byte) string {
+	var envelope struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &envelope); err == nil && len(envelope.Data) > 0 {
+		return string(envelope.Data)
+	}
+	return string(body)
+}
+
+// publish writes the record to the audit topic, waiting at most
+// auditPublishTimeout for the broker. A record the broker does not accept in
+// time is spooled to disk and delivered later by RunRetry, so an admin action
+// is never left unaudited.
+func (am *AuditMiddleware) publish(record sharedModels.AuditRecord) {
+	payload, err := json.Marshal(record)
+	if err != nil {
+		log.Printf("Failed to serialize audit record: %v", err)
+		return
+	}
+
+	value, err := json.Marshal(sharedModels.Event{
+		ID:          record.ID,
+		Type:        sharedModels.AuditEventAdminAction,
+		AggregateID: record.ActorID,
+		OccurredAt:  record.OccurredAt,
+		Payload:     string(payload),
+	})
+	if err != nil {
+		log.Printf("Failed to serialize audit event: %v", err)
+		return
+	}
+
+	message := kafka.Message{
+		Key:   []byte(record.ActorID),
+		Value: value,
+	}
+	err = am.write(message)
+	if err == nil {
+		return
+	}
+	log.Printf("Failed to publish audit event %s (%s by %s), spooling it: %v", record.ID, record.Action, record.ActorID, err)
+
+	if err := am.spool.append(message); err != nil {
+		log.Printf("Failed to spool audit event %s (%s by %s): %v", record.ID, record.Action, record.ActorID, err)
+	}
+}
+
+// write publishes messages, waiting at most auditPublishTimeout
+func (am *AuditMiddleware) write(messages ...kafka.Message) error {
+	ctx, cancel := context.WithTimeout(context.Background(), auditPublishTimeout)
+	defer cancel()
+
+	return am.writer.WriteMessages(ctx, messages...)
+}
+
+// RunRetry delivers spooled audit events until the process exits
+func (am *AuditMiddleware) RunRetry() {
+	ticker := time.NewTicker(auditRetryInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		delivered, err := am.spool.drain(am.write)
+		if err != nil {
+			log.Printf("Failed to deliver spooled audit events: %v", err)
+			continue
+		}
+		if delivered > 0 {
+			log.Printf("Delivered %d spooled audit events", delivered)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package middleware
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Spool constants
+const (
+	DefaultAuditSpoolFile = "audit-spool.jsonl"
+	auditSpoolBatchSize   = 100
+)
+
+// spooledMessage is one line of the spool file
+type spooledMessage struct {
+	Key   string          `json:"key"`
+	Value json.RawMessage `json:"value"`
+}
+
+// auditSpool keeps audit events the broker did not accept in a local file
+// until they can be delivered. New events are appended to path; a drain
+// first moves the file aside so appends never wait on the broker.
+type auditSpool struct {
+	mu   sync.Mutex
+	path string
+}
+
+func newAuditSpool(path string) *auditSpool {
+	return &auditSpool{path: path}
+}
+
+// draining is the file a drain in progress reads from
+func (s *auditSpool) draining() string {
+	return s.path + ".draining"
+}
+
+// append persists a message, syncing it to disk before returning
+func (s *auditSpool) append(message kafka.Message) error {
+	line, err := json.Marshal(spooledMessage{Key: string(message.Key), Value: message.Value})
+	if err != nil {
+		return fmt.Errorf("failed to serialize spooled message: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit spool: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit spool: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync audit spool: %w", err)
+	}
+
+	return nil
+}
+
+// drain hands spooled messages to write in batches and returns how many
+// were delivered. Messages left over after a failed batch stay spooled for
+// the next drain.
+func (s *auditSpool) drain(write func(messages ...kafka.Message) error) (int, error) {
+	// A previous drain that failed part way leaves its file behind, which
+	// is retried before anything spooled since
+	s.mu.Lock()
+	if _, err := os.Stat(s.draining()); errors.Is(err, os.ErrNotExist) {
+		err = os.Rename(s.path, s.draining())
+		if errors.Is(err, os.ErrNotExist) {
+			s.mu.Unlock()
+			return 0, nil
+		}
+		if err != nil {
+			s.mu.Unlock()
+			return 0, fmt.Errorf("failed to move audit spool aside: %w", err)
+		}
+	}
+	s.mu.Unlock()
+
+	messages, err := readSpool(s.draining())
+	if err != nil {
+		return 0, err
+	}
+
+	delivered := 0
+	for delivered < len(messages) {
+		end := min(delivered+auditSpoolBatchSize, len(messages))
+		if err := write(messages[delivered:end]...); err != nil {
+			if rewriteErr := writeSpool(s.draining(), messages[delivered:]); rewriteErr != nil {
+				return delivered, fmt.Errorf("%w; failed to rewrite audit spool: %v", err, rewriteErr)
+			}
+			return delivered, err
+		}
+		delivered = end
+	}
+
+	if err := os.Remove(s.draining()); err != nil {
+		return delivered, fmt.Errorf("failed to remove drained audit spool: %w", err)
+	}
+
+	return delivered, nil
+}
+
+// readSpool loads the messages of a spool file
+func readSpool(path string) ([]kafka.Message, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit spool: %w", err)
+	}
+	defer file.Close()
+
+	var messages []kafka.Message
+	reader := bufio.NewReader(file)
+	for {
+		data, err := reader.ReadBytes('\n')
+		if len(data) > 0 {
+			var line spooledMessage
+			// A line torn by a crash mid-append cannot be recovered
+			if jsonErr := json.Unmarshal(data, &line); jsonErr == nil {
+				messages = append(messages, kafka.Message{Key: []byte(line.Key), Value: line.Value})
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read audit spool: %w", err)
+		}
+	}
+
+	return messages, nil
+}
+
+// writeSpool replaces the contents of a spool file
+func writeSpool(path string, messages []kafka.Message) error {
+	tmp := path + ".tmp"
+	file, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+
+	writer := bufio.NewWriter(file)
+	for _, message := range messages {
+		line, err := json.Marshal(spooledMessage{Key: string(message.Key), Value: message.Value})
+		if err != nil {
+			file.Close()
+			return err
+		}
+		writer.Write(append(line, '\n'))
+	}
+	if err := writer.Flush(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
@@ -70,6 +70,19 @@ func (am *AuthMiddleware) AuthMiddleware(blackListCheck bool) gin.HandlerFunc {
 	}
 }
 
+// OptionalAuthMiddleware authenticates requests that carry a token like
+// AuthMiddleware and lets requests without one through anonymously
+func (am *AuthMiddleware) OptionalAuthMiddleware() gin.HandlerFunc {
+	authenticate := am.AuthMiddleware(true)
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			c.Next()
+			return
+		}
+		authenticate(c)
+	}
+}
+
 func (am *AuthMiddleware) RequireRole(role string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userRole, exists := c.Get("user_role")
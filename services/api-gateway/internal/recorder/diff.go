@@ -0,0 +1,137 @@
+package recorder
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	sharedModels "github.com/lucas/gokafka/shared/models"
+)
+
+// DefaultIgnoreFields are volatile fields that differ between runs
+var DefaultIgnoreFields = []string{
+	"correlation_id",
+	"timestamp",
+	"token",
+	"created_at",
+	"updated_at",
+}
+
+// DiffResponses compares a recorded reply with a replayed one and returns a
+// human-readable line per difference. Fields named in ignore are skipped at
+// any depth.
+func DiffResponses(recorded, replayed *sharedModels.Response, ignore map[string]bool) []string {
+	if recorded == nil && replayed == nil {
+		return nil
+	}
+	if recorded == nil {
+		return []string{"recorded: no reply, replayed: reply received"}
+	}
+	if replayed == nil {
+		return []string{"recorded: reply received, replayed: no reply"}
+	}
+
+	var diffs []string
+	if recorded.Success != replayed.Success {
+		diffs = append(diffs, fmt.Sprintf("success: %v != %v", recorded.Success, replayed.Success))
+	}
+	if recorded.Error != replayed.Error {
+		diffs = append(diffs, fmt.Sprintf("error: %q != %q", recorded.Error, replayed.Error))
+	}
+	return append(diffs, DiffJSON("data", recorded.Data, replayed.Data, ignore)...)
+}
+
+// DiffJSON compares two JSON documents structurally. Inputs that are not
+// valid JSON are compared as plain strings.
+func DiffJSON(path, a, b string, ignore map[string]bool) []string {
+	var av, bv interface{}
+	if json.Unmarshal([]byte(a), &av) != nil || json.Unmarshal([]byte(b), &bv) != nil {
+		if a != b {
+			return []string{fmt.Sprintf("%s: %q != %q", path, a, b)}
+		}
+		return nil
+	}
+	return diffValues(path, av, bv, ignore)
+}
+
+func diffValues(path string, a, b interface{}, ignore map[string]bool) []string {
+	switch av := a.(type) {
+	case map[string]interface{}:
+		bv, ok := b.(map[string]interface{})
+		if !ok {
+			return []string{fmt.Sprintf("%s: %s != %s", path, describe(a), describe(b))}
+		}
+
+		keys := make(map[string]bool)
+		for key := range av {
+			keys[key] = true
+		}
+		for key := range bv {
+			keys[key] = true
+		}
+		sorted := make([]string, 0, len(keys))
+		for key := range keys {
+			if !ignore[key] {
+				sorted = append(sorted, key)
+			}
+		}
+		sort.Strings(sorted)
+
+		var diffs []string
+		for _, key := range sorted {
+			child := path + "." + key
+			aChild, aOK := av[key]
+			bChild, bOK := bv[key]
+			switch {
+			case !aOK:
+				diffs = append(diffs, fmt.Sprintf("%s: missing in recording, replayed %s", child, describe(bChild)))
+			case !bOK:
+				diffs = append(diffs, fmt.Sprintf("%s: recorded %s, missing in replay", child, describe(aChild)))
+			default:
+				diffs = append(diffs, diffValues(child, aChild, bChild, ignore)...)
+			}
+		}
+		return diffs
+
+	case []interface{}:
+		bv, ok := b.([]interface{})
+		if !ok {
+			return []string{fmt.Sprintf("%s: %s != %s", path, describe(a), describe(b))}
+		}
+
+		var diffs []string
+		if len(av) != len(bv) {
+			diffs = append(diffs, fmt.Sprintf("%s: length %d != %d", path, len(av), len(bv)))
+		}
+		for i := 0; i < len(av) && i < len(bv); i++ {
+			diffs = append(diffs, diffValues(fmt.Sprintf("%s[%d]", path, i), av[i], bv[i], ignore)...)
+		}
+		return diffs
+
+	case string:
+		// Nested JSON documents (e.g. envelope data) are compared structurally
+		if bs, ok := b.(string); ok && looksLikeJSON(av) && looksLikeJSON(bs) {
+			return DiffJSON(path, av, bs, ignore)
+		}
+	}
+
+	if !reflect.DeepEqual(a, b) {
+		return []string{fmt.Sprintf("%s: %s != %s", path, describe(a), describe(b))}
+	}
+	return nil
+}
+
+func looksLikeJSON(s string) bool {
+	s = strings.TrimSpace(s)
+	return strings.HasPrefix(s, "{") || strings.HasPrefix(s, "[")
+}
+
+func describe(value interface{}) string {
+	bytes, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(bytes)
+}
@@ -0,0 +1,242 @@
+package recorder
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	sharedModels "github.com/lucas/gokafka/shared/models"
+	"github.com/lucas/gokafka/shared/utils"
+)
+
+// Recorder constants
+const (
+	DefaultMaxFileBytes = 10 * 1024 * 1024
+	DefaultMaxFiles     = 20
+	fileExtension       = ".jsonl"
+)
+
+// Entry is a single captured request/reply exchange
+type Entry struct {
+	SessionID  string                 `json:"session_id"`
+	RecordedAt time.Time              `json:"recorded_at"`
+	DurationMs int64                  `json:"duration_ms"`
+	Key        string                 `json:"key,omitempty"` // Kafka message key the request was sent with
+	Request    sharedModels.Request   `json:"request"`
+	Response   *sharedModels.Response `json:"response,omitempty"`
+	Error      string                 `json:"error,omitempty"`
+}
+
+// Recorder writes redacted envelopes to rotating JSON Lines files.
+// A nil *Recorder is valid and records nothing.
+type Recorder struct {
+	mu           sync.Mutex
+	dir          string
+	sessionID    string
+	maxFileBytes int64
+	maxFiles     int
+	redactor     *Redactor
+	file         *os.File
+	writer       *bufio.Writer
+	written      int64
+	seq          int
+}
+
+// NewRecorder creates a recorder from the environment. It returns nil when
+// RECORD_DIR is not set, which disables recording.
+func NewRecorder() *Recorder {
+	dir := os.Getenv("RECORD_DIR")
+	if dir == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		log.Printf("recorder disabled: failed to create %s: %v", dir, err)
+		return nil
+	}
+
+	r := &Recorder{
+		dir:          dir,
+		sessionID:    time.Now().UTC().Format("20060102T150405Z"),
+		maxFileBytes: envInt64("RECORD_MAX_FILE_BYTES", DefaultMaxFileBytes),
+		maxFiles:     int(envInt64("RECORD_MAX_FILES", DefaultMaxFiles)),
+		redactor:     NewRedactor(strings.Split(os.Getenv("RECORD_REDACT_FIELDS"), ",")),
+	}
+
+	log.Printf("Recording request traffic to %s (session %s)", dir, r.sessionID)
+	return r
+}
+
+func envInt64(key string, defaultValue int64) int64 {
+	value, err := strconv.ParseInt(utils.GetEnvOrDefault(key, ""), 10, 64)
+	if err != nil || value <= 0 {
+		return defaultValue
+	}
+	return value
+}
+
+// SessionID returns the identifier shared by all files of this process
+func (r *Recorder) SessionID() string {
+	if r == nil {
+		return ""
+	}
+	return r.sessionID
+}
+
+// Record redacts and appends an exchange to the current session file
+func (r *Recorder) Record(entry Entry) {
+	if r == nil {
+		return
+	}
+
+	entry.SessionID = r.sessionID
+	entry.Request.Payload = r.redactor.Redact(entry.Request.Payload)
+	if entry.Response != nil {
+		resp := *entry.Response
+		resp.Data = r.redactor.Redact(resp.Data)
+		entry.Response = &resp
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		log.Printf("recorder: failed to marshal entry: %v", err)
+		return
+	}
+	line = append(line, '\n')
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil || r.written+int64(len(line)) > r.maxFileBytes {
+		if err := r.rotate(); err != nil {
+			log.Printf("recorder: failed to rotate file: %v", err)
+			return
+		}
+	}
+
+	n, err := r.writer.Write(line)
+	r.written += int64(n)
+	if err != nil {
+		log.Printf("recorder: failed to write entry: %v", err)
+		return
+	}
+	if err := r.writer.Flush(); err != nil {
+		log.Printf("recorder: failed to flush entry: %v", err)
+	}
+}
+
+// Close flushes and closes the current file
+func (r *Recorder) Close() error {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.closeFile()
+}
+
+func (r *Recorder) closeFile() error {
+	if r.file == nil {
+		return nil
+	}
+	if err := r.writer.Flush(); err != nil {
+		r.file.Close()
+		return err
+	}
+	err := r.file.Close()
+	r.file = nil
+	r.writer = nil
+	return err
+}
+
+// rotate closes the current file, opens the next one and prunes old files
+func (r *Recorder) rotate() error {
+	if err := r.closeFile(); err != nil {
+		return err
+	}
+
+	r.seq++
+	name := fmt.Sprintf("%s-%04d%s", r.sessionID, r.seq, fileExtension)
+	file, err := os.OpenFile(filepath.Join(r.dir, name), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+
+	r.file = file
+	r.writer = bufio.NewWriter(file)
+	r.written = 0
+
+	r.prune()
+	return nil
+}
+
+// prune removes the oldest recording files beyond the configured limit
+func (r *Recorder) prune() {
+	files, err := SessionFiles(r.dir, "")
+	if err != nil || len(files) <= r.maxFiles {
+		return
+	}
+
+	for _, path := range files[:len(files)-r.maxFiles] {
+		if err := os.Remove(path); err != nil {
+			log.Printf("recorder: failed to remove %s: %v", path, err)
+		}
+	}
+}
+
+// SessionFiles lists recording files in dir, oldest first. An empty session
+// matches every session.
+func SessionFiles(dir, session string) ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, session+"*"+fileExtension))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(matches)
+	return matches, nil
+}
+
+// ReadEntries loads every entry of a session in recording order
+func ReadEntries(dir, session string) ([]Entry, error) {
+	files, err := SessionFiles(dir, session)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no recordings found in %s for session %q", dir, session)
+	}
+
+	var entries []Entry
+	for _, path := range files {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+
+		scanner := bufio.NewScanner(file)
+		scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+		for scanner.Scan() {
+			var entry Entry
+			if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+				file.Close()
+				return nil, fmt.Errorf("invalid entry in %s: %w", path, err)
+			}
+			entries = append(entries, entry)
+		}
+		err = scanner.Err()
+		file.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,83 @@
+package recorder
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// RedactedValue replaces the value of every sensitive field
+const RedactedValue = "[REDACTED]"
+
+// defaultRedactFields lists the PII and secret fields found in our payloads
+var defaultRedactFields = []string{
+	"password",
+	"token",
+	"email",
+	"first_name",
+	"last_name",
+	"authorization",
+}
+
+// Redactor masks sensitive fields inside JSON payloads
+type Redactor struct {
+	fields map[string]bool
+}
+
+// NewRedactor creates a redactor for the default fields plus any extra ones
+func NewRedactor(extra []string) *Redactor {
+	fields := make(map[string]bool)
+	for _, field := range append(defaultRedactFields, extra...) {
+		field = strings.ToLower(strings.TrimSpace(field))
+		if field != "" {
+			fields[field] = true
+		}
+	}
+	return &Redactor{fields: fields}
+}
+
+// Redact returns payload with sensitive fields masked. Payloads that are not
+// JSON are returned unchanged. JSON encoded inside string values (as used by
+// the envelope Data field) is redacted recursively.
+func (r *Redactor) Redact(payload string) string {
+	if payload == "" {
+		return payload
+	}
+
+	var value interface{}
+	if err := json.Unmarshal([]byte(payload), &value); err != nil {
+		return payload
+	}
+
+	redacted, err := json.Marshal(r.redactValue(value))
+	if err != nil {
+		return payload
+	}
+	return string(redacted)
+}
+
+func (r *Redactor) redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if r.fields[strings.ToLower(key)] {
+				v[key] = RedactedValue
+				continue
+			}
+			v[key] = r.redactValue(child)
+		}
+		return v
+	case []interface{}:
+		for i, child := range v {
+			v[i] = r.redactValue(child)
+		}
+		return v
+	case string:
+		trimmed := strings.TrimSpace(v)
+		if strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
+			return r.Redact(v)
+		}
+		return v
+	default:
+		return v
+	}
+}
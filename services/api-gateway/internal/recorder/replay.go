@@ -0,0 +1,152 @@
+package recorder
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	sharedModels "github.com/lucas/gokafka/shared/models"
+	"github.com/segmentio/kafka-go"
+)
+
+// ReplayResult is the outcome of resending a single recorded request
+type ReplayResult struct {
+	Entry    Entry
+	Replayed *sharedModels.Response
+	Diffs    []string
+	Err      error
+}
+
+// Replayer resends recorded requests and collects the new replies
+type Replayer struct {
+	writer        *kafka.Writer
+	readers       map[string]*kafka.Reader
+	responseChans map[string]chan sharedModels.Response
+	mu            sync.Mutex
+	broker        string
+	timeout       time.Duration
+	ignore        map[string]bool
+}
+
+// NewReplayer creates a replayer that publishes to the request topic
+func NewReplayer(broker, requestTopic string, timeout time.Duration, ignore []string) *Replayer {
+	ignoreSet := make(map[string]bool)
+	for _, field := range ignore {
+		if field != "" {
+			ignoreSet[field] = true
+		}
+	}
+
+	return &Replayer{
+		writer: kafka.NewWriter(kafka.WriterConfig{
+			Brokers:      []string{broker},
+			Topic:        requestTopic,
+			RequiredAcks: int(kafka.RequireOne),
+		}),
+		readers:       make(map[string]*kafka.Reader),
+		responseChans: make(map[string]chan sharedModels.Response),
+		broker:        broker,
+		timeout:       timeout,
+		ignore:        ignoreSet,
+	}
+}
+
+// listen starts consuming a reply topic once. Each replay run uses its own
+// consumer group so it never steals replies from the gateway. The group
+// starts from the first offset so no reply is missed while it joins; only
+// correlation IDs issued by this run are picked up.
+func (rp *Replayer) listen(topic string) {
+	rp.mu.Lock()
+	if _, ok := rp.readers[topic]; ok {
+		rp.mu.Unlock()
+		return
+	}
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers: []string{rp.broker},
+		Topic:   topic,
+		GroupID: "replay-" + uuid.NewString(),
+	})
+	rp.readers[topic] = reader
+	rp.mu.Unlock()
+
+	go func() {
+		for {
+			m, err := reader.ReadMessage(context.Background())
+			if err != nil {
+				return
+			}
+			var resp sharedModels.Response
+			if err := json.Unmarshal(m.Value, &resp); err != nil {
+				continue
+			}
+			rp.mu.Lock()
+			ch, ok := rp.responseChans[resp.CorrelationID]
+			rp.mu.Unlock()
+			if ok {
+				select {
+				case ch <- resp:
+				default:
+				}
+			}
+		}
+	}()
+}
+
+// Replay resends one entry and diffs the reply against the recorded one
+func (rp *Replayer) Replay(entry Entry) ReplayResult {
+	result := ReplayResult{Entry: entry}
+	rp.listen(entry.Request.ReplyTo)
+
+	req := entry.Request
+	req.CorrelationID = uuid.NewString()
+
+	replyChan := make(chan sharedModels.Response, 1)
+	rp.mu.Lock()
+	rp.responseChans[req.CorrelationID] = replyChan
+	rp.mu.Unlock()
+	defer func() {
+		rp.mu.Lock()
+		delete(rp.responseChans, req.CorrelationID)
+		rp.mu.Unlock()
+	}()
+
+	reqBytes, err := json.Marshal(req)
+	if err != nil {
+		result.Err = fmt.Errorf("failed to serialize request: %w", err)
+		return result
+	}
+
+	// Reuse the recorded key so replayed requests land on the same
+	// partitions, in the same order, as the original traffic
+	if err := rp.writer.WriteMessages(context.Background(), kafka.Message{
+		Key:   []byte(entry.Key),
+		Value: reqBytes,
+	}); err != nil {
+		result.Err = fmt.Errorf("failed to send message: %w", err)
+		return result
+	}
+
+	select {
+	case resp := <-replyChan:
+		result.Replayed = &resp
+	case <-time.After(rp.timeout):
+		log.Printf("timeout waiting for reply to %s (%s)", req.Type, req.CorrelationID)
+	}
+
+	result.Diffs = DiffResponses(entry.Response, result.Replayed, rp.ignore)
+	return result
+}
+
+// Close releases the Kafka connections
+func (rp *Replayer) Close() {
+	rp.writer.Close()
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	for _, reader := range rp.readers {
+		reader.Close()
+	}
+}
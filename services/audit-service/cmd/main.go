@@ -0,0 +1,59 @@
+package main
+
+import (
+	"log"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lucas/gokafka/audit-service/internal/consumer"
+	"github.com/lucas/gokafka/audit-service/internal/handlers"
+	"github.com/lucas/gokafka/audit-service/internal/repository"
+	"github.com/lucas/gokafka/audit-service/internal/service"
+	"github.com/lucas/gokafka/shared/utils"
+)
+
+const (
+	DefaultPort = "8084"
+)
+
+func main() {
+	log.Println("Starting audit-service...")
+
+	// Initialize dependencies
+	repo := repository.NewAuditRepository()
+	service := service.NewAuditService(repo)
+	handler := handlers.NewAuditHandler(service)
+	auditEvents := consumer.NewAuditEventConsumer(service)
+
+	log.Println("Audit-service started, waiting for events...")
+
+	// Start Kafka message listener in background
+	go handler.ListenMessages()
+
+	// Start audit event consumer in background
+	go auditEvents.Run()
+
+	// Start HTTP server
+	startHTTPServer()
+}
+
+func startHTTPServer() {
+	router := gin.Default()
+
+	// Health endpoints
+	router.GET("/health", healthHandler)
+	router.GET("/ready", readyHandler)
+
+	port := utils.GetEnvOrDefault("PORT", DefaultPort)
+	log.Printf("Starting HTTP server on port %s", port)
+	if err := router.Run(":" + port); err != nil {
+		log.Fatalf("Failed to start HTTP server: %v", err)
+	}
+}
+
+func healthHandler(c *gin.Context) {
+	c.JSON(200, gin.H{"status": "healthy"})
+}
+
+func readyHandler(c *gin.Context) {
+	c.JSON(200, gin.H{"status": "ready"})
+}
@@ -0,0 +1,84 @@
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/lucas/gokafka/audit-service/internal/service"
+	sharedModels "github.com/lucas/gokafka/shared/models"
+	"github.com/lucas/gokafka/shared/utils"
+	"github.com/segmentio/kafka-go"
+)
+
+// Consumer constants
+const (
+	ConsumerGroupID     = "audit-service-events"
+	InitialEventBackoff = time.Second
+	MaxEventBackoff     = time.Minute
+)
+
+// AuditEventConsumer feeds audit events to the audit service
+type AuditEventConsumer struct {
+	reader  *kafka.Reader
+	service *service.AuditService
+}
+
+func NewAuditEventConsumer(service *service.AuditService) *AuditEventConsumer {
+	broker := utils.GetEnvOrDefault("KAFKA_BROKERS", "localhost:9092")
+
+	return &AuditEventConsumer{
+		service: service,
+		reader: kafka.NewReader(kafka.ReaderConfig{
+			Brokers: []string{broker},
+			Topic:   sharedModels.AuditEventsTopic,
+			GroupID: ConsumerGroupID,
+		}),
+	}
+}
+
+// Run consumes events until the process exits. Offsets are committed only
+// after an event is handled, so events are never lost on failure.
+func (c *AuditEventConsumer) Run() {
+	log.Printf("Consuming audit events from %s", sharedModels.AuditEventsTopic)
+
+	for {
+		m, err := c.reader.FetchMessage(context.Background())
+		if err != nil {
+			log.Println("read error:", err)
+			continue
+		}
+
+		var event sharedModels.Event
+		if err := json.Unmarshal(m.Value, &event); err != nil {
+			log.Println("unmarshal error:", err)
+		} else {
+			c.handleWithRetry(event)
+		}
+
+		if err := c.reader.CommitMessages(context.Background(), m); err != nil {
+			log.Println("commit error:", err)
+		}
+	}
+}
+
+// handleWithRetry retries an event with exponential backoff until it is
+// stored. Audit events must never be dropped, so a stuck event holds back
+// later ones of the same partition.
+func (c *AuditEventConsumer) handleWithRetry(event sharedModels.Event) {
+	backoff := InitialEventBackoff
+	for {
+		err := c.service.RecordEvent(event)
+		if err == nil {
+			return
+		}
+
+		log.Printf("Failed to handle %s event %s, retrying in %s: %v", event.Type, event.ID, backoff, err)
+		time.Sleep(backoff)
+		backoff *= 2
+		if backoff > MaxEventBackoff {
+			backoff = MaxEventBackoff
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/lucas/gokafka/audit-service/internal/service"
+	sharedModels "github.com/lucas/gokafka/shared/models"
+	"github.com/lucas/gokafka/shared/utils"
+	"github.com/segmentio/kafka-go"
+)
+
+// ReplyTopic is the topic the gateway listens on for this service's replies
+const ReplyTopic = "audit-service-topic"
+
+// Request type constants
+const (
+	RequestTypeHealth          = "health"
+	RequestTypeListAuditEvents = "list-audit-events"
+)
+
+type AuditHandler struct {
+	writer  *kafka.Writer
+	reader  *kafka.Reader
+	service *service.AuditService
+}
+
+func NewAuditHandler(service *service.AuditService) *AuditHandler {
+	broker := utils.GetEnvOrDefault("KAFKA_BROKERS", "localhost:9092")
+
+	return &AuditHandler{
+		service: service,
+		writer: kafka.NewWriter(kafka.WriterConfig{
+			Brokers: []string{broker},
+		}),
+		reader: kafka.NewReader(kafka.ReaderConfig{
+			Brokers: []string{broker},
+			Topic:   "api-gateway-topic",
+			GroupID: "audit-service-group",
+		}),
+	}
+}
+
+// Helper method to create error responses
+func (h *AuditHandler) createErrorResponse(correlationID, errorMsg string) sharedModels.Response {
+	return sharedModels.Response{
+		CorrelationID: correlationID,
+		Success:       false,
+		Error:         errorMsg,
+	}
+}
+
+// Helper method to create success responses
+func (h *AuditHandler) createSuccessResponse(correlationID string, data interface{}) sharedModels.Response {
+	dataBytes, _ := json.Marshal(data)
+	return sharedModels.Response{
+		CorrelationID: correlationID,
+		Success:       true,
+		Data:          string(dataBytes),
+	}
+}
+
+// Helper method to unmarshal request payload
+func (h *AuditHandler) unmarshalPayload(payload string, target interface{}) error {
+	return json.Unmarshal([]byte(payload), target)
+}
+
+func (h *AuditHandler) ListenMessages() {
+	for {
+		m, err := h.reader.ReadMessage(context.Background())
+		if err != nil {
+			log.Println("read error:", err)
+			continue
+		}
+
+		var req sharedModels.Request
+		if err := json.Unmarshal(m.Value, &req); err != nil {
+			log.Println("unmarshal error:", err)
+			continue
+		}
+
+		resp, ok := h.handleRequest(req)
+		if !ok {
+			continue
+		}
+
+		respBytes, _ := json.Marshal(resp)
+		err = h.writer.WriteMessages(context.Background(),
+			kafka.Message{
+				Topic: req.ReplyTo,
+				Value: respBytes,
+			},
+		)
+		if err != nil {
+			log.Println("write error:", err)
+		} else {
+			log.Printf("responded to %s with correlation_id %s", req.ReplyTo, req.CorrelationID)
+		}
+	}
+}
+
+// handleRequest processes different request types. It returns false for
+// requests owned by other services, which share the same request topic.
+func (h *AuditHandler) handleRequest(req sharedModels.Request) (sharedModels.Response, bool) {
+	switch req.Type {
+	case RequestTypeHealth:
+		// Every service receives health checks; answer only our own
+		if req.ReplyTo != ReplyTopic {
+			return sharedModels.Response{}, false
+		}
+		return h.handleHealth(req.CorrelationID), true
+	case RequestTypeListAuditEvents:
+		return h.handleListAuditEvents(req), true
+	default:
+		return sharedModels.Response{}, false
+	}
+}
+
+// handleHealth returns health status
+func (h *AuditHandler) handleHealth(correlationID string) sharedModels.Response {
+	healthResponse := map[string]interface{}{
+		"service":   "audit-service",
+		"status":    "healthy",
+		"timestamp": time.Now().UTC().Format(time.RFC3339Nano),
+	}
+	return h.createSuccessResponse(correlationID, healthResponse)
+}
+
+// handleListAuditEvents processes list audit events request
+func (h *AuditHandler) handleListAuditEvents(req sharedModels.Request) sharedModels.Response {
+	var listReq sharedModels.ListAuditEventsRequest
+	if err := h.unmarshalPayload(req.Payload, &listReq); err != nil {
+		log.Printf("Failed to parse list audit events request: %v", err)
+		return h.createErrorResponse(req.CorrelationID, "Invalid list audit events request format")
+	}
+
+	result, err := h.service.ListEvents(listReq)
+	if err != nil {
+		log.Printf("Failed to list audit events: %v", err)
+		return h.createErrorResponse(req.CorrelationID, err.Error())
+	}
+
+	return h.createSuccessResponse(req.CorrelationID, result)
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+type AuditEntry struct {
+	Seq        int64     `json:"seq" db:"seq"`
+	ID         string    `json:"id" db:"id"`
+	OccurredAt time.Time `json:"occurred_at" db:"occurred_at"`
+	ActorID    string    `json:"actor_id" db:"actor_id"`
+	ActorEmail string    `json:"actor_email" db:"actor_email"`
+	ActorRole  string    `json:"actor_role" db:"actor_role"`
+	Action     string    `json:"action" db:"action"`
+	Method     string    `json:"method" db:"method"`
+	Path       string    `json:"path" db:"path"`
+	TargetType string    `json:"target_type" db:"target_type"`
+	TargetID   string    `json:"target_id" db:"target_id"`
+	Before     string    `json:"before" db:"before_state"`
+	After      string    `json:"after" db:"after_state"`
+	Outcome    string    `json:"outcome" db:"outcome"`
+	StatusCode int       `json:"status_code" db:"status_code"`
+	ClientIP   string    `json:"client_ip" db:"client_ip"`
+}
+
+// AuditFilter narrows an audit listing. Zero values match everything.
+type AuditFilter struct {
+	ActorID    string
+	Action     string
+	TargetType string
+	TargetID   string
+	Outcome    string
+	From       *time.Time
+	To         *time.Time
+	BeforeSeq  int64
+	Limit      int
+}
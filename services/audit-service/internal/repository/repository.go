@@ -0,0 +1,184 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+
+	_ "github.com/lib/pq" // PostgreSQL driver
+	"github.com/lucas/gokafka/audit-service/internal/models"
+	"github.com/lucas/gokafka/shared/utils"
+)
+
+// Database constants
+const (
+	DefaultPostgresHost     = "localhost"
+	DefaultPostgresPort     = "5432"
+	DefaultPostgresDB       = "gokafka"
+	DefaultPostgresUser     = "postgres"
+	DefaultPostgresPassword = "postgres"
+)
+
+type AuditRepository struct {
+	db *sql.DB
+}
+
+func NewAuditRepository() *AuditRepository {
+	db := initDatabase()
+	repo := &AuditRepository{db: db}
+	repo.createTableIfNotExists()
+	return repo
+}
+
+// initDatabase initializes the database connection
+func initDatabase() *sql.DB {
+	host := utils.GetEnvOrDefault("POSTGRES_HOST", DefaultPostgresHost)
+	port := utils.GetEnvOrDefault("POSTGRES_PORT", DefaultPostgresPort)
+	dbname := utils.GetEnvOrDefault("POSTGRES_DB", DefaultPostgresDB)
+	user := utils.GetEnvOrDefault("POSTGRES_USER", DefaultPostgresUser)
+	password := utils.GetEnvOrDefault("POSTGRES_PASSWORD", DefaultPostgresPassword)
+
+	connStr := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable",
+		user, password, host, port, dbname)
+
+	log.Printf("Connecting to PostgreSQL at %s:%s", host, port)
+	db, err := sql.Open("postgres", connStr)
+	if err != nil {
+		log.Fatalf("failed to connect to postgres: %v", err)
+	}
+
+	if err := db.Ping(); err != nil {
+		log.Fatalf("failed to ping postgres: %v", err)
+	}
+
+	log.Println("Connected to PostgreSQL database")
+	return db
+}
+
+// createTableIfNotExists creates the audit table. A trigger rejects every
+// UPDATE and DELETE so the log stays append-only.
+func (r *AuditRepository) createTableIfNotExists() {
+	query := `
+	CREATE TABLE IF NOT EXISTS audit_log (
+		seq BIGSERIAL PRIMARY KEY,
+		id VARCHAR(36) NOT NULL UNIQUE,
+		occurred_at TIMESTAMPTZ NOT NULL,
+		actor_id VARCHAR(255) NOT NULL,
+		actor_email VARCHAR(255) NOT NULL,
+		actor_role VARCHAR(50) NOT NULL,
+		action VARCHAR(100) NOT NULL,
+		method VARCHAR(10) NOT NULL,
+		path TEXT NOT NULL,
+		target_type VARCHAR(100) NOT NULL,
+		target_id VARCHAR(255) NOT NULL,
+		before_state TEXT NOT NULL DEFAULT '',
+		after_state TEXT NOT NULL DEFAULT '',
+		outcome VARCHAR(20) NOT NULL,
+		status_code INTEGER NOT NULL,
+		client_ip VARCHAR(64) NOT NULL,
+		recorded_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE INDEX IF NOT EXISTS idx_audit_log_actor ON audit_log (actor_id, seq);
+	CREATE INDEX IF NOT EXISTS idx_audit_log_target ON audit_log (target_type, target_id, seq);
+	CREATE INDEX IF NOT EXISTS idx_audit_log_occurred_at ON audit_log (occurred_at);
+	CREATE OR REPLACE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+	BEGIN
+		RAISE EXCEPTION 'audit_log is append-only';
+	END;
+	$$ LANGUAGE plpgsql;
+	CREATE OR REPLACE TRIGGER audit_log_append_only
+		BEFORE UPDATE OR DELETE ON audit_log
+		FOR EACH ROW EXECUTE FUNCTION audit_log_append_only()`
+
+	if _, err := r.db.Exec(query); err != nil {
+		log.Fatalf("failed to create audit_log table: %v", err)
+	}
+
+	log.Println("Audit log table is ready")
+}
+
+// AppendEntry stores an entry. Redelivered entries are ignored.
+func (r *AuditRepository) AppendEntry(entry *models.AuditEntry) error {
+	query := `
+	INSERT INTO audit_log (id, occurred_at, actor_id, actor_email, actor_role, action, method,
+		path, target_type, target_id, before_state, after_state, outcome, status_code, client_ip)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+	ON CONFLICT (id) DO NOTHING`
+
+	_, err := r.db.Exec(query, entry.ID, entry.OccurredAt, entry.ActorID, entry.ActorEmail,
+		entry.ActorRole, entry.Action, entry.Method, entry.Path, entry.TargetType, entry.TargetID,
+		entry.Before, entry.After, entry.Outcome, entry.StatusCode, entry.ClientIP)
+	if err != nil {
+		return fmt.Errorf("failed to append audit entry: %w", err)
+	}
+
+	return nil
+}
+
+// ListEntries returns matching entries, newest first
+func (r *AuditRepository) ListEntries(filter models.AuditFilter) ([]*models.AuditEntry, error) {
+	var conditions []string
+	var args []interface{}
+	addCondition := func(clause string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(clause, len(args)))
+	}
+
+	if filter.ActorID != "" {
+		addCondition("actor_id = $%d", filter.ActorID)
+	}
+	if filter.Action != "" {
+		addCondition("action = $%d", filter.Action)
+	}
+	if filter.TargetType != "" {
+		addCondition("target_type = $%d", filter.TargetType)
+	}
+	if filter.TargetID != "" {
+		addCondition("target_id = $%d", filter.TargetID)
+	}
+	if filter.Outcome != "" {
+		addCondition("outcome = $%d", filter.Outcome)
+	}
+	if filter.From != nil {
+		addCondition("occurred_at >= $%d", *filter.From)
+	}
+	if filter.To != nil {
+		addCondition("occurred_at < $%d", *filter.To)
+	}
+	if filter.BeforeSeq > 0 {
+		addCondition("seq < $%d", filter.BeforeSeq)
+	}
+
+	query := `
+	SELECT seq, id, occurred_at, actor_id, actor_email, actor_role, action, method, path,
+		target_type, target_id, before_state, after_state, outcome, status_code, client_ip
+	FROM audit_log`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, filter.Limit)
+	query += fmt.Sprintf(" ORDER BY seq DESC LIMIT $%d", len(args))
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]*models.AuditEntry, 0)
+	for rows.Next() {
+		var entry models.AuditEntry
+		if err := rows.Scan(
+			&entry.Seq, &entry.ID, &entry.OccurredAt, &entry.ActorID, &entry.ActorEmail,
+			&entry.ActorRole, &entry.Action, &entry.Method, &entry.Path, &entry.TargetType,
+			&entry.TargetID, &entry.Before, &entry.After, &entry.Outcome, &entry.StatusCode,
+			&entry.ClientIP,
+		); err != nil {
+			return nil, err
+		}
+		entries = append(entries, &entry)
+	}
+
+	return entries, rows.Err()
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/lucas/gokafka/audit-service/internal/models"
+	"github.com/lucas/gokafka/audit-service/internal/repository"
+	sharedModels "github.com/lucas/gokafka/shared/models"
+)
+
+// Listing constants
+const (
+	DefaultListLimit = 50
+	MaxListLimit     = 500
+)
+
+type AuditService struct {
+	repo *repository.AuditRepository
+}
+
+func NewAuditService(repo *repository.AuditRepository) *AuditService {
+	return &AuditService{
+		repo: repo,
+	}
+}
+
+// Helper method to convert AuditEntry to AuditRecord
+func (s *AuditService) entryToRecord(entry *models.AuditEntry) sharedModels.AuditRecord {
+	return sharedModels.AuditRecord{
+		ID:         entry.ID,
+		OccurredAt: entry.OccurredAt.UTC().Format(time.RFC3339Nano),
+		ActorID:    entry.ActorID,
+		ActorEmail: entry.ActorEmail,
+		ActorRole:  entry.ActorRole,
+		Action:     entry.Action,
+		Method:     entry.Method,
+		Path:       entry.Path,
+		TargetType: entry.TargetType,
+		TargetID:   entry.TargetID,
+		Before:     entry.Before,
+		After:      entry.After,
+		Outcome:    entry.Outcome,
+		StatusCode: entry.StatusCode,
+		ClientIP:   entry.ClientIP,
+	}
+}
+
+// RecordEvent stores an audit event. Malformed events are logged and
+// dropped since retrying them can never succeed.
+func (s *AuditService) RecordEvent(event sharedModels.Event) error {
+	if event.Type != sharedModels.AuditEventAdminAction {
+		return nil
+	}
+
+	var record sharedModels.AuditRecord
+	if err := json.Unmarshal([]byte(event.Payload), &record); err != nil {
+		log.Printf("Dropping audit event %s with invalid payload: %v", event.ID, err)
+		return nil
+	}
+
+	occurredAt, err := time.Parse(time.RFC3339Nano, record.OccurredAt)
+	if err != nil {
+		log.Printf("Audit event %s has invalid timestamp %q, using receive time", event.ID, record.OccurredAt)
+		occurredAt = time.Now().UTC()
+	}
+
+	entry := &models.AuditEntry{
+		ID:         record.ID,
+		OccurredAt: occurredAt,
+		ActorID:    record.ActorID,
+		ActorEmail: record.ActorEmail,
+		ActorRole:  record.ActorRole,
+		Action:     record.Action,
+		Method:     record.Method,
+		Path:       record.Path,
+		TargetType: record.TargetType,
+		TargetID:   record.TargetID,
+		Before:     record.Before,
+		After:      record.After,
+		Outcome:    record.Outcome,
+		StatusCode: record.StatusCode,
+		ClientIP:   record.ClientIP,
+	}
+	if entry.ID == "" {
+		entry.ID = event.ID
+	}
+
+	return s.repo.AppendEntry(entry)
+}
+
+func (s *AuditService) ListEvents(req sharedModels.ListAuditEventsRequest) (*sharedModels.ListAuditEventsResponse, error) {
+	filter := models.AuditFilter{
+		ActorID:    req.ActorID,
+		Action:     req.Action,
+		TargetType: req.TargetType,
+		TargetID:   req.TargetID,
+		Outcome:    req.Outcome,
+		Limit:      req.Limit,
+	}
+
+	// Validate input
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultListLimit
+	}
+	if filter.Limit > MaxListLimit {
+		filter.Limit = MaxListLimit
+	}
+	if req.From != "" {
+		from, err := time.Parse(time.RFC3339, req.From)
+		if err != nil {
+			return nil, fmt.Errorf("invalid from timestamp")
+		}
+		filter.From = &from
+	}
+	if req.To != "" {
+		to, err := time.Parse(time.RFC3339, req.To)
+		if err != nil {
+			return nil, fmt.Errorf("invalid to timestamp")
+		}
+		filter.To = &to
+	}
+	if req.Cursor != "" {
+		seq, err := strconv.ParseInt(req.Cursor, 10, 64)
+		if err != nil || seq <= 0 {
+			return nil, fmt.Errorf("invalid cursor")
+		}
+		filter.BeforeSeq = seq
+	}
+
+	entries, err := s.repo.ListEntries(filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit events: %w", err)
+	}
+
+	records := make([]sharedModels.AuditRecord, 0, len(entries))
+	for _, entry := range entries {
+		records = append(records, s.entryToRecord(entry))
+	}
+
+	response := &sharedModels.ListAuditEventsResponse{
+		Status: "success",
+		Data:   records,
+	}
+	if len(entries) == filter.Limit {
+		response.NextCursor = strconv.FormatInt(entries[len(entries)-1].Seq, 10)
+	}
+
+	return response, nil
+}
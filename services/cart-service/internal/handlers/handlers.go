@@ -0,0 +1,263 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/lucas/gokafka/cart-service/internal/service"
+	sharedModels "github.com/lucas/gokafka/shared/models"
+	"github.com/lucas/gokafka/shared/utils"
+	"github.com/segmentio/kafka-go"
+)
+
+// ReplyTopic is the topic the gateway listens on for this service's replies
+const ReplyTopic = "cart-service-topic"
+
+// Request type constants
+const (
+	RequestTypeHealth         = "health"
+	RequestTypeGetCart        = "get-cart"
+	RequestTypeAddCartItem    = "add-cart-item"
+	RequestTypeUpdateCartItem = "update-cart-item"
+	RequestTypeRemoveCartItem = "remove-cart-item"
+	RequestTypeClearCart      = "clear-cart"
+	RequestTypeMergeCart      = "merge-cart"
+	RequestTypeCheckoutCart   = "checkout-cart"
+)
+
+type CartHandler struct {
+	writer  *kafka.Writer
+	reader  *kafka.Reader
+	service *service.CartService
+}
+
+func NewCartHandler(service *service.CartService) *CartHandler {
+	broker := utils.GetEnvOrDefault("KAFKA_BROKERS", "localhost:9092")
+
+	return &CartHandler{
+		service: service,
+		writer: kafka.NewWriter(kafka.WriterConfig{
+			Brokers: []string{broker},
+		}),
+		reader: kafka.NewReader(kafka.ReaderConfig{
+			Brokers: []string{broker},
+			Topic:   "api-gateway-topic",
+			GroupID: "cart-service-group",
+		}),
+	}
+}
+
+// Helper method to create error responses
+func (h *CartHandler) createErrorResponse(correlationID, errorMsg string) sharedModels.Response {
+	return sharedModels.Response{
+		CorrelationID: correlationID,
+		Success:       false,
+		Error:         errorMsg,
+	}
+}
+
+// Helper method to create success responses
+func (h *CartHandler) createSuccessResponse(correlationID string, data interface{}) sharedModels.Response {
+	dataBytes, _ := json.Marshal(data)
+	return sharedModels.Response{
+		CorrelationID: correlationID,
+		Success:       true,
+		Data:          string(dataBytes),
+	}
+}
+
+// Helper method to unmarshal request payload
+func (h *CartHandler) unmarshalPayload(payload string, target interface{}) error {
+	return json.Unmarshal([]byte(payload), target)
+}
+
+func (h *CartHandler) ListenMessages() {
+	for {
+		m, err := h.reader.ReadMessage(context.Background())
+		if err != nil {
+			log.Println("read error:", err)
+			continue
+		}
+
+		var req sharedModels.Request
+		if err := json.Unmarshal(m.Value, &req); err != nil {
+			log.Println("unmarshal error:", err)
+			continue
+		}
+
+		resp, ok := h.handleRequest(req)
+		if !ok {
+			continue
+		}
+
+		respBytes, _ := json.Marshal(resp)
+		err = h.writer.WriteMessages(context.Background(),
+			kafka.Message{
+				Topic: req.ReplyTo,
+				Value: respBytes,
+			},
+		)
+		if err != nil {
+			log.Println("write error:", err)
+		} else {
+			log.Printf("responded to %s with correlation_id %s", req.ReplyTo, req.CorrelationID)
+		}
+	}
+}
+
+// handleRequest processes different request types. It returns false for
+// requests owned by other services, which share the same request topic.
+func (h *CartHandler) handleRequest(req sharedModels.Request) (sharedModels.Response, bool) {
+	switch req.Type {
+	case RequestTypeHealth:
+		// Every service receives health checks; answer only our own
+		if req.ReplyTo != ReplyTopic {
+			return sharedModels.Response{}, false
+		}
+		return h.handleHealth(req.CorrelationID), true
+	case RequestTypeGetCart:
+		return h.handleGetCart(req), true
+	case RequestTypeAddCartItem, RequestTypeUpdateCartItem, RequestTypeRemoveCartItem:
+		return h.handleCartItem(req), true
+	case RequestTypeClearCart:
+		return h.handleClearCart(req), true
+	case RequestTypeMergeCart:
+		return h.handleMergeCart(req), true
+	case RequestTypeCheckoutCart:
+		return h.handleCheckoutCart(req), true
+	default:
+		return sharedModels.Response{}, false
+	}
+}
+
+// handleHealth returns health status
+func (h *CartHandler) handleHealth(correlationID string) sharedModels.Response {
+	healthResponse := map[string]interface{}{
+		"service":   "cart-service",
+		"status":    "healthy",
+		"timestamp": time.Now().UTC().Format(time.RFC3339Nano),
+	}
+	return h.createSuccessResponse(correlationID, healthResponse)
+}
+
+// handleGetCart processes get cart request
+func (h *CartHandler) handleGetCart(req sharedModels.Request) sharedModels.Response {
+	var cartReq sharedModels.CartRequest
+	if err := h.unmarshalPayload(req.Payload, &cartReq); err != nil {
+		log.Printf("Failed to parse get cart request: %v", err)
+		return h.createErrorResponse(req.CorrelationID, "Invalid get cart request format")
+	}
+
+	result, err := h.service.GetCart(cartReq)
+	if err != nil {
+		log.Printf("Failed to get cart: %v", err)
+		return h.createErrorResponse(req.CorrelationID, err.Error())
+	}
+
+	response := sharedModels.CartResponse{
+		Status: "success",
+		Data:   *result,
+	}
+	return h.createSuccessResponse(req.CorrelationID, response)
+}
+
+// handleCartItem processes adding, updating and removing cart items
+func (h *CartHandler) handleCartItem(req sharedModels.Request) sharedModels.Response {
+	var itemReq sharedModels.CartItemRequest
+	if err := h.unmarshalPayload(req.Payload, &itemReq); err != nil {
+		log.Printf("Failed to parse %s request: %v", req.Type, err)
+		return h.createErrorResponse(req.CorrelationID, "Invalid cart item request format")
+	}
+
+	var result *sharedModels.CartData
+	var err error
+	var message string
+	switch req.Type {
+	case RequestTypeAddCartItem:
+		result, err = h.service.AddItem(itemReq)
+		message = "Item added to cart"
+	case RequestTypeUpdateCartItem:
+		result, err = h.service.UpdateItem(itemReq)
+		message = "Cart item updated"
+	default:
+		result, err = h.service.RemoveItem(itemReq)
+		message = "Item removed from cart"
+	}
+	if err != nil {
+		log.Printf("Cart update failed: %v", err)
+		return h.createErrorResponse(req.CorrelationID, err.Error())
+	}
+
+	response := sharedModels.CartResponse{
+		Status:  "success",
+		Message: message,
+		Data:    *result,
+	}
+	return h.createSuccessResponse(req.CorrelationID, response)
+}
+
+// handleClearCart processes clear cart request
+func (h *CartHandler) handleClearCart(req sharedModels.Request) sharedModels.Response {
+	var cartReq sharedModels.CartRequest
+	if err := h.unmarshalPayload(req.Payload, &cartReq); err != nil {
+		log.Printf("Failed to parse clear cart request: %v", err)
+		return h.createErrorResponse(req.CorrelationID, "Invalid clear cart request format")
+	}
+
+	if err := h.service.ClearCart(cartReq); err != nil {
+		log.Printf("Failed to clear cart: %v", err)
+		return h.createErrorResponse(req.CorrelationID, err.Error())
+	}
+
+	response := map[string]interface{}{
+		"status":  "success",
+		"message": "Cart cleared",
+	}
+	return h.createSuccessResponse(req.CorrelationID, response)
+}
+
+// handleMergeCart processes merging an anonymous cart into a user's cart
+func (h *CartHandler) handleMergeCart(req sharedModels.Request) sharedModels.Response {
+	var mergeReq sharedModels.MergeCartRequest
+	if err := h.unmarshalPayload(req.Payload, &mergeReq); err != nil {
+		log.Printf("Failed to parse merge cart request: %v", err)
+		return h.createErrorResponse(req.CorrelationID, "Invalid merge cart request format")
+	}
+
+	result, err := h.service.MergeCart(mergeReq)
+	if err != nil {
+		log.Printf("Cart merge failed: %v", err)
+		return h.createErrorResponse(req.CorrelationID, err.Error())
+	}
+
+	response := sharedModels.CartResponse{
+		Status:  "success",
+		Message: "Carts merged",
+		Data:    *result,
+	}
+	return h.createSuccessResponse(req.CorrelationID, response)
+}
+
+// handleCheckoutCart processes checkout of the user's cart
+func (h *CartHandler) handleCheckoutCart(req sharedModels.Request) sharedModels.Response {
+	var checkoutReq sharedModels.CheckoutCartRequest
+	if err := h.unmarshalPayload(req.Payload, &checkoutReq); err != nil {
+		log.Printf("Failed to parse checkout request: %v", err)
+		return h.createErrorResponse(req.CorrelationID, "Invalid checkout request format")
+	}
+
+	result, err := h.service.Checkout(checkoutReq)
+	if err != nil {
+		log.Printf("Checkout failed: %v", err)
+		return h.createErrorResponse(req.CorrelationID, err.Error())
+	}
+
+	response := sharedModels.OrderResponse{
+		Status:  "success",
+		Message: "Order accepted",
+		Data:    *result,
+	}
+	return h.createSuccessResponse(req.CorrelationID, response)
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	sharedModels "github.com/lucas/gokafka/shared/models"
+)
+
+// CartItem is one line of a cart as stored in Redis
+type CartItem struct {
+	ProductID int                `json:"product_id"`
+	VariantID int                `json:"variant_id,omitempty"`
+	SKU       string             `json:"sku,omitempty"`
+	Name      string             `json:"name"`
+	Quantity  int                `json:"quantity"`
+	UnitPrice sharedModels.Money `json:"unit_price"` // price when the item was added
+	AddedAt   time.Time          `json:"added_at"`
+}
+
+// LineKey identifies a cart line: a product, or one of its variants when
+// VariantID is set
+type LineKey struct {
+	ProductID int
+	VariantID int
+}
+
+// Key returns the key of the item's line
+func (i *CartItem) Key() LineKey {
+	return LineKey{ProductID: i.ProductID, VariantID: i.VariantID}
+}
+
+// Cart maps product and variant IDs to cart lines
+type Cart map[LineKey]*CartItem
@@ -0,0 +1,224 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/lucas/gokafka/cart-service/internal/models"
+	"github.com/lucas/gokafka/shared/utils"
+)
+
+// Cart storage constants
+const (
+	DefaultRedisAddr = "localhost:6379"
+	UserCartTTL      = 30 * 24 * time.Hour
+	SessionCartTTL   = 7 * 24 * time.Hour
+	// maxUpdateRetries bounds optimistic-lock retries when the same cart is
+	// changed concurrently
+	maxUpdateRetries = 5
+)
+
+// CartRepository stores each cart as a Redis hash of line to item. A line's
+// field is the product ID, followed by ":" and the variant ID for variants.
+type CartRepository struct {
+	client *redis.Client
+}
+
+func NewCartRepository() *CartRepository {
+	addr := utils.GetEnvOrDefault("REDIS_ADDR", DefaultRedisAddr)
+	client := redis.NewClient(&redis.Options{
+		Addr: addr,
+		DB:   0,
+	})
+
+	log.Printf("Connecting to Redis at %s", addr)
+	if err := client.Ping().Err(); err != nil {
+		log.Fatalf("failed to ping redis: %v", err)
+	}
+
+	log.Println("Connected to Redis")
+	return &CartRepository{client: client}
+}
+
+// UserCartKey returns the key of a logged-in user's cart
+func UserCartKey(userID string) string {
+	return "cart:user:" + userID
+}
+
+// SessionCartKey returns the key of an anonymous session's cart
+func SessionCartKey(sessionID string) string {
+	return "cart:session:" + sessionID
+}
+
+// GetCart returns the cart stored under key; a missing cart is empty
+func (r *CartRepository) GetCart(key string) (models.Cart, error) {
+	return loadCart(r.client, key)
+}
+
+// UpdateCart applies update to the cart under key and stores the result. The
+// cart is watched, so concurrent changes are retried instead of lost.
+func (r *CartRepository) UpdateCart(key string, ttl time.Duration, update func(cart models.Cart) error) (models.Cart, error) {
+	var result models.Cart
+
+	err := r.retryOnConflict(func() error {
+		return r.client.Watch(func(tx *redis.Tx) error {
+			cart, err := loadCart(tx, key)
+			if err != nil {
+				return err
+			}
+			if err := update(cart); err != nil {
+				return err
+			}
+
+			_, err = tx.Pipelined(func(pipe redis.Pipeliner) error {
+				return saveCart(pipe, key, cart, ttl)
+			})
+			result = cart
+			return err
+		}, key)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// MergeCarts moves every item of the cart under fromKey into the cart under
+// toKey, combining lines with merge, and deletes the source cart
+func (r *CartRepository) MergeCarts(fromKey, toKey string, ttl time.Duration, merge func(dst *models.CartItem, src *models.CartItem)) (models.Cart, error) {
+	var result models.Cart
+
+	err := r.retryOnConflict(func() error {
+		return r.client.Watch(func(tx *redis.Tx) error {
+			from, err := loadCart(tx, fromKey)
+			if err != nil {
+				return err
+			}
+			to, err := loadCart(tx, toKey)
+			if err != nil {
+				return err
+			}
+
+			for key, item := range from {
+				if existing, ok := to[key]; ok {
+					merge(existing, item)
+				} else {
+					to[key] = item
+				}
+			}
+
+			_, err = tx.Pipelined(func(pipe redis.Pipeliner) error {
+				if err := saveCart(pipe, toKey, to, ttl); err != nil {
+					return err
+				}
+				pipe.Del(fromKey)
+				return nil
+			})
+			result = to
+			return err
+		}, fromKey, toKey)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// DeleteCart removes the cart under key
+func (r *CartRepository) DeleteCart(key string) error {
+	if err := r.client.Del(key).Err(); err != nil {
+		return fmt.Errorf("failed to delete cart: %w", err)
+	}
+	return nil
+}
+
+func (r *CartRepository) retryOnConflict(fn func() error) error {
+	for i := 0; i < maxUpdateRetries; i++ {
+		err := fn()
+		if err != redis.TxFailedErr {
+			return err
+		}
+	}
+	return fmt.Errorf("cart is being modified concurrently, please retry")
+}
+
+// hashGetter is implemented by both *redis.Client and *redis.Tx
+type hashGetter interface {
+	HGetAll(key string) *redis.StringStringMapCmd
+}
+
+func loadCart(client hashGetter, key string) (models.Cart, error) {
+	fields, err := client.HGetAll(key).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load cart: %w", err)
+	}
+
+	cart := make(models.Cart, len(fields))
+	for field, value := range fields {
+		line, err := parseLineField(field)
+		if err != nil {
+			continue
+		}
+		var item models.CartItem
+		if err := json.Unmarshal([]byte(value), &item); err != nil {
+			log.Printf("Skipping unreadable cart item %s in %s: %v", field, key, err)
+			continue
+		}
+		item.ProductID, item.VariantID = line.ProductID, line.VariantID
+		cart[line] = &item
+	}
+
+	return cart, nil
+}
+
+// lineField returns the hash field of a cart line
+func lineField(line models.LineKey) string {
+	if line.VariantID == 0 {
+		return strconv.Itoa(line.ProductID)
+	}
+	return strconv.Itoa(line.ProductID) + ":" + strconv.Itoa(line.VariantID)
+}
+
+func parseLineField(field string) (models.LineKey, error) {
+	var line models.LineKey
+	productID, variantID, hasVariant := strings.Cut(field, ":")
+
+	var err error
+	if line.ProductID, err = strconv.Atoi(productID); err != nil {
+		return line, err
+	}
+	if hasVariant {
+		if line.VariantID, err = strconv.Atoi(variantID); err != nil {
+			return line, err
+		}
+	}
+	return line, nil
+}
+
+// saveCart replaces the stored cart; an empty cart is deleted
+func saveCart(pipe redis.Pipeliner, key string, cart models.Cart, ttl time.Duration) error {
+	pipe.Del(key)
+	if len(cart) == 0 {
+		return nil
+	}
+
+	fields := make(map[string]interface{}, len(cart))
+	for line, item := range cart {
+		value, err := json.Marshal(item)
+		if err != nil {
+			return fmt.Errorf("failed to serialize cart item: %w", err)
+		}
+		fields[lineField(line)] = string(value)
+	}
+
+	pipe.HMSet(key, fields)
+	pipe.Expire(key, ttl)
+	return nil
+}
@@ -0,0 +1,395 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/lucas/gokafka/cart-service/internal/models"
+	"github.com/lucas/gokafka/cart-service/internal/repository"
+	"github.com/lucas/gokafka/shared/bus"
+	sharedModels "github.com/lucas/gokafka/shared/models"
+)
+
+// Cart limits
+const (
+	MaxItemQuantity = 99
+	MaxCartLines    = 50
+	// checkoutTimeout covers the user and product lookups order-service
+	// makes before it accepts an order
+	checkoutTimeout = 25 * time.Second
+)
+
+type CartService struct {
+	repo   *repository.CartRepository
+	client *bus.Client
+}
+
+func NewCartService(repo *repository.CartRepository, client *bus.Client) *CartService {
+	return &CartService{
+		repo:   repo,
+		client: client,
+	}
+}
+
+// cartKey picks the user's cart when logged in, otherwise the session's
+func cartKey(userID, sessionID string) (string, time.Duration, error) {
+	if userID != "" {
+		return repository.UserCartKey(userID), repository.UserCartTTL, nil
+	}
+	if sessionID != "" {
+		return repository.SessionCartKey(sessionID), repository.SessionCartTTL, nil
+	}
+	return "", 0, fmt.Errorf("user ID or session ID is required")
+}
+
+// getProduct fetches a product's current data from product-service
+func (s *CartService) getProduct(productID int) (*sharedModels.ProductData, error) {
+	var product sharedModels.GetProductResponse
+	if err := s.client.Call("get-product-by-id", sharedModels.GetProductRequest{ID: productID}, &product); err != nil {
+		return nil, err
+	}
+	return &product.Data, nil
+}
+
+// getProducts fetches the current data of several products in one call.
+// Products that no longer exist are left out of the result.
+func (s *CartService) getProducts(productIDs []int) (map[int]*sharedModels.ProductData, error) {
+	products := make(map[int]*sharedModels.ProductData, len(productIDs))
+	if len(productIDs) == 0 {
+		return products, nil
+	}
+
+	var resp sharedModels.GetProductsResponse
+	if err := s.client.Call("get-products-by-ids", sharedModels.GetProductsRequest{IDs: productIDs}, &resp); err != nil {
+		return nil, err
+	}
+	for i := range resp.Data {
+		products[resp.Data[i].ID] = &resp.Data[i]
+	}
+	return products, nil
+}
+
+func (s *CartService) GetCart(req sharedModels.CartRequest) (*sharedModels.CartData, error) {
+	key, _, err := cartKey(req.UserID, req.SessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	cart, err := s.repo.GetCart(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.priceCart(req.UserID, req.SessionID, cart)
+}
+
+// AddItem adds a product, or one of its variants, to the cart, or increases
+// its quantity
+func (s *CartService) AddItem(req sharedModels.CartItemRequest) (*sharedModels.CartData, error) {
+	key, ttl, err := cartKey(req.UserID, req.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	if req.ProductID <= 0 {
+		return nil, fmt.Errorf("invalid product ID")
+	}
+	if req.VariantID < 0 {
+		return nil, fmt.Errorf("invalid variant ID")
+	}
+	if req.Quantity <= 0 {
+		return nil, fmt.Errorf("quantity must be greater than 0")
+	}
+
+	product, err := s.getProduct(req.ProductID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add product %d: %w", req.ProductID, err)
+	}
+	saleItem, err := product.SaleItem(req.VariantID)
+	if err != nil {
+		return nil, err
+	}
+	line := models.LineKey{ProductID: product.ID, VariantID: saleItem.VariantID}
+
+	cart, err := s.repo.UpdateCart(key, ttl, func(cart models.Cart) error {
+		// A cart is paid in one currency
+		for otherLine, other := range cart {
+			if otherLine != line && other.UnitPrice.Currency != saleItem.UnitPrice.Currency {
+				return fmt.Errorf("%s is priced in %s but the cart is in %s",
+					product.Name, saleItem.UnitPrice.Currency, other.UnitPrice.Currency)
+			}
+		}
+
+		item, ok := cart[line]
+		if !ok {
+			if len(cart) >= MaxCartLines {
+				return fmt.Errorf("cart cannot hold more than %d products", MaxCartLines)
+			}
+			item = &models.CartItem{ProductID: line.ProductID, VariantID: line.VariantID, AddedAt: time.Now().UTC()}
+			cart[line] = item
+		}
+		item.Name = product.Name
+		item.SKU = saleItem.SKU
+		item.UnitPrice = saleItem.UnitPrice
+		item.Quantity = min(item.Quantity+req.Quantity, MaxItemQuantity)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.priceCart(req.UserID, req.SessionID, cart)
+}
+
+// UpdateItem sets the quantity of a product or variant in the cart; 0
+// removes it
+func (s *CartService) UpdateItem(req sharedModels.CartItemRequest) (*sharedModels.CartData, error) {
+	key, ttl, err := cartKey(req.UserID, req.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	if req.Quantity < 0 {
+		return nil, fmt.Errorf("quantity cannot be negative")
+	}
+	if req.Quantity > MaxItemQuantity {
+		return nil, fmt.Errorf("quantity cannot exceed %d", MaxItemQuantity)
+	}
+
+	line := models.LineKey{ProductID: req.ProductID, VariantID: req.VariantID}
+	cart, err := s.repo.UpdateCart(key, ttl, func(cart models.Cart) error {
+		item, ok := cart[line]
+		if !ok {
+			if line.VariantID != 0 {
+				return fmt.Errorf("variant %d of product %d is not in the cart", line.VariantID, line.ProductID)
+			}
+			return fmt.Errorf("product %d is not in the cart", line.ProductID)
+		}
+		if req.Quantity == 0 {
+			delete(cart, line)
+			return nil
+		}
+		item.Quantity = req.Quantity
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.priceCart(req.UserID, req.SessionID, cart)
+}
+
+// RemoveItem removes a product or variant from the cart
+func (s *CartService) RemoveItem(req sharedModels.CartItemRequest) (*sharedModels.CartData, error) {
+	req.Quantity = 0
+	return s.UpdateItem(req)
+}
+
+// ClearCart empties the cart
+func (s *CartService) ClearCart(req sharedModels.CartRequest) error {
+	key, _, err := cartKey(req.UserID, req.SessionID)
+	if err != nil {
+		return err
+	}
+	return s.repo.DeleteCart(key)
+}
+
+// MergeCart moves an anonymous session's cart into the user's cart after
+// login. Quantities of products in both carts are added up.
+func (s *CartService) MergeCart(req sharedModels.MergeCartRequest) (*sharedModels.CartData, error) {
+	if req.UserID == "" || req.SessionID == "" {
+		return nil, fmt.Errorf("user ID and session ID are required")
+	}
+
+	cart, err := s.repo.MergeCarts(
+		repository.SessionCartKey(req.SessionID),
+		repository.UserCartKey(req.UserID),
+		repository.UserCartTTL,
+		func(dst, src *models.CartItem) {
+			dst.Quantity = min(dst.Quantity+src.Quantity, MaxItemQuantity)
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to merge carts: %w", err)
+	}
+
+	return s.priceCart(req.UserID, "", cart)
+}
+
+// Checkout turns the user's cart into an order and empties the cart once
+// order-service has accepted it
+func (s *CartService) Checkout(req sharedModels.CheckoutCartRequest) (*sharedModels.OrderData, error) {
+	if req.UserID == "" {
+		return nil, fmt.Errorf("user ID is required")
+	}
+
+	key := repository.UserCartKey(req.UserID)
+	cart, err := s.repo.GetCart(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(cart) == 0 {
+		return nil, fmt.Errorf("cart is empty")
+	}
+
+	priced, err := s.priceCart(req.UserID, "", cart)
+	if err != nil {
+		return nil, err
+	}
+
+	createReq := sharedModels.CreateOrderRequest{
+		UserID:       req.UserID,
+		PaymentToken: req.PaymentToken,
+		CouponCode:   req.CouponCode,
+	}
+	for _, item := range priced.Items {
+		if !item.Available {
+			return nil, fmt.Errorf("cart contains unavailable items: %v", priced.Warnings)
+		}
+		createReq.Items = append(createReq.Items, sharedModels.OrderItemRequest{
+			ProductID: item.ProductID,
+			VariantID: item.VariantID,
+			Quantity:  item.Quantity,
+		})
+	}
+
+	var order sharedModels.OrderResponse
+	if err := s.client.CallWithTimeout("create-order", createReq, &order, checkoutTimeout); err != nil {
+		return nil, fmt.Errorf("failed to place order: %w", err)
+	}
+
+	if err := s.repo.DeleteCart(key); err != nil {
+		// The order exists; a stale cart is only an inconvenience
+		log.Printf("Failed to clear cart of user %s after checkout: %v", req.UserID, err)
+	}
+
+	return &order.Data, nil
+}
+
+// priceCart shows the cart at current product prices and flags products
+// that changed price, disappeared or lack stock. The total is in the
+// currency of the oldest line still for sale; lines priced in another
+// currency, which a merge or a product's price change can leave behind,
+// are flagged instead of added up.
+func (s *CartService) priceCart(userID, sessionID string, cart models.Cart) (*sharedModels.CartData, error) {
+	data := &sharedModels.CartData{
+		UserID:    userID,
+		SessionID: sessionID,
+		Items:     make([]sharedModels.CartItemData, 0, len(cart)),
+	}
+	if userID != "" {
+		data.SessionID = ""
+	}
+
+	lines := make([]models.LineKey, 0, len(cart))
+	for line := range cart {
+		lines = append(lines, line)
+	}
+	sort.Slice(lines, func(i, j int) bool { return lineBefore(lines[i], lines[j]) })
+
+	productIDs := make([]int, 0, len(lines))
+	for i, line := range lines {
+		if i == 0 || line.ProductID != lines[i-1].ProductID {
+			productIDs = append(productIDs, line.ProductID)
+		}
+	}
+
+	products, err := s.getProducts(productIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to price cart: %w", err)
+	}
+	currency := cartCurrency(cart, products)
+
+	for _, key := range lines {
+		item := cart[key]
+		line := sharedModels.CartItemData{
+			ProductID:  item.ProductID,
+			VariantID:  item.VariantID,
+			SKU:        item.SKU,
+			Name:       item.Name,
+			Quantity:   item.Quantity,
+			UnitPrice:  item.UnitPrice,
+			AddedPrice: item.UnitPrice,
+		}
+
+		product, ok := products[key.ProductID]
+		if !ok {
+			data.Warnings = append(data.Warnings, fmt.Sprintf("%s is no longer available", item.Name))
+		} else if saleItem, err := product.SaleItem(key.VariantID); err != nil {
+			// The variant was deleted, or the product gained variants
+			line.Name = product.Name
+			data.Warnings = append(data.Warnings, err.Error())
+		} else {
+			line.Name = product.Name
+			line.SKU = saleItem.SKU
+			line.UnitPrice = saleItem.UnitPrice
+			line.PriceChanged = saleItem.UnitPrice != item.UnitPrice
+			line.Available = saleItem.Stock >= item.Quantity
+
+			if line.PriceChanged {
+				data.Warnings = append(data.Warnings,
+					fmt.Sprintf("price of %s changed from %s to %s", product.Name, item.UnitPrice, saleItem.UnitPrice))
+			}
+			if !line.Available {
+				data.Warnings = append(data.Warnings,
+					fmt.Sprintf("only %d of %s in stock", saleItem.Stock, product.Name))
+			}
+			if saleItem.UnitPrice.Currency != currency {
+				line.Available = false
+				data.Warnings = append(data.Warnings,
+					fmt.Sprintf("%s is priced in %s, not in the cart's currency %s", product.Name, saleItem.UnitPrice.Currency, currency))
+			}
+
+			lineTotal, err := saleItem.UnitPrice.Mul(item.Quantity)
+			if err != nil {
+				line.Available = false
+				data.Warnings = append(data.Warnings, fmt.Sprintf("%s cannot be priced: %v", product.Name, err))
+			}
+			line.LineTotal = lineTotal
+		}
+
+		if line.Available {
+			total, err := data.Total.Add(line.LineTotal)
+			if err != nil {
+				line.Available = false
+				data.Warnings = append(data.Warnings, fmt.Sprintf("%s cannot be added to the total: %v", line.Name, err))
+			} else {
+				data.Total = total
+			}
+		}
+		data.Items = append(data.Items, line)
+	}
+
+	return data, nil
+}
+
+// cartCurrency returns the current price currency of the oldest cart line
+// that is still for sale, or "" when there is none
+func cartCurrency(cart models.Cart, products map[int]*sharedModels.ProductData) string {
+	var oldest *models.CartItem
+	var currency string
+	for line, item := range cart {
+		product, ok := products[line.ProductID]
+		if !ok {
+			continue
+		}
+		saleItem, err := product.SaleItem(line.VariantID)
+		if err != nil {
+			continue
+		}
+		if oldest == nil || item.AddedAt.Before(oldest.AddedAt) ||
+			(item.AddedAt.Equal(oldest.AddedAt) && lineBefore(line, oldest.Key())) {
+			oldest = item
+			currency = saleItem.UnitPrice.Currency
+		}
+	}
+	return currency
+}
+
+// lineBefore orders cart lines by product, then variant
+func lineBefore(a, b models.LineKey) bool {
+	if a.ProductID != b.ProductID {
+		return a.ProductID < b.ProductID
+	}
+	return a.VariantID < b.VariantID
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"log"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lucas/gokafka/notification-service/internal/consumer"
+	"github.com/lucas/gokafka/notification-service/internal/handlers"
+	"github.com/lucas/gokafka/notification-service/internal/repository"
+	"github.com/lucas/gokafka/notification-service/internal/sender"
+	"github.com/lucas/gokafka/notification-service/internal/service"
+	"github.com/lucas/gokafka/notification-service/internal/templates"
+	"github.com/lucas/gokafka/shared/utils"
+)
+
+const (
+	DefaultPort = "8083"
+)
+
+func main() {
+	log.Println("Starting notification-service...")
+
+	// Initialize dependencies
+	renderer, err := templates.NewRenderer()
+	if err != nil {
+		log.Fatalf("Failed to load templates: %v", err)
+	}
+	repo := repository.NewNotificationRepository()
+	service := service.NewNotificationService(repo, sender.NewSender(), renderer)
+	handler := handlers.NewNotificationHandler(service)
+	userEvents := consumer.NewUserEventConsumer(service)
+
+	log.Println("Notification-service started, waiting for events...")
+
+	// Start Kafka message listener in background
+	go handler.ListenMessages()
+
+	// Start user event consumer and delivery retries in background
+	go userEvents.Run()
+	go service.RunRetries()
+
+	// Start HTTP server
+	startHTTPServer()
+}
+
+func startHTTPServer() {
+	router := gin.Default()
+
+	// Health endpoints
+	router.GET("/health", healthHandler)
+	router.GET("/ready", readyHandler)
+
+	port := utils.GetEnvOrDefault("PORT", DefaultPort)
+	log.Printf("Starting HTTP server on port %s", port)
+	if err := router.Run(":" + port); err != nil {
+		log.Fatalf("Failed to start HTTP server: %v", err)
+	}
+}
+
+func healthHandler(c *gin.Context) {
+	c.JSON(200, gin.H{"status": "healthy"})
+}
+
+func readyHandler(c *gin.Context) {
+	c.JSON(200, gin.H{"status": "ready"})
+}
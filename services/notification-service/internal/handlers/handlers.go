@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/lucas/gokafka/notification-service/internal/service"
+	sharedModels "github.com/lucas/gokafka/shared/models"
+	"github.com/lucas/gokafka/shared/utils"
+	"github.com/segmentio/kafka-go"
+)
+
+// ReplyTopic is the topic the gateway listens on for this service's replies
+const ReplyTopic = "notification-service-topic"
+
+// Request type constants
+const (
+	RequestTypeHealth            = "health"
+	RequestTypeGetPreferences    = "get-notification-preferences"
+	RequestTypeUpdatePreferences = "update-notification-preferences"
+)
+
+type NotificationHandler struct {
+	writer  *kafka.Writer
+	reader  *kafka.Reader
+	service *service.NotificationService
+}
+
+func NewNotificationHandler(service *service.NotificationService) *NotificationHandler {
+	broker := utils.GetEnvOrDefault("KAFKA_BROKERS", "localhost:9092")
+
+	return &NotificationHandler{
+		service: service,
+		writer: kafka.NewWriter(kafka.WriterConfig{
+			Brokers: []string{broker},
+		}),
+		reader: kafka.NewReader(kafka.ReaderConfig{
+			Brokers: []string{broker},
+			Topic:   "api-gateway-topic",
+			GroupID: "notification-service-group",
+		}),
+	}
+}
+
+// Helper method to create error responses
+func (h *NotificationHandler) createErrorResponse(correlationID, errorMsg string) sharedModels.Response {
+	return sharedModels.Response{
+		CorrelationID: correlationID,
+		Success:       false,
+		Error:         errorMsg,
+	}
+}
+
+// Helper method to create success responses
+func (h *NotificationHandler) createSuccessResponse(correlationID string, data interface{}) sharedModels.Response {
+	dataBytes, _ := json.Marshal(data)
+	return sharedModels.Response{
+		CorrelationID: correlationID,
+		Success:       true,
+		Data:          string(dataBytes),
+	}
+}
+
+// Helper method to unmarshal request payload
+func (h *NotificationHandler) unmarshalPayload(payload string, target interface{}) error {
+	return json.Unmarshal([]byte(payload), target)
+}
+
+func (h *NotificationHandler) ListenMessages() {
+	for {
+		m, err := h.reader.ReadMessage(context.Background())
+		if err != nil {
+			log.Println("read error:", err)
+			continue
+		}
+
+		var req sharedModels.Request
+		if err := json.Unmarshal(m.Value, &req); err != nil {
+			log.Println("unmarshal error:", err)
+			continue
+		}
+
+		resp, ok := h.handleRequest(req)
+		if !ok {
+			continue
+		}
+
+		respBytes, _ := json.Marshal(resp)
+		err = h.writer.WriteMessages(context.Background(),
+			kafka.Message{
+				Topic: req.ReplyTo,
+				Value: respBytes,
+			},
+		)
+		if err != nil {
+			log.Println("write error:", err)
+		} else {
+			log.Printf("responded to %s with correlation_id %s", req.ReplyTo, req.CorrelationID)
+		}
+	}
+}
+
+// handleRequest processes different request types. It returns false for
+// requests owned by other services, which share the same request topic.
+func (h *NotificationHandler) handleRequest(req sharedModels.Request) (sharedModels.Response, bool) {
+	switch req.Type {
+	case RequestTypeHealth:
+		// Every service receives health checks; answer only our own
+		if req.ReplyTo != ReplyTopic {
+			return sharedModels.Response{}, false
+		}
+		return h.handleHealth(req.CorrelationID), true
+	case RequestTypeGetPreferences:
+		return h.handleGetPreferences(req), true
+	case RequestTypeUpdatePreferences:
+		return h.handleUpdatePreferences(req), true
+	default:
+		return sharedModels.Response{}, false
+	}
+}
+
+// handleHealth returns health status
+func (h *NotificationHandler) handleHealth(correlationID string) sharedModels.Response {
+	healthResponse := map[string]interface{}{
+		"service":   "notification-service",
+		"status":    "healthy",
+		"timestamp": time.Now().UTC().Format(time.RFC3339Nano),
+	}
+	return h.createSuccessResponse(correlationID, healthResponse)
+}
+
+// handleGetPreferences processes get notification preferences request
+func (h *NotificationHandler) handleGetPreferences(req sharedModels.Request) sharedModels.Response {
+	var getReq sharedModels.GetNotificationPreferencesRequest
+	if err := h.unmarshalPayload(req.Payload, &getReq); err != nil {
+		log.Printf("Failed to parse get preferences request: %v", err)
+		return h.createErrorResponse(req.CorrelationID, "Invalid get preferences request format")
+	}
+
+	result, err := h.service.GetPreferences(getReq.UserID)
+	if err != nil {
+		log.Printf("Failed to get preferences: %v", err)
+		return h.createErrorResponse(req.CorrelationID, err.Error())
+	}
+
+	response := sharedModels.NotificationPreferencesResponse{
+		Status: "success",
+		Data:   *result,
+	}
+	return h.createSuccessResponse(req.CorrelationID, response)
+}
+
+// handleUpdatePreferences processes update notification preferences request
+func (h *NotificationHandler) handleUpdatePreferences(req sharedModels.Request) sharedModels.Response {
+	var updateReq sharedModels.UpdateNotificationPreferencesRequest
+	if err := h.unmarshalPayload(req.Payload, &updateReq); err != nil {
+		log.Printf("Failed to parse update preferences request: %v", err)
+		return h.createErrorResponse(req.CorrelationID, "Invalid update preferences request format")
+	}
+
+	result, err := h.service.UpdatePreferences(updateReq)
+	if err != nil {
+		log.Printf("Failed to update preferences: %v", err)
+		return h.createErrorResponse(req.CorrelationID, err.Error())
+	}
+
+	response := sharedModels.NotificationPreferencesResponse{
+		Status: "success",
+		Data:   *result,
+	}
+	return h.createSuccessResponse(req.CorrelationID, response)
+}
@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// Delivery statuses
+const (
+	DeliveryStatusPending = "pending"
+	DeliveryStatusSent    = "sent"
+	DeliveryStatusFailed  = "failed"
+	DeliveryStatusSkipped = "skipped"
+)
+
+type Preference struct {
+	UserID            string    `json:"user_id" db:"user_id"`
+	EmailEnabled      bool      `json:"email_enabled" db:"email_enabled"`
+	DisabledTemplates []string  `json:"disabled_templates" db:"disabled_templates"`
+	UpdatedAt         time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// TemplateEnabled reports whether the user accepts emails from a template
+func (p *Preference) TemplateEnabled(template string) bool {
+	if !p.EmailEnabled {
+		return false
+	}
+	for _, disabled := range p.DisabledTemplates {
+		if disabled == template {
+			return false
+		}
+	}
+	return true
+}
+
+type Delivery struct {
+	ID            int64      `json:"id" db:"id"`
+	EventID       string     `json:"event_id" db:"event_id"`
+	Template      string     `json:"template" db:"template"`
+	UserID        string     `json:"user_id" db:"user_id"`
+	Recipient     string     `json:"recipient" db:"recipient"`
+	Subject       string     `json:"subject" db:"subject"`
+	Body          string     `json:"body" db:"body"`
+	Status        string     `json:"status" db:"status"`
+	Attempts      int        `json:"attempts" db:"attempts"`
+	LastError     string     `json:"last_error" db:"last_error"`
+	NextAttemptAt time.Time  `json:"next_attempt_at" db:"next_attempt_at"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	SentAt        *time.Time `json:"sent_at" db:"sent_at"`
+}
@@ -0,0 +1,232 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/lucas/gokafka/notification-service/internal/models"
+	"github.com/lucas/gokafka/shared/utils"
+)
+
+// Database constants
+const (
+	DefaultPostgresHost     = "localhost"
+	DefaultPostgresPort     = "5432"
+	DefaultPostgresDB       = "gokafka"
+	DefaultPostgresUser     = "postgres"
+	DefaultPostgresPassword = "postgres"
+)
+
+type NotificationRepository struct {
+	db *sql.DB
+}
+
+func NewNotificationRepository() *NotificationRepository {
+	db := initDatabase()
+	repo := &NotificationRepository{db: db}
+	repo.createTablesIfNotExists()
+	return repo
+}
+
+// initDatabase initializes the database connection
+func initDatabase() *sql.DB {
+	host := utils.GetEnvOrDefault("POSTGRES_HOST", DefaultPostgresHost)
+	port := utils.GetEnvOrDefault("POSTGRES_PORT", DefaultPostgresPort)
+	dbname := utils.GetEnvOrDefault("POSTGRES_DB", DefaultPostgresDB)
+	user := utils.GetEnvOrDefault("POSTGRES_USER", DefaultPostgresUser)
+	password := utils.GetEnvOrDefault("POSTGRES_PASSWORD", DefaultPostgresPassword)
+
+	connStr := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable",
+		user, password, host, port, dbname)
+
+	log.Printf("Connecting to PostgreSQL at %s:%s", host, port)
+	db, err := sql.Open("postgres", connStr)
+	if err != nil {
+		log.Fatalf("failed to connect to postgres: %v", err)
+	}
+
+	if err := db.Ping(); err != nil {
+		log.Fatalf("failed to ping postgres: %v", err)
+	}
+
+	log.Println("Connected to PostgreSQL database")
+	return db
+}
+
+func (r *NotificationRepository) createTablesIfNotExists() {
+	query := `
+	CREATE TABLE IF NOT EXISTS notification_preferences (
+		user_id VARCHAR(255) PRIMARY KEY,
+		email_enabled BOOLEAN NOT NULL DEFAULT TRUE,
+		disabled_templates TEXT[] NOT NULL DEFAULT '{}',
+		updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE TABLE IF NOT EXISTS notification_deliveries (
+		id BIGSERIAL PRIMARY KEY,
+		event_id VARCHAR(255) NOT NULL,
+		template VARCHAR(100) NOT NULL,
+		user_id VARCHAR(255) NOT NULL,
+		recipient VARCHAR(255) NOT NULL,
+		subject TEXT NOT NULL,
+		body TEXT NOT NULL,
+		status VARCHAR(20) NOT NULL,
+		attempts INTEGER NOT NULL DEFAULT 0,
+		last_error TEXT NOT NULL DEFAULT '',
+		next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		sent_at TIMESTAMP,
+		UNIQUE (event_id, template)
+	);
+	CREATE INDEX IF NOT EXISTS idx_notification_deliveries_retry
+		ON notification_deliveries (next_attempt_at) WHERE status IN ('pending', 'failed')`
+
+	if _, err := r.db.Exec(query); err != nil {
+		log.Fatalf("failed to create notification tables: %v", err)
+	}
+
+	log.Println("Notification tables are ready")
+}
+
+// GetPreferences returns the user's preferences, or the defaults when the
+// user never changed them
+func (r *NotificationRepository) GetPreferences(userID string) (*models.Preference, error) {
+	query := `
+	SELECT user_id, email_enabled, disabled_templates, updated_at
+	FROM notification_preferences WHERE user_id = $1`
+
+	pref := models.Preference{UserID: userID, EmailEnabled: true, DisabledTemplates: []string{}}
+	err := r.db.QueryRow(query, userID).Scan(
+		&pref.UserID, &pref.EmailEnabled, pq.Array(&pref.DisabledTemplates), &pref.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return &pref, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &pref, nil
+}
+
+func (r *NotificationRepository) SavePreferences(pref *models.Preference) error {
+	query := `
+	INSERT INTO notification_preferences (user_id, email_enabled, disabled_templates, updated_at)
+	VALUES ($1, $2, $3, $4)
+	ON CONFLICT (user_id) DO UPDATE
+	SET email_enabled = EXCLUDED.email_enabled,
+		disabled_templates = EXCLUDED.disabled_templates,
+		updated_at = EXCLUDED.updated_at
+	RETURNING updated_at`
+
+	err := r.db.QueryRow(query, pref.UserID, pref.EmailEnabled,
+		pq.Array(pref.DisabledTemplates), time.Now()).Scan(&pref.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save preferences: %w", err)
+	}
+
+	return nil
+}
+
+func (r *NotificationRepository) DeletePreferences(userID string) error {
+	if _, err := r.db.Exec(`DELETE FROM notification_preferences WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete preferences: %w", err)
+	}
+	return nil
+}
+
+// CreateDelivery records a delivery. It returns false when the same event
+// already produced a delivery for this template, so redelivered events
+// never send twice. A pending delivery is leased to the caller: the retry
+// sweeper leaves it alone until lease has passed, and picks it up after
+// that if the caller never recorded an outcome.
+func (r *NotificationRepository) CreateDelivery(delivery *models.Delivery, lease time.Duration) (bool, error) {
+	query := `
+	INSERT INTO notification_deliveries
+		(event_id, template, user_id, recipient, subject, body, status, created_at, next_attempt_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	ON CONFLICT (event_id, template) DO NOTHING
+	RETURNING id, created_at`
+
+	now := time.Now()
+	err := r.db.QueryRow(query, delivery.EventID, delivery.Template, delivery.UserID,
+		delivery.Recipient, delivery.Subject, delivery.Body, delivery.Status, now, now.Add(lease),
+	).Scan(&delivery.ID, &delivery.CreatedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to create delivery: %w", err)
+	}
+
+	return true, nil
+}
+
+// MarkDeliverySent records a successful send
+func (r *NotificationRepository) MarkDeliverySent(id int64, attempts int) error {
+	query := `
+	UPDATE notification_deliveries
+	SET status = $1, attempts = $2, last_error = '', sent_at = $3
+	WHERE id = $4`
+
+	if _, err := r.db.Exec(query, models.DeliveryStatusSent, attempts, time.Now(), id); err != nil {
+		return fmt.Errorf("failed to mark delivery sent: %w", err)
+	}
+	return nil
+}
+
+// MarkDeliveryFailed records a failed send and when to try again
+func (r *NotificationRepository) MarkDeliveryFailed(id int64, attempts int, lastError string, nextAttemptAt time.Time) error {
+	query := `
+	UPDATE notification_deliveries
+	SET status = $1, attempts = $2, last_error = $3, next_attempt_at = $4
+	WHERE id = $5`
+
+	if _, err := r.db.Exec(query, models.DeliveryStatusFailed, attempts, lastError, nextAttemptAt, id); err != nil {
+		return fmt.Errorf("failed to mark delivery failed: %w", err)
+	}
+	return nil
+}
+
+// ClaimRetryableDeliveries returns failed deliveries that are due for
+// another attempt, and pending ones whose lease ran out without an outcome
+// being recorded, for example because the process crashed mid send. It
+// pushes their next attempt forward by lease, so other replicas don't pick
+// the same rows.
+func (r *NotificationRepository) ClaimRetryableDeliveries(maxAttempts, limit int, lease time.Duration) ([]*models.Delivery, error) {
+	query := `
+	UPDATE notification_deliveries SET next_attempt_at = $1
+	WHERE id IN (
+		SELECT id FROM notification_deliveries
+		WHERE status = ANY($2) AND attempts < $3 AND next_attempt_at <= $4
+		ORDER BY next_attempt_at
+		LIMIT $5
+		FOR UPDATE SKIP LOCKED
+	)
+	RETURNING id, event_id, template, user_id, recipient, subject, body, status, attempts, last_error, created_at`
+
+	now := time.Now()
+	statuses := pq.Array([]string{models.DeliveryStatusPending, models.DeliveryStatusFailed})
+	rows, err := r.db.Query(query, now.Add(lease), statuses, maxAttempts, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []*models.Delivery
+	for rows.Next() {
+		var delivery models.Delivery
+		if err := rows.Scan(
+			&delivery.ID, &delivery.EventID, &delivery.Template, &delivery.UserID,
+			&delivery.Recipient, &delivery.Subject, &delivery.Body, &delivery.Status,
+			&delivery.Attempts, &delivery.LastError, &delivery.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, &delivery)
+	}
+
+	return deliveries, rows.Err()
+}
@@ -0,0 +1,39 @@
+package sender
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// FileSender appends messages to a file, or to stdout when path is empty.
+// It is meant for local development and tests.
+type FileSender struct {
+	mu   sync.Mutex
+	path string
+}
+
+func NewFileSender(path string) *FileSender {
+	return &FileSender{path: path}
+}
+
+func (s *FileSender) Send(msg Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var out io.Writer = os.Stdout
+	if s.path != "" {
+		file, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return fmt.Errorf("failed to open %s: %w", s.path, err)
+		}
+		defer file.Close()
+		out = file
+	}
+
+	_, err := fmt.Fprintf(out, "----- %s\nTo: %s\nSubject: %s\n\n%s\n",
+		time.Now().UTC().Format(time.RFC3339), msg.To, msg.Subject, msg.Body)
+	return err
+}
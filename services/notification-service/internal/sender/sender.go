@@ -0,0 +1,45 @@
+package sender
+
+import (
+	"log"
+
+	"github.com/lucas/gokafka/shared/utils"
+)
+
+// Sender types
+const (
+	SenderTypeSMTP   = "smtp"
+	SenderTypeFile   = "file"
+	SenderTypeStdout = "stdout"
+)
+
+// Message is a rendered email ready to be delivered
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender delivers rendered messages
+type Sender interface {
+	Send(msg Message) error
+}
+
+// NewSender builds the sender selected by NOTIFICATION_SENDER. Local
+// development defaults to stdout so no mail server is needed.
+func NewSender() Sender {
+	senderType := utils.GetEnvOrDefault("NOTIFICATION_SENDER", SenderTypeStdout)
+
+	switch senderType {
+	case SenderTypeSMTP:
+		log.Println("Sending notifications via SMTP")
+		return NewSMTPSender()
+	case SenderTypeFile:
+		path := utils.GetEnvOrDefault("NOTIFICATION_FILE", "notifications.log")
+		log.Printf("Writing notifications to %s", path)
+		return NewFileSender(path)
+	default:
+		log.Println("Writing notifications to stdout")
+		return NewFileSender("")
+	}
+}
@@ -0,0 +1,117 @@
+package sender
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/lucas/gokafka/shared/utils"
+)
+
+// DefaultSMTPTimeout bounds dialing and the whole SMTP conversation, so a
+// hung relay cannot stall the consumer that sends inline
+const DefaultSMTPTimeout = 30 * time.Second
+
+// SMTPSender delivers messages through an SMTP relay
+type SMTPSender struct {
+	host    string
+	addr    string
+	from    string
+	auth    smtp.Auth
+	timeout time.Duration
+}
+
+func NewSMTPSender() *SMTPSender {
+	host := utils.GetEnvOrDefault("SMTP_HOST", "localhost")
+	port := utils.GetEnvOrDefault("SMTP_PORT", "25")
+	username := utils.GetEnvOrDefault("SMTP_USERNAME", "")
+	password := utils.GetEnvOrDefault("SMTP_PASSWORD", "")
+
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return &SMTPSender{
+		host:    host,
+		addr:    net.JoinHostPort(host, port),
+		from:    utils.GetEnvOrDefault("SMTP_FROM", "no-reply@gokafka.local"),
+		auth:    auth,
+		timeout: DefaultSMTPTimeout,
+	}
+}
+
+func (s *SMTPSender) Send(msg Message) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", s.from)
+	fmt.Fprintf(&b, "To: %s\r\n", headerValue(msg.To))
+	fmt.Fprintf(&b, "Subject: %s\r\n", headerValue(msg.Subject))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=\"utf-8\"\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+
+	if err := s.sendMail(msg.To, []byte(b.String())); err != nil {
+		return fmt.Errorf("smtp send failed: %w", err)
+	}
+	return nil
+}
+
+// sendMail does what smtp.SendMail does, on a connection with a deadline
+func (s *SMTPSender) sendMail(to string, data []byte) error {
+	conn, err := net.DialTimeout("tcp", s.addr, s.timeout)
+	if err != nil {
+		return err
+	}
+	if err := conn.SetDeadline(time.Now().Add(s.timeout)); err != nil {
+		conn.Close()
+		return err
+	}
+
+	c, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: s.host}); err != nil {
+			return err
+		}
+	}
+	if s.auth != nil {
+		if ok, _ := c.Extension("AUTH"); !ok {
+			return errors.New("server doesn't support AUTH")
+		}
+		if err := c.Auth(s.auth); err != nil {
+			return err
+		}
+	}
+
+	if err := c.Mail(s.from); err != nil {
+		return err
+	}
+	if err := c.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// headerValue strips line breaks so user data cannot inject headers
+func headerValue(value string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(value)
+}
@@ -0,0 +1,230 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/lucas/gokafka/notification-service/internal/models"
+	"github.com/lucas/gokafka/notification-service/internal/repository"
+	"github.com/lucas/gokafka/notification-service/internal/sender"
+	"github.com/lucas/gokafka/notification-service/internal/templates"
+	sharedModels "github.com/lucas/gokafka/shared/models"
+)
+
+// Retry constants
+const (
+	MaxDeliveryAttempts = 5
+	InitialRetryBackoff = 30 * time.Second
+	MaxRetryBackoff     = time.Hour
+	RetryInterval       = 15 * time.Second
+	RetryBatchSize      = 50
+	RetryLease          = 5 * time.Minute
+)
+
+type NotificationService struct {
+	repo     *repository.NotificationRepository
+	sender   sender.Sender
+	renderer *templates.Renderer
+}
+
+func NewNotificationService(repo *repository.NotificationRepository, sender sender.Sender, renderer *templates.Renderer) *NotificationService {
+	return &NotificationService{
+		repo:     repo,
+		sender:   sender,
+		renderer: renderer,
+	}
+}
+
+// Helper method to convert Preference to the shared representation
+func (s *NotificationService) preferenceToData(pref *models.Preference) *sharedModels.NotificationPreferences {
+	updatedAt := ""
+	if !pref.UpdatedAt.IsZero() {
+		updatedAt = pref.UpdatedAt.Format(time.RFC3339)
+	}
+	return &sharedModels.NotificationPreferences{
+		UserID:            pref.UserID,
+		EmailEnabled:      pref.EmailEnabled,
+		DisabledTemplates: pref.DisabledTemplates,
+		UpdatedAt:         updatedAt,
+	}
+}
+
+// HandleUserEvent sends every email a user event triggers. An error means
+// the event could not be recorded and should be redelivered; send failures
+// are recorded and retried in the background instead.
+func (s *NotificationService) HandleUserEvent(event sharedModels.Event) error {
+	names := templates.TemplatesForEvent(event.Type)
+	if len(names) == 0 {
+		return nil
+	}
+
+	var user sharedModels.UserData
+	if err := json.Unmarshal([]byte(event.Payload), &user); err != nil {
+		log.Printf("Skipping %s event %s with invalid payload: %v", event.Type, event.ID, err)
+		return nil
+	}
+	if user.Email == "" {
+		log.Printf("Skipping %s event %s without recipient", event.Type, event.ID)
+		return nil
+	}
+
+	pref, err := s.repo.GetPreferences(user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to load preferences for %s: %w", user.ID, err)
+	}
+
+	for _, name := range names {
+		if err := s.deliver(event, name, &user, pref); err != nil {
+			return err
+		}
+	}
+
+	// Preferences are meaningless once the account is gone
+	if event.Type == sharedModels.UserEventDeleted {
+		if err := s.repo.DeletePreferences(user.ID); err != nil {
+			log.Printf("Failed to delete preferences for %s: %v", user.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// deliver renders, records and sends one templated email
+func (s *NotificationService) deliver(event sharedModels.Event, name string, user *sharedModels.UserData, pref *models.Preference) error {
+	subject, body, err := s.renderer.Render(name, user)
+	if err != nil {
+		return err
+	}
+
+	delivery := &models.Delivery{
+		EventID:   event.ID,
+		Template:  name,
+		UserID:    user.ID,
+		Recipient: user.Email,
+		Subject:   subject,
+		Body:      body,
+		Status:    models.DeliveryStatusPending,
+	}
+	if !pref.TemplateEnabled(name) {
+		delivery.Status = models.DeliveryStatusSkipped
+	}
+
+	// The lease keeps the retry sweeper from sending the same email while
+	// this attempt is in flight
+	created, err := s.repo.CreateDelivery(delivery, RetryLease)
+	if err != nil {
+		return err
+	}
+	if !created {
+		log.Printf("Delivery of %s for event %s already recorded, skipping", name, event.ID)
+		return nil
+	}
+	if delivery.Status == models.DeliveryStatusSkipped {
+		log.Printf("User %s opted out of %s emails", user.ID, name)
+		return nil
+	}
+
+	s.attempt(delivery)
+	return nil
+}
+
+// attempt sends a recorded delivery and stores the outcome
+func (s *NotificationService) attempt(delivery *models.Delivery) {
+	delivery.Attempts++
+
+	err := s.sender.Send(sender.Message{
+		To:      delivery.Recipient,
+		Subject: delivery.Subject,
+		Body:    delivery.Body,
+	})
+	if err == nil {
+		if err := s.repo.MarkDeliverySent(delivery.ID, delivery.Attempts); err != nil {
+			log.Printf("Failed to record delivery %d: %v", delivery.ID, err)
+		}
+		log.Printf("Sent %s email to user %s", delivery.Template, delivery.UserID)
+		return
+	}
+
+	log.Printf("Delivery %d attempt %d failed: %v", delivery.ID, delivery.Attempts, err)
+	nextAttemptAt := time.Now().Add(retryBackoff(delivery.Attempts))
+	if err := s.repo.MarkDeliveryFailed(delivery.ID, delivery.Attempts, err.Error(), nextAttemptAt); err != nil {
+		log.Printf("Failed to record delivery %d: %v", delivery.ID, err)
+	}
+}
+
+// retryBackoff doubles the wait after every failed attempt
+func retryBackoff(attempts int) time.Duration {
+	backoff := InitialRetryBackoff
+	for i := 1; i < attempts && backoff < MaxRetryBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > MaxRetryBackoff {
+		backoff = MaxRetryBackoff
+	}
+	return backoff
+}
+
+// RunRetries periodically resends failed deliveries, and pending ones that
+// were never attempted, until they succeed or reach MaxDeliveryAttempts
+func (s *NotificationService) RunRetries() {
+	ticker := time.NewTicker(RetryInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		deliveries, err := s.repo.ClaimRetryableDeliveries(MaxDeliveryAttempts, RetryBatchSize, RetryLease)
+		if err != nil {
+			log.Printf("Failed to load retryable deliveries: %v", err)
+			continue
+		}
+		for _, delivery := range deliveries {
+			s.attempt(delivery)
+		}
+	}
+}
+
+func (s *NotificationService) GetPreferences(userID string) (*sharedModels.NotificationPreferences, error) {
+	// Validate input
+	if userID == "" {
+		return nil, fmt.Errorf("user ID is required")
+	}
+
+	pref, err := s.repo.GetPreferences(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get preferences: %w", err)
+	}
+
+	return s.preferenceToData(pref), nil
+}
+
+func (s *NotificationService) UpdatePreferences(req sharedModels.UpdateNotificationPreferencesRequest) (*sharedModels.NotificationPreferences, error) {
+	// Validate input
+	if req.UserID == "" {
+		return nil, fmt.Errorf("user ID is required")
+	}
+	for _, name := range req.DisabledTemplates {
+		if !templates.IsKnownTemplate(name) {
+			return nil, fmt.Errorf("unknown notification template: %s", name)
+		}
+	}
+
+	pref, err := s.repo.GetPreferences(req.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get preferences: %w", err)
+	}
+
+	// Only change the fields that were supplied
+	if req.EmailEnabled != nil {
+		pref.EmailEnabled = *req.EmailEnabled
+	}
+	if req.DisabledTemplates != nil {
+		pref.DisabledTemplates = req.DisabledTemplates
+	}
+
+	if err := s.repo.SavePreferences(pref); err != nil {
+		return nil, err
+	}
+
+	return s.preferenceToData(pref), nil
+}
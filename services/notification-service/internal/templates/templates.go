@@ -0,0 +1,81 @@
+package templates
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"strings"
+	"text/template"
+
+	sharedModels "github.com/lucas/gokafka/shared/models"
+)
+
+// Template names
+const (
+	TemplateWelcome        = "welcome"
+	TemplateProfileUpdated = "profile_updated"
+	TemplateAccountDeleted = "account_deleted"
+)
+
+// allTemplates lists every template users can opt out of
+var allTemplates = []string{TemplateWelcome, TemplateProfileUpdated, TemplateAccountDeleted}
+
+// eventTemplates maps user event types to the emails they trigger
+var eventTemplates = map[string][]string{
+	sharedModels.UserEventRegistered: {TemplateWelcome},
+	sharedModels.UserEventUpdated:    {TemplateProfileUpdated},
+	sharedModels.UserEventDeleted:    {TemplateAccountDeleted},
+}
+
+//go:embed files/*.tmpl
+var files embed.FS
+
+// Renderer renders email templates
+type Renderer struct {
+	templates map[string]*template.Template
+}
+
+func NewRenderer() (*Renderer, error) {
+	r := &Renderer{templates: make(map[string]*template.Template)}
+	for _, name := range allTemplates {
+		tmpl, err := template.ParseFS(files, "files/"+name+".tmpl")
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse template %s: %w", name, err)
+		}
+		r.templates[name] = tmpl
+	}
+	return r, nil
+}
+
+// TemplatesForEvent returns the templates an event type triggers
+func TemplatesForEvent(eventType string) []string {
+	return eventTemplates[eventType]
+}
+
+// IsKnownTemplate reports whether name is a template this service sends
+func IsKnownTemplate(name string) bool {
+	for _, known := range allTemplates {
+		if known == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Render executes a template and returns its subject and body
+func (r *Renderer) Render(name string, data interface{}) (string, string, error) {
+	tmpl, ok := r.templates[name]
+	if !ok {
+		return "", "", fmt.Errorf("unknown template %s", name)
+	}
+
+	var subject, body bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return "", "", fmt.Errorf("failed to render %s subject: %w", name, err)
+	}
+	if err := tmpl.ExecuteTemplate(&body, "body", data); err != nil {
+		return "", "", fmt.Errorf("failed to render %s body: %w", name, err)
+	}
+
+	return strings.TrimSpace(subject.String()), body.String(), nil
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"log"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lucas/gokafka/order-service/internal/handlers"
+	"github.com/lucas/gokafka/order-service/internal/repository"
+	"github.com/lucas/gokafka/order-service/internal/service"
+	"github.com/lucas/gokafka/shared/bus"
+	"github.com/lucas/gokafka/shared/utils"
+)
+
+const (
+	DefaultPort = "8085"
+	// ReplyTopic receives replies to the requests order-service sends to
+	// other services during checkout
+	ReplyTopic = "order-service-replies"
+)
+
+func main() {
+	log.Println("Starting order-service...")
+
+	// Initialize dependencies
+	repo := repository.NewOrderRepository()
+	client := bus.NewClient(ReplyTopic)
+	service := service.NewOrderService(repo, client)
+	handler := handlers.NewOrderHandler(service)
+
+	log.Println("Order-service started, waiting for requests...")
+
+	// Start Kafka message listener in background
+	go handler.ListenMessages()
+
+	// Start recovery of interrupted checkouts in background
+	go service.RunRecovery()
+
+	// Start HTTP server
+	startHTTPServer()
+}
+
+func startHTTPServer() {
+	router := gin.Default()
+
+	// Health endpoints
+	router.GET("/health", healthHandler)
+	router.GET("/ready", readyHandler)
+
+	port := utils.GetEnvOrDefault("PORT", DefaultPort)
+	log.Printf("Starting HTTP server on port %s", port)
+	if err := router.Run(":" + port); err != nil {
+		log.Fatalf("Failed to start HTTP server: %v", err)
+	}
+}
+
+func healthHandler(c *gin.Context) {
+	c.JSON(200, gin.H{"status": "healthy"})
+}
+
+func readyHandler(c *gin.Context) {
+	c.JSON(200, gin.H{"status": "ready"})
+}
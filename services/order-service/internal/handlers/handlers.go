@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/lucas/gokafka/order-service/internal/service"
+	sharedModels "github.com/lucas/gokafka/shared/models"
+	"github.com/lucas/gokafka/shared/utils"
+	"github.com/segmentio/kafka-go"
+)
+
+// ReplyTopic is the topic the gateway listens on for this service's replies
+const ReplyTopic = "order-service-topic"
+
+// Request type constants
+const (
+	RequestTypeHealth      = "health"
+	RequestTypeCreateOrder = "create-order"
+	RequestTypeGetOrder    = "get-order"
+	RequestTypeListOrders  = "list-orders"
+)
+
+type OrderHandler struct {
+	writer  *kafka.Writer
+	reader  *kafka.Reader
+	service *service.OrderService
+}
+
+func NewOrderHandler(service *service.OrderService) *OrderHandler {
+	broker := utils.GetEnvOrDefault("KAFKA_BROKERS", "localhost:9092")
+
+	return &OrderHandler{
+		service: service,
+		writer: kafka.NewWriter(kafka.WriterConfig{
+			Brokers: []string{broker},
+		}),
+		reader: kafka.NewReader(kafka.ReaderConfig{
+			Brokers: []string{broker},
+			Topic:   "api-gateway-topic",
+			GroupID: "order-service-group",
+		}),
+	}
+}
+
+// Helper method to create error responses
+func (h *OrderHandler) createErrorResponse(correlationID, errorMsg string) sharedModels.Response {
+	return sharedModels.Response{
+		CorrelationID: correlationID,
+		Success:       false,
+		Error:         errorMsg,
+	}
+}
+
+// Helper method to create success responses
+func (h *OrderHandler) createSuccessResponse(correlationID string, data interface{}) sharedModels.Response {
+	dataBytes, _ := json.Marshal(data)
+	return sharedModels.Response{
+		CorrelationID: correlationID,
+		Success:       true,
+		Data:          string(dataBytes),
+	}
+}
+
+// Helper method to unmarshal request payload
+func (h *OrderHandler) unmarshalPayload(payload string, target interface{}) error {
+	return json.Unmarshal([]byte(payload), target)
+}
+
+func (h *OrderHandler) ListenMessages() {
+	for {
+		m, err := h.reader.ReadMessage(context.Background())
+		if err != nil {
+			log.Println("read error:", err)
+			continue
+		}
+
+		var req sharedModels.Request
+		if err := json.Unmarshal(m.Value, &req); err != nil {
+			log.Println("unmarshal error:", err)
+			continue
+		}
+
+		resp, ok := h.handleRequest(req)
+		if !ok {
+			continue
+		}
+
+		respBytes, _ := json.Marshal(resp)
+		err = h.writer.WriteMessages(context.Background(),
+			kafka.Message{
+				Topic: req.ReplyTo,
+				Value: respBytes,
+			},
+		)
+		if err != nil {
+			log.Println("write error:", err)
+		} else {
+			log.Printf("responded to %s with correlation_id %s", req.ReplyTo, req.CorrelationID)
+		}
+	}
+}
+
+// handleRequest processes different request types. It returns false for
+// requests owned by other services, which share the same request topic.
+func (h *OrderHandler) handleRequest(req sharedModels.Request) (sharedModels.Response, bool) {
+	switch req.Type {
+	case RequestTypeHealth:
+		// Every service receives health checks; answer only our own
+		if req.ReplyTo != ReplyTopic {
+			return sharedModels.Response{}, false
+		}
+		return h.handleHealth(req.CorrelationID), true
+	case RequestTypeCreateOrder:
+		return h.handleCreateOrder(req), true
+	case RequestTypeGetOrder:
+		return h.handleGetOrder(req), true
+	case RequestTypeListOrders:
+		return h.handleListOrders(req), true
+	default:
+		return sharedModels.Response{}, false
+	}
+}
+
+// handleHealth returns health status
+func (h *OrderHandler) handleHealth(correlationID string) sharedModels.Response {
+	healthResponse := map[string]interface{}{
+		"service":   "order-service",
+		"status":    "healthy",
+		"timestamp": time.Now().UTC().Format(time.RFC3339Nano),
+	}
+	return h.createSuccessResponse(correlationID, healthResponse)
+}
+
+// handleCreateOrder processes order creation. The reply carries the pending
+// order; checkout continues in the background.
+func (h *OrderHandler) handleCreateOrder(req sharedModels.Request) sharedModels.Response {
+	var createReq sharedModels.CreateOrderRequest
+	if err := h.unmarshalPayload(req.Payload, &createReq); err != nil {
+		log.Printf("Failed to parse create order request: %v", err)
+		return h.createErrorResponse(req.CorrelationID, "Invalid create order request format")
+	}
+
+	result, err := h.service.CreateOrder(createReq)
+	if err != nil {
+		log.Printf("Order creation failed: %v", err)
+		return h.createErrorResponse(req.CorrelationID, err.Error())
+	}
+
+	response := sharedModels.OrderResponse{
+		Status:  "success",
+		Message: "Order accepted",
+		Data:    *result,
+	}
+	return h.createSuccessResponse(req.CorrelationID, response)
+}
+
+// handleGetOrder processes get order by ID request
+func (h *OrderHandler) handleGetOrder(req sharedModels.Request) sharedModels.Response {
+	var getReq sharedModels.GetOrderRequest
+	if err := h.unmarshalPayload(req.Payload, &getReq); err != nil {
+		log.Printf("Failed to parse get order request: %v", err)
+		return h.createErrorResponse(req.CorrelationID, "Invalid get order request format")
+	}
+
+	result, err := h.service.GetOrder(getReq)
+	if err != nil {
+		log.Printf("Failed to get order: %v", err)
+		return h.createErrorResponse(req.CorrelationID, err.Error())
+	}
+
+	response := sharedModels.OrderResponse{
+		Status: "success",
+		Data:   *result,
+	}
+	return h.createSuccessResponse(req.CorrelationID, response)
+}
+
+// handleListOrders processes list orders request
+func (h *OrderHandler) handleListOrders(req sharedModels.Request) sharedModels.Response {
+	var listReq sharedModels.ListOrdersRequest
+	if err := h.unmarshalPayload(req.Payload, &listReq); err != nil {
+		log.Printf("Failed to parse list orders request: %v", err)
+		return h.createErrorResponse(req.CorrelationID, "Invalid list orders request format")
+	}
+
+	result, err := h.service.ListOrders(listReq)
+	if err != nil {
+		log.Printf("Failed to list orders: %v", err)
+		return h.createErrorResponse(req.CorrelationID, err.Error())
+	}
+
+	orders := make([]sharedModels.OrderData, len(result))
+	for i, o := range result {
+		orders[i] = *o
+	}
+
+	response := sharedModels.ListOrdersResponse{
+		Status: "success",
+		Data:   orders,
+	}
+	return h.createSuccessResponse(req.CorrelationID, response)
+}
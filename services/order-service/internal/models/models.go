@@ -0,0 +1,84 @@
+package models
+
+import (
+	"time"
+
+	sharedModels "github.com/lucas/gokafka/shared/models"
+)
+
+// Order statuses
+const (
+	OrderStatusPending    = "pending"
+	OrderStatusProcessing = "processing"
+	OrderStatusConfirmed  = "confirmed"
+	OrderStatusCancelled  = "cancelled"
+	// OrderStatusNeedsAttention means compensation failed and the order
+	// must be reconciled by hand
+	OrderStatusNeedsAttention = "needs_attention"
+)
+
+// Saga step statuses
+const (
+	StepStatusStarted     = "started"
+	StepStatusCompleted   = "completed"
+	StepStatusCompensated = "compensated"
+	StepStatusFailed      = "failed"
+)
+
+type Order struct {
+	ID            string             `json:"id" db:"id"`
+	UserID        string             `json:"user_id" db:"user_id"`
+	Status        string             `json:"status" db:"status"`
+	CouponCode    string             `json:"coupon_code" db:"coupon_code"`
+	Discount      sharedModels.Money `json:"discount" db:"discount"` // zero until the coupon is redeemed
+	Total         sharedModels.Money `json:"total" db:"total"`
+	PaymentToken  string             `json:"-"` // kept in memory only, never stored
+	ReservationID string             `json:"reservation_id" db:"reservation_id"`
+	PaymentID     string             `json:"payment_id" db:"payment_id"`
+	FailureReason string             `json:"failure_reason" db:"failure_reason"`
+	Items         []OrderItem        `json:"items"`
+	CreatedAt     time.Time          `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time          `json:"updated_at" db:"updated_at"`
+}
+
+// OrderItem is a line for a product, or for one of its variants when
+// VariantID is set
+type OrderItem struct {
+	ProductID int                `json:"product_id" db:"product_id"`
+	VariantID int                `json:"variant_id" db:"variant_id"`
+	SKU       string             `json:"sku" db:"sku"`
+	Name      string             `json:"name" db:"name"`
+	Quantity  int                `json:"quantity" db:"quantity"`
+	UnitPrice sharedModels.Money `json:"unit_price" db:"unit_price"`
+}
+
+// Subtotal returns the sum of the line totals, before any discount
+func (o *Order) Subtotal() (sharedModels.Money, error) {
+	var subtotal sharedModels.Money
+	for _, item := range o.Items {
+		lineTotal, err := item.LineTotal()
+		if err != nil {
+			return sharedModels.Money{}, err
+		}
+		total, err := subtotal.Add(lineTotal)
+		if err != nil {
+			return sharedModels.Money{}, err
+		}
+		subtotal = total
+	}
+	return subtotal, nil
+}
+
+// LineTotal returns the price of the item times its quantity
+func (i OrderItem) LineTotal() (sharedModels.Money, error) {
+	return i.UnitPrice.Mul(i.Quantity)
+}
+
+// SagaStep records the progress of one checkout step for an order
+type SagaStep struct {
+	OrderID   string    `json:"order_id" db:"order_id"`
+	Step      string    `json:"step" db:"step"`
+	Status    string    `json:"status" db:"status"`
+	Detail    string    `json:"detail" db:"detail"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
@@ -0,0 +1,358 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/lucas/gokafka/order-service/internal/models"
+	sharedModels "github.com/lucas/gokafka/shared/models"
+	"github.com/lucas/gokafka/shared/utils"
+)
+
+// Database constants
+const (
+	DefaultPostgresHost     = "localhost"
+	DefaultPostgresPort     = "5432"
+	DefaultPostgresDB       = "gokafka"
+	DefaultPostgresUser     = "postgres"
+	DefaultPostgresPassword = "postgres"
+)
+
+type OrderRepository struct {
+	db *sql.DB
+}
+
+func NewOrderRepository() *OrderRepository {
+	db := initDatabase()
+	repo := &OrderRepository{db: db}
+	repo.createTablesIfNotExists()
+	return repo
+}
+
+// initDatabase initializes the database connection
+func initDatabase() *sql.DB {
+	host := utils.GetEnvOrDefault("POSTGRES_HOST", DefaultPostgresHost)
+	port := utils.GetEnvOrDefault("POSTGRES_PORT", DefaultPostgresPort)
+	dbname := utils.GetEnvOrDefault("POSTGRES_DB", DefaultPostgresDB)
+	user := utils.GetEnvOrDefault("POSTGRES_USER", DefaultPostgresUser)
+	password := utils.GetEnvOrDefault("POSTGRES_PASSWORD", DefaultPostgresPassword)
+
+	connStr := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable",
+		user, password, host, port, dbname)
+
+	log.Printf("Connecting to PostgreSQL at %s:%s", host, port)
+	db, err := sql.Open("postgres", connStr)
+	if err != nil {
+		log.Fatalf("failed to connect to postgres: %v", err)
+	}
+
+	if err := db.Ping(); err != nil {
+		log.Fatalf("failed to ping postgres: %v", err)
+	}
+
+	log.Println("Connected to PostgreSQL database")
+	return db
+}
+
+func (r *OrderRepository) createTablesIfNotExists() {
+	query := `
+	CREATE TABLE IF NOT EXISTS orders (
+		id UUID PRIMARY KEY,
+		user_id VARCHAR(255) NOT NULL,
+		status VARCHAR(30) NOT NULL,
+		total NUMERIC(12, 2) NOT NULL,
+		currency VARCHAR(3) NOT NULL DEFAULT 'USD',
+		reservation_id VARCHAR(255) NOT NULL DEFAULT '',
+		payment_id VARCHAR(255) NOT NULL DEFAULT '',
+		failure_reason TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE INDEX IF NOT EXISTS idx_orders_user ON orders (user_id, created_at DESC);
+	CREATE INDEX IF NOT EXISTS idx_orders_in_flight ON orders (updated_at)
+		WHERE status IN ('pending', 'processing');
+	CREATE TABLE IF NOT EXISTS order_items (
+		order_id UUID NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+		product_id INTEGER NOT NULL,
+		variant_id INTEGER NOT NULL DEFAULT 0,
+		sku VARCHAR(64) NOT NULL DEFAULT '',
+		name VARCHAR(100) NOT NULL,
+		quantity INTEGER NOT NULL CHECK (quantity > 0),
+		unit_price NUMERIC(10, 2) NOT NULL,
+		PRIMARY KEY (order_id, product_id, variant_id)
+	);
+	CREATE TABLE IF NOT EXISTS order_saga_steps (
+		order_id UUID NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+		step VARCHAR(50) NOT NULL,
+		position INTEGER NOT NULL,
+		status VARCHAR(20) NOT NULL,
+		detail TEXT NOT NULL DEFAULT '',
+		updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (order_id, step)
+	);
+	ALTER TABLE orders ADD COLUMN IF NOT EXISTS coupon_code VARCHAR(50) NOT NULL DEFAULT '';
+	ALTER TABLE orders ADD COLUMN IF NOT EXISTS discount NUMERIC(12, 2) NOT NULL DEFAULT 0;
+	ALTER TABLE order_items ADD COLUMN IF NOT EXISTS variant_id INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE order_items ADD COLUMN IF NOT EXISTS sku VARCHAR(64) NOT NULL DEFAULT '';
+	DO $$
+	BEGIN
+		IF NOT EXISTS (
+			SELECT 1 FROM information_schema.key_column_usage
+			WHERE table_name = 'order_items'
+				AND constraint_name = 'order_items_pkey' AND column_name = 'variant_id'
+		) THEN
+			ALTER TABLE order_items DROP CONSTRAINT order_items_pkey;
+			ALTER TABLE order_items ADD PRIMARY KEY (order_id, product_id, variant_id);
+		END IF;
+	END $$`
+
+	if _, err := r.db.Exec(query); err != nil {
+		log.Fatalf("failed to create order tables: %v", err)
+	}
+
+	log.Println("Order tables are ready")
+}
+
+func (r *OrderRepository) CreateOrder(order *models.Order) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+	INSERT INTO orders (id, user_id, status, total, currency, coupon_code, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $7)
+	RETURNING created_at, updated_at`
+
+	err = tx.QueryRow(query, order.ID, order.UserID, order.Status, order.Total.Decimal(),
+		order.Total.Currency, order.CouponCode, time.Now()).
+		Scan(&order.CreatedAt, &order.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create order: %w", err)
+	}
+
+	for _, item := range order.Items {
+		if _, err := tx.Exec(`
+		INSERT INTO order_items (order_id, product_id, variant_id, sku, name, quantity, unit_price)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+			order.ID, item.ProductID, item.VariantID, item.SKU, item.Name, item.Quantity, item.UnitPrice.Decimal(),
+		); err != nil {
+			return fmt.Errorf("failed to create order item: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// UpdateOrderStatus stores the order's status, saga references and the
+// total once a coupon discount has been applied
+func (r *OrderRepository) UpdateOrderStatus(order *models.Order) error {
+	query := `
+	UPDATE orders
+	SET status = $1, reservation_id = $2, payment_id = $3, failure_reason = $4, total = $5,
+		discount = $6, updated_at = $7
+	WHERE id = $8
+	RETURNING updated_at`
+
+	err := r.db.QueryRow(query, order.Status, order.ReservationID, order.PaymentID,
+		order.FailureReason, order.Total.Decimal(), order.Discount.Decimal(), time.Now(), order.ID).Scan(&order.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to update order: %w", err)
+	}
+
+	return nil
+}
+
+// orderColumns lists the columns read by scanOrder, in order
+const orderColumns = `id, user_id, status, total, currency, coupon_code, discount,
+	reservation_id, payment_id, failure_reason, created_at, updated_at`
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanOrder reads an order row without its items. The NUMERIC total and
+// discount are scanned as text so they convert to Money without passing
+// through float64.
+func scanOrder(row rowScanner) (*models.Order, error) {
+	var order models.Order
+	var total, currency, discount string
+	err := row.Scan(
+		&order.ID, &order.UserID, &order.Status, &total, &currency, &order.CouponCode, &discount,
+		&order.ReservationID, &order.PaymentID, &order.FailureReason, &order.CreatedAt, &order.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	order.Total, err = sharedModels.ParseMoney(total, currency)
+	if err != nil {
+		return nil, fmt.Errorf("invalid total of order %s: %w", order.ID, err)
+	}
+
+	order.Discount, err = sharedModels.ParseMoney(discount, currency)
+	if err != nil {
+		return nil, fmt.Errorf("invalid discount of order %s: %w", order.ID, err)
+	}
+
+	return &order, nil
+}
+
+func (r *OrderRepository) GetOrderByID(id string) (*models.Order, error) {
+	order, err := scanOrder(r.db.QueryRow(`SELECT `+orderColumns+` FROM orders WHERE id = $1`, id))
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := r.getOrderItems([]string{order.ID})
+	if err != nil {
+		return nil, err
+	}
+	order.Items = items[order.ID]
+
+	return order, nil
+}
+
+func (r *OrderRepository) GetOrdersByUser(userID string) ([]*models.Order, error) {
+	query := `
+	SELECT ` + orderColumns + `
+	FROM orders WHERE user_id = $1 ORDER BY created_at DESC`
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orders []*models.Order
+	var ids []string
+	for rows.Next() {
+		order, err := scanOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, order)
+		ids = append(ids, order.ID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	items, err := r.getOrderItems(ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, order := range orders {
+		order.Items = items[order.ID]
+	}
+
+	return orders, nil
+}
+
+func (r *OrderRepository) getOrderItems(orderIDs []string) (map[string][]models.OrderItem, error) {
+	items := make(map[string][]models.OrderItem)
+	if len(orderIDs) == 0 {
+		return items, nil
+	}
+
+	// Items are priced in their order's currency
+	rows, err := r.db.Query(`
+	SELECT i.order_id, i.product_id, i.variant_id, i.sku, i.name, i.quantity, i.unit_price, o.currency
+	FROM order_items i JOIN orders o ON o.id = i.order_id
+	WHERE i.order_id = ANY($1) ORDER BY i.product_id, i.variant_id`, pq.Array(orderIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var orderID, unitPrice, currency string
+		var item models.OrderItem
+		if err := rows.Scan(&orderID, &item.ProductID, &item.VariantID, &item.SKU, &item.Name, &item.Quantity, &unitPrice, &currency); err != nil {
+			return nil, err
+		}
+		if item.UnitPrice, err = sharedModels.ParseMoney(unitPrice, currency); err != nil {
+			return nil, fmt.Errorf("invalid price in order %s: %w", orderID, err)
+		}
+		items[orderID] = append(items[orderID], item)
+	}
+
+	return items, rows.Err()
+}
+
+// SaveSagaStep records the status of a checkout step
+func (r *OrderRepository) SaveSagaStep(orderID, step string, position int, status, detail string) error {
+	query := `
+	INSERT INTO order_saga_steps (order_id, step, position, status, detail, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	ON CONFLICT (order_id, step) DO UPDATE
+	SET status = EXCLUDED.status, detail = EXCLUDED.detail, updated_at = EXCLUDED.updated_at`
+
+	if _, err := r.db.Exec(query, orderID, step, position, status, detail, time.Now()); err != nil {
+		return fmt.Errorf("failed to save saga step %s: %w", step, err)
+	}
+	return nil
+}
+
+// GetSagaSteps returns the recorded steps of an order in execution order
+func (r *OrderRepository) GetSagaSteps(orderID string) ([]*models.SagaStep, error) {
+	rows, err := r.db.Query(`
+	SELECT order_id, step, status, detail, updated_at
+	FROM order_saga_steps WHERE order_id = $1 ORDER BY position`, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var steps []*models.SagaStep
+	for rows.Next() {
+		var step models.SagaStep
+		if err := rows.Scan(&step.OrderID, &step.Step, &step.Status, &step.Detail, &step.UpdatedAt); err != nil {
+			return nil, err
+		}
+		steps = append(steps, &step)
+	}
+
+	return steps, rows.Err()
+}
+
+// ClaimStaleOrders marks in-flight orders untouched for longer than
+// staleAfter as processing again and returns them. It is used to recover
+// sagas interrupted by a crash; SKIP LOCKED keeps replicas from claiming
+// the same order.
+func (r *OrderRepository) ClaimStaleOrders(staleAfter time.Duration, limit int) ([]*models.Order, error) {
+	query := `
+	UPDATE orders SET status = $1, updated_at = $2
+	WHERE id IN (
+		SELECT id FROM orders
+		WHERE status IN ($3, $1) AND updated_at < $4
+		ORDER BY updated_at
+		LIMIT $5
+		FOR UPDATE SKIP LOCKED
+	)
+	RETURNING ` + orderColumns
+
+	now := time.Now()
+	rows, err := r.db.Query(query, models.OrderStatusProcessing, now,
+		models.OrderStatusPending, now.Add(-staleAfter), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orders []*models.Order
+	for rows.Next() {
+		order, err := scanOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+
+	return orders, rows.Err()
+}
@@ -0,0 +1,115 @@
+package saga
+
+import (
+	"log"
+
+	"github.com/lucas/gokafka/order-service/internal/models"
+	"github.com/lucas/gokafka/order-service/internal/repository"
+)
+
+// Step is one checkout action with its compensating action. Both must be
+// idempotent and Compensate must succeed when Execute never took effect,
+// because after a timeout or crash the outcome of Execute is unknown.
+type Step interface {
+	Name() string
+	Execute(order *models.Order) error
+	Compensate(order *models.Order) error
+}
+
+// Orchestrator runs checkout steps in order and undoes them in reverse when
+// one fails. Step progress is stored so interrupted sagas can be recovered.
+type Orchestrator struct {
+	repo  *repository.OrderRepository
+	steps []Step
+}
+
+func NewOrchestrator(repo *repository.OrderRepository, steps ...Step) *Orchestrator {
+	return &Orchestrator{
+		repo:  repo,
+		steps: steps,
+	}
+}
+
+// Run executes the saga for a freshly created order
+func (o *Orchestrator) Run(order *models.Order) {
+	order.Status = models.OrderStatusProcessing
+	o.saveOrder(order)
+
+	for i, step := range o.steps {
+		o.saveStep(order, i, models.StepStatusStarted, "")
+
+		if err := step.Execute(order); err != nil {
+			log.Printf("Order %s: step %s failed: %v", order.ID, step.Name(), err)
+			o.saveStep(order, i, models.StepStatusFailed, err.Error())
+			// The failed step is compensated too since it may have
+			// partially taken effect
+			o.compensate(order, i, err.Error())
+			return
+		}
+
+		o.saveStep(order, i, models.StepStatusCompleted, "")
+		// Persist references (reservation, payment) as soon as we have them
+		o.saveOrder(order)
+	}
+
+	order.Status = models.OrderStatusConfirmed
+	o.saveOrder(order)
+	log.Printf("Order %s confirmed", order.ID)
+}
+
+// Recover compensates a saga that was interrupted, e.g. by a crash. Every
+// step that was started and not yet compensated is undone.
+func (o *Orchestrator) Recover(order *models.Order) {
+	recorded, err := o.repo.GetSagaSteps(order.ID)
+	if err != nil {
+		log.Printf("Order %s: failed to load saga steps: %v", order.ID, err)
+		return
+	}
+
+	last := -1
+	for _, step := range recorded {
+		if step.Status == models.StepStatusCompensated {
+			continue
+		}
+		for i, known := range o.steps {
+			if known.Name() == step.Step && i > last {
+				last = i
+			}
+		}
+	}
+
+	log.Printf("Order %s: recovering interrupted checkout", order.ID)
+	o.compensate(order, last, "checkout interrupted")
+}
+
+// compensate undoes steps[0..upTo] in reverse order
+func (o *Orchestrator) compensate(order *models.Order, upTo int, reason string) {
+	order.Status = models.OrderStatusCancelled
+	order.FailureReason = reason
+
+	for i := upTo; i >= 0; i-- {
+		step := o.steps[i]
+		if err := step.Compensate(order); err != nil {
+			log.Printf("Order %s: compensating %s failed: %v", order.ID, step.Name(), err)
+			o.saveStep(order, i, models.StepStatusFailed, "compensation failed: "+err.Error())
+			order.Status = models.OrderStatusNeedsAttention
+			continue
+		}
+		o.saveStep(order, i, models.StepStatusCompensated, "")
+	}
+
+	o.saveOrder(order)
+	log.Printf("Order %s %s: %s", order.ID, order.Status, reason)
+}
+
+func (o *Orchestrator) saveStep(order *models.Order, position int, status, detail string) {
+	if err := o.repo.SaveSagaStep(order.ID, o.steps[position].Name(), position, status, detail); err != nil {
+		log.Printf("Order %s: %v", order.ID, err)
+	}
+}
+
+func (o *Orchestrator) saveOrder(order *models.Order) {
+	if err := o.repo.UpdateOrderStatus(order); err != nil {
+		log.Printf("Order %s: %v", order.ID, err)
+	}
+}
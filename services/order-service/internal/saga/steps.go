@@ -0,0 +1,174 @@
+package saga
+
+import (
+	"fmt"
+
+	"github.com/lucas/gokafka/order-service/internal/models"
+	"github.com/lucas/gokafka/shared/bus"
+	sharedModels "github.com/lucas/gokafka/shared/models"
+)
+
+// RedeemCouponStep redeems the order's coupon in promotion-service and
+// takes the discount off the total. It runs first so the amount charged
+// is final before payment.
+type RedeemCouponStep struct {
+	client *bus.Client
+}
+
+func NewRedeemCouponStep(client *bus.Client) *RedeemCouponStep {
+	return &RedeemCouponStep{client: client}
+}
+
+func (s *RedeemCouponStep) Name() string {
+	return "redeem_coupon"
+}
+
+func (s *RedeemCouponStep) Execute(order *models.Order) error {
+	if order.CouponCode == "" {
+		return nil
+	}
+
+	subtotal, err := order.Subtotal()
+	if err != nil {
+		return err
+	}
+
+	var redemption sharedModels.CouponRedemptionData
+	if err := s.client.Call("redeem-coupon", sharedModels.RedeemCouponRequest{
+		Code:     order.CouponCode,
+		OrderID:  order.ID,
+		UserID:   order.UserID,
+		Subtotal: subtotal,
+	}, &redemption); err != nil {
+		return err
+	}
+
+	total, err := subtotal.Sub(redemption.Discount)
+	if err != nil {
+		return err
+	}
+	order.Discount = redemption.Discount
+	order.Total = total
+	return nil
+}
+
+func (s *RedeemCouponStep) Compensate(order *models.Order) error {
+	if order.CouponCode == "" {
+		return nil
+	}
+	return s.client.Call("release-coupon", sharedModels.ReleaseCouponRequest{
+		OrderID: order.ID,
+	}, nil)
+}
+
+// ReserveStockStep holds the ordered quantities in product-service
+type ReserveStockStep struct {
+	client *bus.Client
+}
+
+func NewReserveStockStep(client *bus.Client) *ReserveStockStep {
+	return &ReserveStockStep{client: client}
+}
+
+func (s *ReserveStockStep) Name() string {
+	return "reserve_stock"
+}
+
+func (s *ReserveStockStep) Execute(order *models.Order) error {
+	items := make([]sharedModels.StockItem, 0, len(order.Items))
+	for _, item := range order.Items {
+		items = append(items, sharedModels.StockItem{
+			ProductID: item.ProductID,
+			VariantID: item.VariantID,
+			Quantity:  item.Quantity,
+		})
+	}
+
+	var reservation sharedModels.ReservationData
+	if err := s.client.Call("reserve-stock", sharedModels.ReserveStockRequest{
+		OrderID: order.ID,
+		Items:   items,
+	}, &reservation); err != nil {
+		return err
+	}
+
+	order.ReservationID = reservation.ID
+	return nil
+}
+
+func (s *ReserveStockStep) Compensate(order *models.Order) error {
+	return s.client.Call("release-reservation", sharedModels.ReservationRequest{
+		ReservationID: order.ReservationID,
+		OrderID:       order.ID,
+	}, nil)
+}
+
+// ChargePaymentStep charges the order total through payment-service
+type ChargePaymentStep struct {
+	client *bus.Client
+}
+
+func NewChargePaymentStep(client *bus.Client) *ChargePaymentStep {
+	return &ChargePaymentStep{client: client}
+}
+
+func (s *ChargePaymentStep) Name() string {
+	return "charge_payment"
+}
+
+func (s *ChargePaymentStep) Execute(order *models.Order) error {
+	// A coupon can cover the whole order
+	if !order.Total.IsPositive() {
+		return nil
+	}
+
+	var payment sharedModels.PaymentData
+	if err := s.client.Call("charge-payment", sharedModels.ChargePaymentRequest{
+		IdempotencyKey: "charge-" + order.ID,
+		OrderID:        order.ID,
+		UserID:         order.UserID,
+		Amount:         order.Total,
+		PaymentToken:   order.PaymentToken,
+	}, &payment); err != nil {
+		return err
+	}
+
+	order.PaymentID = payment.ID
+	if payment.Status != sharedModels.PaymentStatusSucceeded {
+		return fmt.Errorf("payment %s: %s", payment.Status, payment.FailureReason)
+	}
+	return nil
+}
+
+func (s *ChargePaymentStep) Compensate(order *models.Order) error {
+	return s.client.Call("refund-payment", sharedModels.RefundPaymentRequest{
+		IdempotencyKey: "refund-" + order.ID,
+		PaymentID:      order.PaymentID,
+		OrderID:        order.ID,
+	}, nil)
+}
+
+// ConfirmOrderStep turns the stock reservation into a permanent sale. It is
+// the last step, so there is nothing to undo once it succeeds.
+type ConfirmOrderStep struct {
+	client *bus.Client
+}
+
+func NewConfirmOrderStep(client *bus.Client) *ConfirmOrderStep {
+	return &ConfirmOrderStep{client: client}
+}
+
+func (s *ConfirmOrderStep) Name() string {
+	return "confirm_order"
+}
+
+func (s *ConfirmOrderStep) Execute(order *models.Order) error {
+	return s.client.Call("commit-reservation", sharedModels.ReservationRequest{
+		ReservationID: order.ReservationID,
+		OrderID:       order.ID,
+	}, nil)
+}
+
+func (s *ConfirmOrderStep) Compensate(order *models.Order) error {
+	return nil
+}
@@ -0,0 +1,249 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lucas/gokafka/order-service/internal/models"
+	"github.com/lucas/gokafka/order-service/internal/repository"
+	"github.com/lucas/gokafka/order-service/internal/saga"
+	"github.com/lucas/gokafka/shared/bus"
+	sharedModels "github.com/lucas/gokafka/shared/models"
+)
+
+// Recovery constants
+const (
+	RecoveryInterval = time.Minute
+	// StaleOrderAfter must be well above the time a healthy saga takes
+	StaleOrderAfter   = 5 * time.Minute
+	RecoveryBatchSize = 20
+)
+
+type OrderService struct {
+	repo   *repository.OrderRepository
+	client *bus.Client
+	saga   *saga.Orchestrator
+}
+
+func NewOrderService(repo *repository.OrderRepository, client *bus.Client) *OrderService {
+	return &OrderService{
+		repo:   repo,
+		client: client,
+		saga: saga.NewOrchestrator(repo,
+			saga.NewRedeemCouponStep(client),
+			saga.NewReserveStockStep(client),
+			saga.NewChargePaymentStep(client),
+			saga.NewConfirmOrderStep(client),
+		),
+	}
+}
+
+// Helper method to convert Order to OrderData
+func (s *OrderService) orderToOrderData(order *models.Order) *sharedModels.OrderData {
+	items := make([]sharedModels.OrderItemData, 0, len(order.Items))
+	for _, item := range order.Items {
+		// Line totals were checked for overflow when the order was created
+		lineTotal, _ := item.LineTotal()
+		items = append(items, sharedModels.OrderItemData{
+			ProductID: item.ProductID,
+			VariantID: item.VariantID,
+			SKU:       item.SKU,
+			Name:      item.Name,
+			Quantity:  item.Quantity,
+			UnitPrice: item.UnitPrice,
+			LineTotal: lineTotal,
+		})
+	}
+
+	data := &sharedModels.OrderData{
+		ID:            order.ID,
+		UserID:        order.UserID,
+		Status:        order.Status,
+		Items:         items,
+		CouponCode:    order.CouponCode,
+		Total:         order.Total,
+		FailureReason: order.FailureReason,
+		CreatedAt:     order.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:     order.UpdatedAt.Format(time.RFC3339),
+	}
+	if !order.Discount.IsZero() {
+		discount := order.Discount
+		data.Discount = &discount
+	}
+	return data
+}
+
+// CreateOrder stores a pending order priced from product-service and starts
+// the checkout saga in the background. Clients poll the order for the result.
+func (s *OrderService) CreateOrder(req sharedModels.CreateOrderRequest) (*sharedModels.OrderData, error) {
+	// Validate input
+	if req.UserID == "" {
+		return nil, fmt.Errorf("user ID is required")
+	}
+	if len(req.Items) == 0 {
+		return nil, fmt.Errorf("order must contain at least one item")
+	}
+
+	// Merge duplicate lines so each product and variant appears once
+	type line struct{ productID, variantID int }
+	quantities := make(map[line]int)
+	var lines []line
+	var productIDs []int
+	seen := make(map[int]bool)
+	for _, item := range req.Items {
+		if item.ProductID <= 0 {
+			return nil, fmt.Errorf("invalid product ID")
+		}
+		if item.VariantID < 0 {
+			return nil, fmt.Errorf("invalid variant ID")
+		}
+		if item.Quantity <= 0 {
+			return nil, fmt.Errorf("quantity must be greater than 0")
+		}
+		key := line{item.ProductID, item.VariantID}
+		if _, ok := quantities[key]; !ok {
+			lines = append(lines, key)
+		}
+		quantities[key] += item.Quantity
+		if !seen[item.ProductID] {
+			seen[item.ProductID] = true
+			productIDs = append(productIDs, item.ProductID)
+		}
+	}
+	if len(productIDs) > sharedModels.MaxProductsPerLookup {
+		return nil, fmt.Errorf("an order cannot contain more than %d products", sharedModels.MaxProductsPerLookup)
+	}
+
+	var user sharedModels.UserData
+	if err := s.client.Call("get-by-id", sharedModels.GetProfileRequest{ID: req.UserID}, &user); err != nil {
+		return nil, fmt.Errorf("failed to verify user: %w", err)
+	}
+
+	order := &models.Order{
+		ID:           uuid.NewString(),
+		UserID:       user.ID,
+		Status:       models.OrderStatusPending,
+		PaymentToken: req.PaymentToken,
+		CouponCode:   strings.ToUpper(strings.TrimSpace(req.CouponCode)),
+	}
+
+	// Prices always come from product-service, never from the client
+	var products sharedModels.GetProductsResponse
+	if err := s.client.Call("get-products-by-ids", sharedModels.GetProductsRequest{IDs: productIDs}, &products); err != nil {
+		return nil, fmt.Errorf("failed to price products: %w", err)
+	}
+	if len(products.Missing) > 0 {
+		return nil, fmt.Errorf("product %d not found", products.Missing[0])
+	}
+
+	byID := make(map[int]*sharedModels.ProductData, len(products.Data))
+	for i := range products.Data {
+		byID[products.Data[i].ID] = &products.Data[i]
+	}
+
+	for _, key := range lines {
+		product := byID[key.productID]
+		if product == nil {
+			return nil, fmt.Errorf("product %d not found", key.productID)
+		}
+		saleItem, err := product.SaleItem(key.variantID)
+		if err != nil {
+			return nil, err
+		}
+		item := models.OrderItem{
+			ProductID: product.ID,
+			VariantID: saleItem.VariantID,
+			SKU:       saleItem.SKU,
+			Name:      product.Name,
+			Quantity:  quantities[key],
+			UnitPrice: saleItem.UnitPrice,
+		}
+		order.Items = append(order.Items, item)
+
+		lineTotal, err := item.LineTotal()
+		if err != nil {
+			return nil, fmt.Errorf("invalid quantity of product %d: %w", product.ID, err)
+		}
+		total, err := order.Total.Add(lineTotal)
+		if err != nil {
+			return nil, fmt.Errorf("products in one order must share a currency")
+		}
+		order.Total = total
+	}
+
+	if err := s.repo.CreateOrder(order); err != nil {
+		return nil, fmt.Errorf("failed to create order: %w", err)
+	}
+
+	go s.saga.Run(order)
+
+	return s.orderToOrderData(order), nil
+}
+
+// GetOrder returns an order owned by the given user
+func (s *OrderService) GetOrder(req sharedModels.GetOrderRequest) (*sharedModels.OrderData, error) {
+	// Validate input
+	if req.ID == "" {
+		return nil, fmt.Errorf("order ID is required")
+	}
+	if _, err := uuid.Parse(req.ID); err != nil {
+		return nil, fmt.Errorf("order not found")
+	}
+
+	order, err := s.repo.GetOrderByID(req.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("order not found")
+		}
+		return nil, fmt.Errorf("failed to get order: %w", err)
+	}
+
+	// Other users' orders look the same as missing ones
+	if order.UserID != req.UserID {
+		return nil, fmt.Errorf("order not found")
+	}
+
+	return s.orderToOrderData(order), nil
+}
+
+// ListOrders returns a user's orders, newest first
+func (s *OrderService) ListOrders(req sharedModels.ListOrdersRequest) ([]*sharedModels.OrderData, error) {
+	if req.UserID == "" {
+		return nil, fmt.Errorf("user ID is required")
+	}
+
+	orders, err := s.repo.GetOrdersByUser(req.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list orders: %w", err)
+	}
+
+	result := make([]*sharedModels.OrderData, 0, len(orders))
+	for _, order := range orders {
+		result = append(result, s.orderToOrderData(order))
+	}
+	return result, nil
+}
+
+// RunRecovery periodically compensates orders whose saga was interrupted,
+// e.g. because the service restarted mid-checkout
+func (s *OrderService) RunRecovery() {
+	ticker := time.NewTicker(RecoveryInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		orders, err := s.repo.ClaimStaleOrders(StaleOrderAfter, RecoveryBatchSize)
+		if err != nil {
+			log.Printf("Failed to claim stale orders: %v", err)
+			continue
+		}
+
+		for _, order := range orders {
+			s.saga.Recover(order)
+		}
+	}
+}
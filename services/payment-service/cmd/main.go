@@ -0,0 +1,61 @@
+package main
+
+import (
+	"log"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lucas/gokafka/payment-service/internal/handlers"
+	"github.com/lucas/gokafka/payment-service/internal/provider"
+	"github.com/lucas/gokafka/payment-service/internal/repository"
+	"github.com/lucas/gokafka/payment-service/internal/service"
+	sharedModels "github.com/lucas/gokafka/shared/models"
+	"github.com/lucas/gokafka/shared/outbox"
+	"github.com/lucas/gokafka/shared/utils"
+)
+
+const (
+	DefaultPort = "8087"
+)
+
+func main() {
+	log.Println("Starting payment-service...")
+
+	// Initialize dependencies
+	repo := repository.NewPaymentRepository()
+	service := service.NewPaymentService(repo, provider.NewProvider())
+	handler := handlers.NewPaymentHandler(service)
+	relay := outbox.NewRelay(repo.Outbox(), sharedModels.PaymentEventsTopic)
+
+	log.Println("Payment-service started, waiting for requests...")
+
+	// Start Kafka message listener in background
+	go handler.ListenMessages()
+
+	// Start outbox relay publishing payment events in background
+	go relay.Run()
+
+	// Start HTTP server
+	startHTTPServer()
+}
+
+func startHTTPServer() {
+	router := gin.Default()
+
+	// Health endpoints
+	router.GET("/health", healthHandler)
+	router.GET("/ready", readyHandler)
+
+	port := utils.GetEnvOrDefault("PORT", DefaultPort)
+	log.Printf("Starting HTTP server on port %s", port)
+	if err := router.Run(":" + port); err != nil {
+		log.Fatalf("Failed to start HTTP server: %v", err)
+	}
+}
+
+func healthHandler(c *gin.Context) {
+	c.JSON(200, gin.H{"status": "healthy"})
+}
+
+func readyHandler(c *gin.Context) {
+	c.JSON(200, gin.H{"status": "ready"})
+}
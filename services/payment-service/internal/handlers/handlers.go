@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/lucas/gokafka/payment-service/internal/service"
+	sharedModels "github.com/lucas/gokafka/shared/models"
+	"github.com/lucas/gokafka/shared/utils"
+	"github.com/segmentio/kafka-go"
+)
+
+// ReplyTopic is the topic the gateway listens on for this service's replies
+const ReplyTopic = "payment-service-topic"
+
+// Request type constants
+const (
+	RequestTypeHealth        = "health"
+	RequestTypeChargePayment = "charge-payment"
+	RequestTypeRefundPayment = "refund-payment"
+	RequestTypeGetPayment    = "get-payment"
+)
+
+type PaymentHandler struct {
+	writer  *kafka.Writer
+	reader  *kafka.Reader
+	service *service.PaymentService
+}
+
+func NewPaymentHandler(service *service.PaymentService) *PaymentHandler {
+	broker := utils.GetEnvOrDefault("KAFKA_BROKERS", "localhost:9092")
+
+	return &PaymentHandler{
+		service: service,
+		writer: kafka.NewWriter(kafka.WriterConfig{
+			Brokers: []string{broker},
+		}),
+		reader: kafka.NewReader(kafka.ReaderConfig{
+			Brokers: []string{broker},
+			Topic:   "api-gateway-topic",
+			GroupID: "payment-service-group",
+		}),
+	}
+}
+
+// Helper method to create error responses
+func (h *PaymentHandler) createErrorResponse(correlationID, errorMsg string) sharedModels.Response {
+	return sharedModels.Response{
+		CorrelationID: correlationID,
+		Success:       false,
+		Error:         errorMsg,
+	}
+}
+
+// Helper method to create success responses
+func (h *PaymentHandler) createSuccessResponse(correlationID string, data interface{}) sharedModels.Response {
+	dataBytes, _ := json.Marshal(data)
+	return sharedModels.Response{
+		CorrelationID: correlationID,
+		Success:       true,
+		Data:          string(dataBytes),
+	}
+}
+
+// Helper method to unmarshal request payload
+func (h *PaymentHandler) unmarshalPayload(payload string, target interface{}) error {
+	return json.Unmarshal([]byte(payload), target)
+}
+
+func (h *PaymentHandler) ListenMessages() {
+	for {
+		m, err := h.reader.ReadMessage(context.Background())
+		if err != nil {
+			log.Println("read error:", err)
+			continue
+		}
+
+		var req sharedModels.Request
+		if err := json.Unmarshal(m.Value, &req); err != nil {
+			log.Println("unmarshal error:", err)
+			continue
+		}
+
+		resp, ok := h.handleRequest(req)
+		if !ok {
+			continue
+		}
+
+		respBytes, _ := json.Marshal(resp)
+		err = h.writer.WriteMessages(context.Background(),
+			kafka.Message{
+				Topic: req.ReplyTo,
+				Value: respBytes,
+			},
+		)
+		if err != nil {
+			log.Println("write error:", err)
+		} else {
+			log.Printf("responded to %s with correlation_id %s", req.ReplyTo, req.CorrelationID)
+		}
+	}
+}
+
+// handleRequest processes different request types. It returns false for
+// requests owned by other services, which share the same request topic.
+func (h *PaymentHandler) handleRequest(req sharedModels.Request) (sharedModels.Response, bool) {
+	switch req.Type {
+	case RequestTypeHealth:
+		// Every service receives health checks; answer only our own
+		if req.ReplyTo != ReplyTopic {
+			return sharedModels.Response{}, false
+		}
+		return h.handleHealth(req.CorrelationID), true
+	case RequestTypeChargePayment:
+		return h.handleChargePayment(req), true
+	case RequestTypeRefundPayment:
+		return h.handleRefundPayment(req), true
+	case RequestTypeGetPayment:
+		return h.handleGetPayment(req), true
+	default:
+		return sharedModels.Response{}, false
+	}
+}
+
+// handleHealth returns health status
+func (h *PaymentHandler) handleHealth(correlationID string) sharedModels.Response {
+	healthResponse := map[string]interface{}{
+		"service":   "payment-service",
+		"status":    "healthy",
+		"timestamp": time.Now().UTC().Format(time.RFC3339Nano),
+	}
+	return h.createSuccessResponse(correlationID, healthResponse)
+}
+
+// handleChargePayment processes charge requests. A declined charge is a
+// successful reply carrying the declined payment.
+func (h *PaymentHandler) handleChargePayment(req sharedModels.Request) sharedModels.Response {
+	var chargeReq sharedModels.ChargePaymentRequest
+	if err := h.unmarshalPayload(req.Payload, &chargeReq); err != nil {
+		log.Printf("Failed to parse charge payment request: %v", err)
+		return h.createErrorResponse(req.CorrelationID, "Invalid charge payment request format")
+	}
+
+	result, err := h.service.Charge(chargeReq)
+	if err != nil {
+		log.Printf("Charge failed: %v", err)
+		return h.createErrorResponse(req.CorrelationID, err.Error())
+	}
+
+	return h.createSuccessResponse(req.CorrelationID, result)
+}
+
+// handleRefundPayment processes refund requests
+func (h *PaymentHandler) handleRefundPayment(req sharedModels.Request) sharedModels.Response {
+	var refundReq sharedModels.RefundPaymentRequest
+	if err := h.unmarshalPayload(req.Payload, &refundReq); err != nil {
+		log.Printf("Failed to parse refund payment request: %v", err)
+		return h.createErrorResponse(req.CorrelationID, "Invalid refund payment request format")
+	}
+
+	result, err := h.service.Refund(refundReq)
+	if err != nil {
+		log.Printf("Refund failed: %v", err)
+		return h.createErrorResponse(req.CorrelationID, err.Error())
+	}
+
+	if result == nil {
+		response := map[string]interface{}{
+			"status":   "success",
+			"message":  "No payment to refund",
+			"order_id": refundReq.OrderID,
+		}
+		return h.createSuccessResponse(req.CorrelationID, response)
+	}
+
+	return h.createSuccessResponse(req.CorrelationID, result)
+}
+
+// handleGetPayment processes get payment by ID request
+func (h *PaymentHandler) handleGetPayment(req sharedModels.Request) sharedModels.Response {
+	var getReq sharedModels.GetPaymentRequest
+	if err := h.unmarshalPayload(req.Payload, &getReq); err != nil {
+		log.Printf("Failed to parse get payment request: %v", err)
+		return h.createErrorResponse(req.CorrelationID, "Invalid get payment request format")
+	}
+
+	result, err := h.service.GetPayment(getReq.ID)
+	if err != nil {
+		log.Printf("Failed to get payment: %v", err)
+		return h.createErrorResponse(req.CorrelationID, err.Error())
+	}
+
+	return h.createSuccessResponse(req.CorrelationID, result)
+}
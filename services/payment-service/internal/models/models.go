@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	sharedModels "github.com/lucas/gokafka/shared/models"
+)
+
+type Payment struct {
+	ID             string             `json:"id" db:"id"`
+	IdempotencyKey string             `json:"idempotency_key" db:"idempotency_key"`
+	OrderID        string             `json:"order_id" db:"order_id"`
+	UserID         string             `json:"user_id" db:"user_id"`
+	Amount         sharedModels.Money `json:"amount" db:"amount"`
+	Status         string             `json:"status" db:"status"`
+	FailureReason  string             `json:"failure_reason" db:"failure_reason"`
+	Provider       string             `json:"provider" db:"provider"`
+	ProviderRef    string             `json:"provider_ref" db:"provider_ref"`
+	RefundRef      string             `json:"refund_ref" db:"refund_ref"`
+	CreatedAt      time.Time          `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time          `json:"updated_at" db:"updated_at"`
+}
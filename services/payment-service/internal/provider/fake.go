@@ -0,0 +1,83 @@
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Test tokens understood by the fake provider. Any other token succeeds.
+const (
+	// FakeTokenDecline is declined as a generic card decline
+	FakeTokenDecline = "tok_decline"
+	// FakeTokenInsufficientFunds is declined for lack of funds
+	FakeTokenInsufficientFunds = "tok_insufficient_funds"
+	// FakeTokenError fails with a provider error, leaving the outcome unknown
+	FakeTokenError = "tok_error"
+	// FakeTokenDelayPrefix delays the charge, e.g. "tok_delay_15s", then
+	// succeeds
+	FakeTokenDelayPrefix = "tok_delay_"
+)
+
+// FakeProvider is a deterministic provider for local development and
+// offline checkout testing. Outcomes depend only on the request, so retries
+// with the same idempotency key always see the same result.
+type FakeProvider struct{}
+
+func NewFakeProvider() *FakeProvider {
+	return &FakeProvider{}
+}
+
+func (p *FakeProvider) Name() string {
+	return ProviderTypeFake
+}
+
+func (p *FakeProvider) Charge(ctx context.Context, req ChargeRequest) (*Result, error) {
+	if strings.HasPrefix(req.Token, FakeTokenDelayPrefix) {
+		delay, err := time.ParseDuration(strings.TrimPrefix(req.Token, FakeTokenDelayPrefix))
+		if err != nil {
+			return nil, fmt.Errorf("invalid delay in token %q: %w", req.Token, err)
+		}
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	result := &Result{ProviderRef: fakeRef("ch", req.IdempotencyKey)}
+
+	switch req.Token {
+	case FakeTokenDecline:
+		result.FailureReason = "card_declined"
+		return result, ErrDeclined
+	case FakeTokenInsufficientFunds:
+		result.FailureReason = "insufficient_funds"
+		return result, ErrDeclined
+	case FakeTokenError:
+		return nil, fmt.Errorf("fake provider unavailable")
+	}
+
+	if !req.Amount.IsPositive() {
+		result.FailureReason = "invalid_amount"
+		return result, ErrDeclined
+	}
+
+	return result, nil
+}
+
+func (p *FakeProvider) Refund(ctx context.Context, req RefundRequest) (*Result, error) {
+	if req.ProviderRef == "" {
+		return nil, fmt.Errorf("provider reference is required")
+	}
+	return &Result{ProviderRef: fakeRef("re", req.IdempotencyKey)}, nil
+}
+
+// fakeRef derives a stable provider reference from the idempotency key
+func fakeRef(prefix, idempotencyKey string) string {
+	sum := sha256.Sum256([]byte(idempotencyKey))
+	return "fake_" + prefix + "_" + hex.EncodeToString(sum[:8])
+}
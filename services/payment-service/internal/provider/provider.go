@@ -0,0 +1,62 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	sharedModels "github.com/lucas/gokafka/shared/models"
+	"github.com/lucas/gokafka/shared/utils"
+)
+
+// Provider types
+const (
+	ProviderTypeFake = "fake"
+)
+
+// ErrDeclined marks a charge the provider refused. Any other error means the
+// outcome is unknown and the call may be retried with the same idempotency
+// key.
+var ErrDeclined = errors.New("payment declined")
+
+type ChargeRequest struct {
+	IdempotencyKey string
+	Amount         sharedModels.Money
+	Token          string
+}
+
+type RefundRequest struct {
+	IdempotencyKey string
+	ProviderRef    string
+	Amount         sharedModels.Money
+}
+
+// Result describes a completed provider operation
+type Result struct {
+	ProviderRef   string
+	FailureReason string // set when the charge was declined
+}
+
+// Provider moves money. Implementations must treat the idempotency key like
+// real payment providers do: repeating a request returns the first outcome
+// instead of charging or refunding again.
+type Provider interface {
+	Name() string
+	Charge(ctx context.Context, req ChargeRequest) (*Result, error)
+	Refund(ctx context.Context, req RefundRequest) (*Result, error)
+}
+
+// NewProvider builds the provider selected by PAYMENT_PROVIDER. Only the
+// fake provider ships today; real ones plug in here.
+func NewProvider() Provider {
+	providerType := utils.GetEnvOrDefault("PAYMENT_PROVIDER", ProviderTypeFake)
+
+	switch providerType {
+	case ProviderTypeFake:
+		log.Println("Using fake payment provider")
+		return NewFakeProvider()
+	default:
+		log.Fatalf("unknown PAYMENT_PROVIDER %q", providerType)
+		return nil
+	}
+}
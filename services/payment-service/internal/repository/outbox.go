@@ -0,0 +1,12 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/lucas/gokafka/payment-service/internal/models"
+)
+
+// insertOutboxEvent records a payment event inside the caller's transaction
+func (r *PaymentRepository) insertOutboxEvent(tx *sql.Tx, eventType string, payment *models.Payment) error {
+	return r.outbox.Insert(tx, eventType, payment.ID, r.PaymentToPaymentData(payment))
+}
@@ -0,0 +1,234 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	_ "github.com/lib/pq" // PostgreSQL driver
+	"github.com/lucas/gokafka/payment-service/internal/models"
+	"github.com/lucas/gokafka/shared/locks"
+	sharedModels "github.com/lucas/gokafka/shared/models"
+	"github.com/lucas/gokafka/shared/outbox"
+	"github.com/lucas/gokafka/shared/utils"
+)
+
+// Database constants
+const (
+	DefaultPostgresHost     = "localhost"
+	DefaultPostgresPort     = "5432"
+	DefaultPostgresDB       = "gokafka"
+	DefaultPostgresUser     = "postgres"
+	DefaultPostgresPassword = "postgres"
+)
+
+// ErrStatusChanged is returned when a payment's status changed concurrently
+var ErrStatusChanged = errors.New("payment status changed concurrently")
+
+type PaymentRepository struct {
+	db     *sql.DB
+	outbox *outbox.Outbox
+}
+
+func NewPaymentRepository() *PaymentRepository {
+	db := initDatabase()
+	repo := &PaymentRepository{
+		db:     db,
+		outbox: outbox.New(db, "payment_outbox", locks.PaymentOutboxRelay),
+	}
+	repo.createTablesIfNotExists()
+	return repo
+}
+
+// Outbox returns the outbox payment events are recorded in
+func (r *PaymentRepository) Outbox() *outbox.Outbox {
+	return r.outbox
+}
+
+// initDatabase initializes the database connection
+func initDatabase() *sql.DB {
+	host := utils.GetEnvOrDefault("POSTGRES_HOST", DefaultPostgresHost)
+	port := utils.GetEnvOrDefault("POSTGRES_PORT", DefaultPostgresPort)
+	dbname := utils.GetEnvOrDefault("POSTGRES_DB", DefaultPostgresDB)
+	user := utils.GetEnvOrDefault("POSTGRES_USER", DefaultPostgresUser)
+	password := utils.GetEnvOrDefault("POSTGRES_PASSWORD", DefaultPostgresPassword)
+
+	connStr := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable",
+		user, password, host, port, dbname)
+
+	log.Printf("Connecting to PostgreSQL at %s:%s", host, port)
+	db, err := sql.Open("postgres", connStr)
+	if err != nil {
+		log.Fatalf("failed to connect to postgres: %v", err)
+	}
+
+	if err := db.Ping(); err != nil {
+		log.Fatalf("failed to ping postgres: %v", err)
+	}
+
+	log.Println("Connected to PostgreSQL database")
+	return db
+}
+
+func (r *PaymentRepository) createTablesIfNotExists() {
+	query := `
+	CREATE TABLE IF NOT EXISTS payments (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		idempotency_key VARCHAR(255) NOT NULL UNIQUE,
+		order_id VARCHAR(255) NOT NULL,
+		user_id VARCHAR(255) NOT NULL,
+		amount NUMERIC(12, 2) NOT NULL CHECK (amount > 0),
+		currency VARCHAR(3) NOT NULL DEFAULT 'USD',
+		status VARCHAR(20) NOT NULL,
+		failure_reason TEXT NOT NULL DEFAULT '',
+		provider VARCHAR(50) NOT NULL,
+		provider_ref VARCHAR(255) NOT NULL DEFAULT '',
+		refund_ref VARCHAR(255) NOT NULL DEFAULT '',
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE INDEX IF NOT EXISTS idx_payments_order ON payments (order_id, created_at DESC)`
+
+	if _, err := r.db.Exec(query); err != nil {
+		log.Fatalf("failed to create payments table: %v", err)
+	}
+
+	if err := r.outbox.CreateTableIfNotExists(); err != nil {
+		log.Fatal(err)
+	}
+
+	log.Println("Payments table is ready")
+}
+
+const paymentColumns = `id, idempotency_key, order_id, user_id, amount, currency, status, failure_reason,
+	provider, provider_ref, refund_ref, created_at, updated_at`
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanPayment reads a payment row. The NUMERIC amount is scanned as text so
+// it converts to Money without passing through float64.
+func scanPayment(row rowScanner) (*models.Payment, error) {
+	var payment models.Payment
+	var amount, currency string
+	err := row.Scan(
+		&payment.ID, &payment.IdempotencyKey, &payment.OrderID, &payment.UserID,
+		&amount, &currency, &payment.Status, &payment.FailureReason,
+		&payment.Provider, &payment.ProviderRef, &payment.RefundRef,
+		&payment.CreatedAt, &payment.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	payment.Amount, err = sharedModels.ParseMoney(amount, currency)
+	if err != nil {
+		return nil, fmt.Errorf("invalid amount of payment %s: %w", payment.ID, err)
+	}
+
+	return &payment, nil
+}
+
+// CreatePayment stores a new pending payment. If a payment with the same
+// idempotency key exists it is returned instead, with created false.
+func (r *PaymentRepository) CreatePayment(payment *models.Payment) (*models.Payment, bool, error) {
+	query := `
+	INSERT INTO payments (idempotency_key, order_id, user_id, amount, currency, status, provider, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $8)
+	ON CONFLICT (idempotency_key) DO NOTHING
+	RETURNING ` + paymentColumns
+
+	created, err := scanPayment(r.db.QueryRow(query, payment.IdempotencyKey, payment.OrderID,
+		payment.UserID, payment.Amount.Decimal(), payment.Amount.Currency, payment.Status,
+		payment.Provider, time.Now()))
+	if err == nil {
+		return created, true, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, false, fmt.Errorf("failed to create payment: %w", err)
+	}
+
+	existing, err := r.GetPaymentByIdempotencyKey(payment.IdempotencyKey)
+	if err != nil {
+		return nil, false, err
+	}
+	return existing, false, nil
+}
+
+func (r *PaymentRepository) GetPaymentByID(id string) (*models.Payment, error) {
+	return scanPayment(r.db.QueryRow(`SELECT `+paymentColumns+` FROM payments WHERE id::text = $1`, id))
+}
+
+func (r *PaymentRepository) GetPaymentByIdempotencyKey(key string) (*models.Payment, error) {
+	return scanPayment(r.db.QueryRow(`SELECT `+paymentColumns+` FROM payments WHERE idempotency_key = $1`, key))
+}
+
+// GetLatestPaymentForOrder returns the most recent charge for an order
+func (r *PaymentRepository) GetLatestPaymentForOrder(orderID string) (*models.Payment, error) {
+	return scanPayment(r.db.QueryRow(`
+	SELECT `+paymentColumns+` FROM payments
+	WHERE order_id = $1 ORDER BY created_at DESC LIMIT 1`, orderID))
+}
+
+// UpdatePaymentStatus stores the payment's new status and provider details
+// together with the matching event, provided the payment is still in
+// fromStatus. It returns ErrStatusChanged when another request got there
+// first.
+func (r *PaymentRepository) UpdatePaymentStatus(payment *models.Payment, fromStatus, eventType string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+	UPDATE payments
+	SET status = $1, failure_reason = $2, provider_ref = $3, refund_ref = $4, updated_at = $5
+	WHERE id = $6 AND status = $7
+	RETURNING updated_at`
+
+	err = tx.QueryRow(query, payment.Status, payment.FailureReason, payment.ProviderRef,
+		payment.RefundRef, time.Now(), payment.ID, fromStatus).Scan(&payment.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return ErrStatusChanged
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update payment: %w", err)
+	}
+
+	if err := r.insertOutboxEvent(tx, eventType, payment); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// PaymentToPaymentData converts a payment to its bus form
+func (r *PaymentRepository) PaymentToPaymentData(payment *models.Payment) *sharedModels.PaymentData {
+	return &sharedModels.PaymentData{
+		ID:            payment.ID,
+		OrderID:       payment.OrderID,
+		UserID:        payment.UserID,
+		Amount:        payment.Amount,
+		Status:        payment.Status,
+		FailureReason: payment.FailureReason,
+		ProviderRef:   payment.ProviderRef,
+		CreatedAt:     payment.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:     payment.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+// RecordLateRefund stores the provider references of a charge that
+// completed after the payment had already been cancelled, and was refunded
+// right away
+func (r *PaymentRepository) RecordLateRefund(id, providerRef, refundRef string) error {
+	query := `UPDATE payments SET provider_ref = $1, refund_ref = $2, updated_at = $3 WHERE id = $4`
+	if _, err := r.db.Exec(query, providerRef, refundRef, time.Now(), id); err != nil {
+		return fmt.Errorf("failed to record late refund: %w", err)
+	}
+	return nil
+}
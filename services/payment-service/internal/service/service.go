@@ -0,0 +1,238 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/lucas/gokafka/payment-service/internal/models"
+	"github.com/lucas/gokafka/payment-service/internal/provider"
+	"github.com/lucas/gokafka/payment-service/internal/repository"
+	sharedModels "github.com/lucas/gokafka/shared/models"
+)
+
+// ProviderTimeout bounds a single call to the payment provider
+const ProviderTimeout = 30 * time.Second
+
+type PaymentService struct {
+	repo     *repository.PaymentRepository
+	provider provider.Provider
+}
+
+func NewPaymentService(repo *repository.PaymentRepository, provider provider.Provider) *PaymentService {
+	return &PaymentService{
+		repo:     repo,
+		provider: provider,
+	}
+}
+
+// Charge charges a payment token once per idempotency key. Repeating a
+// request returns the stored payment; a payment left pending by a failed
+// attempt is sent to the provider again.
+func (s *PaymentService) Charge(req sharedModels.ChargePaymentRequest) (*sharedModels.PaymentData, error) {
+	// Validate input
+	if req.IdempotencyKey == "" {
+		return nil, fmt.Errorf("idempotency key is required")
+	}
+	if req.OrderID == "" || req.UserID == "" {
+		return nil, fmt.Errorf("order ID and user ID are required")
+	}
+	if !req.Amount.IsPositive() {
+		return nil, fmt.Errorf("amount must be greater than 0")
+	}
+	if req.PaymentToken == "" {
+		return nil, fmt.Errorf("payment token is required")
+	}
+
+	payment, created, err := s.repo.CreatePayment(&models.Payment{
+		IdempotencyKey: req.IdempotencyKey,
+		OrderID:        req.OrderID,
+		UserID:         req.UserID,
+		Amount:         req.Amount,
+		Status:         sharedModels.PaymentStatusPending,
+		Provider:       s.provider.Name(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !created {
+		if payment.OrderID != req.OrderID || payment.Amount != req.Amount {
+			return nil, fmt.Errorf("idempotency key %s was used for a different payment", req.IdempotencyKey)
+		}
+		if payment.Status != sharedModels.PaymentStatusPending {
+			return s.repo.PaymentToPaymentData(payment), nil
+		}
+	}
+
+	if err := s.completeCharge(payment, req.PaymentToken); err != nil {
+		return nil, err
+	}
+
+	return s.repo.PaymentToPaymentData(payment), nil
+}
+
+// completeCharge sends a pending payment to the provider and stores the
+// outcome. On a provider error the payment stays pending so a retry with
+// the same idempotency key can finish it.
+func (s *PaymentService) completeCharge(payment *models.Payment, token string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), ProviderTimeout)
+	defer cancel()
+
+	result, err := s.provider.Charge(ctx, provider.ChargeRequest{
+		IdempotencyKey: payment.IdempotencyKey,
+		Amount:         payment.Amount,
+		Token:          token,
+	})
+
+	var eventType string
+	switch {
+	case errors.Is(err, provider.ErrDeclined):
+		payment.Status = sharedModels.PaymentStatusDeclined
+		payment.FailureReason = result.FailureReason
+		payment.ProviderRef = result.ProviderRef
+		eventType = sharedModels.PaymentEventDeclined
+	case err != nil:
+		return fmt.Errorf("payment provider error: %w", err)
+	default:
+		payment.Status = sharedModels.PaymentStatusSucceeded
+		payment.ProviderRef = result.ProviderRef
+		eventType = sharedModels.PaymentEventSucceeded
+	}
+
+	err = s.repo.UpdatePaymentStatus(payment, sharedModels.PaymentStatusPending, eventType)
+	if errors.Is(err, repository.ErrStatusChanged) {
+		return s.resolveLateCharge(payment)
+	}
+	return err
+}
+
+// resolveLateCharge handles a charge that completed after its payment was
+// cancelled by a refund, or finished by a concurrent retry
+func (s *PaymentService) resolveLateCharge(payment *models.Payment) error {
+	current, err := s.repo.GetPaymentByID(payment.ID)
+	if err != nil {
+		return fmt.Errorf("failed to reload payment: %w", err)
+	}
+
+	charged := payment.Status == sharedModels.PaymentStatusSucceeded
+	if charged && current.Status == sharedModels.PaymentStatusRefunded && current.RefundRef == "" {
+		ctx, cancel := context.WithTimeout(context.Background(), ProviderTimeout)
+		defer cancel()
+
+		result, err := s.provider.Refund(ctx, provider.RefundRequest{
+			IdempotencyKey: "refund-" + payment.IdempotencyKey,
+			ProviderRef:    payment.ProviderRef,
+			Amount:         payment.Amount,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to refund late charge of payment %s: %w", payment.ID, err)
+		}
+		if err := s.repo.RecordLateRefund(payment.ID, payment.ProviderRef, result.ProviderRef); err != nil {
+			return err
+		}
+		log.Printf("Refunded late charge of cancelled payment %s", payment.ID)
+
+		current.ProviderRef = payment.ProviderRef
+		current.RefundRef = result.ProviderRef
+	}
+
+	*payment = *current
+	return nil
+}
+
+// Refund refunds a payment identified by ID, or the latest payment of an
+// order. Refunding an order that was never charged succeeds with no
+// payment, so checkout compensation works even if the charge reply was lost.
+func (s *PaymentService) Refund(req sharedModels.RefundPaymentRequest) (*sharedModels.PaymentData, error) {
+	if req.PaymentID == "" && req.OrderID == "" {
+		return nil, fmt.Errorf("payment ID or order ID is required")
+	}
+
+	var payment *models.Payment
+	var err error
+	if req.PaymentID != "" {
+		payment, err = s.repo.GetPaymentByID(req.PaymentID)
+	} else {
+		payment, err = s.repo.GetLatestPaymentForOrder(req.OrderID)
+	}
+	if err == sql.ErrNoRows {
+		if req.PaymentID == "" {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("payment not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payment: %w", err)
+	}
+
+	switch payment.Status {
+	case sharedModels.PaymentStatusRefunded, sharedModels.PaymentStatusDeclined:
+		// Nothing (more) to refund
+		return s.repo.PaymentToPaymentData(payment), nil
+
+	case sharedModels.PaymentStatusPending:
+		// The charge is still in flight. Cancel it here; if it succeeds
+		// later, completeCharge refunds it right away.
+		payment.Status = sharedModels.PaymentStatusRefunded
+		payment.FailureReason = "cancelled before the charge completed"
+		if err := s.repo.UpdatePaymentStatus(payment, sharedModels.PaymentStatusPending,
+			sharedModels.PaymentEventRefunded); err != nil {
+			if errors.Is(err, repository.ErrStatusChanged) {
+				return s.Refund(sharedModels.RefundPaymentRequest{IdempotencyKey: req.IdempotencyKey, PaymentID: payment.ID})
+			}
+			return nil, err
+		}
+		return s.repo.PaymentToPaymentData(payment), nil
+	}
+
+	idempotencyKey := req.IdempotencyKey
+	if idempotencyKey == "" {
+		idempotencyKey = "refund-" + payment.IdempotencyKey
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), ProviderTimeout)
+	defer cancel()
+
+	result, err := s.provider.Refund(ctx, provider.RefundRequest{
+		IdempotencyKey: idempotencyKey,
+		ProviderRef:    payment.ProviderRef,
+		Amount:         payment.Amount,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("payment provider error: %w", err)
+	}
+
+	payment.Status = sharedModels.PaymentStatusRefunded
+	payment.RefundRef = result.ProviderRef
+	err = s.repo.UpdatePaymentStatus(payment, sharedModels.PaymentStatusSucceeded, sharedModels.PaymentEventRefunded)
+	if errors.Is(err, repository.ErrStatusChanged) {
+		// A concurrent refund won; the provider deduplicated ours
+		current, err := s.repo.GetPaymentByID(payment.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to reload payment: %w", err)
+		}
+		return s.repo.PaymentToPaymentData(current), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.PaymentToPaymentData(payment), nil
+}
+
+func (s *PaymentService) GetPayment(id string) (*sharedModels.PaymentData, error) {
+	if id == "" {
+		return nil, fmt.Errorf("payment ID is required")
+	}
+
+	payment, err := s.repo.GetPaymentByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("payment not found")
+	}
+
+	return s.repo.PaymentToPaymentData(payment), nil
+}
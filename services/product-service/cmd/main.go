@@ -4,7 +4,13 @@ import (
 	"log"
 
 	"github.com/gin-gonic/gin"
+	"github.com/lucas/gokafka/product-service/internal/currency"
 	"github.com/lucas/gokafka/product-service/internal/handlers"
+	"github.com/lucas/gokafka/product-service/internal/repository"
+	"github.com/lucas/gokafka/product-service/internal/service"
+	"github.com/lucas/gokafka/product-service/internal/storage"
+	sharedModels "github.com/lucas/gokafka/shared/models"
+	"github.com/lucas/gokafka/shared/outbox"
 	"github.com/lucas/gokafka/shared/utils"
 )
 
@@ -15,25 +21,49 @@ const (
 func main() {
 	log.Println("Starting product-service...")
 
-	// Initialize handler
-	handler := handlers.NewProductHandler()
+	// Initialize dependencies
+	repo := repository.NewProductRepository()
+	storage := storage.NewStorage()
+	service := service.NewProductService(repo, currency.NewConverter(), storage)
+	handler := handlers.NewProductHandler(service)
+	mediaHandler := handlers.NewMediaHandler(service, storage)
+	bulkHandler := handlers.NewBulkHandler(service)
+	relay := outbox.NewRelay(repo.Outbox(), sharedModels.ProductEventsTopic)
 
 	log.Println("Product-service started, waiting for requests...")
 	
 	// Start Kafka message listener in background
 	go handler.ListenMessages()
 
+	// Start outbox relay publishing product events in background
+	go relay.Run()
+
+	// Start returning the stock of expired reservations in background
+	go service.RunReservationExpiry()
+
+	// Start purging products past their soft delete retention in background
+	go service.RunPurge()
+
+	// Start announcing price schedules as they start and end in background
+	go service.RunPriceScheduler()
+
 	// Start HTTP server
-	startHTTPServer()
+	startHTTPServer(mediaHandler, bulkHandler)
 }
 
-func startHTTPServer() {
+func startHTTPServer(mediaHandler *handlers.MediaHandler, bulkHandler *handlers.BulkHandler) {
 	router := gin.Default()
 
 	// Health endpoints
 	router.GET("/health", healthHandler)
 	router.GET("/ready", readyHandler)
 
+	// Image uploads and media files
+	mediaHandler.RegisterRoutes(router)
+
+	// Catalogue import and export
+	bulkHandler.RegisterRoutes(router)
+
 	port := utils.GetEnvOrDefault("PORT", DefaultPort)
 	log.Printf("Starting HTTP server on port %s", port)
 	if err := router.Run(":" + port); err != nil {
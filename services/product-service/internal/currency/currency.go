@@ -0,0 +1,178 @@
+package currency
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"strings"
+
+	sharedModels "github.com/lucas/gokafka/shared/models"
+	"github.com/lucas/gokafka/shared/utils"
+)
+
+// DefaultRatesFile is read when EXCHANGE_RATES_FILE is not set. If it does
+// not exist the built-in table is used.
+const DefaultRatesFile = "/etc/product-service/rates.json"
+
+// RateTable is the on-disk format of the exchange rates. Rates are decimal
+// strings giving units of each currency per one unit of Base, so they are
+// parsed exactly. Rounding maps a currency to its rounding increment in minor
+// units, e.g. 5 for CHF cash rounding to 0.05.
+type RateTable struct {
+	Base     string            `json:"base"`
+	Rates    map[string]string `json:"rates"`
+	Rounding map[string]int64  `json:"rounding"`
+}
+
+// defaultRateTable is used when no rate file is available
+var defaultRateTable = RateTable{
+	Base: "USD",
+	Rates: map[string]string{
+		"USD": "1",
+		"EUR": "0.92",
+		"GBP": "0.79",
+		"CHF": "0.88",
+		"CAD": "1.36",
+		"JPY": "151.5",
+		"BRL": "5.05",
+	},
+	Rounding: map[string]int64{
+		"CHF": 5,
+	},
+}
+
+// Converter converts money between the currencies of its rate table
+type Converter struct {
+	base     string
+	rates    map[string]*big.Rat
+	rounding map[string]int64
+}
+
+// NewConverter loads the rate table from EXCHANGE_RATES_FILE, falling back to
+// the built-in table when the file does not exist
+func NewConverter() *Converter {
+	path := utils.GetEnvOrDefault("EXCHANGE_RATES_FILE", DefaultRatesFile)
+
+	table := defaultRateTable
+	data, err := os.ReadFile(path)
+	switch {
+	case os.IsNotExist(err):
+		log.Printf("No exchange rate file at %s, using built-in rates", path)
+	case err != nil:
+		log.Fatalf("failed to read exchange rates: %v", err)
+	default:
+		table = RateTable{}
+		if err := json.Unmarshal(data, &table); err != nil {
+			log.Fatalf("failed to parse exchange rates %s: %v", path, err)
+		}
+		log.Printf("Loaded exchange rates from %s", path)
+	}
+
+	converter, err := newConverter(table)
+	if err != nil {
+		log.Fatalf("invalid exchange rates: %v", err)
+	}
+	return converter
+}
+
+func newConverter(table RateTable) (*Converter, error) {
+	base := strings.ToUpper(table.Base)
+	if err := sharedModels.ValidateCurrency(base); err != nil {
+		return nil, fmt.Errorf("base: %w", err)
+	}
+
+	c := &Converter{
+		base:     base,
+		rates:    map[string]*big.Rat{base: big.NewRat(1, 1)},
+		rounding: make(map[string]int64),
+	}
+
+	for code, value := range table.Rates {
+		code = strings.ToUpper(code)
+		if err := sharedModels.ValidateCurrency(code); err != nil {
+			return nil, err
+		}
+		rate, ok := new(big.Rat).SetString(value)
+		if !ok || rate.Sign() <= 0 {
+			return nil, fmt.Errorf("invalid rate %q for %s", value, code)
+		}
+		c.rates[code] = rate
+	}
+
+	for code, increment := range table.Rounding {
+		if increment <= 0 {
+			return nil, fmt.Errorf("invalid rounding increment %d for %s", increment, code)
+		}
+		c.rounding[strings.ToUpper(code)] = increment
+	}
+
+	return c, nil
+}
+
+// Base returns the currency all rates are quoted against
+func (c *Converter) Base() string {
+	return c.base
+}
+
+// Supports reports whether currency is in the rate table
+func (c *Converter) Supports(currency string) bool {
+	_, ok := c.rates[strings.ToUpper(currency)]
+	return ok
+}
+
+// Convert returns m in the target currency, rounded half away from zero to
+// the target's rounding increment
+func (c *Converter) Convert(m sharedModels.Money, target string) (sharedModels.Money, error) {
+	target = strings.ToUpper(target)
+	if m.Currency == target {
+		return m, nil
+	}
+
+	fromRate, ok := c.rates[m.Currency]
+	if !ok {
+		return sharedModels.Money{}, fmt.Errorf("unsupported currency %s", m.Currency)
+	}
+	toRate, ok := c.rates[target]
+	if !ok {
+		return sharedModels.Money{}, fmt.Errorf("unsupported currency %s", target)
+	}
+
+	// minor(target) = minor(source) / 10^exp(source) / fromRate * toRate * 10^exp(target)
+	value := new(big.Rat).SetInt64(m.Amount)
+	value.Quo(value, new(big.Rat).SetInt(pow10(sharedModels.CurrencyExponent(m.Currency))))
+	value.Quo(value, fromRate)
+	value.Mul(value, toRate)
+	value.Mul(value, new(big.Rat).SetInt(pow10(sharedModels.CurrencyExponent(target))))
+
+	increment := c.rounding[target]
+	if increment == 0 {
+		increment = 1
+	}
+	value.Quo(value, new(big.Rat).SetInt64(increment))
+	rounded := roundHalfAway(value)
+	rounded.Mul(rounded, big.NewInt(increment))
+
+	if !rounded.IsInt64() {
+		return sharedModels.Money{}, fmt.Errorf("converted amount is out of range")
+	}
+	return sharedModels.NewMoney(rounded.Int64(), target), nil
+}
+
+func pow10(exponent int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exponent)), nil)
+}
+
+// roundHalfAway rounds r to the nearest integer, ties away from zero
+func roundHalfAway(r *big.Rat) *big.Int {
+	num := new(big.Int).Abs(r.Num())
+	quo, rem := new(big.Int).QuoRem(num, r.Denom(), new(big.Int))
+	if rem.Mul(rem, big.NewInt(2)).Cmp(r.Denom()) >= 0 {
+		quo.Add(quo, big.NewInt(1))
+	}
+	if r.Sign() < 0 {
+		quo.Neg(quo)
+	}
+	return quo
+}
@@ -0,0 +1,122 @@
+package currency
+
+import (
+	"math"
+	"math/big"
+	"testing"
+
+	sharedModels "github.com/lucas/gokafka/shared/models"
+)
+
+func TestConvert(t *testing.T) {
+	defaultConverter, err := newConverter(defaultRateTable)
+	if err != nil {
+		t.Fatalf("newConverter(defaultRateTable) returned error: %v", err)
+	}
+	// Rates that land exactly on a CHF rounding tie
+	tieConverter, err := newConverter(RateTable{
+		Base:     "USD",
+		Rates:    map[string]string{"USD": "1", "CHF": "0.5"},
+		Rounding: map[string]int64{"CHF": 5},
+	})
+	if err != nil {
+		t.Fatalf("newConverter(tie table) returned error: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		converter *Converter
+		money     sharedModels.Money
+		target    string
+		want      sharedModels.Money
+		wantErr   bool
+	}{
+		{name: "same currency", converter: defaultConverter, money: sharedModels.NewMoney(1999, "USD"), target: "USD", want: sharedModels.NewMoney(1999, "USD")},
+		{name: "base to other", converter: defaultConverter, money: sharedModels.NewMoney(1000, "USD"), target: "EUR", want: sharedModels.NewMoney(920, "EUR")},
+		{name: "lower case target", converter: defaultConverter, money: sharedModels.NewMoney(1000, "USD"), target: "eur", want: sharedModels.NewMoney(920, "EUR")},
+		{name: "cross rate", converter: defaultConverter, money: sharedModels.NewMoney(1000, "EUR"), target: "GBP", want: sharedModels.NewMoney(859, "GBP")},
+		{name: "to zero decimal currency", converter: defaultConverter, money: sharedModels.NewMoney(101, "USD"), target: "JPY", want: sharedModels.NewMoney(153, "JPY")},
+		{name: "to zero decimal currency on a tie", converter: defaultConverter, money: sharedModels.NewMoney(100, "USD"), target: "JPY", want: sharedModels.NewMoney(152, "JPY")},
+		{name: "negative tie rounds away from zero", converter: defaultConverter, money: sharedModels.NewMoney(-100, "USD"), target: "JPY", want: sharedModels.NewMoney(-152, "JPY")},
+		{name: "from zero decimal currency", converter: defaultConverter, money: sharedModels.NewMoney(1000, "JPY"), target: "USD", want: sharedModels.NewMoney(660, "USD")},
+		{name: "CHF rounds up to increment", converter: defaultConverter, money: sharedModels.NewMoney(100, "USD"), target: "CHF", want: sharedModels.NewMoney(90, "CHF")},
+		{name: "CHF rounds down to increment", converter: defaultConverter, money: sharedModels.NewMoney(1234, "USD"), target: "CHF", want: sharedModels.NewMoney(1085, "CHF")},
+		{name: "CHF tie rounds away from zero", converter: tieConverter, money: sharedModels.NewMoney(5, "USD"), target: "CHF", want: sharedModels.NewMoney(5, "CHF")},
+		{name: "negative CHF tie rounds away from zero", converter: tieConverter, money: sharedModels.NewMoney(-5, "USD"), target: "CHF", want: sharedModels.NewMoney(-5, "CHF")},
+		{name: "CHF below half an increment", converter: tieConverter, money: sharedModels.NewMoney(4, "USD"), target: "CHF", want: sharedModels.NewMoney(0, "CHF")},
+		{name: "zero", converter: defaultConverter, money: sharedModels.NewMoney(0, "USD"), target: "JPY", want: sharedModels.NewMoney(0, "JPY")},
+		{name: "unsupported source", converter: defaultConverter, money: sharedModels.NewMoney(100, "XYZ"), target: "USD", wantErr: true},
+		{name: "unsupported target", converter: defaultConverter, money: sharedModels.NewMoney(100, "USD"), target: "XYZ", wantErr: true},
+		{name: "out of range", converter: defaultConverter, money: sharedModels.NewMoney(math.MaxInt64, "USD"), target: "JPY", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.converter.Convert(tt.money, tt.target)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Convert(%v, %s) = %v, want error", tt.money, tt.target, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Convert(%v, %s) returned error: %v", tt.money, tt.target, err)
+			}
+			if got != tt.want {
+				t.Errorf("Convert(%v, %s) = %v, want %v", tt.money, tt.target, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoundHalfAway(t *testing.T) {
+	tests := []struct {
+		num, denom int64
+		want       int64
+	}{
+		{num: 0, denom: 1, want: 0},
+		{num: 4, denom: 1, want: 4},
+		{num: -4, denom: 1, want: -4},
+		{num: 1, denom: 2, want: 1},
+		{num: -1, denom: 2, want: -1},
+		{num: 3, denom: 2, want: 2},
+		{num: 5, denom: 2, want: 3},
+		{num: -5, denom: 2, want: -3},
+		{num: 49, denom: 100, want: 0},
+		{num: -49, denom: 100, want: 0},
+		{num: 51, denom: 100, want: 1},
+		{num: 7, denom: 3, want: 2},
+		{num: -7, denom: 3, want: -2},
+		{num: 5, denom: 3, want: 2},
+		{num: -5, denom: 3, want: -2},
+	}
+
+	for _, tt := range tests {
+		got := roundHalfAway(big.NewRat(tt.num, tt.denom))
+		if got.Int64() != tt.want {
+			t.Errorf("roundHalfAway(%d/%d) = %s, want %d", tt.num, tt.denom, got, tt.want)
+		}
+	}
+}
+
+func TestNewConverterRejectsInvalidTables(t *testing.T) {
+	tests := []struct {
+		name  string
+		table RateTable
+	}{
+		{name: "invalid base", table: RateTable{Base: "DOLLAR"}},
+		{name: "invalid currency", table: RateTable{Base: "USD", Rates: map[string]string{"EU": "0.92"}}},
+		{name: "unparsable rate", table: RateTable{Base: "USD", Rates: map[string]string{"EUR": "abc"}}},
+		{name: "zero rate", table: RateTable{Base: "USD", Rates: map[string]string{"EUR": "0"}}},
+		{name: "negative rate", table: RateTable{Base: "USD", Rates: map[string]string{"EUR": "-1"}}},
+		{name: "zero rounding increment", table: RateTable{Base: "USD", Rounding: map[string]int64{"CHF": 0}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := newConverter(tt.table); err == nil {
+				t.Errorf("newConverter(%+v) succeeded, want error", tt.table)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lucas/gokafka/product-service/internal/service"
+)
+
+// maxImportBytes caps the size of an import file
+const maxImportBytes = 50 << 20
+
+// importContentTypes maps request content types to import formats
+var importContentTypes = map[string]string{
+	"text/csv":             service.FormatCSV,
+	"application/x-ndjson": service.FormatNDJSON,
+	"application/jsonl":    service.FormatNDJSON,
+}
+
+// exportContentTypes maps export formats to response content types
+var exportContentTypes = map[string]string{
+	service.FormatCSV:    "text/csv; charset=utf-8",
+	service.FormatNDJSON: "application/x-ndjson",
+}
+
+// BulkHandler serves catalogue import and export over HTTP. Files of this
+// size do not fit in Kafka messages, so the gateway proxies these routes
+// straight to the service.
+type BulkHandler struct {
+	service *service.ProductService
+}
+
+func NewBulkHandler(service *service.ProductService) *BulkHandler {
+	return &BulkHandler{service: service}
+}
+
+// RegisterRoutes adds the import and export routes to the service's HTTP
+// server
+func (h *BulkHandler) RegisterRoutes(router *gin.Engine) {
+	admin := router.Group("/products")
+	admin.Use(requireAdmin)
+	{
+		admin.POST("/import", h.ImportProducts)
+		admin.GET("/export", h.ExportProducts)
+	}
+}
+
+// ImportProducts handles POST /products/import. The body is the file itself;
+// its format comes from the format query parameter or the Content-Type.
+func (h *BulkHandler) ImportProducts(c *gin.Context) {
+	format := c.Query("format")
+	if format == "" {
+		contentType, _, _ := mime.ParseMediaType(c.GetHeader("Content-Type"))
+		format = importContentTypes[contentType]
+	}
+	if format == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Send text/csv or application/x-ndjson, or set format to csv or ndjson"})
+		return
+	}
+
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, maxImportBytes)
+	report, err := h.service.ImportProducts(format, body, c.GetString("user_id"))
+	if err != nil {
+		log.Printf("Product import stopped: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+			"data":  report,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Products imported",
+		"data":    report,
+	})
+}
+
+// ExportProducts handles GET /products/export, streaming the catalogue as
+// CSV (the default) or NDJSON
+func (h *BulkHandler) ExportProducts(c *gin.Context) {
+	format := c.DefaultQuery("format", service.FormatCSV)
+	contentType, ok := exportContentTypes[format]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": service.ErrUnsupportedFormat.Error()})
+		return
+	}
+
+	filename := fmt.Sprintf("products-%s.%s", time.Now().UTC().Format("20060102-150405"), format)
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Status(http.StatusOK)
+
+	// The status is already sent, so a failure can only cut the file short
+	if err := h.service.ExportProducts(format, c.Writer); err != nil {
+		log.Printf("Product export failed: %v", err)
+	}
+}
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"log"
+
+	sharedModels "github.com/lucas/gokafka/shared/models"
+)
+
+// handleCreateCategory processes category creation
+func (h *ProductHandler) handleCreateCategory(req sharedModels.Request) sharedModels.Response {
+	var createReq sharedModels.CreateCategoryRequest
+	if err := h.unmarshalPayload(req.Payload, &createReq); err != nil {
+		log.Printf("Failed to parse create category request: %v", err)
+		return h.createErrorResponse(req.CorrelationID, "Invalid create category request format")
+	}
+
+	result, err := h.service.CreateCategory(createReq)
+	if err != nil {
+		log.Printf("Category creation failed: %v", err)
+		return h.createErrorResponse(req.CorrelationID, err.Error())
+	}
+
+	response := sharedModels.CategoryResponse{
+		Status: "success",
+		Data:   *result,
+	}
+	return h.createSuccessResponse(req.CorrelationID, response)
+}
+
+// handleGetCategory processes get category by ID request
+func (h *ProductHandler) handleGetCategory(req sharedModels.Request) sharedModels.Response {
+	var getReq sharedModels.GetCategoryRequest
+	if err := h.unmarshalPayload(req.Payload, &getReq); err != nil {
+		log.Printf("Failed to parse get category request: %v", err)
+		return h.createErrorResponse(req.CorrelationID, "Invalid get category request format")
+	}
+
+	result, err := h.service.GetCategory(getReq.ID)
+	if err != nil {
+		log.Printf("Failed to get category: %v", err)
+		return h.createErrorResponse(req.CorrelationID, err.Error())
+	}
+
+	response := sharedModels.CategoryResponse{
+		Status: "success",
+		Data:   *result,
+	}
+	return h.createSuccessResponse(req.CorrelationID, response)
+}
+
+// handleListCategories processes list all categories request
+func (h *ProductHandler) handleListCategories(correlationID string) sharedModels.Response {
+	result, err := h.service.ListCategories()
+	if err != nil {
+		log.Printf("Failed to list categories: %v", err)
+		return h.createErrorResponse(correlationID, err.Error())
+	}
+
+	response := sharedModels.ListCategoriesResponse{
+		Status: "success",
+		Data:   result,
+	}
+	return h.createSuccessResponse(correlationID, response)
+}
+
+// handleUpdateCategory processes category updates
+func (h *ProductHandler) handleUpdateCategory(req sharedModels.Request) sharedModels.Response {
+	var updateReq sharedModels.UpdateCategoryRequest
+	if err := h.unmarshalPayload(req.Payload, &updateReq); err != nil {
+		log.Printf("Failed to parse update category request: %v", err)
+		return h.createErrorResponse(req.CorrelationID, "Invalid update category request format")
+	}
+
+	result, err := h.service.UpdateCategory(updateReq)
+	if err != nil {
+		log.Printf("Category update failed: %v", err)
+		return h.createErrorResponse(req.CorrelationID, err.Error())
+	}
+
+	response := sharedModels.CategoryResponse{
+		Status: "success",
+		Data:   *result,
+	}
+	return h.createSuccessResponse(req.CorrelationID, response)
+}
+
+// handleDeleteCategory processes category deletion
+func (h *ProductHandler) handleDeleteCategory(req sharedModels.Request) sharedModels.Response {
+	var deleteReq sharedModels.DeleteCategoryRequest
+	if err := h.unmarshalPayload(req.Payload, &deleteReq); err != nil {
+		log.Printf("Failed to parse delete category request: %v", err)
+		return h.createErrorResponse(req.CorrelationID, "Invalid delete category request format")
+	}
+
+	if err := h.service.DeleteCategory(deleteReq.ID); err != nil {
+		log.Printf("Category deletion failed: %v", err)
+		return h.createErrorResponse(req.CorrelationID, err.Error())
+	}
+
+	response := map[string]interface{}{
+		"status":  "success",
+		"message": "Category deleted successfully",
+		"id":      deleteReq.ID,
+	}
+	return h.createSuccessResponse(req.CorrelationID, response)
+}
+
+// handleSetProductCategories processes category assignment for a product
+func (h *ProductHandler) handleSetProductCategories(req sharedModels.Request) sharedModels.Response {
+	var setReq sharedModels.SetProductCategoriesRequest
+	if err := h.unmarshalPayload(req.Payload, &setReq); err != nil {
+		log.Printf("Failed to parse set product categories request: %v", err)
+		return h.createErrorResponse(req.CorrelationID, "Invalid set product categories request format")
+	}
+
+	result, err := h.service.SetProductCategories(setReq, req.Actor)
+	if err != nil {
+		log.Printf("Setting product categories failed: %v", err)
+		return h.createErrorResponse(req.CorrelationID, err.Error())
+	}
+
+	response := sharedModels.GetProductResponse{
+		Status: "success",
+		Data:   *result,
+	}
+	return h.createSuccessResponse(req.CorrelationID, response)
+}
+
+// handleSetProductTags processes tag assignment for a product
+func (h *ProductHandler) handleSetProductTags(req sharedModels.Request) sharedModels.Response {
+	var setReq sharedModels.SetProductTagsRequest
+	if err := h.unmarshalPayload(req.Payload, &setReq); err != nil {
+		log.Printf("Failed to parse set product tags request: %v", err)
+		return h.createErrorResponse(req.CorrelationID, "Invalid set product tags request format")
+	}
+
+	result, err := h.service.SetProductTags(setReq, req.Actor)
+	if err != nil {
+		log.Printf("Setting product tags failed: %v", err)
+		return h.createErrorResponse(req.CorrelationID, err.Error())
+	}
+
+	response := sharedModels.GetProductResponse{
+		Status: "success",
+		Data:   *result,
+	}
+	return h.createSuccessResponse(req.CorrelationID, response)
+}
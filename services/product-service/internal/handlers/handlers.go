@@ -3,24 +3,57 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"log"
 	"time"
 
+	"github.com/lucas/gokafka/product-service/internal/repository"
 	"github.com/lucas/gokafka/product-service/internal/service"
 	sharedModels "github.com/lucas/gokafka/shared/models"
 	"github.com/lucas/gokafka/shared/utils"
 	"github.com/segmentio/kafka-go"
 )
 
+// ReplyTopic is the topic the gateway listens on for this service's replies
+const ReplyTopic = "product-service-topic"
+
 // Request type constants
 const (
-	RequestTypeHealth            = "health"
-	RequestTypeCreateProduct     = "create-product"
-	RequestTypeGetProduct        = "get-product"
-	RequestTypeGetProductByID    = "get-product-by-id"
-	RequestTypeListProducts      = "list-products"
-	RequestTypeUpdateProduct     = "update-product"
-	RequestTypeDeleteProduct     = "delete-product"
+	RequestTypeHealth               = "health"
+	RequestTypeCreateProduct        = "create-product"
+	RequestTypeGetProduct           = "get-product"
+	RequestTypeGetProductByID       = "get-product-by-id"
+	RequestTypeGetProductsByIDs     = "get-products-by-ids"
+	RequestTypeListProducts         = "list-products"
+	RequestTypeSearchProducts       = "search-products"
+	RequestTypeUpdateProduct        = "update-product"
+	RequestTypePatchProduct         = "patch-product"
+	RequestTypeDeleteProduct        = "delete-product"
+	RequestTypeRestoreProduct       = "restore-product"
+	RequestTypeAdjustStock          = "adjust-stock"
+	RequestTypeReserveStock         = "reserve-stock"
+	RequestTypeCommitReservation    = "commit-reservation"
+	RequestTypeReleaseReservation   = "release-reservation"
+	RequestTypeCreateCategory       = "create-category"
+	RequestTypeGetCategory          = "get-category"
+	RequestTypeListCategories       = "list-categories"
+	RequestTypeUpdateCategory       = "update-category"
+	RequestTypeDeleteCategory       = "delete-category"
+	RequestTypeSetProductCategories = "set-product-categories"
+	RequestTypeSetProductTags       = "set-product-tags"
+	RequestTypeCreateVariant        = "create-variant"
+	RequestTypeUpdateVariant        = "update-variant"
+	RequestTypeDeleteVariant        = "delete-variant"
+	RequestTypeGetProductHistory    = "get-product-history"
+	RequestTypeGetPriceHistory      = "get-price-history"
+	RequestTypeCreatePriceSchedule  = "create-price-schedule"
+	RequestTypeDeletePriceSchedule  = "delete-price-schedule"
+	RequestTypeListPriceSchedules   = "list-price-schedules"
+	RequestTypeCreateReview         = "create-review"
+	RequestTypeGetReview            = "get-review"
+	RequestTypeListReviews          = "list-reviews"
+	RequestTypeModerateReview       = "moderate-review"
+	RequestTypeDeleteReview         = "delete-review"
 )
 
 type ProductHandler struct {
@@ -29,11 +62,11 @@ type ProductHandler struct {
 	service *service.ProductService
 }
 
-func NewProductHandler() *ProductHandler {
+func NewProductHandler(service *service.ProductService) *ProductHandler {
 	broker := utils.GetEnvOrDefault("KAFKA_BROKERS", "localhost:9092")
 
 	return &ProductHandler{
-		service: service.NewProductService(),
+		service: service,
 		writer: kafka.NewWriter(kafka.WriterConfig{
 			Brokers: []string{broker},
 		}),
@@ -83,7 +116,10 @@ func (h *ProductHandler) ListenMessages() {
 			continue
 		}
 
-		resp := h.handleRequest(req)
+		resp, ok := h.handleRequest(req)
+		if !ok {
+			continue
+		}
 
 		respBytes, _ := json.Marshal(resp)
 		err = h.writer.WriteMessages(context.Background(),
@@ -100,26 +136,84 @@ func (h *ProductHandler) ListenMessages() {
 	}
 }
 
-// handleRequest processes different request types
-func (h *ProductHandler) handleRequest(req sharedModels.Request) sharedModels.Response {
+// handleRequest processes different request types. It returns false for
+// requests owned by other services, which share the same request topic.
+func (h *ProductHandler) handleRequest(req sharedModels.Request) (sharedModels.Response, bool) {
 	switch req.Type {
 	case RequestTypeHealth:
-		return h.handleHealth(req.CorrelationID)
+		// Every service receives health checks; answer only our own
+		if req.ReplyTo != ReplyTopic {
+			return sharedModels.Response{}, false
+		}
+		return h.handleHealth(req.CorrelationID), true
 	case RequestTypeCreateProduct:
-		return h.handleCreateProduct(req)
+		return h.handleCreateProduct(req), true
 	case RequestTypeGetProduct, RequestTypeGetProductByID:
-		return h.handleGetProduct(req)
+		return h.handleGetProduct(req), true
+	case RequestTypeGetProductsByIDs:
+		return h.handleGetProducts(req), true
 	case RequestTypeListProducts:
-		return h.handleListProducts(req.CorrelationID)
+		return h.handleListProducts(req), true
+	case RequestTypeSearchProducts:
+		return h.handleSearchProducts(req), true
 	case RequestTypeUpdateProduct:
-		return h.handleUpdateProduct(req)
+		return h.handleUpdateProduct(req), true
+	case RequestTypePatchProduct:
+		return h.handlePatchProduct(req), true
 	case RequestTypeDeleteProduct:
-		return h.handleDeleteProduct(req)
+		return h.handleDeleteProduct(req), true
+	case RequestTypeRestoreProduct:
+		return h.handleRestoreProduct(req), true
+	case RequestTypeAdjustStock:
+		return h.handleAdjustStock(req), true
+	case RequestTypeReserveStock:
+		return h.handleReserveStock(req), true
+	case RequestTypeCommitReservation:
+		return h.handleCommitReservation(req), true
+	case RequestTypeReleaseReservation:
+		return h.handleReleaseReservation(req), true
+	case RequestTypeCreateCategory:
+		return h.handleCreateCategory(req), true
+	case RequestTypeGetCategory:
+		return h.handleGetCategory(req), true
+	case RequestTypeListCategories:
+		return h.handleListCategories(req.CorrelationID), true
+	case RequestTypeUpdateCategory:
+		return h.handleUpdateCategory(req), true
+	case RequestTypeDeleteCategory:
+		return h.handleDeleteCategory(req), true
+	case RequestTypeSetProductCategories:
+		return h.handleSetProductCategories(req), true
+	case RequestTypeSetProductTags:
+		return h.handleSetProductTags(req), true
+	case RequestTypeCreateVariant:
+		return h.handleCreateVariant(req), true
+	case RequestTypeUpdateVariant:
+		return h.handleUpdateVariant(req), true
+	case RequestTypeDeleteVariant:
+		return h.handleDeleteVariant(req), true
+	case RequestTypeGetProductHistory:
+		return h.handleGetProductHistory(req), true
+	case RequestTypeGetPriceHistory:
+		return h.handleGetPriceHistory(req), true
+	case RequestTypeCreatePriceSchedule:
+		return h.handleCreatePriceSchedule(req), true
+	case RequestTypeDeletePriceSchedule:
+		return h.handleDeletePriceSchedule(req), true
+	case RequestTypeListPriceSchedules:
+		return h.handleListPriceSchedules(req), true
+	case RequestTypeCreateReview:
+		return h.handleCreateReview(req), true
+	case RequestTypeGetReview:
+		return h.handleGetReview(req), true
+	case RequestTypeListReviews:
+		return h.handleListReviews(req), true
+	case RequestTypeModerateReview:
+		return h.handleModerateReview(req), true
+	case RequestTypeDeleteReview:
+		return h.handleDeleteReview(req), true
 	default:
-		return sharedModels.Response{
-			CorrelationID: req.CorrelationID,
-			Data:          "Unknown request type: " + req.Type,
-		}
+		return sharedModels.Response{}, false
 	}
 }
 
@@ -141,7 +235,7 @@ func (h *ProductHandler) handleCreateProduct(req sharedModels.Request) sharedMod
 		return h.createErrorResponse(req.CorrelationID, "Invalid create product request format")
 	}
 
-	result, err := h.service.CreateProduct(createReq)
+	result, err := h.service.CreateProduct(createReq, req.Actor)
 	if err != nil {
 		log.Printf("Product creation failed: %v", err)
 		return h.createErrorResponse(req.CorrelationID, err.Error())
@@ -163,7 +257,7 @@ func (h *ProductHandler) handleGetProduct(req sharedModels.Request) sharedModels
 		return h.createErrorResponse(req.CorrelationID, "Invalid get product request format")
 	}
 
-	result, err := h.service.GetProductByID(getReq.ID)
+	result, err := h.service.GetProductByID(getReq.ID, getReq.Currency)
 	if err != nil {
 		log.Printf("Failed to get product: %v", err)
 		return h.createErrorResponse(req.CorrelationID, err.Error())
@@ -176,9 +270,47 @@ func (h *ProductHandler) handleGetProduct(req sharedModels.Request) sharedModels
 	return h.createSuccessResponse(req.CorrelationID, response)
 }
 
+// handleGetProducts processes a batch product lookup
+func (h *ProductHandler) handleGetProducts(req sharedModels.Request) sharedModels.Response {
+	var getReq sharedModels.GetProductsRequest
+	if err := h.unmarshalPayload(req.Payload, &getReq); err != nil {
+		log.Printf("Failed to parse get products request: %v", err)
+		return h.createErrorResponse(req.CorrelationID, "Invalid get products request format")
+	}
+
+	result, missing, err := h.service.GetProductsByIDs(getReq.IDs, getReq.Currency)
+	if err != nil {
+		log.Printf("Failed to get products: %v", err)
+		return h.createErrorResponse(req.CorrelationID, err.Error())
+	}
+
+	productDataVals := make([]sharedModels.ProductData, len(result))
+	for i, p := range result {
+		productDataVals[i] = *p
+	}
+
+	response := sharedModels.GetProductsResponse{
+		Status:  "success",
+		Data:    productDataVals,
+		Missing: missing,
+	}
+	return h.createSuccessResponse(req.CorrelationID, response)
+}
+
 // handleListProducts processes list all products request
-func (h *ProductHandler) handleListProducts(correlationID string) sharedModels.Response {
-	result, err := h.service.GetAllProducts()
+func (h *ProductHandler) handleListProducts(req sharedModels.Request) sharedModels.Response {
+	correlationID := req.CorrelationID
+
+	// Older gateways send an empty payload
+	var listReq sharedModels.ListProductsRequest
+	if req.Payload != "" && req.Payload != `""` {
+		if err := h.unmarshalPayload(req.Payload, &listReq); err != nil {
+			log.Printf("Failed to parse list products request: %v", err)
+			return h.createErrorResponse(correlationID, "Invalid list products request format")
+		}
+	}
+
+	result, nextCursor, err := h.service.ListProducts(listReq)
 	if err != nil {
 		log.Printf("Failed to list products: %v", err)
 		return h.createErrorResponse(correlationID, err.Error())
@@ -193,12 +325,34 @@ func (h *ProductHandler) handleListProducts(correlationID string) sharedModels.R
 	}
 
 	response := sharedModels.ListProductResponse{
-		Status: "success",
-		Data:   productDataVals,
+		Status:     "success",
+		Data:       productDataVals,
+		NextCursor: nextCursor,
 	}
 	return h.createSuccessResponse(correlationID, response)
 }
 
+// handleSearchProducts processes a full-text product search
+func (h *ProductHandler) handleSearchProducts(req sharedModels.Request) sharedModels.Response {
+	var searchReq sharedModels.SearchProductsRequest
+	if err := h.unmarshalPayload(req.Payload, &searchReq); err != nil {
+		log.Printf("Failed to parse search products request: %v", err)
+		return h.createErrorResponse(req.CorrelationID, "Invalid search products request format")
+	}
+
+	result, err := h.service.SearchProducts(searchReq)
+	if err != nil {
+		log.Printf("Product search failed: %v", err)
+		return h.createErrorResponse(req.CorrelationID, err.Error())
+	}
+
+	response := sharedModels.SearchProductsResponse{
+		Status: "success",
+		Data:   result,
+	}
+	return h.createSuccessResponse(req.CorrelationID, response)
+}
+
 // handleUpdateProduct processes product update
 func (h *ProductHandler) handleUpdateProduct(req sharedModels.Request) sharedModels.Response {
 	var updateReq sharedModels.UpdateProductRequest
@@ -207,10 +361,40 @@ func (h *ProductHandler) handleUpdateProduct(req sharedModels.Request) sharedMod
 		return h.createErrorResponse(req.CorrelationID, "Invalid update product request format")
 	}
 
-	result, err := h.service.UpdateProduct(updateReq)
+	result, err := h.service.UpdateProduct(updateReq, req.Actor)
 	if err != nil {
 		log.Printf("Product update failed: %v", err)
-		return h.createErrorResponse(req.CorrelationID, err.Error())
+		response := h.createErrorResponse(req.CorrelationID, err.Error())
+		if errors.Is(err, repository.ErrVersionConflict) {
+			response.Code = sharedModels.ErrorCodeVersionConflict
+		}
+		return response
+	}
+
+	response := sharedModels.ProductResponse{
+		Status:  "success",
+		Message: "Product updated successfully",
+		Data:    *result,
+	}
+	return h.createSuccessResponse(req.CorrelationID, response)
+}
+
+// handlePatchProduct processes a partial product update
+func (h *ProductHandler) handlePatchProduct(req sharedModels.Request) sharedModels.Response {
+	var patchReq sharedModels.PatchProductRequest
+	if err := h.unmarshalPayload(req.Payload, &patchReq); err != nil {
+		log.Printf("Failed to parse patch product request: %v", err)
+		return h.createErrorResponse(req.CorrelationID, "Invalid patch product request format")
+	}
+
+	result, err := h.service.PatchProduct(patchReq, req.Actor)
+	if err != nil {
+		log.Printf("Product patch failed: %v", err)
+		response := h.createErrorResponse(req.CorrelationID, err.Error())
+		if errors.Is(err, repository.ErrVersionConflict) {
+			response.Code = sharedModels.ErrorCodeVersionConflict
+		}
+		return response
 	}
 
 	response := sharedModels.ProductResponse{
@@ -229,7 +413,7 @@ func (h *ProductHandler) handleDeleteProduct(req sharedModels.Request) sharedMod
 		return h.createErrorResponse(req.CorrelationID, "Invalid delete product request format")
 	}
 
-	err := h.service.DeleteProduct(deleteReq.ID)
+	err := h.service.DeleteProduct(deleteReq.ID, req.Actor)
 	if err != nil {
 		log.Printf("Product deletion failed: %v", err)
 		return h.createErrorResponse(req.CorrelationID, err.Error())
@@ -242,3 +426,97 @@ func (h *ProductHandler) handleDeleteProduct(req sharedModels.Request) sharedMod
 	}
 	return h.createSuccessResponse(req.CorrelationID, response)
 }
+
+// handleRestoreProduct processes restoring a soft deleted product
+func (h *ProductHandler) handleRestoreProduct(req sharedModels.Request) sharedModels.Response {
+	var restoreReq sharedModels.RestoreProductRequest
+	if err := h.unmarshalPayload(req.Payload, &restoreReq); err != nil {
+		log.Printf("Failed to parse restore product request: %v", err)
+		return h.createErrorResponse(req.CorrelationID, "Invalid restore product request format")
+	}
+
+	result, err := h.service.RestoreProduct(restoreReq.ID, req.Actor)
+	if err != nil {
+		log.Printf("Product restore failed: %v", err)
+		return h.createErrorResponse(req.CorrelationID, err.Error())
+	}
+
+	response := sharedModels.GetProductResponse{
+		Status: "success",
+		Data:   *result,
+	}
+	return h.createSuccessResponse(req.CorrelationID, response)
+}
+
+// handleAdjustStock processes admin stock adjustments
+func (h *ProductHandler) handleAdjustStock(req sharedModels.Request) sharedModels.Response {
+	var adjustReq sharedModels.AdjustStockRequest
+	if err := h.unmarshalPayload(req.Payload, &adjustReq); err != nil {
+		log.Printf("Failed to parse adjust stock request: %v", err)
+		return h.createErrorResponse(req.CorrelationID, "Invalid adjust stock request format")
+	}
+
+	result, err := h.service.AdjustStock(adjustReq, req.Actor)
+	if err != nil {
+		log.Printf("Stock adjustment failed: %v", err)
+		return h.createErrorResponse(req.CorrelationID, err.Error())
+	}
+
+	response := sharedModels.ProductResponse{
+		Status:  "success",
+		Message: "Stock adjusted successfully",
+		Data:    *result,
+	}
+	return h.createSuccessResponse(req.CorrelationID, response)
+}
+
+// handleReserveStock processes stock reservation for an order
+func (h *ProductHandler) handleReserveStock(req sharedModels.Request) sharedModels.Response {
+	var reserveReq sharedModels.ReserveStockRequest
+	if err := h.unmarshalPayload(req.Payload, &reserveReq); err != nil {
+		log.Printf("Failed to parse reserve stock request: %v", err)
+		return h.createErrorResponse(req.CorrelationID, "Invalid reserve stock request format")
+	}
+
+	result, err := h.service.ReserveStock(reserveReq)
+	if err != nil {
+		log.Printf("Stock reservation failed: %v", err)
+		return h.createErrorResponse(req.CorrelationID, err.Error())
+	}
+
+	return h.createSuccessResponse(req.CorrelationID, result)
+}
+
+// handleCommitReservation processes reservation commits
+func (h *ProductHandler) handleCommitReservation(req sharedModels.Request) sharedModels.Response {
+	var reservationReq sharedModels.ReservationRequest
+	if err := h.unmarshalPayload(req.Payload, &reservationReq); err != nil {
+		log.Printf("Failed to parse commit reservation request: %v", err)
+		return h.createErrorResponse(req.CorrelationID, "Invalid commit reservation request format")
+	}
+
+	result, err := h.service.CommitReservation(reservationReq)
+	if err != nil {
+		log.Printf("Reservation commit failed: %v", err)
+		return h.createErrorResponse(req.CorrelationID, err.Error())
+	}
+
+	return h.createSuccessResponse(req.CorrelationID, result)
+}
+
+// handleReleaseReservation processes reservation releases
+func (h *ProductHandler) handleReleaseReservation(req sharedModels.Request) sharedModels.Response {
+	var reservationReq sharedModels.ReservationRequest
+	if err := h.unmarshalPayload(req.Payload, &reservationReq); err != nil {
+		log.Printf("Failed to parse release reservation request: %v", err)
+		return h.createErrorResponse(req.CorrelationID, "Invalid release reservation request format")
+	}
+
+	result, err := h.service.ReleaseReservation(reservationReq)
+	if err != nil {
+		log.Printf("Reservation release failed: %v", err)
+		return h.createErrorResponse(req.CorrelationID, err.Error())
+	}
+
+	return h.createSuccessResponse(req.CorrelationID, result)
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"log"
+
+	sharedModels "github.com/lucas/gokafka/shared/models"
+)
+
+// handleGetProductHistory processes a request for a page of product history
+func (h *ProductHandler) handleGetProductHistory(req sharedModels.Request) sharedModels.Response {
+	var historyReq sharedModels.ProductHistoryRequest
+	if err := h.unmarshalPayload(req.Payload, &historyReq); err != nil {
+		log.Printf("Failed to parse product history request: %v", err)
+		return h.createErrorResponse(req.CorrelationID, "Invalid product history request format")
+	}
+
+	entries, nextCursor, err := h.service.GetProductHistory(historyReq)
+	if err != nil {
+		log.Printf("Failed to get product history: %v", err)
+		return h.createErrorResponse(req.CorrelationID, err.Error())
+	}
+
+	response := sharedModels.ProductHistoryResponse{
+		Status:     "success",
+		Data:       entries,
+		NextCursor: nextCursor,
+	}
+	return h.createSuccessResponse(req.CorrelationID, response)
+}
+
+// handleGetPriceHistory processes a request for a product's price history
+func (h *ProductHandler) handleGetPriceHistory(req sharedModels.Request) sharedModels.Response {
+	var historyReq sharedModels.PriceHistoryRequest
+	if err := h.unmarshalPayload(req.Payload, &historyReq); err != nil {
+		log.Printf("Failed to parse price history request: %v", err)
+		return h.createErrorResponse(req.CorrelationID, "Invalid price history request format")
+	}
+
+	result, err := h.service.GetPriceHistory(historyReq)
+	if err != nil {
+		log.Printf("Failed to get price history: %v", err)
+		return h.createErrorResponse(req.CorrelationID, err.Error())
+	}
+
+	response := sharedModels.PriceHistoryResponse{
+		Status: "success",
+		Data:   *result,
+	}
+	return h.createSuccessResponse(req.CorrelationID, response)
+}
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lucas/gokafka/product-service/internal/media"
+	"github.com/lucas/gokafka/product-service/internal/repository"
+	"github.com/lucas/gokafka/product-service/internal/service"
+	"github.com/lucas/gokafka/product-service/internal/storage"
+	"github.com/lucas/gokafka/shared/auth"
+)
+
+// MediaHandler serves image uploads over HTTP. Images are too large for
+// Kafka messages, so the gateway proxies these routes straight to the
+// service.
+type MediaHandler struct {
+	service *service.ProductService
+	storage storage.Storage
+}
+
+func NewMediaHandler(service *service.ProductService, storage storage.Storage) *MediaHandler {
+	return &MediaHandler{
+		service: service,
+		storage: storage,
+	}
+}
+
+// RegisterRoutes adds the media routes to the service's HTTP server
+func (h *MediaHandler) RegisterRoutes(router *gin.Engine) {
+	router.GET("/media/*key", h.ServeMedia)
+
+	admin := router.Group("/products/:id/images")
+	admin.Use(requireAdmin)
+	{
+		admin.POST("", h.UploadImage)
+		admin.DELETE("/:imageId", h.DeleteImage)
+	}
+}
+
+// requireAdmin checks the token the gateway forwards and stores the caller's
+// user ID for history. The gateway already enforces the admin role; this
+// keeps the service safe when reached directly.
+func requireAdmin(c *gin.Context) {
+	token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	claims, err := auth.ValidateToken(token)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+		return
+	}
+	if claims.Role != "admin" {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+		return
+	}
+	c.Set("user_id", claims.UserID)
+	c.Next()
+}
+
+// UploadImage handles POST /products/:id/images with the file in the
+// multipart field "image"
+func (h *MediaHandler) UploadImage(c *gin.Context) {
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || productID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	// Leave room for the multipart framing around the file
+	limit := h.service.MaxImageBytes()
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit+1<<20)
+
+	file, _, err := c.Request.FormFile("image")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": service.ErrImageTooLarge.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Multipart field \"image\" is required"})
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, limit+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read image"})
+		return
+	}
+
+	product, err := h.service.AddProductImage(c.Request.Context(), productID, data, c.GetString("user_id"))
+	if err != nil {
+		c.JSON(imageErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Image uploaded successfully",
+		"data":    product,
+	})
+}
+
+// DeleteImage handles DELETE /products/:id/images/:imageId
+func (h *MediaHandler) DeleteImage(c *gin.Context) {
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || productID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	if err := h.service.DeleteProductImage(productID, c.Param("imageId"), c.GetString("user_id")); err != nil {
+		c.JSON(imageErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Image deleted successfully"})
+}
+
+// ServeMedia handles GET /media/*key for the local storage backend
+func (h *MediaHandler) ServeMedia(c *gin.Context) {
+	key := strings.TrimPrefix(c.Param("key"), "/")
+	object, err := h.storage.Open(c.Request.Context(), key)
+	if errors.Is(err, storage.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to open media %s: %v", key, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read media"})
+		return
+	}
+	defer object.Close()
+
+	contentType := mime.TypeByExtension(path.Ext(key))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	// Keys are never reused, so the files can be cached indefinitely
+	c.Header("Cache-Control", "public, max-age=31536000, immutable")
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.DataFromReader(http.StatusOK, -1, contentType, object, nil)
+}
+
+// imageErrorStatus maps image upload errors to HTTP statuses
+func imageErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrImageTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, media.ErrUnsupportedType):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, repository.ErrProductNotFound),
+		errors.Is(err, repository.ErrImageNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, repository.ErrTooManyImages):
+		return http.StatusConflict
+	case strings.HasPrefix(err.Error(), "failed to"):
+		return http.StatusInternalServerError
+	default:
+		return http.StatusBadRequest
+	}
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"log"
+
+	sharedModels "github.com/lucas/gokafka/shared/models"
+)
+
+// handleCreateReview processes review creation
+func (h *ProductHandler) handleCreateReview(req sharedModels.Request) sharedModels.Response {
+	var createReq sharedModels.CreateReviewRequest
+	if err := h.unmarshalPayload(req.Payload, &createReq); err != nil {
+		log.Printf("Failed to parse create review request: %v", err)
+		return h.createErrorResponse(req.CorrelationID, "Invalid create review request format")
+	}
+
+	result, err := h.service.CreateReview(createReq)
+	if err != nil {
+		log.Printf("Review creation failed: %v", err)
+		return h.createErrorResponse(req.CorrelationID, err.Error())
+	}
+
+	response := sharedModels.ReviewResponse{
+		Status: "success",
+		Data:   *result,
+	}
+	return h.createSuccessResponse(req.CorrelationID, response)
+}
+
+// handleGetReview processes a request for a single review
+func (h *ProductHandler) handleGetReview(req sharedModels.Request) sharedModels.Response {
+	var getReq sharedModels.GetReviewRequest
+	if err := h.unmarshalPayload(req.Payload, &getReq); err != nil {
+		log.Printf("Failed to parse get review request: %v", err)
+		return h.createErrorResponse(req.CorrelationID, "Invalid get review request format")
+	}
+
+	result, err := h.service.GetReview(getReq)
+	if err != nil {
+		log.Printf("Failed to get review: %v", err)
+		return h.createErrorResponse(req.CorrelationID, err.Error())
+	}
+
+	response := sharedModels.ReviewResponse{
+		Status: "success",
+		Data:   *result,
+	}
+	return h.createSuccessResponse(req.CorrelationID, response)
+}
+
+// handleListReviews processes a request for a page of a product's reviews
+func (h *ProductHandler) handleListReviews(req sharedModels.Request) sharedModels.Response {
+	var listReq sharedModels.ListReviewsRequest
+	if err := h.unmarshalPayload(req.Payload, &listReq); err != nil {
+		log.Printf("Failed to parse list reviews request: %v", err)
+		return h.createErrorResponse(req.CorrelationID, "Invalid list reviews request format")
+	}
+
+	reviews, nextCursor, err := h.service.ListReviews(listReq)
+	if err != nil {
+		log.Printf("Failed to list reviews: %v", err)
+		return h.createErrorResponse(req.CorrelationID, err.Error())
+	}
+
+	response := sharedModels.ListReviewsResponse{
+		Status:     "success",
+		Data:       reviews,
+		NextCursor: nextCursor,
+	}
+	return h.createSuccessResponse(req.CorrelationID, response)
+}
+
+// handleModerateReview processes hiding or publishing a review
+func (h *ProductHandler) handleModerateReview(req sharedModels.Request) sharedModels.Response {
+	var moderateReq sharedModels.ModerateReviewRequest
+	if err := h.unmarshalPayload(req.Payload, &moderateReq); err != nil {
+		log.Printf("Failed to parse moderate review request: %v", err)
+		return h.createErrorResponse(req.CorrelationID, "Invalid moderate review request format")
+	}
+
+	result, err := h.service.ModerateReview(moderateReq)
+	if err != nil {
+		log.Printf("Review moderation failed: %v", err)
+		return h.createErrorResponse(req.CorrelationID, err.Error())
+	}
+
+	response := sharedModels.ReviewResponse{
+		Status: "success",
+		Data:   *result,
+	}
+	return h.createSuccessResponse(req.CorrelationID, response)
+}
+
+// handleDeleteReview processes review deletion
+func (h *ProductHandler) handleDeleteReview(req sharedModels.Request) sharedModels.Response {
+	var deleteReq sharedModels.DeleteReviewRequest
+	if err := h.unmarshalPayload(req.Payload, &deleteReq); err != nil {
+		log.Printf("Failed to parse delete review request: %v", err)
+		return h.createErrorResponse(req.CorrelationID, "Invalid delete review request format")
+	}
+
+	if err := h.service.DeleteReview(deleteReq); err != nil {
+		log.Printf("Review deletion failed: %v", err)
+		return h.createErrorResponse(req.CorrelationID, err.Error())
+	}
+
+	response := map[string]interface{}{
+		"status":  "success",
+		"message": "Review deleted successfully",
+		"id":      deleteReq.ID,
+	}
+	return h.createSuccessResponse(req.CorrelationID, response)
+}
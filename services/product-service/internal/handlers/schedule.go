@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"log"
+
+	sharedModels "github.com/lucas/gokafka/shared/models"
+)
+
+// handleCreatePriceSchedule processes price schedule creation
+func (h *ProductHandler) handleCreatePriceSchedule(req sharedModels.Request) sharedModels.Response {
+	var createReq sharedModels.CreatePriceScheduleRequest
+	if err := h.unmarshalPayload(req.Payload, &createReq); err != nil {
+		log.Printf("Failed to parse create price schedule request: %v", err)
+		return h.createErrorResponse(req.CorrelationID, "Invalid create price schedule request format")
+	}
+
+	result, err := h.service.CreatePriceSchedule(createReq, req.Actor)
+	if err != nil {
+		log.Printf("Price schedule creation failed: %v", err)
+		return h.createErrorResponse(req.CorrelationID, err.Error())
+	}
+
+	response := sharedModels.PriceScheduleResponse{
+		Status: "success",
+		Data:   *result,
+	}
+	return h.createSuccessResponse(req.CorrelationID, response)
+}
+
+// handleDeletePriceSchedule processes price schedule deletion
+func (h *ProductHandler) handleDeletePriceSchedule(req sharedModels.Request) sharedModels.Response {
+	var deleteReq sharedModels.DeletePriceScheduleRequest
+	if err := h.unmarshalPayload(req.Payload, &deleteReq); err != nil {
+		log.Printf("Failed to parse delete price schedule request: %v", err)
+		return h.createErrorResponse(req.CorrelationID, "Invalid delete price schedule request format")
+	}
+
+	if err := h.service.DeletePriceSchedule(deleteReq, req.Actor); err != nil {
+		log.Printf("Price schedule deletion failed: %v", err)
+		return h.createErrorResponse(req.CorrelationID, err.Error())
+	}
+
+	response := map[string]interface{}{
+		"status":     "success",
+		"message":    "Price schedule deleted successfully",
+		"id":         deleteReq.ID,
+		"product_id": deleteReq.ProductID,
+	}
+	return h.createSuccessResponse(req.CorrelationID, response)
+}
+
+// handleListPriceSchedules processes a request for a product's price
+// schedules
+func (h *ProductHandler) handleListPriceSchedules(req sharedModels.Request) sharedModels.Response {
+	var listReq sharedModels.ListPriceSchedulesRequest
+	if err := h.unmarshalPayload(req.Payload, &listReq); err != nil {
+		log.Printf("Failed to parse list price schedules request: %v", err)
+		return h.createErrorResponse(req.CorrelationID, "Invalid list price schedules request format")
+	}
+
+	schedules, err := h.service.ListPriceSchedules(listReq)
+	if err != nil {
+		log.Printf("Failed to list price schedules: %v", err)
+		return h.createErrorResponse(req.CorrelationID, err.Error())
+	}
+
+	response := sharedModels.ListPriceSchedulesResponse{
+		Status: "success",
+		Data:   schedules,
+	}
+	return h.createSuccessResponse(req.CorrelationID, response)
+}
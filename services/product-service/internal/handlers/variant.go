@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"log"
+
+	sharedModels "github.com/lucas/gokafka/shared/models"
+)
+
+// handleCreateVariant processes variant creation
+func (h *ProductHandler) handleCreateVariant(req sharedModels.Request) sharedModels.Response {
+	var createReq sharedModels.CreateVariantRequest
+	if err := h.unmarshalPayload(req.Payload, &createReq); err != nil {
+		log.Printf("Failed to parse create variant request: %v", err)
+		return h.createErrorResponse(req.CorrelationID, "Invalid create variant request format")
+	}
+
+	result, err := h.service.CreateVariant(createReq, req.Actor)
+	if err != nil {
+		log.Printf("Variant creation failed: %v", err)
+		return h.createErrorResponse(req.CorrelationID, err.Error())
+	}
+
+	response := sharedModels.VariantResponse{
+		Status: "success",
+		Data:   *result,
+	}
+	return h.createSuccessResponse(req.CorrelationID, response)
+}
+
+// handleUpdateVariant processes variant updates
+func (h *ProductHandler) handleUpdateVariant(req sharedModels.Request) sharedModels.Response {
+	var updateReq sharedModels.UpdateVariantRequest
+	if err := h.unmarshalPayload(req.Payload, &updateReq); err != nil {
+		log.Printf("Failed to parse update variant request: %v", err)
+		return h.createErrorResponse(req.CorrelationID, "Invalid update variant request format")
+	}
+
+	result, err := h.service.UpdateVariant(updateReq, req.Actor)
+	if err != nil {
+		log.Printf("Variant update failed: %v", err)
+		return h.createErrorResponse(req.CorrelationID, err.Error())
+	}
+
+	response := sharedModels.VariantResponse{
+		Status: "success",
+		Data:   *result,
+	}
+	return h.createSuccessResponse(req.CorrelationID, response)
+}
+
+// handleDeleteVariant processes variant deletion
+func (h *ProductHandler) handleDeleteVariant(req sharedModels.Request) sharedModels.Response {
+	var deleteReq sharedModels.DeleteVariantRequest
+	if err := h.unmarshalPayload(req.Payload, &deleteReq); err != nil {
+		log.Printf("Failed to parse delete variant request: %v", err)
+		return h.createErrorResponse(req.CorrelationID, "Invalid delete variant request format")
+	}
+
+	if err := h.service.DeleteVariant(deleteReq, req.Actor); err != nil {
+		log.Printf("Variant deletion failed: %v", err)
+		return h.createErrorResponse(req.CorrelationID, err.Error())
+	}
+
+	response := map[string]interface{}{
+		"status":     "success",
+		"message":    "Variant deleted successfully",
+		"id":         deleteReq.ID,
+		"product_id": deleteReq.ProductID,
+	}
+	return h.createSuccessResponse(req.CorrelationID, response)
+}
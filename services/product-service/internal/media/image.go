@@ -0,0 +1,147 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/webp"
+)
+
+// Image limits
+const (
+	// MaxImagePixels guards against decompression bombs: small files that
+	// decode to huge bitmaps
+	MaxImagePixels = 25_000_000
+	ThumbnailSize  = 320
+	thumbnailJPEG  = 85
+)
+
+// ErrUnsupportedType is returned for uploads that are not JPEG, PNG, GIF or
+// WebP images
+var ErrUnsupportedType = errors.New("image must be JPEG, PNG, GIF or WebP")
+
+// extensions maps the accepted content types to file extensions
+var extensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// Image is a validated upload and its generated thumbnail
+type Image struct {
+	ContentType string
+	Extension   string
+	Width       int
+	Height      int
+
+	Thumbnail            []byte
+	ThumbnailContentType string
+	ThumbnailExtension   string
+}
+
+// Process checks that data is a supported image, sniffing the type from its
+// content rather than trusting the client, and renders a thumbnail that fits
+// in ThumbnailSize x ThumbnailSize
+func Process(data []byte) (*Image, error) {
+	contentType := http.DetectContentType(data)
+	extension, ok := extensions[contentType]
+	if !ok {
+		return nil, ErrUnsupportedType
+	}
+
+	config, err := decodeConfig(contentType, data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid image: %w", err)
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > MaxImagePixels {
+		return nil, fmt.Errorf("image dimensions %dx%d are not allowed", config.Width, config.Height)
+	}
+
+	src, err := decode(contentType, data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid image: %w", err)
+	}
+
+	result := &Image{
+		ContentType: contentType,
+		Extension:   extension,
+		Width:       config.Width,
+		Height:      config.Height,
+	}
+	if err := result.renderThumbnail(src); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// renderThumbnail scales src down to fit the thumbnail box. Opaque images
+// become JPEG; images with transparency stay PNG.
+func (img *Image) renderThumbnail(src image.Image) error {
+	width, height := fit(img.Width, img.Height, ThumbnailSize)
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, src.Bounds(), draw.Src, nil)
+
+	var buf bytes.Buffer
+	if dst.Opaque() {
+		if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: thumbnailJPEG}); err != nil {
+			return fmt.Errorf("failed to encode thumbnail: %w", err)
+		}
+		img.ThumbnailContentType, img.ThumbnailExtension = "image/jpeg", ".jpg"
+	} else {
+		if err := png.Encode(&buf, dst); err != nil {
+			return fmt.Errorf("failed to encode thumbnail: %w", err)
+		}
+		img.ThumbnailContentType, img.ThumbnailExtension = "image/png", ".png"
+	}
+	img.Thumbnail = buf.Bytes()
+	return nil
+}
+
+// fit scales width x height down to fit in a size x size box, keeping the
+// aspect ratio. Smaller images keep their size.
+func fit(width, height, size int) (int, int) {
+	if width <= size && height <= size {
+		return width, height
+	}
+	if width >= height {
+		return size, max(1, height*size/width)
+	}
+	return max(1, width*size/height), size
+}
+
+func decodeConfig(contentType string, data []byte) (image.Config, error) {
+	reader := bytes.NewReader(data)
+	switch contentType {
+	case "image/jpeg":
+		return jpeg.DecodeConfig(reader)
+	case "image/png":
+		return png.DecodeConfig(reader)
+	case "image/gif":
+		return gif.DecodeConfig(reader)
+	default:
+		return webp.DecodeConfig(reader)
+	}
+}
+
+// decode reads the first frame of animated GIFs
+func decode(contentType string, data []byte) (image.Image, error) {
+	reader := bytes.NewReader(data)
+	switch contentType {
+	case "image/jpeg":
+		return jpeg.Decode(reader)
+	case "image/png":
+		return png.Decode(reader)
+	case "image/gif":
+		return gif.Decode(reader)
+	default:
+		return webp.Decode(reader)
+	}
+}
@@ -1,12 +1,129 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	sharedModels "github.com/lucas/gokafka/shared/models"
+)
 
 type Product struct {
-	ID          int       `json:"id" db:"id"`
-	Name        string    `json:"name" db:"name"`
-	Description string    `json:"description" db:"description"`
-	Price       float64   `json:"price" db:"price"`
-	CreatedAt   time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+	ID          int                `json:"id" db:"id"`
+	Name        string             `json:"name" db:"name"`
+	Description string             `json:"description" db:"description"`
+	Price       sharedModels.Money `json:"price" db:"price"`
+	Stock       int                `json:"stock" db:"stock"`
+	Version     int                `json:"version" db:"version"` // bumped by admin edits, not by reservations
+	CategoryIDs []int              `json:"category_ids"`
+	Tags        []string           `json:"tags"`
+	Images      []ProductImage     `json:"images"`
+	Variants    []ProductVariant   `json:"variants"`
+	Schedules   []PriceSchedule    `json:"price_schedules"` // upcoming and active price schedules
+	CreatedAt   time.Time          `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at" db:"updated_at"`
+	DeletedAt   *time.Time         `json:"deleted_at" db:"deleted_at"`
+	// Ratings are kept in step with the published reviews
+	RatingAverage float64 `json:"rating_average" db:"rating_average"`
+	ReviewCount   int     `json:"review_count" db:"review_count"`
+}
+
+// ProductImage is an uploaded product photo and its thumbnail. The keys
+// locate the files in storage; the URLs are where clients fetch them.
+type ProductImage struct {
+	ID           string    `json:"id" db:"id"`
+	ProductID    int       `json:"product_id" db:"product_id"`
+	StorageKey   string    `json:"storage_key" db:"storage_key"`
+	ThumbnailKey string    `json:"thumbnail_key" db:"thumbnail_key"`
+	URL          string    `json:"url" db:"url"`
+	ThumbnailURL string    `json:"thumbnail_url" db:"thumbnail_url"`
+	ContentType  string    `json:"content_type" db:"content_type"`
+	Width        int       `json:"width" db:"width"`
+	Height       int       `json:"height" db:"height"`
+	SizeBytes    int       `json:"size_bytes" db:"size_bytes"`
+	Position     int       `json:"position" db:"position"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
+
+// ProductVariant is a sellable version of a product, such as a size and
+// colour combination. Price overrides the product price when set.
+type ProductVariant struct {
+	ID         int                 `json:"id" db:"id"`
+	ProductID  int                 `json:"product_id" db:"product_id"`
+	SKU        string              `json:"sku" db:"sku"`
+	Attributes map[string]string   `json:"attributes" db:"attributes"`
+	Price      *sharedModels.Money `json:"price" db:"price"`
+	Stock      int                 `json:"stock" db:"stock"`
+	CreatedAt  time.Time           `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time           `json:"updated_at" db:"updated_at"`
+}
+
+// PriceSchedule replaces a product's price from StartsAt until EndsAt. One
+// without an end becomes the product's regular price once it starts.
+// StartedAt and EndedAt record when the scheduler announced the change.
+type PriceSchedule struct {
+	ID        int                `json:"id" db:"id"`
+	ProductID int                `json:"product_id" db:"product_id"`
+	Name      string             `json:"name" db:"name"`
+	Price     sharedModels.Money `json:"price" db:"price"`
+	StartsAt  time.Time          `json:"starts_at" db:"starts_at"`
+	EndsAt    *time.Time         `json:"ends_at" db:"ends_at"`
+	StartedAt *time.Time         `json:"started_at" db:"started_at"`
+	EndedAt   *time.Time         `json:"ended_at" db:"ended_at"`
+	CreatedAt time.Time          `json:"created_at" db:"created_at"`
+}
+
+// ProductReview is a user's 1 to 5 star rating of a product with a text.
+// The pair of product and user is unique.
+type ProductReview struct {
+	ID        int       `json:"id" db:"id"`
+	ProductID int       `json:"product_id" db:"product_id"`
+	UserID    string    `json:"user_id" db:"user_id"`
+	Rating    int       `json:"rating" db:"rating"`
+	Text      string    `json:"text" db:"text"`
+	Status    string    `json:"status" db:"status"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// ProductHistoryEntry records one change to a product: who made it and the
+// fields it changed. Price is the product's price after the change.
+type ProductHistoryEntry struct {
+	ID        int64                               `json:"id" db:"id"`
+	ProductID int                                 `json:"product_id" db:"product_id"`
+	Version   int                                 `json:"version" db:"version"`
+	Action    string                              `json:"action" db:"action"`
+	Actor     string                              `json:"actor" db:"actor"`
+	Changes   map[string]sharedModels.FieldChange `json:"changes" db:"changes"`
+	Price     sharedModels.Money                  `json:"price" db:"price"`
+	CreatedAt time.Time                           `json:"created_at" db:"created_at"`
+}
+
+// Category is a node in the category tree; ParentID is nil for top level
+// categories
+type Category struct {
+	ID        int       `json:"id" db:"id"`
+	Name      string    `json:"name" db:"name"`
+	Slug      string    `json:"slug" db:"slug"`
+	ParentID  *int      `json:"parent_id" db:"parent_id"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Reservation holds stock for an order until it is committed, released or
+// expires
+type Reservation struct {
+	ID        string            `json:"id" db:"id"`
+	OrderID   string            `json:"order_id" db:"order_id"`
+	Status    string            `json:"status" db:"status"`
+	Items     []ReservationItem `json:"items"`
+	ExpiresAt time.Time         `json:"expires_at" db:"expires_at"`
+	CreatedAt time.Time         `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at" db:"updated_at"`
+}
+
+// ReservationItem holds stock of a variant when VariantID is set, otherwise
+// of the product
+type ReservationItem struct {
+	ProductID int `json:"product_id" db:"product_id"`
+	VariantID int `json:"variant_id" db:"variant_id"`
+	Quantity  int `json:"quantity" db:"quantity"`
 }
@@ -0,0 +1,219 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lucas/gokafka/product-service/internal/models"
+	sharedModels "github.com/lucas/gokafka/shared/models"
+)
+
+// ExportBatchSize is how many products ExportProducts reads per query
+const ExportBatchSize = 500
+
+// Import actions
+const (
+	ImportActionCreated = "created"
+	ImportActionUpdated = "updated"
+)
+
+// ImportRow is one validated product from an import file. Products with an
+// ID update that product; products without one are created. A nil Stock
+// keeps the current stock of an updated product.
+type ImportRow struct {
+	Line    int
+	Product *models.Product
+	Stock   *int
+}
+
+// ImportResult is the outcome of one import row: an action, or an error
+type ImportResult struct {
+	Line   int
+	ID     int
+	Action string
+	Err    error
+}
+
+// ImportProducts upserts a batch of products in one transaction on behalf of
+// actor. Each row runs in its own savepoint, so a failing row is reported
+// and skipped without losing the rest of the batch.
+func (r *ProductRepository) ImportProducts(rows []ImportRow, actor string) ([]ImportResult, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	results := make([]ImportResult, 0, len(rows))
+	for _, row := range rows {
+		if _, err := tx.Exec(`SAVEPOINT import_row`); err != nil {
+			return nil, fmt.Errorf("failed to create savepoint: %w", err)
+		}
+
+		result := ImportResult{Line: row.Line}
+		result.Action, result.Err = r.importProduct(tx, row, actor)
+		result.ID = row.Product.ID
+
+		release := `RELEASE SAVEPOINT import_row`
+		if result.Err != nil {
+			release = `ROLLBACK TO SAVEPOINT import_row`
+		}
+		if _, err := tx.Exec(release); err != nil {
+			return nil, fmt.Errorf("failed to release savepoint: %w", err)
+		}
+		results = append(results, result)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit import: %w", err)
+	}
+	return results, nil
+}
+
+// importProduct creates or updates one product with its categories and tags
+func (r *ProductRepository) importProduct(tx *sql.Tx, row ImportRow, actor string) (string, error) {
+	product := row.Product
+	action := ImportActionCreated
+	now := time.Now()
+	var stockBefore *int
+
+	if product.ID == 0 {
+		if row.Stock != nil {
+			product.Stock = *row.Stock
+		}
+		err := tx.QueryRow(`
+		INSERT INTO products (name, description, price, currency, stock, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $6)
+		RETURNING id, version, created_at, updated_at`,
+			product.Name, product.Description, product.Price.Decimal(), product.Price.Currency,
+			product.Stock, now).Scan(&product.ID, &product.Version, &product.CreatedAt, &product.UpdatedAt)
+		if err != nil {
+			return "", fmt.Errorf("failed to create product: %w", err)
+		}
+	} else {
+		action = ImportActionUpdated
+
+		var before int
+		err := tx.QueryRow(`SELECT stock FROM products WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, product.ID).Scan(&before)
+		if err == sql.ErrNoRows {
+			return "", fmt.Errorf("product with id %d not found", product.ID)
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to get product stock: %w", err)
+		}
+
+		var mismatched bool
+		err = tx.QueryRow(`
+		SELECT EXISTS (SELECT 1 FROM product_variants WHERE product_id = $1 AND currency <> $2)`,
+			product.ID, product.Price.Currency).Scan(&mismatched)
+		if err != nil {
+			return "", fmt.Errorf("failed to check variant prices: %w", err)
+		}
+		if mismatched {
+			return "", fmt.Errorf("cannot change the currency of a product with variant price overrides")
+		}
+		err = tx.QueryRow(`
+		SELECT EXISTS (SELECT 1 FROM price_schedules WHERE product_id = $1 AND ended_at IS NULL AND currency <> $2)`,
+			product.ID, product.Price.Currency).Scan(&mismatched)
+		if err != nil {
+			return "", fmt.Errorf("failed to check price schedules: %w", err)
+		}
+		if mismatched {
+			return "", ErrPriceSchedulePending
+		}
+
+		product.Stock = before
+		if row.Stock != nil {
+			product.Stock = *row.Stock
+		}
+
+		err = tx.QueryRow(`
+		UPDATE products
+		SET name = $1, description = $2, price = $3, currency = $4, stock = $5, updated_at = $6,
+			version = version + 1
+		WHERE id = $7
+		RETURNING version, created_at, updated_at, rating_average, review_count`,
+			product.Name, product.Description, product.Price.Decimal(), product.Price.Currency,
+			product.Stock, now, product.ID).Scan(&product.Version, &product.CreatedAt, &product.UpdatedAt,
+			&product.RatingAverage, &product.ReviewCount)
+		if err != nil {
+			return "", fmt.Errorf("failed to update product: %w", err)
+		}
+		if err := r.recordStockChange(tx, product, before); err != nil {
+			return "", err
+		}
+		stockBefore = &before
+	}
+
+	if err := checkCategoriesExist(tx, product.CategoryIDs); err != nil {
+		return "", err
+	}
+	if err := replaceProductCategories(tx, product.ID, product.CategoryIDs); err != nil {
+		return "", err
+	}
+	if err := replaceProductTags(tx, product.ID, product.Tags); err != nil {
+		return "", err
+	}
+	if err := loadDetails(tx, product); err != nil {
+		return "", err
+	}
+
+	eventType := sharedModels.ProductEventCreated
+	if action == ImportActionUpdated {
+		eventType = sharedModels.ProductEventUpdated
+	}
+	if err := r.insertOutboxEvent(tx, eventType, product); err != nil {
+		return "", err
+	}
+	if err := recordHistory(tx, HistoryActionImported, actor, product, stockChange(stockBefore, product.Stock)); err != nil {
+		return "", err
+	}
+	return action, nil
+}
+
+// ExportProducts calls fn for every product in ID order, reading the
+// catalogue in batches so it never has to fit in memory
+func (r *ProductRepository) ExportProducts(fn func(product *models.Product) error) error {
+	lastID := 0
+	for {
+		rows, err := r.db.Query(`
+		SELECT `+productColumns+`
+		FROM products WHERE id > $1 AND deleted_at IS NULL
+		ORDER BY id LIMIT $2`, lastID, ExportBatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to export products: %w", err)
+		}
+
+		var batch []*models.Product
+		for rows.Next() {
+			product, err := scanProduct(rows)
+			if err != nil {
+				rows.Close()
+				return err
+			}
+			batch = append(batch, product)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+
+		if err := loadDetails(r.db, batch...); err != nil {
+			return err
+		}
+		for _, product := range batch {
+			if err := fn(product); err != nil {
+				return err
+			}
+		}
+
+		if len(batch) < ExportBatchSize {
+			return nil
+		}
+		lastID = batch[len(batch)-1].ID
+	}
+}
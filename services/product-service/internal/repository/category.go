@@ -0,0 +1,310 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/lucas/gokafka/product-service/internal/models"
+	sharedModels "github.com/lucas/gokafka/shared/models"
+)
+
+// Category errors
+var (
+	ErrCategoryNotFound    = errors.New("category not found")
+	ErrCategorySlugTaken   = errors.New("category slug is already in use")
+	ErrCategoryCycle       = errors.New("category cannot be moved below itself")
+	ErrCategoryHasChildren = errors.New("category has subcategories")
+)
+
+// pqUniqueViolation is the Postgres error code for unique constraint errors
+const pqUniqueViolation = "23505"
+
+func (r *ProductRepository) createCategoryTablesIfNotExists() {
+	query := `
+	CREATE TABLE IF NOT EXISTS categories (
+		id SERIAL PRIMARY KEY,
+		name VARCHAR(100) NOT NULL,
+		slug VARCHAR(100) NOT NULL UNIQUE,
+		parent_id INTEGER REFERENCES categories (id) ON DELETE RESTRICT,
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE INDEX IF NOT EXISTS idx_categories_parent ON categories (parent_id);
+	CREATE TABLE IF NOT EXISTS product_categories (
+		product_id INTEGER NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+		category_id INTEGER NOT NULL REFERENCES categories (id) ON DELETE CASCADE,
+		PRIMARY KEY (product_id, category_id)
+	);
+	CREATE INDEX IF NOT EXISTS idx_product_categories_category ON product_categories (category_id);
+	CREATE TABLE IF NOT EXISTS product_tags (
+		product_id INTEGER NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+		tag VARCHAR(50) NOT NULL,
+		PRIMARY KEY (product_id, tag)
+	);
+	CREATE INDEX IF NOT EXISTS idx_product_tags_tag ON product_tags (tag)`
+
+	if _, err := r.db.Exec(query); err != nil {
+		log.Fatalf("failed to create category tables: %v", err)
+	}
+}
+
+const categoryColumns = `id, name, slug, parent_id, created_at, updated_at`
+
+func scanCategory(row rowScanner) (*models.Category, error) {
+	var category models.Category
+	var parentID sql.NullInt64
+	err := row.Scan(&category.ID, &category.Name, &category.Slug, &parentID,
+		&category.CreatedAt, &category.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrCategoryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if parentID.Valid {
+		id := int(parentID.Int64)
+		category.ParentID = &id
+	}
+	return &category, nil
+}
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == pqUniqueViolation
+}
+
+func (r *ProductRepository) CreateCategory(category *models.Category) error {
+	query := `
+	INSERT INTO categories (name, slug, parent_id, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $4)
+	RETURNING id, created_at, updated_at`
+
+	err := r.db.QueryRow(query, category.Name, category.Slug, category.ParentID, time.Now()).
+		Scan(&category.ID, &category.CreatedAt, &category.UpdatedAt)
+	if isUniqueViolation(err) {
+		return ErrCategorySlugTaken
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create category: %w", err)
+	}
+	return nil
+}
+
+func (r *ProductRepository) GetCategoryByID(id int) (*models.Category, error) {
+	return scanCategory(r.db.QueryRow(`SELECT `+categoryColumns+` FROM categories WHERE id = $1`, id))
+}
+
+// ListCategories returns every category. Parents are not guaranteed to come
+// before their children; clients build the tree from ParentID.
+func (r *ProductRepository) ListCategories() ([]*models.Category, error) {
+	rows, err := r.db.Query(`SELECT ` + categoryColumns + ` FROM categories ORDER BY name, id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	categories := []*models.Category{}
+	for rows.Next() {
+		category, err := scanCategory(rows)
+		if err != nil {
+			return nil, err
+		}
+		categories = append(categories, category)
+	}
+	return categories, rows.Err()
+}
+
+// UpdateCategory renames or moves a category. Moving a category below one of
+// its own descendants is rejected with ErrCategoryCycle.
+func (r *ProductRepository) UpdateCategory(category *models.Category) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if category.ParentID != nil {
+		// Serialize moves so two concurrent ones cannot form a cycle together
+		if _, err := tx.Exec(`LOCK TABLE categories IN SHARE ROW EXCLUSIVE MODE`); err != nil {
+			return fmt.Errorf("failed to lock categories: %w", err)
+		}
+
+		var cycle bool
+		err := tx.QueryRow(`
+		WITH RECURSIVE subtree AS (
+			SELECT id FROM categories WHERE id = $1
+			UNION ALL
+			SELECT c.id FROM categories c JOIN subtree s ON c.parent_id = s.id
+		)
+		SELECT EXISTS (SELECT 1 FROM subtree WHERE id = $2)`, category.ID, *category.ParentID).Scan(&cycle)
+		if err != nil {
+			return fmt.Errorf("failed to check category tree: %w", err)
+		}
+		if cycle {
+			return ErrCategoryCycle
+		}
+	}
+
+	query := `
+	UPDATE categories SET name = $1, slug = $2, parent_id = $3, updated_at = $4
+	WHERE id = $5
+	RETURNING created_at, updated_at`
+
+	err = tx.QueryRow(query, category.Name, category.Slug, category.ParentID, time.Now(), category.ID).
+		Scan(&category.CreatedAt, &category.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return ErrCategoryNotFound
+	}
+	if isUniqueViolation(err) {
+		return ErrCategorySlugTaken
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update category: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// DeleteCategory removes a category without subcategories. Products in it
+// simply lose the category.
+func (r *ProductRepository) DeleteCategory(id int) error {
+	var hasChildren bool
+	if err := r.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM categories WHERE parent_id = $1)`, id).Scan(&hasChildren); err != nil {
+		return fmt.Errorf("failed to check subcategories: %w", err)
+	}
+	if hasChildren {
+		return ErrCategoryHasChildren
+	}
+
+	result, err := r.db.Exec(`DELETE FROM categories WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete category: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrCategoryNotFound
+	}
+	return nil
+}
+
+// SetProductCategories replaces the categories of a product
+func (r *ProductRepository) SetProductCategories(productID int, categoryIDs []int, actor string) (*models.Product, error) {
+	return r.changeProduct(productID, HistoryActionCategoriesSet, actor, func(tx *sql.Tx, product *models.Product) error {
+		if err := checkCategoriesExist(tx, categoryIDs); err != nil {
+			return err
+		}
+		return replaceProductCategories(tx, productID, categoryIDs)
+	})
+}
+
+// SetProductTags replaces the tags of a product
+func (r *ProductRepository) SetProductTags(productID int, tags []string, actor string) (*models.Product, error) {
+	return r.changeProduct(productID, HistoryActionTagsSet, actor, func(tx *sql.Tx, product *models.Product) error {
+		return replaceProductTags(tx, productID, tags)
+	})
+}
+
+func checkCategoriesExist(q queryer, categoryIDs []int) error {
+	if len(categoryIDs) == 0 {
+		return nil
+	}
+	var found int
+	err := q.QueryRow(`SELECT COUNT(*) FROM categories WHERE id = ANY($1)`, pq.Array(categoryIDs)).Scan(&found)
+	if err != nil {
+		return fmt.Errorf("failed to check categories: %w", err)
+	}
+	if found != len(categoryIDs) {
+		return ErrCategoryNotFound
+	}
+	return nil
+}
+
+// replaceProductCategories expects categoryIDs without duplicates
+func replaceProductCategories(tx *sql.Tx, productID int, categoryIDs []int) error {
+	if _, err := tx.Exec(`DELETE FROM product_categories WHERE product_id = $1`, productID); err != nil {
+		return fmt.Errorf("failed to clear product categories: %w", err)
+	}
+	if len(categoryIDs) == 0 {
+		return nil
+	}
+	_, err := tx.Exec(`
+	INSERT INTO product_categories (product_id, category_id)
+	SELECT $1, unnest($2::integer[])`, productID, pq.Array(categoryIDs))
+	if err != nil {
+		return fmt.Errorf("failed to assign product categories: %w", err)
+	}
+	return nil
+}
+
+// replaceProductTags expects normalized tags without duplicates
+func replaceProductTags(tx *sql.Tx, productID int, tags []string) error {
+	if _, err := tx.Exec(`DELETE FROM product_tags WHERE product_id = $1`, productID); err != nil {
+		return fmt.Errorf("failed to clear product tags: %w", err)
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+	_, err := tx.Exec(`
+	INSERT INTO product_tags (product_id, tag)
+	SELECT $1, unnest($2::text[])`, productID, pq.Array(tags))
+	if err != nil {
+		return fmt.Errorf("failed to tag product: %w", err)
+	}
+	return nil
+}
+
+// loadTaxonomy fills in the category IDs and tags of products
+func loadTaxonomy(q queryer, products ...*models.Product) error {
+	if len(products) == 0 {
+		return nil
+	}
+
+	byID := make(map[int]*models.Product, len(products))
+	ids := make([]int, 0, len(products))
+	for _, product := range products {
+		product.CategoryIDs = []int{}
+		product.Tags = []string{}
+		byID[product.ID] = product
+		ids = append(ids, product.ID)
+	}
+
+	rows, err := q.Query(`
+	SELECT p.id,
+		ARRAY(SELECT category_id FROM product_categories WHERE product_id = p.id ORDER BY category_id),
+		ARRAY(SELECT tag FROM product_tags WHERE product_id = p.id ORDER BY tag)
+	FROM unnest($1::integer[]) AS p (id)`, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to load product categories: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		var categoryIDs pq.Int64Array
+		var tags pq.StringArray
+		if err := rows.Scan(&id, &categoryIDs, &tags); err != nil {
+			return err
+		}
+		product := byID[id]
+		for _, categoryID := range categoryIDs {
+			product.CategoryIDs = append(product.CategoryIDs, int(categoryID))
+		}
+		product.Tags = append(product.Tags, tags...)
+	}
+	return rows.Err()
+}
+
+// CategoryToCategoryData converts a Category to its shared representation
+func (r *ProductRepository) CategoryToCategoryData(category *models.Category) *sharedModels.CategoryData {
+	return &sharedModels.CategoryData{
+		ID:        category.ID,
+		Name:      category.Name,
+		Slug:      category.Slug,
+		ParentID:  category.ParentID,
+		CreatedAt: category.CreatedAt.Format(time.RFC3339),
+		UpdatedAt: category.UpdatedAt.Format(time.RFC3339),
+	}
+}
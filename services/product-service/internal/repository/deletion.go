@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/lucas/gokafka/product-service/internal/models"
+	sharedModels "github.com/lucas/gokafka/shared/models"
+)
+
+// DeleteProduct soft deletes a product: it disappears from every query but
+// keeps its row, so it can be restored until it is purged
+func (r *ProductRepository) DeleteProduct(id int, actor string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	query := `
+	UPDATE products SET deleted_at = $1, updated_at = $1, version = version + 1
+	WHERE id = $2 AND deleted_at IS NULL
+	RETURNING ` + productColumns
+
+	product, err := scanProduct(tx.QueryRow(query, now, id))
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("product with id %d not found", id)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete product: %w", err)
+	}
+
+	if err := r.insertOutboxEvent(tx, sharedModels.ProductEventDeleted, product); err != nil {
+		return err
+	}
+	if err := recordHistory(tx, HistoryActionDeleted, actor, product, nil); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RestoreProduct undoes a soft delete and records a product.restored event
+func (r *ProductRepository) RestoreProduct(id int, actor string) (*models.Product, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+	UPDATE products SET deleted_at = NULL, updated_at = $1, version = version + 1
+	WHERE id = $2 AND deleted_at IS NOT NULL
+	RETURNING ` + productColumns
+
+	product, err := scanProduct(tx.QueryRow(query, time.Now(), id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("deleted product with id %d not found", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore product: %w", err)
+	}
+
+	if err := loadDetails(tx, product); err != nil {
+		return nil, err
+	}
+	if err := r.insertOutboxEvent(tx, sharedModels.ProductEventRestored, product); err != nil {
+		return nil, err
+	}
+	if err := recordHistory(tx, HistoryActionRestored, actor, product, nil); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit: %w", err)
+	}
+	return product, nil
+}
+
+// PurgeDeletedProducts permanently removes up to limit products deleted
+// before cutoff, with their categories, tags, images and variants. It
+// returns the purged images so the caller can remove the stored files.
+// SKIP LOCKED lets replicas purge in parallel.
+func (r *ProductRepository) PurgeDeletedProducts(cutoff time.Time, limit int) (int, []models.ProductImage, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`
+	SELECT `+productColumns+`
+	FROM products
+	WHERE deleted_at < $1
+	ORDER BY deleted_at
+	LIMIT $2
+	FOR UPDATE SKIP LOCKED`, cutoff, limit)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to find deleted products: %w", err)
+	}
+
+	var products []*models.Product
+	var ids []int
+	for rows.Next() {
+		product, err := scanProduct(rows)
+		if err != nil {
+			rows.Close()
+			return 0, nil, err
+		}
+		products = append(products, product)
+		ids = append(ids, product.ID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, nil, err
+	}
+	if len(products) == 0 {
+		return 0, nil, nil
+	}
+
+	if err := loadImages(tx, products...); err != nil {
+		return 0, nil, err
+	}
+	if _, err := tx.Exec(`DELETE FROM products WHERE id = ANY($1)`, pq.Array(ids)); err != nil {
+		return 0, nil, fmt.Errorf("failed to purge products: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, nil, fmt.Errorf("failed to purge products: %w", err)
+	}
+
+	var images []models.ProductImage
+	for _, product := range products {
+		images = append(images, product.Images...)
+	}
+	return len(products), images, nil
+}
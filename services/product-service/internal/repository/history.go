@@ -0,0 +1,471 @@
+package repository
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/lucas/gokafka/product-service/internal/models"
+	"github.com/lucas/gokafka/shared/locks"
+	sharedModels "github.com/lucas/gokafka/shared/models"
+)
+
+// History actions name the kind of change an entry records
+const (
+	HistoryActionBaseline       = "baseline"
+	HistoryActionCreated        = "created"
+	HistoryActionUpdated        = "updated"
+	HistoryActionDeleted        = "deleted"
+	HistoryActionRestored       = "restored"
+	HistoryActionStockAdjusted  = "stock_adjusted"
+	HistoryActionCategoriesSet  = "categories_set"
+	HistoryActionTagsSet        = "tags_set"
+	HistoryActionImageAdded     = "image_added"
+	HistoryActionImageDeleted   = "image_deleted"
+	HistoryActionVariantCreated = "variant_created"
+	HistoryActionVariantUpdated = "variant_updated"
+	HistoryActionVariantDeleted = "variant_deleted"
+	HistoryActionImported       = "imported"
+	// Price schedule actions; the scheduler records the changes of the
+	// effective price without an actor
+	HistoryActionPriceScheduleCreated  = "price_schedule_created"
+	HistoryActionPriceScheduleDeleted  = "price_schedule_deleted"
+	HistoryActionPriceScheduleApplied  = "price_schedule_applied"
+	HistoryActionScheduledPriceChanged = "scheduled_price_changed"
+)
+
+// Pagination limits for GetProductHistory
+const (
+	DefaultHistoryPageSize = 50
+	MaxHistoryPageSize     = 200
+)
+
+const historyBackfillBatch = 500
+
+func (r *ProductRepository) createHistoryTableIfNotExists() {
+	query := `
+	CREATE TABLE IF NOT EXISTS product_history (
+		id BIGSERIAL PRIMARY KEY,
+		product_id INTEGER NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+		version INTEGER NOT NULL,
+		action VARCHAR(30) NOT NULL,
+		actor VARCHAR(255) NOT NULL DEFAULT '',
+		changes JSONB NOT NULL,
+		snapshot JSONB NOT NULL,
+		price NUMERIC(10, 2) NOT NULL,
+		currency VARCHAR(3) NOT NULL,
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE INDEX IF NOT EXISTS idx_product_history_product ON product_history (product_id, id);
+	CREATE INDEX IF NOT EXISTS idx_product_history_created_at ON product_history (product_id, created_at)`
+
+	if _, err := r.db.Exec(query); err != nil {
+		log.Fatalf("failed to create product_history table: %v", err)
+	}
+	if err := r.backfillHistory(); err != nil {
+		log.Fatalf("failed to backfill product history: %v", err)
+	}
+}
+
+// historySnapshot is the state of the fields history tracks. Product stock
+// is left out because reservations change it all the time; stock
+// adjustments record their change explicitly instead. Variant stock is only
+// changed by admins, so it is part of the snapshot.
+type historySnapshot struct {
+	Name        string             `json:"name"`
+	Description string             `json:"description"`
+	Price       sharedModels.Money `json:"price"`
+	CategoryIDs []int              `json:"category_ids"`
+	Tags        []string           `json:"tags"`
+	Images      []string           `json:"images"`
+	Variants    []historyVariant   `json:"variants"`
+	Deleted     bool               `json:"deleted"`
+	// ScheduledPrice is the price of the active price schedule, if any
+	ScheduledPrice *sharedModels.Money `json:"scheduled_price"`
+	Schedules      []historySchedule   `json:"price_schedules"`
+}
+
+type historyVariant struct {
+	SKU        string              `json:"sku"`
+	Attributes map[string]string   `json:"attributes"`
+	Price      *sharedModels.Money `json:"price"`
+	Stock      int                 `json:"stock"`
+}
+
+type historySchedule struct {
+	Name     string             `json:"name"`
+	Price    sharedModels.Money `json:"price"`
+	StartsAt string             `json:"starts_at"`
+	EndsAt   string             `json:"ends_at"`
+}
+
+// snapshotOf captures product as of now
+func snapshotOf(product *models.Product, now time.Time) historySnapshot {
+	snapshot := historySnapshot{
+		Name:        product.Name,
+		Description: product.Description,
+		Price:       product.Price,
+		CategoryIDs: product.CategoryIDs,
+		Tags:        product.Tags,
+		Images:      make([]string, 0, len(product.Images)),
+		Variants:    make([]historyVariant, 0, len(product.Variants)),
+		Deleted:     product.DeletedAt != nil,
+		Schedules:   make([]historySchedule, 0, len(product.Schedules)),
+	}
+	if schedule := activeSchedule(product, now); schedule != nil {
+		snapshot.ScheduledPrice = &schedule.Price
+	}
+	for _, image := range product.Images {
+		snapshot.Images = append(snapshot.Images, image.URL)
+	}
+	for _, variant := range product.Variants {
+		snapshot.Variants = append(snapshot.Variants, historyVariant{
+			SKU:        variant.SKU,
+			Attributes: variant.Attributes,
+			Price:      variant.Price,
+			Stock:      variant.Stock,
+		})
+	}
+	for _, schedule := range product.Schedules {
+		entry := historySchedule{
+			Name:     schedule.Name,
+			Price:    schedule.Price,
+			StartsAt: schedule.StartsAt.Format(time.RFC3339),
+		}
+		if schedule.EndsAt != nil {
+			entry.EndsAt = schedule.EndsAt.Format(time.RFC3339)
+		}
+		snapshot.Schedules = append(snapshot.Schedules, entry)
+	}
+	return snapshot
+}
+
+// snapshotFields encodes each field of a snapshot separately, so two
+// snapshots can be compared field by field
+func snapshotFields(snapshot historySnapshot) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	err = json.Unmarshal(data, &fields)
+	return fields, err
+}
+
+// emptyJSON holds the encodings of unset fields, which a product's first
+// entry leaves out
+var emptyJSON = map[string]bool{`""`: true, `[]`: true, `null`: true, `false`: true}
+
+// diffSnapshots returns the fields that differ between before and after.
+// A nil before stands for a product that did not exist yet.
+func diffSnapshots(before *historySnapshot, after historySnapshot) (map[string]sharedModels.FieldChange, error) {
+	afterFields, err := snapshotFields(after)
+	if err != nil {
+		return nil, err
+	}
+	var beforeFields map[string]json.RawMessage
+	if before != nil {
+		if beforeFields, err = snapshotFields(*before); err != nil {
+			return nil, err
+		}
+	}
+
+	changes := make(map[string]sharedModels.FieldChange)
+	for name, value := range afterFields {
+		from, ok := beforeFields[name]
+		if !ok {
+			if emptyJSON[string(value)] {
+				continue
+			}
+			from = json.RawMessage("null")
+		}
+		if !bytes.Equal(from, value) {
+			changes[name] = sharedModels.FieldChange{From: from, To: value}
+		}
+	}
+	return changes, nil
+}
+
+// stockChange describes a stock change for history, which snapshots leave
+// out. A nil before means the product was just created.
+func stockChange(before *int, after int) map[string]sharedModels.FieldChange {
+	from := json.RawMessage("null")
+	if before != nil {
+		if *before == after {
+			return nil
+		}
+		from = json.RawMessage(strconv.Itoa(*before))
+	}
+	return map[string]sharedModels.FieldChange{
+		"stock": {From: from, To: json.RawMessage(strconv.Itoa(after))},
+	}
+}
+
+// recordHistory stores a change made by actor to product inside the
+// caller's transaction, which must hold the product's row lock. The changed
+// fields are found by comparing the product with the snapshot of its
+// previous entry; extra adds changes snapshots do not track, like stock.
+func recordHistory(tx *sql.Tx, action, actor string, product *models.Product, extra map[string]sharedModels.FieldChange) error {
+	if product.CategoryIDs == nil || product.Tags == nil || product.Images == nil || product.Variants == nil ||
+		product.Schedules == nil {
+		if err := loadDetails(tx, product); err != nil {
+			return err
+		}
+	}
+
+	var before *historySnapshot
+	var previous []byte
+	err := tx.QueryRow(`
+	SELECT snapshot FROM product_history WHERE product_id = $1
+	ORDER BY id DESC LIMIT 1`, product.ID).Scan(&previous)
+	switch {
+	case err == sql.ErrNoRows:
+	case err != nil:
+		return fmt.Errorf("failed to load product history: %w", err)
+	default:
+		before = &historySnapshot{}
+		if err := json.Unmarshal(previous, before); err != nil {
+			return fmt.Errorf("invalid history snapshot of product %d: %w", product.ID, err)
+		}
+	}
+
+	now := time.Now()
+	after := snapshotOf(product, now)
+	changes, err := diffSnapshots(before, after)
+	if err != nil {
+		return fmt.Errorf("failed to compare product history: %w", err)
+	}
+	for name, change := range extra {
+		changes[name] = change
+	}
+
+	return insertHistoryEntry(tx, action, actor, product, after, changes, now)
+}
+
+func insertHistoryEntry(tx *sql.Tx, action, actor string, product *models.Product, snapshot historySnapshot,
+	changes map[string]sharedModels.FieldChange, at time.Time) error {
+	snapshotJSON, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("failed to serialize history snapshot: %w", err)
+	}
+	changesJSON, err := json.Marshal(changes)
+	if err != nil {
+		return fmt.Errorf("failed to serialize history changes: %w", err)
+	}
+
+	// The price history follows the effective price
+	price := effectivePrice(product, at)
+	query := `
+	INSERT INTO product_history (product_id, version, action, actor, changes, snapshot, price, currency, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+
+	_, err = tx.Exec(query, product.ID, product.Version, action, actor, string(changesJSON),
+		string(snapshotJSON), price.Decimal(), price.Currency, at)
+	if err != nil {
+		return fmt.Errorf("failed to record product history: %w", err)
+	}
+	return nil
+}
+
+// backfillHistory gives every product without history a baseline entry, so
+// the first recorded change has something to compare with. The entry is
+// dated at the product's last update, since its current state has held from
+// then on.
+func (r *ProductRepository) backfillHistory() error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`SELECT pg_advisory_xact_lock($1)`, locks.ProductHistoryBackfill); err != nil {
+		return fmt.Errorf("failed to acquire history backfill lock: %w", err)
+	}
+
+	query := `
+	SELECT ` + productColumns + ` FROM products p
+	WHERE NOT EXISTS (SELECT 1 FROM product_history h WHERE h.product_id = p.id)
+	ORDER BY id LIMIT $1`
+
+	total := 0
+	for {
+		rows, err := tx.Query(query, historyBackfillBatch)
+		if err != nil {
+			return fmt.Errorf("failed to find products without history: %w", err)
+		}
+		var products []*models.Product
+		for rows.Next() {
+			product, err := scanProduct(rows)
+			if err != nil {
+				rows.Close()
+				return err
+			}
+			products = append(products, product)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		if len(products) == 0 {
+			break
+		}
+
+		if err := loadDetails(tx, products...); err != nil {
+			return err
+		}
+		for _, product := range products {
+			err := insertHistoryEntry(tx, HistoryActionBaseline, "", product, snapshotOf(product, product.UpdatedAt),
+				map[string]sharedModels.FieldChange{}, product.UpdatedAt)
+			if err != nil {
+				return err
+			}
+		}
+		total += len(products)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit: %w", err)
+	}
+	if total > 0 {
+		log.Printf("Recorded baseline history for %d products", total)
+	}
+	return nil
+}
+
+// HistoryPage is one page of a product's history, newest first. NextCursor
+// is empty on the last page.
+type HistoryPage struct {
+	Entries    []*models.ProductHistoryEntry
+	NextCursor string
+}
+
+// GetProductHistory returns a page of the history of a product, including
+// soft deleted ones. The cursor is the ID of the last entry of the previous
+// page.
+func (r *ProductRepository) GetProductHistory(productID int, cursor string, limit int) (*HistoryPage, error) {
+	if limit <= 0 {
+		limit = DefaultHistoryPageSize
+	}
+	if limit > MaxHistoryPageSize {
+		limit = MaxHistoryPageSize
+	}
+	var before int64
+	if cursor != "" {
+		var err error
+		if before, err = strconv.ParseInt(cursor, 10, 64); err != nil || before <= 0 {
+			return nil, ErrInvalidCursor
+		}
+	}
+
+	var exists bool
+	if err := r.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM products WHERE id = $1)`, productID).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("failed to check product %d: %w", productID, err)
+	}
+	if !exists {
+		return nil, ErrProductNotFound
+	}
+
+	query := `
+	SELECT id, product_id, version, action, actor, changes, price, currency, created_at
+	FROM product_history
+	WHERE product_id = $1 AND ($2::bigint = 0 OR id < $2)
+	ORDER BY id DESC LIMIT $3`
+
+	// Fetch one extra row to learn whether another page follows
+	rows, err := r.db.Query(query, productID, before, limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load product history: %w", err)
+	}
+	defer rows.Close()
+
+	page := &HistoryPage{Entries: []*models.ProductHistoryEntry{}}
+	for rows.Next() {
+		var entry models.ProductHistoryEntry
+		var changes []byte
+		var price, currency string
+		err := rows.Scan(&entry.ID, &entry.ProductID, &entry.Version, &entry.Action, &entry.Actor,
+			&changes, &price, &currency, &entry.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(changes, &entry.Changes); err != nil {
+			return nil, fmt.Errorf("invalid changes in history entry %d: %w", entry.ID, err)
+		}
+		if entry.Price, err = sharedModels.ParseMoney(price, currency); err != nil {
+			return nil, fmt.Errorf("invalid price in history entry %d: %w", entry.ID, err)
+		}
+		page.Entries = append(page.Entries, &entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(page.Entries) > limit {
+		page.Entries = page.Entries[:limit]
+		page.NextCursor = strconv.FormatInt(page.Entries[limit-1].ID, 10)
+	}
+	return page, nil
+}
+
+// PricePoint is a price a product took on at a point in time
+type PricePoint struct {
+	At    time.Time
+	Price sharedModels.Money
+}
+
+// GetPriceHistory returns the prices of a live product between from and to
+// in time order, starting with the price it had at from, if known
+func (r *ProductRepository) GetPriceHistory(productID int, from, to time.Time) ([]PricePoint, error) {
+	var exists bool
+	err := r.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM products WHERE id = $1 AND deleted_at IS NULL)`, productID).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check product %d: %w", productID, err)
+	}
+	if !exists {
+		return nil, ErrProductNotFound
+	}
+
+	query := `
+	SELECT price, currency, created_at FROM product_history
+	WHERE product_id = $1 AND created_at < $3 AND created_at >= COALESCE(
+		(SELECT MAX(created_at) FROM product_history WHERE product_id = $1 AND created_at <= $2),
+		'-infinity'::timestamp)
+	ORDER BY created_at, id`
+
+	rows, err := r.db.Query(query, productID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load price history: %w", err)
+	}
+	defer rows.Close()
+
+	var points []PricePoint
+	for rows.Next() {
+		var point PricePoint
+		var price, currency string
+		if err := rows.Scan(&price, &currency, &point.At); err != nil {
+			return nil, err
+		}
+		if point.Price, err = sharedModels.ParseMoney(price, currency); err != nil {
+			return nil, fmt.Errorf("invalid price in history of product %d: %w", productID, err)
+		}
+		points = append(points, point)
+	}
+	return points, rows.Err()
+}
+
+// HistoryEntryToHistoryData converts a history entry for the wire
+func (r *ProductRepository) HistoryEntryToHistoryData(entry *models.ProductHistoryEntry) *sharedModels.ProductHistoryEntry {
+	return &sharedModels.ProductHistoryEntry{
+		ID:        entry.ID,
+		ProductID: entry.ProductID,
+		Version:   entry.Version,
+		Action:    entry.Action,
+		Actor:     entry.Actor,
+		Changes:   entry.Changes,
+		CreatedAt: entry.CreatedAt.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/lucas/gokafka/product-service/internal/models"
+)
+
+// MaxImagesPerProduct caps the gallery of a single product
+const MaxImagesPerProduct = 10
+
+var (
+	ErrImageNotFound   = errors.New("image not found")
+	ErrTooManyImages   = fmt.Errorf("a product cannot have more than %d images", MaxImagesPerProduct)
+	ErrProductNotFound = errors.New("product not found")
+)
+
+func (r *ProductRepository) createImageTableIfNotExists() {
+	query := `
+	CREATE TABLE IF NOT EXISTS product_images (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		product_id INTEGER NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+		storage_key VARCHAR(255) NOT NULL,
+		thumbnail_key VARCHAR(255) NOT NULL,
+		url VARCHAR(512) NOT NULL,
+		thumbnail_url VARCHAR(512) NOT NULL,
+		content_type VARCHAR(50) NOT NULL,
+		width INTEGER NOT NULL,
+		height INTEGER NOT NULL,
+		size_bytes INTEGER NOT NULL,
+		position INTEGER NOT NULL,
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE INDEX IF NOT EXISTS idx_product_images_product ON product_images (product_id, position)`
+
+	if _, err := r.db.Exec(query); err != nil {
+		log.Fatalf("failed to create product images table: %v", err)
+	}
+}
+
+const imageColumns = `id, product_id, storage_key, thumbnail_key, url, thumbnail_url,
+	content_type, width, height, size_bytes, position, created_at`
+
+func scanImage(row rowScanner) (*models.ProductImage, error) {
+	var image models.ProductImage
+	err := row.Scan(
+		&image.ID, &image.ProductID, &image.StorageKey, &image.ThumbnailKey, &image.URL,
+		&image.ThumbnailURL, &image.ContentType, &image.Width, &image.Height,
+		&image.SizeBytes, &image.Position, &image.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &image, nil
+}
+
+// AddProductImage appends an already stored image to a product's gallery
+// and records a product.updated event
+func (r *ProductRepository) AddProductImage(image *models.ProductImage, actor string) (*models.Product, error) {
+	return r.changeProduct(image.ProductID, HistoryActionImageAdded, actor, func(tx *sql.Tx, product *models.Product) error {
+		var count int
+		if err := tx.QueryRow(`SELECT COUNT(*) FROM product_images WHERE product_id = $1`, image.ProductID).Scan(&count); err != nil {
+			return fmt.Errorf("failed to count product images: %w", err)
+		}
+		if count >= MaxImagesPerProduct {
+			return ErrTooManyImages
+		}
+
+		query := `
+		INSERT INTO product_images (product_id, storage_key, thumbnail_key, url, thumbnail_url,
+			content_type, width, height, size_bytes, position, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9,
+			(SELECT COALESCE(MAX(position), 0) + 1 FROM product_images WHERE product_id = $1), $10)
+		RETURNING id, position, created_at`
+
+		err := tx.QueryRow(query, image.ProductID, image.StorageKey, image.ThumbnailKey, image.URL,
+			image.ThumbnailURL, image.ContentType, image.Width, image.Height, image.SizeBytes, time.Now()).
+			Scan(&image.ID, &image.Position, &image.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to add product image: %w", err)
+		}
+		return nil
+	})
+}
+
+// DeleteProductImage removes an image from a product's gallery. The caller
+// deletes the stored files once this succeeds.
+func (r *ProductRepository) DeleteProductImage(productID int, imageID, actor string) (*models.ProductImage, error) {
+	var image *models.ProductImage
+	_, err := r.changeProduct(productID, HistoryActionImageDeleted, actor, func(tx *sql.Tx, product *models.Product) error {
+		var err error
+		image, err = scanImage(tx.QueryRow(`
+		DELETE FROM product_images WHERE id = $1 AND product_id = $2
+		RETURNING `+imageColumns, imageID, productID))
+		if err == sql.ErrNoRows {
+			return ErrImageNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("failed to delete product image: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return image, nil
+}
+
+// loadImages fills in the image galleries of products
+func loadImages(q queryer, products ...*models.Product) error {
+	if len(products) == 0 {
+		return nil
+	}
+
+	byID := make(map[int]*models.Product, len(products))
+	ids := make([]int, 0, len(products))
+	for _, product := range products {
+		product.Images = []models.ProductImage{}
+		byID[product.ID] = product
+		ids = append(ids, product.ID)
+	}
+
+	rows, err := q.Query(`
+	SELECT `+imageColumns+`
+	FROM product_images WHERE product_id = ANY($1)
+	ORDER BY product_id, position`, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to load product images: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		image, err := scanImage(rows)
+		if err != nil {
+			return err
+		}
+		product := byID[image.ProductID]
+		product.Images = append(product.Images, *image)
+	}
+	return rows.Err()
+}
+
+// loadDetails fills in everything stored outside the products table
+func loadDetails(q queryer, products ...*models.Product) error {
+	if err := loadTaxonomy(q, products...); err != nil {
+		return err
+	}
+	if err := loadImages(q, products...); err != nil {
+		return err
+	}
+	if err := loadVariants(q, products...); err != nil {
+		return err
+	}
+	return loadPriceSchedules(q, products...)
+}
@@ -0,0 +1,471 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/lucas/gokafka/product-service/internal/models"
+	sharedModels "github.com/lucas/gokafka/shared/models"
+)
+
+// ErrReservationNotFound is returned when no reservation matches a request
+var ErrReservationNotFound = errors.New("reservation not found")
+
+func (r *ProductRepository) createReservationTablesIfNotExists() {
+	query := `
+	CREATE TABLE IF NOT EXISTS stock_reservations (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		order_id VARCHAR(255) NOT NULL UNIQUE,
+		status VARCHAR(20) NOT NULL,
+		expires_at TIMESTAMP NOT NULL,
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE INDEX IF NOT EXISTS idx_stock_reservations_active
+		ON stock_reservations (expires_at) WHERE status = 'active';
+	CREATE TABLE IF NOT EXISTS stock_reservation_items (
+		reservation_id UUID NOT NULL REFERENCES stock_reservations (id) ON DELETE CASCADE,
+		product_id INTEGER NOT NULL,
+		variant_id INTEGER NOT NULL DEFAULT 0,
+		quantity INTEGER NOT NULL CHECK (quantity > 0),
+		PRIMARY KEY (reservation_id, product_id, variant_id)
+	);
+	ALTER TABLE stock_reservation_items ADD COLUMN IF NOT EXISTS variant_id INTEGER NOT NULL DEFAULT 0;
+	DO $$
+	BEGIN
+		IF NOT EXISTS (
+			SELECT 1 FROM information_schema.key_column_usage
+			WHERE table_name = 'stock_reservation_items'
+				AND constraint_name = 'stock_reservation_items_pkey' AND column_name = 'variant_id'
+		) THEN
+			ALTER TABLE stock_reservation_items DROP CONSTRAINT stock_reservation_items_pkey;
+			ALTER TABLE stock_reservation_items ADD PRIMARY KEY (reservation_id, product_id, variant_id);
+		END IF;
+	END $$`
+
+	if _, err := r.db.Exec(query); err != nil {
+		log.Fatalf("failed to create stock reservation tables: %v", err)
+	}
+}
+
+// ReserveStock takes the requested quantities out of stock and records them
+// as an active reservation for the order. It is idempotent per order: a
+// repeated request returns the existing reservation. Stock is decremented
+// with a conditional update, so concurrent reservations can never oversell.
+func (r *ProductRepository) ReserveStock(orderID string, items []models.ReservationItem, ttl time.Duration) (*models.Reservation, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	reservation := &models.Reservation{OrderID: orderID, Status: sharedModels.ReservationStatusActive}
+
+	// A concurrent request for the same order blocks here on the unique
+	// index until the first one finishes
+	err = tx.QueryRow(`
+	INSERT INTO stock_reservations (order_id, status, expires_at, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $4)
+	ON CONFLICT (order_id) DO NOTHING
+	RETURNING id, expires_at, created_at, updated_at`,
+		orderID, reservation.Status, now.Add(ttl), now,
+	).Scan(&reservation.ID, &reservation.ExpiresAt, &reservation.CreatedAt, &reservation.UpdatedAt)
+	if err == sql.ErrNoRows {
+		tx.Rollback()
+		return r.GetReservation("", orderID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create reservation: %w", err)
+	}
+
+	// Lock products and variants in a fixed order so concurrent
+	// reservations cannot deadlock
+	sorted := append([]models.ReservationItem(nil), items...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].ProductID != sorted[j].ProductID {
+			return sorted[i].ProductID < sorted[j].ProductID
+		}
+		return sorted[i].VariantID < sorted[j].VariantID
+	})
+
+	for _, item := range sorted {
+		if item.VariantID != 0 {
+			if err := reserveVariantStock(tx, reservation.ID, item, now); err != nil {
+				return nil, err
+			}
+			reservation.Items = append(reservation.Items, item)
+			continue
+		}
+
+		product, err := scanProduct(tx.QueryRow(`
+		UPDATE products SET stock = stock - $1
+		WHERE id = $2 AND stock >= $1 AND deleted_at IS NULL
+		RETURNING `+productColumns, item.Quantity, item.ProductID))
+		if err == sql.ErrNoRows {
+			var exists bool
+			if err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM products WHERE id = $1 AND deleted_at IS NULL)`, item.ProductID).Scan(&exists); err != nil {
+				return nil, fmt.Errorf("failed to check product %d: %w", item.ProductID, err)
+			}
+			if !exists {
+				return nil, fmt.Errorf("product with id %d not found", item.ProductID)
+			}
+			return nil, fmt.Errorf("insufficient stock for product %d", item.ProductID)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to reserve product %d: %w", item.ProductID, err)
+		}
+
+		if _, err := tx.Exec(`
+		INSERT INTO stock_reservation_items (reservation_id, product_id, quantity)
+		VALUES ($1, $2, $3)`, reservation.ID, item.ProductID, item.Quantity); err != nil {
+			return nil, fmt.Errorf("failed to record reservation item: %w", err)
+		}
+
+		if err := r.recordStockChange(tx, product, product.Stock+item.Quantity); err != nil {
+			return nil, err
+		}
+		reservation.Items = append(reservation.Items, item)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit reservation: %w", err)
+	}
+	return reservation, nil
+}
+
+// reserveVariantStock takes a reservation item out of its variant's stock
+func reserveVariantStock(tx *sql.Tx, reservationID string, item models.ReservationItem, now time.Time) error {
+	result, err := tx.Exec(`
+	UPDATE product_variants v SET stock = v.stock - $1, updated_at = $4
+	FROM products p
+	WHERE v.id = $2 AND v.product_id = $3 AND v.stock >= $1
+		AND p.id = v.product_id AND p.deleted_at IS NULL`,
+		item.Quantity, item.VariantID, item.ProductID, now)
+	if err != nil {
+		return fmt.Errorf("failed to reserve variant %d: %w", item.VariantID, err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		var exists bool
+		if err := tx.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1 FROM product_variants v JOIN products p ON p.id = v.product_id
+			WHERE v.id = $1 AND v.product_id = $2 AND p.deleted_at IS NULL
+		)`, item.VariantID, item.ProductID).Scan(&exists); err != nil {
+			return fmt.Errorf("failed to check variant %d: %w", item.VariantID, err)
+		}
+		if !exists {
+			return fmt.Errorf("variant %d of product %d not found", item.VariantID, item.ProductID)
+		}
+		return fmt.Errorf("insufficient stock for variant %d of product %d", item.VariantID, item.ProductID)
+	}
+
+	if _, err := tx.Exec(`
+	INSERT INTO stock_reservation_items (reservation_id, product_id, variant_id, quantity)
+	VALUES ($1, $2, $3, $4)`, reservationID, item.ProductID, item.VariantID, item.Quantity); err != nil {
+		return fmt.Errorf("failed to record reservation item: %w", err)
+	}
+	return nil
+}
+
+// GetReservation finds a reservation by ID, or by order when id is empty
+func (r *ProductRepository) GetReservation(id, orderID string) (*models.Reservation, error) {
+	return r.getReservation(r.db, id, orderID, false)
+}
+
+// CommitReservation makes an active reservation permanent. Committing an
+// already committed reservation is a no-op.
+func (r *ProductRepository) CommitReservation(id, orderID string) (*models.Reservation, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	reservation, err := r.getReservation(tx, id, orderID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	switch reservation.Status {
+	case sharedModels.ReservationStatusCommitted:
+		return reservation, nil
+	case sharedModels.ReservationStatusActive:
+	default:
+		return nil, fmt.Errorf("reservation %s is %s", reservation.ID, reservation.Status)
+	}
+
+	if err := r.setReservationStatus(tx, reservation, sharedModels.ReservationStatusCommitted); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit reservation: %w", err)
+	}
+	return reservation, nil
+}
+
+// ReleaseReservation returns the stock of an active reservation. Releasing a
+// released or expired reservation is a no-op; committed ones cannot be
+// released.
+func (r *ProductRepository) ReleaseReservation(id, orderID string) (*models.Reservation, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	reservation, err := r.getReservation(tx, id, orderID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	switch reservation.Status {
+	case sharedModels.ReservationStatusReleased, sharedModels.ReservationStatusExpired:
+		return reservation, nil
+	case sharedModels.ReservationStatusActive:
+	default:
+		return nil, fmt.Errorf("reservation %s is %s", reservation.ID, reservation.Status)
+	}
+
+	if err := r.restoreStock(tx, reservation, sharedModels.ReservationStatusReleased); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to release reservation: %w", err)
+	}
+	return reservation, nil
+}
+
+// ExpireReservations returns the stock of active reservations past their
+// expiry and marks them expired. SKIP LOCKED lets replicas sweep in parallel.
+func (r *ProductRepository) ExpireReservations(limit int) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`
+	SELECT id, order_id, status, expires_at, created_at, updated_at
+	FROM stock_reservations
+	WHERE status = $1 AND expires_at < $2
+	ORDER BY expires_at
+	LIMIT $3
+	FOR UPDATE SKIP LOCKED`, sharedModels.ReservationStatusActive, time.Now(), limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find expired reservations: %w", err)
+	}
+
+	var expired []*models.Reservation
+	for rows.Next() {
+		var reservation models.Reservation
+		if err := rows.Scan(
+			&reservation.ID, &reservation.OrderID, &reservation.Status,
+			&reservation.ExpiresAt, &reservation.CreatedAt, &reservation.UpdatedAt,
+		); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		expired = append(expired, &reservation)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, reservation := range expired {
+		items, err := r.getReservationItems(tx, reservation.ID)
+		if err != nil {
+			return 0, err
+		}
+		reservation.Items = items
+
+		if err := r.restoreStock(tx, reservation, sharedModels.ReservationStatusExpired); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to expire reservations: %w", err)
+	}
+	return len(expired), nil
+}
+
+// AdjustStock changes a product's stock by delta, or sets it to quantity
+// when quantity is not nil. Stock never goes below zero.
+func (r *ProductRepository) AdjustStock(id, delta int, quantity *int, actor string) (*models.Product, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var before int
+	if err := tx.QueryRow(`SELECT stock FROM products WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, id).Scan(&before); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("product with id %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get product stock: %w", err)
+	}
+
+	after := before + delta
+	if quantity != nil {
+		after = *quantity
+	}
+	if after < 0 {
+		return nil, fmt.Errorf("stock cannot be negative (available: %d)", before)
+	}
+
+	product, err := scanProduct(tx.QueryRow(`
+	UPDATE products SET stock = $1, updated_at = $2, version = version + 1
+	WHERE id = $3
+	RETURNING `+productColumns, after, time.Now(), id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to adjust stock: %w", err)
+	}
+
+	if err := r.insertOutboxEvent(tx, sharedModels.ProductEventUpdated, product); err != nil {
+		return nil, err
+	}
+	if err := r.recordStockChange(tx, product, before); err != nil {
+		return nil, err
+	}
+	if err := recordHistory(tx, HistoryActionStockAdjusted, actor, product, stockChange(&before, after)); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to adjust stock: %w", err)
+	}
+	return product, nil
+}
+
+// recordStockChange emits a low stock event when stock crosses below the
+// threshold, so consumers are told once rather than on every sale
+func (r *ProductRepository) recordStockChange(tx *sql.Tx, product *models.Product, before int) error {
+	if before >= r.lowStockThreshold && product.Stock < r.lowStockThreshold {
+		return r.insertOutboxEvent(tx, sharedModels.ProductEventLowStock, product)
+	}
+	return nil
+}
+
+// restoreStock puts a reservation's items back into stock and closes it
+// with the given status
+func (r *ProductRepository) restoreStock(tx *sql.Tx, reservation *models.Reservation, status string) error {
+	for _, item := range reservation.Items {
+		// The product or variant may have been deleted in the meantime
+		if item.VariantID != 0 {
+			if _, err := tx.Exec(`UPDATE product_variants SET stock = stock + $1 WHERE id = $2`,
+				item.Quantity, item.VariantID); err != nil {
+				return fmt.Errorf("failed to restore stock for variant %d: %w", item.VariantID, err)
+			}
+			continue
+		}
+		if _, err := tx.Exec(`UPDATE products SET stock = stock + $1 WHERE id = $2`,
+			item.Quantity, item.ProductID); err != nil {
+			return fmt.Errorf("failed to restore stock for product %d: %w", item.ProductID, err)
+		}
+	}
+	return r.setReservationStatus(tx, reservation, status)
+}
+
+func (r *ProductRepository) setReservationStatus(tx *sql.Tx, reservation *models.Reservation, status string) error {
+	err := tx.QueryRow(`
+	UPDATE stock_reservations SET status = $1, updated_at = $2
+	WHERE id = $3
+	RETURNING updated_at`, status, time.Now(), reservation.ID).Scan(&reservation.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to update reservation: %w", err)
+	}
+	reservation.Status = status
+	return nil
+}
+
+// queryer is implemented by both *sql.DB and *sql.Tx
+type queryer interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+func (r *ProductRepository) getReservation(q queryer, id, orderID string, forUpdate bool) (*models.Reservation, error) {
+	query := `
+	SELECT id, order_id, status, expires_at, created_at, updated_at
+	FROM stock_reservations WHERE `
+	arg := orderID
+	if id != "" {
+		query += `id::text = $1`
+		arg = id
+	} else {
+		query += `order_id = $1`
+	}
+	if forUpdate {
+		query += ` FOR UPDATE`
+	}
+
+	var reservation models.Reservation
+	err := q.QueryRow(query, arg).Scan(
+		&reservation.ID, &reservation.OrderID, &reservation.Status,
+		&reservation.ExpiresAt, &reservation.CreatedAt, &reservation.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrReservationNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reservation: %w", err)
+	}
+
+	items, err := r.getReservationItems(q, reservation.ID)
+	if err != nil {
+		return nil, err
+	}
+	reservation.Items = items
+
+	return &reservation, nil
+}
+
+func (r *ProductRepository) getReservationItems(q queryer, reservationID string) ([]models.ReservationItem, error) {
+	rows, err := q.Query(`
+	SELECT product_id, variant_id, quantity FROM stock_reservation_items
+	WHERE reservation_id = $1 ORDER BY product_id, variant_id`, reservationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reservation items: %w", err)
+	}
+	defer rows.Close()
+
+	var items []models.ReservationItem
+	for rows.Next() {
+		var item models.ReservationItem
+		if err := rows.Scan(&item.ProductID, &item.VariantID, &item.Quantity); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+// ReservationToReservationData converts a reservation to its bus form
+func (r *ProductRepository) ReservationToReservationData(reservation *models.Reservation) *sharedModels.ReservationData {
+	items := make([]sharedModels.StockItem, 0, len(reservation.Items))
+	for _, item := range reservation.Items {
+		items = append(items, sharedModels.StockItem{
+			ProductID: item.ProductID,
+			VariantID: item.VariantID,
+			Quantity:  item.Quantity,
+		})
+	}
+
+	return &sharedModels.ReservationData{
+		ID:        reservation.ID,
+		OrderID:   reservation.OrderID,
+		Status:    reservation.Status,
+		Items:     items,
+		ExpiresAt: reservation.ExpiresAt.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"database/sql"
+	"log"
+	"strconv"
+
+	"github.com/lucas/gokafka/product-service/internal/models"
+)
+
+func (r *ProductRepository) createOutboxTableIfNotExists() {
+	if err := r.outbox.CreateTableIfNotExists(); err != nil {
+		log.Fatal(err)
+	}
+
+	// product_outbox predates the shared outbox and keyed events by an
+	// INTEGER product ID
+	query := `
+	DO $$
+	BEGIN
+		IF EXISTS (
+			SELECT 1 FROM information_schema.columns
+			WHERE table_name = 'product_outbox' AND column_name = 'aggregate_id' AND data_type = 'integer'
+		) THEN
+			ALTER TABLE product_outbox ALTER COLUMN aggregate_id TYPE VARCHAR(255);
+		END IF;
+	END $$`
+
+	if _, err := r.db.Exec(query); err != nil {
+		log.Fatalf("failed to migrate product_outbox table: %v", err)
+	}
+}
+
+// insertOutboxEvent records a product event inside the caller's transaction
+func (r *ProductRepository) insertOutboxEvent(tx *sql.Tx, eventType string, product *models.Product) error {
+	// Stock changes load products without their categories, tags, images
+	// and variants
+	if product.CategoryIDs == nil || product.Tags == nil || product.Images == nil || product.Variants == nil {
+		if err := loadDetails(tx, product); err != nil {
+			return err
+		}
+	}
+
+	return r.outbox.Insert(tx, eventType, strconv.Itoa(product.ID), r.ProductToProductData(product))
+}
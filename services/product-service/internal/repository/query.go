@@ -0,0 +1,249 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lucas/gokafka/product-service/internal/models"
+	sharedModels "github.com/lucas/gokafka/shared/models"
+)
+
+// Pagination limits for ListProducts
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+// DefaultProductSort lists the newest products first
+const DefaultProductSort = "-created_at"
+
+var (
+	ErrInvalidCursor = errors.New("invalid cursor")
+	ErrInvalidSort   = errors.New("invalid sort field")
+)
+
+// productSortColumns whitelists the fields products can be sorted by. The
+// cast turns a cursor value back into the column's type.
+var productSortColumns = map[string]struct {
+	column string
+	cast   string
+}{
+	"id":         {"id", "integer"},
+	"name":       {"name", "text"},
+	"price":      {"price", "numeric"}, // replaced by effectivePriceColumn
+	"created_at": {"created_at", "timestamp"},
+	"updated_at": {"updated_at", "timestamp"},
+}
+
+// ProductFilter selects one page of products. Price bounds only match
+// products priced in the bound's currency. CategoryID matches products in
+// the category or any of its subcategories.
+type ProductFilter struct {
+	MinPrice      *sharedModels.Money
+	MaxPrice      *sharedModels.Money
+	NamePrefix    string
+	CategoryID    int
+	Tag           string
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	// Deleted lists soft deleted products instead of live ones
+	Deleted bool
+	// Sort is a field from productSortColumns, prefixed with "-" for
+	// descending order
+	Sort   string
+	Cursor string
+	Limit  int
+}
+
+// ProductPage is one page of products. NextCursor is empty on the last page.
+type ProductPage struct {
+	Products   []*models.Product
+	NextCursor string
+}
+
+// productCursor is the position after the last product of a page. It
+// records the sort so a cursor cannot be replayed against another order.
+type productCursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v"`
+	ID    int    `json:"id"`
+}
+
+func encodeCursor(cursor productCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(value string) (*productCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var cursor productCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &cursor, nil
+}
+
+// sortValue returns the cursor value of product for a sort field
+func sortValue(product *models.Product, field string, now time.Time) string {
+	switch field {
+	case "id":
+		return fmt.Sprint(product.ID)
+	case "name":
+		return product.Name
+	case "price":
+		return effectivePrice(product, now).Decimal()
+	case "created_at":
+		return product.CreatedAt.Format(time.RFC3339Nano)
+	default:
+		return product.UpdatedAt.Format(time.RFC3339Nano)
+	}
+}
+
+// ListProducts returns a page of products using keyset pagination on the
+// sort column, with the ID as tie-breaker. Prices are filtered and sorted
+// by the effective price, including active price schedules.
+func (r *ProductRepository) ListProducts(filter ProductFilter) (*ProductPage, error) {
+	if filter.Sort == "" {
+		filter.Sort = DefaultProductSort
+	}
+	field := strings.TrimPrefix(filter.Sort, "-")
+	descending := field != filter.Sort
+	sortColumn, ok := productSortColumns[field]
+	if !ok {
+		return nil, ErrInvalidSort
+	}
+
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultPageSize
+	}
+	if filter.Limit > MaxPageSize {
+		filter.Limit = MaxPageSize
+	}
+
+	var conditions []string
+	var args []interface{}
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	now := time.Now()
+	var priceColumn string
+	if filter.MinPrice != nil || filter.MaxPrice != nil || field == "price" {
+		priceColumn = fmt.Sprintf(effectivePriceColumn, arg(now))
+	}
+	column := sortColumn.column
+	if field == "price" {
+		column = priceColumn
+	}
+
+	if filter.Deleted {
+		conditions = append(conditions, "deleted_at IS NOT NULL")
+	} else {
+		conditions = append(conditions, "deleted_at IS NULL")
+	}
+	if filter.MinPrice != nil {
+		conditions = append(conditions, fmt.Sprintf("currency = %s AND %s >= %s",
+			arg(filter.MinPrice.Currency), priceColumn, arg(filter.MinPrice.Decimal())))
+	}
+	if filter.MaxPrice != nil {
+		conditions = append(conditions, fmt.Sprintf("currency = %s AND %s <= %s",
+			arg(filter.MaxPrice.Currency), priceColumn, arg(filter.MaxPrice.Decimal())))
+	}
+	if filter.NamePrefix != "" {
+		conditions = append(conditions, fmt.Sprintf(`LOWER(name) LIKE LOWER(%s) ESCAPE '\'`,
+			arg(escapeLike(filter.NamePrefix)+"%")))
+	}
+	if filter.CategoryID != 0 {
+		conditions = append(conditions, fmt.Sprintf(`id IN (
+		WITH RECURSIVE subtree AS (
+			SELECT id FROM categories WHERE id = %s
+			UNION ALL
+			SELECT c.id FROM categories c JOIN subtree s ON c.parent_id = s.id
+		)
+		SELECT pc.product_id FROM product_categories pc JOIN subtree s ON pc.category_id = s.id)`,
+			arg(filter.CategoryID)))
+	}
+	if filter.Tag != "" {
+		conditions = append(conditions, "id IN (SELECT product_id FROM product_tags WHERE tag = "+arg(filter.Tag)+")")
+	}
+	if filter.CreatedAfter != nil {
+		conditions = append(conditions, "created_at >= "+arg(*filter.CreatedAfter))
+	}
+	if filter.CreatedBefore != nil {
+		conditions = append(conditions, "created_at < "+arg(*filter.CreatedBefore))
+	}
+
+	comparison, direction := ">", "ASC"
+	if descending {
+		comparison, direction = "<", "DESC"
+	}
+
+	if filter.Cursor != "" {
+		cursor, err := decodeCursor(filter.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		if cursor.Sort != filter.Sort {
+			return nil, fmt.Errorf("%w: cursor was issued for sort %q", ErrInvalidCursor, cursor.Sort)
+		}
+		conditions = append(conditions, fmt.Sprintf("(%s, id) %s (%s::%s, %s)",
+			column, comparison, arg(cursor.Value), sortColumn.cast, arg(cursor.ID)))
+	}
+
+	query := `SELECT ` + productColumns + ` FROM products WHERE ` + strings.Join(conditions, " AND ")
+	// Fetch one extra row to learn whether another page follows
+	query += fmt.Sprintf(` ORDER BY %s %s, id %s LIMIT %s`,
+		column, direction, direction, arg(filter.Limit+1))
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	page := &ProductPage{}
+	for rows.Next() {
+		product, err := scanProduct(rows)
+		if err != nil {
+			return nil, err
+		}
+		page.Products = append(page.Products, product)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	more := len(page.Products) > filter.Limit
+	if more {
+		page.Products = page.Products[:filter.Limit]
+	}
+
+	// Details come first, as the cursor of a price sort needs the schedules
+	if err := loadDetails(r.db, page.Products...); err != nil {
+		return nil, err
+	}
+
+	if more {
+		last := page.Products[len(page.Products)-1]
+		page.NextCursor = encodeCursor(productCursor{
+			Sort:  filter.Sort,
+			Value: sortValue(last, field, now),
+			ID:    last.ID,
+		})
+	}
+
+	return page, nil
+}
+
+// escapeLike escapes the LIKE wildcards in a literal prefix
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
@@ -2,13 +2,17 @@ package repository
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
+	"strconv"
 	"time"
 
-	_ "github.com/lib/pq" // PostgreSQL driver
+	"github.com/lib/pq" // PostgreSQL driver
 	"github.com/lucas/gokafka/product-service/internal/models"
+	"github.com/lucas/gokafka/shared/locks"
 	sharedModels "github.com/lucas/gokafka/shared/models"
+	"github.com/lucas/gokafka/shared/outbox"
 	"github.com/lucas/gokafka/shared/utils"
 )
 
@@ -21,17 +25,35 @@ const (
 	DefaultPostgresPassword = "postgres"
 )
 
+// ErrVersionConflict is returned when a product changed since the version
+// an update was based on
+var ErrVersionConflict = errors.New("product was modified by another request; fetch it again and retry")
+
+// DefaultLowStockThreshold is used when LOW_STOCK_THRESHOLD is not set
+const DefaultLowStockThreshold = 5
+
 type ProductRepository struct {
-	db *sql.DB
+	db                *sql.DB
+	outbox            *outbox.Outbox
+	lowStockThreshold int
 }
 
 func NewProductRepository() *ProductRepository {
 	db := initDatabase()
-	repo := &ProductRepository{db: db}
+	repo := &ProductRepository{
+		db:                db,
+		outbox:            outbox.New(db, "product_outbox", locks.ProductOutboxRelay),
+		lowStockThreshold: lowStockThreshold(),
+	}
 	repo.createTableIfNotExists()
 	return repo
 }
 
+// Outbox returns the outbox product events are recorded in
+func (r *ProductRepository) Outbox() *outbox.Outbox {
+	return r.outbox
+}
+
 // initDatabase initializes the database connection
 func initDatabase() *sql.DB {
 	host := utils.GetEnvOrDefault("POSTGRES_HOST", DefaultPostgresHost)
@@ -57,6 +79,17 @@ func initDatabase() *sql.DB {
 	return db
 }
 
+// lowStockThreshold reads LOW_STOCK_THRESHOLD; stock below it is low
+func lowStockThreshold() int {
+	value := utils.GetEnvOrDefault("LOW_STOCK_THRESHOLD", strconv.Itoa(DefaultLowStockThreshold))
+	threshold, err := strconv.Atoi(value)
+	if err != nil || threshold < 0 {
+		log.Printf("invalid LOW_STOCK_THRESHOLD %q, using %d", value, DefaultLowStockThreshold)
+		return DefaultLowStockThreshold
+	}
+	return threshold
+}
+
 func (r *ProductRepository) createTableIfNotExists() {
 	query := `
 	CREATE TABLE IF NOT EXISTS products (
@@ -66,119 +99,313 @@ func (r *ProductRepository) createTableIfNotExists() {
 		price NUMERIC(10, 2) NOT NULL,
 		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-	)`
+	);
+	ALTER TABLE products ADD COLUMN IF NOT EXISTS stock INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE products ADD COLUMN IF NOT EXISTS currency VARCHAR(3) NOT NULL DEFAULT 'USD';
+	ALTER TABLE products ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+	ALTER TABLE products ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+	ALTER TABLE products ADD COLUMN IF NOT EXISTS rating_average NUMERIC(3, 2) NOT NULL DEFAULT 0;
+	ALTER TABLE products ADD COLUMN IF NOT EXISTS review_count INTEGER NOT NULL DEFAULT 0;
+	DO $$ BEGIN
+		ALTER TABLE products ADD CONSTRAINT products_stock_non_negative CHECK (stock >= 0);
+	EXCEPTION WHEN duplicate_object THEN NULL;
+	END $$;
+	CREATE INDEX IF NOT EXISTS idx_products_created_at ON products (created_at, id);
+	CREATE INDEX IF NOT EXISTS idx_products_price ON products (price, id);
+	CREATE INDEX IF NOT EXISTS idx_products_name ON products (LOWER(name) text_pattern_ops);
+	CREATE INDEX IF NOT EXISTS idx_products_deleted_at ON products (deleted_at) WHERE deleted_at IS NOT NULL`
 	
 	if _, err := r.db.Exec(query); err != nil {
 		log.Fatalf("failed to create products table: %v", err)
 	}
+
+	r.createOutboxTableIfNotExists()
+	r.createReservationTablesIfNotExists()
+	r.createSearchIndexesIfNotExists()
+	r.createCategoryTablesIfNotExists()
+	r.createImageTableIfNotExists()
+	r.createVariantTableIfNotExists()
+	r.createPriceScheduleTableIfNotExists()
+	r.createHistoryTableIfNotExists()
+	r.createReviewTableIfNotExists()
 	
 	log.Println("Products table is ready")
 }
 
-func (r *ProductRepository) CreateProduct(product *models.Product) error {
+// productColumns lists the columns read by scanProduct, in order
+const productColumns = `id, name, description, price, currency, stock, version, created_at, updated_at, deleted_at,
+	rating_average, review_count`
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanProduct reads a product row, followed by any extra columns into
+// extra. The NUMERIC price is scanned as text so it converts to Money
+// without passing through float64.
+func scanProduct(row rowScanner, extra ...interface{}) (*models.Product, error) {
+	var product models.Product
+	var price, currency string
+	var deletedAt sql.NullTime
+	dest := []interface{}{
+		&product.ID, &product.Name, &product.Description,
+		&price, &currency, &product.Stock, &product.Version,
+		&product.CreatedAt, &product.UpdatedAt, &deletedAt,
+		&product.RatingAverage, &product.ReviewCount,
+	}
+	err := row.Scan(append(dest, extra...)...)
+	if err != nil {
+		return nil, err
+	}
+	if deletedAt.Valid {
+		product.DeletedAt = &deletedAt.Time
+	}
+
+	product.Price, err = sharedModels.ParseMoney(price, currency)
+	if err != nil {
+		return nil, fmt.Errorf("invalid price of product %d: %w", product.ID, err)
+	}
+
+	return &product, nil
+}
+
+func (r *ProductRepository) CreateProduct(product *models.Product, actor string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
 	query := `
-	INSERT INTO products (name, description, price, created_at, updated_at)
-	VALUES ($1, $2, $3, $4, $5)
-	RETURNING id, created_at, updated_at`
+	INSERT INTO products (name, description, price, currency, stock, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)
+	RETURNING id, version, created_at, updated_at`
 	
-	err := r.db.QueryRow(query, product.Name, product.Description, product.Price, 
-		time.Now(), time.Now()).Scan(&product.ID, &product.CreatedAt, &product.UpdatedAt)
+	err = tx.QueryRow(query, product.Name, product.Description, product.Price.Decimal(),
+		product.Price.Currency, product.Stock, time.Now(), time.Now()).Scan(&product.ID, &product.Version, &product.CreatedAt, &product.UpdatedAt)
 	if err != nil {
 		return fmt.Errorf("failed to create product: %w", err)
 	}
+
+	if err := checkCategoriesExist(tx, product.CategoryIDs); err != nil {
+		return err
+	}
+	if err := replaceProductCategories(tx, product.ID, product.CategoryIDs); err != nil {
+		return err
+	}
+	if err := replaceProductTags(tx, product.ID, product.Tags); err != nil {
+		return err
+	}
+	if err := loadDetails(tx, product); err != nil {
+		return err
+	}
+
+	if err := r.insertOutboxEvent(tx, sharedModels.ProductEventCreated, product); err != nil {
+		return err
+	}
+	if err := recordHistory(tx, HistoryActionCreated, actor, product, stockChange(nil, product.Stock)); err != nil {
+		return err
+	}
 	
-	return nil
+	return tx.Commit()
 }
 
 func (r *ProductRepository) GetProductByID(id int) (*models.Product, error) {
 	query := `
-	SELECT id, name, description, price, created_at, updated_at 
-	FROM products WHERE id = $1`
-	
-	var product models.Product
-	err := r.db.QueryRow(query, id).Scan(
-		&product.ID, &product.Name, &product.Description, 
-		&product.Price, &product.CreatedAt, &product.UpdatedAt,
-	)
+	SELECT ` + productColumns + `
+	FROM products WHERE id = $1 AND deleted_at IS NULL`
 	
+	product, err := scanProduct(r.db.QueryRow(query, id))
 	if err != nil {
 		return nil, err
 	}
-	
-	return &product, nil
+	if err := loadDetails(r.db, product); err != nil {
+		return nil, err
+	}
+	return product, nil
 }
 
-func (r *ProductRepository) GetAllProducts() ([]*models.Product, error) {
+// GetProductsByIDs returns the products with the given IDs that exist and
+// are not deleted, keyed by ID
+func (r *ProductRepository) GetProductsByIDs(ids []int) (map[int]*models.Product, error) {
 	query := `
-	SELECT id, name, description, price, created_at, updated_at 
-	FROM products ORDER BY created_at DESC`
-	
-	rows, err := r.db.Query(query)
+	SELECT ` + productColumns + `
+	FROM products WHERE id = ANY($1) AND deleted_at IS NULL`
+
+	rows, err := r.db.Query(query, pq.Array(ids))
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to get products: %w", err)
 	}
-	defer rows.Close()
-	
+
 	var products []*models.Product
 	for rows.Next() {
-		var product models.Product
-		err := rows.Scan(
-			&product.ID, &product.Name, &product.Description,
-			&product.Price, &product.CreatedAt, &product.UpdatedAt,
-		)
+		product, err := scanProduct(rows)
 		if err != nil {
+			rows.Close()
 			return nil, err
 		}
-		products = append(products, &product)
+		products = append(products, product)
 	}
-	
-	return products, nil
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get products: %w", err)
+	}
+
+	if err := loadDetails(r.db, products...); err != nil {
+		return nil, err
+	}
+
+	byID := make(map[int]*models.Product, len(products))
+	for _, product := range products {
+		byID[product.ID] = product
+	}
+	return byID, nil
 }
 
-func (r *ProductRepository) UpdateProduct(product *models.Product) error {
-	query := `
-	UPDATE products 
-	SET name = $1, description = $2, price = $3, updated_at = $4
-	WHERE id = $5
-	RETURNING updated_at`
-	
-	err := r.db.QueryRow(query, product.Name, product.Description, 
-		product.Price, time.Now(), product.ID).Scan(&product.UpdatedAt)
+// touchProduct bumps updated_at and the version, locking the product for
+// the rest of the transaction
+func touchProduct(tx *sql.Tx, productID int) (*models.Product, error) {
+	product, err := scanProduct(tx.QueryRow(`
+	UPDATE products SET updated_at = $1, version = version + 1
+	WHERE id = $2 AND deleted_at IS NULL
+	RETURNING `+productColumns, time.Now(), productID))
+	if err == sql.ErrNoRows {
+		return nil, ErrProductNotFound
+	}
 	if err != nil {
-		return fmt.Errorf("failed to update product: %w", err)
+		return nil, fmt.Errorf("failed to lock product: %w", err)
 	}
-	
-	return nil
+	return product, nil
 }
 
-func (r *ProductRepository) DeleteProduct(id int) error {
-	query := `DELETE FROM products WHERE id = $1`
-	
-	result, err := r.db.Exec(query, id)
+// changeProduct runs change against a product locked by touchProduct, then
+// records the resulting product in a product.updated event and its history,
+// all in one transaction
+func (r *ProductRepository) changeProduct(productID int, action, actor string, change func(tx *sql.Tx, product *models.Product) error) (*models.Product, error) {
+	tx, err := r.db.Begin()
 	if err != nil {
-		return fmt.Errorf("failed to delete product: %w", err)
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
-	
-	rowsAffected, err := result.RowsAffected()
+	defer tx.Rollback()
+
+	product, err := touchProduct(tx, productID)
 	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+		return nil, err
+	}
+
+	if err := change(tx, product); err != nil {
+		return nil, err
+	}
+
+	if err := loadDetails(tx, product); err != nil {
+		return nil, err
+	}
+	if err := r.insertOutboxEvent(tx, sharedModels.ProductEventUpdated, product); err != nil {
+		return nil, err
+	}
+	if err := recordHistory(tx, action, actor, product, nil); err != nil {
+		return nil, err
 	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit: %w", err)
+	}
+	return product, nil
+}
+
+// UpdateProduct saves a product if it is still at product.Version. The
+// version is compared in the UPDATE itself, so of two concurrent updates
+// based on the same version only one succeeds; the other gets
+// ErrVersionConflict.
+func (r *ProductRepository) UpdateProduct(product *models.Product, actor string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+	UPDATE products 
+	SET name = $1, description = $2, price = $3, currency = $4, updated_at = $5, version = version + 1
+	WHERE id = $6 AND deleted_at IS NULL AND version = $7
+	RETURNING stock, version, created_at, updated_at`
 	
-	if rowsAffected == 0 {
-		return fmt.Errorf("product with id %d not found", id)
+	err = tx.QueryRow(query, product.Name, product.Description, 
+		product.Price.Decimal(), product.Price.Currency, time.Now(), product.ID, product.Version).
+		Scan(&product.Stock, &product.Version, &product.CreatedAt, &product.UpdatedAt)
+	if err == sql.ErrNoRows {
+		var exists bool
+		if err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM products WHERE id = $1 AND deleted_at IS NULL)`, product.ID).Scan(&exists); err != nil {
+			return fmt.Errorf("failed to check product %d: %w", product.ID, err)
+		}
+		if !exists {
+			return fmt.Errorf("product with id %d not found", product.ID)
+		}
+		return ErrVersionConflict
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update product: %w", err)
+	}
+
+	if err := r.insertOutboxEvent(tx, sharedModels.ProductEventUpdated, product); err != nil {
+		return err
+	}
+	if err := recordHistory(tx, HistoryActionUpdated, actor, product, nil); err != nil {
+		return err
 	}
 	
-	return nil
+	return tx.Commit()
 }
 
 // Helper method to convert Product to ProductData
 func (r *ProductRepository) ProductToProductData(product *models.Product) *sharedModels.ProductData {
-	return &sharedModels.ProductData{
+	images := make([]sharedModels.ProductImageData, 0, len(product.Images))
+	for _, image := range product.Images {
+		images = append(images, sharedModels.ProductImageData{
+			ID:           image.ID,
+			URL:          image.URL,
+			ThumbnailURL: image.ThumbnailURL,
+			ContentType:  image.ContentType,
+			Width:        image.Width,
+			Height:       image.Height,
+			Position:     image.Position,
+		})
+	}
+
+	variants := make([]sharedModels.ProductVariantData, 0, len(product.Variants))
+	for i := range product.Variants {
+		variants = append(variants, *r.VariantToVariantData(&product.Variants[i]))
+	}
+
+	data := &sharedModels.ProductData{
 		ID:          product.ID,
 		Name:        product.Name,
 		Description: product.Description,
 		Price:       product.Price,
+		Stock:       product.Stock,
+		Version:     product.Version,
+		CategoryIDs: product.CategoryIDs,
+		Tags:        product.Tags,
+		Images:      images,
+		Variants:    variants,
 		CreatedAt:   product.CreatedAt.Format(time.RFC3339),
 		UpdatedAt:   product.UpdatedAt.Format(time.RFC3339),
+		// Ratings of the published reviews
+		RatingAverage: product.RatingAverage,
+		ReviewCount:   product.ReviewCount,
+	}
+	if product.DeletedAt != nil {
+		data.DeletedAt = product.DeletedAt.Format(time.RFC3339)
+	}
+	if schedule := activeSchedule(product, time.Now()); schedule != nil {
+		data.Price = schedule.Price
+		// A schedule without an end is about to become the regular price
+		if schedule.EndsAt != nil {
+			regular := product.Price
+			data.RegularPrice = &regular
+			data.ScheduledPriceEndsAt = schedule.EndsAt.Format(time.RFC3339)
+		}
 	}
+	return data
 }
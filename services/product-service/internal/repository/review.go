@@ -0,0 +1,291 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/lucas/gokafka/product-service/internal/models"
+	sharedModels "github.com/lucas/gokafka/shared/models"
+)
+
+// Pagination limits for ListReviews
+const (
+	DefaultReviewPageSize = 20
+	MaxReviewPageSize     = 100
+)
+
+// Review errors
+var (
+	ErrReviewNotFound  = errors.New("review not found")
+	ErrAlreadyReviewed = errors.New("you have already reviewed this product")
+)
+
+func (r *ProductRepository) createReviewTableIfNotExists() {
+	query := `
+	CREATE TABLE IF NOT EXISTS product_reviews (
+		id SERIAL PRIMARY KEY,
+		product_id INTEGER NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+		user_id VARCHAR(255) NOT NULL,
+		rating SMALLINT NOT NULL CHECK (rating BETWEEN 1 AND 5),
+		text TEXT NOT NULL,
+		status VARCHAR(20) NOT NULL,
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (product_id, user_id)
+	);
+	CREATE INDEX IF NOT EXISTS idx_product_reviews_product ON product_reviews (product_id, id DESC)`
+
+	if _, err := r.db.Exec(query); err != nil {
+		log.Fatalf("failed to create product reviews table: %v", err)
+	}
+}
+
+const reviewColumns = `id, product_id, user_id, rating, text, status, created_at, updated_at`
+
+func scanReview(row rowScanner) (*models.ProductReview, error) {
+	var review models.ProductReview
+	err := row.Scan(&review.ID, &review.ProductID, &review.UserID, &review.Rating, &review.Text,
+		&review.Status, &review.CreatedAt, &review.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrReviewNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
+
+// lockProductForReviews locks a product so concurrent review changes
+// update its rating one after another. Soft deleted products are locked
+// only when includeDeleted is set.
+func lockProductForReviews(tx *sql.Tx, productID int, includeDeleted bool) error {
+	var id int
+	err := tx.QueryRow(`
+	SELECT id FROM products WHERE id = $1 AND ($2 OR deleted_at IS NULL) FOR UPDATE`,
+		productID, includeDeleted).Scan(&id)
+	if err == sql.ErrNoRows {
+		return ErrProductNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to lock product %d: %w", productID, err)
+	}
+	return nil
+}
+
+// refreshRating recomputes the denormalized rating of a product from its
+// published reviews, bumping the product's version so ETags change, and
+// records a product.updated event unless the product is soft deleted. The
+// product must be locked by the transaction, so the statement sees every
+// review committed before it.
+func (r *ProductRepository) refreshRating(tx *sql.Tx, productID int) error {
+	product, err := scanProduct(tx.QueryRow(`
+	UPDATE products
+	SET (rating_average, review_count) = (
+		SELECT COALESCE(ROUND(AVG(rating), 2), 0), COUNT(*)
+		FROM product_reviews WHERE product_id = $1 AND status = $2),
+		updated_at = $3, version = version + 1
+	WHERE id = $1
+	RETURNING `+productColumns, productID, sharedModels.ReviewStatusPublished, time.Now()))
+	if err != nil {
+		return fmt.Errorf("failed to update rating of product %d: %w", productID, err)
+	}
+
+	if product.DeletedAt != nil {
+		return nil
+	}
+	return r.insertOutboxEvent(tx, sharedModels.ProductEventUpdated, product)
+}
+
+// CreateReview publishes a review of a live product and updates the
+// product's rating. A second review by the same user is ErrAlreadyReviewed.
+func (r *ProductRepository) CreateReview(review *models.ProductReview) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockProductForReviews(tx, review.ProductID, false); err != nil {
+		return err
+	}
+
+	query := `
+	INSERT INTO product_reviews (product_id, user_id, rating, text, status, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $6)
+	RETURNING id, created_at, updated_at`
+
+	review.Status = sharedModels.ReviewStatusPublished
+	err = tx.QueryRow(query, review.ProductID, review.UserID, review.Rating, review.Text,
+		review.Status, time.Now()).Scan(&review.ID, &review.CreatedAt, &review.UpdatedAt)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == pqUniqueViolation {
+			return ErrAlreadyReviewed
+		}
+		return fmt.Errorf("failed to create review: %w", err)
+	}
+
+	if err := r.refreshRating(tx, review.ProductID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *ProductRepository) GetReview(id int) (*models.ProductReview, error) {
+	return scanReview(r.db.QueryRow(`SELECT `+reviewColumns+` FROM product_reviews WHERE id = $1`, id))
+}
+
+// ReviewPage is one page of a product's reviews, newest first. NextCursor
+// is empty on the last page.
+type ReviewPage struct {
+	Reviews    []*models.ProductReview
+	NextCursor string
+}
+
+// ListReviews returns a page of the reviews of a live product, filtered by
+// status unless it is empty. The cursor is the ID of the last review of
+// the previous page.
+func (r *ProductRepository) ListReviews(productID int, status, cursor string, limit int) (*ReviewPage, error) {
+	if limit <= 0 {
+		limit = DefaultReviewPageSize
+	}
+	if limit > MaxReviewPageSize {
+		limit = MaxReviewPageSize
+	}
+	var before int
+	if cursor != "" {
+		var err error
+		if before, err = strconv.Atoi(cursor); err != nil || before <= 0 {
+			return nil, ErrInvalidCursor
+		}
+	}
+
+	var exists bool
+	err := r.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM products WHERE id = $1 AND deleted_at IS NULL)`,
+		productID).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check product %d: %w", productID, err)
+	}
+	if !exists {
+		return nil, ErrProductNotFound
+	}
+
+	query := `
+	SELECT ` + reviewColumns + `
+	FROM product_reviews
+	WHERE product_id = $1 AND ($2 = '' OR status = $2) AND ($3::integer = 0 OR id < $3)
+	ORDER BY id DESC LIMIT $4`
+
+	// Fetch one extra row to learn whether another page follows
+	rows, err := r.db.Query(query, productID, status, before, limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load reviews: %w", err)
+	}
+	defer rows.Close()
+
+	page := &ReviewPage{Reviews: []*models.ProductReview{}}
+	for rows.Next() {
+		review, err := scanReview(rows)
+		if err != nil {
+			return nil, err
+		}
+		page.Reviews = append(page.Reviews, review)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(page.Reviews) > limit {
+		page.Reviews = page.Reviews[:limit]
+		page.NextCursor = strconv.Itoa(page.Reviews[limit-1].ID)
+	}
+	return page, nil
+}
+
+// changeReview runs change on a review inside a transaction that holds the
+// lock of its product, then brings the product's rating up to date
+func (r *ProductRepository) changeReview(id int, change func(tx *sql.Tx, review *models.ProductReview) error) (*models.ProductReview, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// The product is locked before the review, in the same order as
+	// CreateReview
+	var productID int
+	err = tx.QueryRow(`SELECT product_id FROM product_reviews WHERE id = $1`, id).Scan(&productID)
+	if err == sql.ErrNoRows {
+		return nil, ErrReviewNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get review: %w", err)
+	}
+	if err := lockProductForReviews(tx, productID, true); err != nil {
+		return nil, err
+	}
+
+	review, err := scanReview(tx.QueryRow(`SELECT `+reviewColumns+` FROM product_reviews WHERE id = $1 FOR UPDATE`, id))
+	if err != nil {
+		return nil, err
+	}
+	if err := change(tx, review); err != nil {
+		return nil, err
+	}
+
+	if err := r.refreshRating(tx, productID); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit: %w", err)
+	}
+	return review, nil
+}
+
+// SetReviewStatus hides or publishes a review. Setting the status it
+// already has changes nothing.
+func (r *ProductRepository) SetReviewStatus(id int, status string) (*models.ProductReview, error) {
+	return r.changeReview(id, func(tx *sql.Tx, review *models.ProductReview) error {
+		if review.Status == status {
+			return nil
+		}
+		err := tx.QueryRow(`
+		UPDATE product_reviews SET status = $1, updated_at = $2
+		WHERE id = $3
+		RETURNING status, updated_at`, status, time.Now(), review.ID).Scan(&review.Status, &review.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to update review: %w", err)
+		}
+		return nil
+	})
+}
+
+// DeleteReview removes a review for good
+func (r *ProductRepository) DeleteReview(id int) error {
+	_, err := r.changeReview(id, func(tx *sql.Tx, review *models.ProductReview) error {
+		if _, err := tx.Exec(`DELETE FROM product_reviews WHERE id = $1`, review.ID); err != nil {
+			return fmt.Errorf("failed to delete review: %w", err)
+		}
+		return nil
+	})
+	return err
+}
+
+// ReviewToReviewData converts a review to its bus form
+func (r *ProductRepository) ReviewToReviewData(review *models.ProductReview) *sharedModels.ReviewData {
+	return &sharedModels.ReviewData{
+		ID:        review.ID,
+		ProductID: review.ProductID,
+		UserID:    review.UserID,
+		Rating:    review.Rating,
+		Text:      review.Text,
+		Status:    review.Status,
+		CreatedAt: review.CreatedAt.Format(time.RFC3339),
+		UpdatedAt: review.UpdatedAt.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,386 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/lucas/gokafka/product-service/internal/models"
+	sharedModels "github.com/lucas/gokafka/shared/models"
+)
+
+// MaxPendingPriceSchedules caps the upcoming and active schedules of a
+// single product
+const MaxPendingPriceSchedules = 20
+
+// Price schedule errors
+var (
+	ErrPriceScheduleNotFound = errors.New("price schedule not found")
+	ErrPriceScheduleOverlap  = errors.New("price schedule overlaps another schedule of the product")
+	ErrPriceScheduleCurrency = errors.New("scheduled price must be in the product's currency")
+	ErrPriceScheduleEnded    = errors.New("price schedule has already ended")
+	ErrPriceSchedulePending  = errors.New("cannot change the currency of a product with pending price schedules")
+	ErrTooManyPriceSchedules = fmt.Errorf("a product cannot have more than %d pending price schedules", MaxPendingPriceSchedules)
+)
+
+func (r *ProductRepository) createPriceScheduleTableIfNotExists() {
+	query := `
+	CREATE TABLE IF NOT EXISTS price_schedules (
+		id SERIAL PRIMARY KEY,
+		product_id INTEGER NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+		name VARCHAR(100) NOT NULL DEFAULT '',
+		price NUMERIC(10, 2) NOT NULL,
+		currency VARCHAR(3) NOT NULL,
+		starts_at TIMESTAMP NOT NULL,
+		ends_at TIMESTAMP,
+		started_at TIMESTAMP,
+		ended_at TIMESTAMP,
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		CHECK (ends_at IS NULL OR ends_at > starts_at)
+	);
+	CREATE INDEX IF NOT EXISTS idx_price_schedules_product ON price_schedules (product_id, starts_at);
+	CREATE INDEX IF NOT EXISTS idx_price_schedules_pending ON price_schedules (starts_at) WHERE ended_at IS NULL`
+
+	if _, err := r.db.Exec(query); err != nil {
+		log.Fatalf("failed to create price schedules table: %v", err)
+	}
+}
+
+const priceScheduleColumns = `id, product_id, name, price, currency, starts_at, ends_at, started_at, ended_at, created_at`
+
+func scanPriceSchedule(row rowScanner) (*models.PriceSchedule, error) {
+	var schedule models.PriceSchedule
+	var price, currency string
+	var endsAt, startedAt, endedAt sql.NullTime
+	err := row.Scan(&schedule.ID, &schedule.ProductID, &schedule.Name, &price, &currency,
+		&schedule.StartsAt, &endsAt, &startedAt, &endedAt, &schedule.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrPriceScheduleNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if endsAt.Valid {
+		schedule.EndsAt = &endsAt.Time
+	}
+	if startedAt.Valid {
+		schedule.StartedAt = &startedAt.Time
+	}
+	if endedAt.Valid {
+		schedule.EndedAt = &endedAt.Time
+	}
+	schedule.Price, err = sharedModels.ParseMoney(price, currency)
+	if err != nil {
+		return nil, fmt.Errorf("invalid price of price schedule %d: %w", schedule.ID, err)
+	}
+	return &schedule, nil
+}
+
+// effectivePriceColumn computes in SQL what effectivePrice computes in Go;
+// %[1]s is the placeholder of the current time
+const effectivePriceColumn = `COALESCE((
+	SELECT s.price FROM price_schedules s
+	WHERE s.product_id = products.id AND s.ended_at IS NULL
+	AND s.starts_at <= %[1]s AND (s.ends_at IS NULL OR s.ends_at > %[1]s)
+	ORDER BY s.starts_at DESC LIMIT 1), price)`
+
+// activeSchedule returns the schedule setting the price of product at now,
+// if any. Schedules do not overlap, so there is at most one.
+func activeSchedule(product *models.Product, now time.Time) *models.PriceSchedule {
+	var active *models.PriceSchedule
+	for i := range product.Schedules {
+		schedule := &product.Schedules[i]
+		if schedule.EndedAt != nil || schedule.StartsAt.After(now) {
+			continue
+		}
+		if schedule.EndsAt != nil && !schedule.EndsAt.After(now) {
+			continue
+		}
+		if active == nil || schedule.StartsAt.After(active.StartsAt) {
+			active = schedule
+		}
+	}
+	return active
+}
+
+// effectivePrice is the price of product at now, including an active price
+// schedule
+func effectivePrice(product *models.Product, now time.Time) sharedModels.Money {
+	if schedule := activeSchedule(product, now); schedule != nil {
+		return schedule.Price
+	}
+	return product.Price
+}
+
+// CreatePriceSchedule schedules a price for a product and records a
+// product.updated event
+func (r *ProductRepository) CreatePriceSchedule(schedule *models.PriceSchedule, actor string) error {
+	_, err := r.changeProduct(schedule.ProductID, HistoryActionPriceScheduleCreated, actor, func(tx *sql.Tx, product *models.Product) error {
+		if schedule.Price.Currency != product.Price.Currency {
+			return ErrPriceScheduleCurrency
+		}
+
+		var pending int
+		err := tx.QueryRow(`SELECT COUNT(*) FROM price_schedules WHERE product_id = $1 AND ended_at IS NULL`,
+			product.ID).Scan(&pending)
+		if err != nil {
+			return fmt.Errorf("failed to count price schedules: %w", err)
+		}
+		if pending >= MaxPendingPriceSchedules {
+			return ErrTooManyPriceSchedules
+		}
+
+		// Two schedules overlap when each starts before the other ends
+		var overlaps bool
+		err = tx.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1 FROM price_schedules
+			WHERE product_id = $1 AND ended_at IS NULL
+			AND (ends_at IS NULL OR ends_at > $2)
+			AND ($3::timestamp IS NULL OR starts_at < $3))`,
+			product.ID, schedule.StartsAt, schedule.EndsAt).Scan(&overlaps)
+		if err != nil {
+			return fmt.Errorf("failed to check price schedules: %w", err)
+		}
+		if overlaps {
+			return ErrPriceScheduleOverlap
+		}
+
+		query := `
+		INSERT INTO price_schedules (product_id, name, price, currency, starts_at, ends_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at`
+
+		err = tx.QueryRow(query, schedule.ProductID, schedule.Name, schedule.Price.Decimal(),
+			schedule.Price.Currency, schedule.StartsAt, schedule.EndsAt, time.Now()).
+			Scan(&schedule.ID, &schedule.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to create price schedule: %w", err)
+		}
+		return nil
+	})
+	return err
+}
+
+// DeletePriceSchedule removes a schedule that has not ended. Deleting an
+// active schedule ends it early.
+func (r *ProductRepository) DeletePriceSchedule(productID, id int, actor string) error {
+	_, err := r.changeProduct(productID, HistoryActionPriceScheduleDeleted, actor, func(tx *sql.Tx, product *models.Product) error {
+		schedule, err := scanPriceSchedule(tx.QueryRow(`
+		DELETE FROM price_schedules WHERE id = $1 AND product_id = $2
+		RETURNING `+priceScheduleColumns, id, productID))
+		if err != nil {
+			if errors.Is(err, ErrPriceScheduleNotFound) {
+				return err
+			}
+			return fmt.Errorf("failed to delete price schedule: %w", err)
+		}
+		if schedule.EndedAt != nil {
+			return ErrPriceScheduleEnded
+		}
+		return nil
+	})
+	return err
+}
+
+// ListPriceSchedules returns every schedule of a live product, including
+// ended ones, in start order
+func (r *ProductRepository) ListPriceSchedules(productID int) ([]models.PriceSchedule, error) {
+	var exists bool
+	err := r.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM products WHERE id = $1 AND deleted_at IS NULL)`, productID).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check product %d: %w", productID, err)
+	}
+	if !exists {
+		return nil, ErrProductNotFound
+	}
+
+	rows, err := r.db.Query(`
+	SELECT `+priceScheduleColumns+`
+	FROM price_schedules WHERE product_id = $1
+	ORDER BY starts_at, id`, productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load price schedules: %w", err)
+	}
+	defer rows.Close()
+
+	schedules := []models.PriceSchedule{}
+	for rows.Next() {
+		schedule, err := scanPriceSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, *schedule)
+	}
+	return schedules, rows.Err()
+}
+
+// AnnouncePriceSchedules records the schedules of up to limit live products
+// that started or ended by now, and publishes a product.price_changed event
+// for each product. A schedule without an end is applied once it starts: its
+// price is written to the product and the schedule ends. It returns the
+// number of products handled. SKIP LOCKED lets replicas announce in
+// parallel; a product locked by an admin edit is picked up on the next run.
+func (r *ProductRepository) AnnouncePriceSchedules(now time.Time, limit int) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`
+	SELECT `+productColumns+`
+	FROM products
+	WHERE deleted_at IS NULL AND id IN (
+		SELECT product_id FROM price_schedules
+		WHERE ended_at IS NULL AND starts_at <= $1
+		AND (started_at IS NULL OR ends_at IS NULL OR ends_at <= $1))
+	ORDER BY id
+	LIMIT $2
+	FOR UPDATE SKIP LOCKED`, now, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find due price schedules: %w", err)
+	}
+
+	var products []*models.Product
+	var ids []int
+	for rows.Next() {
+		product, err := scanProduct(rows)
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+		products = append(products, product)
+		ids = append(ids, product.ID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if len(products) == 0 {
+		return 0, nil
+	}
+
+	rows, err = tx.Query(`
+	UPDATE price_schedules
+	SET started_at = COALESCE(started_at, $2),
+		ended_at = CASE WHEN ends_at IS NULL OR ends_at <= $2 THEN $2 END
+	WHERE product_id = ANY($1) AND ended_at IS NULL AND starts_at <= $2
+	AND (started_at IS NULL OR ends_at IS NULL OR ends_at <= $2)
+	RETURNING `+priceScheduleColumns, pq.Array(ids), now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to update price schedules: %w", err)
+	}
+	applied := make(map[int]*models.PriceSchedule)
+	for rows.Next() {
+		schedule, err := scanPriceSchedule(rows)
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+		if schedule.EndsAt == nil {
+			applied[schedule.ProductID] = schedule
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, product := range products {
+		action := HistoryActionScheduledPriceChanged
+		if schedule, ok := applied[product.ID]; ok {
+			action = HistoryActionPriceScheduleApplied
+			updated, err := scanProduct(tx.QueryRow(`
+			UPDATE products SET price = $1, updated_at = $2, version = version + 1
+			WHERE id = $3
+			RETURNING `+productColumns, schedule.Price.Decimal(), now, product.ID))
+			if err != nil {
+				return 0, fmt.Errorf("failed to apply price schedule %d: %w", schedule.ID, err)
+			}
+			*product = *updated
+		}
+
+		if err := loadDetails(tx, product); err != nil {
+			return 0, err
+		}
+		if err := r.insertOutboxEvent(tx, sharedModels.ProductEventPriceChanged, product); err != nil {
+			return 0, err
+		}
+		if err := recordHistory(tx, action, "", product, nil); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit: %w", err)
+	}
+	return len(products), nil
+}
+
+// loadPriceSchedules fills in the schedules of products that have not
+// ended, in start order
+func loadPriceSchedules(q queryer, products ...*models.Product) error {
+	if len(products) == 0 {
+		return nil
+	}
+
+	byID := make(map[int]*models.Product, len(products))
+	ids := make([]int, 0, len(products))
+	for _, product := range products {
+		product.Schedules = []models.PriceSchedule{}
+		byID[product.ID] = product
+		ids = append(ids, product.ID)
+	}
+
+	rows, err := q.Query(`
+	SELECT `+priceScheduleColumns+`
+	FROM price_schedules WHERE product_id = ANY($1) AND ended_at IS NULL
+	ORDER BY product_id, starts_at`, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to load price schedules: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		schedule, err := scanPriceSchedule(rows)
+		if err != nil {
+			return err
+		}
+		product := byID[schedule.ProductID]
+		product.Schedules = append(product.Schedules, *schedule)
+	}
+	return rows.Err()
+}
+
+// PriceScheduleToData converts a price schedule to its bus form, with its
+// status at now
+func (r *ProductRepository) PriceScheduleToData(schedule *models.PriceSchedule, now time.Time) *sharedModels.PriceScheduleData {
+	data := &sharedModels.PriceScheduleData{
+		ID:        schedule.ID,
+		ProductID: schedule.ProductID,
+		Name:      schedule.Name,
+		Price:     schedule.Price,
+		StartsAt:  schedule.StartsAt.Format(time.RFC3339),
+		CreatedAt: schedule.CreatedAt.Format(time.RFC3339),
+	}
+	if schedule.EndsAt != nil {
+		data.EndsAt = schedule.EndsAt.Format(time.RFC3339)
+	}
+
+	switch {
+	case schedule.EndsAt == nil && schedule.EndedAt != nil:
+		data.Status = sharedModels.PriceScheduleStatusApplied
+	case schedule.EndedAt != nil || (schedule.EndsAt != nil && !schedule.EndsAt.After(now)):
+		data.Status = sharedModels.PriceScheduleStatusEnded
+	case schedule.StartsAt.After(now):
+		data.Status = sharedModels.PriceScheduleStatusScheduled
+	default:
+		data.Status = sharedModels.PriceScheduleStatusActive
+	}
+	return data
+}
@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"html"
+	"log"
+	"strings"
+	"unicode"
+
+	"github.com/lucas/gokafka/product-service/internal/models"
+)
+
+// Search limits for SearchProducts
+const (
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 50
+)
+
+// ts_headline marks matched terms with these private use characters rather
+// than with tags, so the text around them can be HTML escaped before the
+// marks become <mark> tags. They are stripped from the text first so
+// product data cannot fake a mark.
+const (
+	highlightStart = "\uE000"
+	highlightStop  = "\uE001"
+)
+
+// searchHeadlineOptions marks matched terms in highlights and snippets
+const searchHeadlineOptions = "StartSel=" + highlightStart + ", StopSel=" + highlightStop +
+	", MaxFragments=2, MaxWords=20, MinWords=5"
+
+// highlightMarkup turns the sentinel marks into <mark> tags
+var highlightMarkup = strings.NewReplacer(highlightStart, "<mark>", highlightStop, "</mark>")
+
+// SearchHit is a product matching a search, with its relevance and the
+// HTML escaped name and description excerpt with matched terms wrapped in
+// <mark> tags
+type SearchHit struct {
+	Product         *models.Product
+	Rank            float64
+	HighlightedName string
+	Snippet         string
+}
+
+// createSearchIndexesIfNotExists adds the generated tsvector column and the
+// GIN indexes used by SearchProducts. Names weigh more than descriptions.
+func (r *ProductRepository) createSearchIndexesIfNotExists() {
+	query := `
+	CREATE EXTENSION IF NOT EXISTS pg_trgm;
+	ALTER TABLE products ADD COLUMN IF NOT EXISTS search_vector tsvector
+		GENERATED ALWAYS AS (
+			setweight(to_tsvector('english', coalesce(name, '')), 'A') ||
+			setweight(to_tsvector('english', coalesce(description, '')), 'B')
+		) STORED;
+	CREATE INDEX IF NOT EXISTS idx_products_search ON products USING GIN (search_vector);
+	CREATE INDEX IF NOT EXISTS idx_products_name_trgm ON products USING GIN (name gin_trgm_ops)`
+
+	if _, err := r.db.Exec(query); err != nil {
+		log.Fatalf("failed to create product search indexes: %v", err)
+	}
+}
+
+// prefixTSQuery turns free text into a tsquery matching every word as a
+// prefix, e.g. "red sho" becomes "red:* & sho:*". Punctuation is dropped so
+// user input can never be a tsquery syntax error.
+func prefixTSQuery(text string) string {
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	terms := make([]string, 0, len(words))
+	for _, word := range words {
+		terms = append(terms, strings.ToLower(word)+":*")
+	}
+	return strings.Join(terms, " & ")
+}
+
+// SearchProducts runs a full-text search over names and descriptions. Words
+// match as prefixes, and names within trigram distance of the text also
+// match so that small typos still find the product.
+func (r *ProductRepository) SearchProducts(text string, limit, offset int) ([]*SearchHit, error) {
+	if limit <= 0 {
+		limit = DefaultSearchLimit
+	}
+	if limit > MaxSearchLimit {
+		limit = MaxSearchLimit
+	}
+
+	tsquery := prefixTSQuery(text)
+	if tsquery == "" {
+		return []*SearchHit{}, nil
+	}
+
+	query := `
+	WITH q AS (SELECT to_tsquery('english', $1) AS query)
+	SELECT ` + prefixColumns("p.", productColumns) + `,
+		ts_rank(p.search_vector, q.query) + word_similarity($2, p.name) AS rank,
+		ts_headline('english', translate(p.name, $6, ''), q.query, $3),
+		ts_headline('english', translate(coalesce(p.description, ''), $6, ''), q.query, $3)
+	FROM products p, q
+	WHERE (p.search_vector @@ q.query OR $2 <% p.name) AND p.deleted_at IS NULL
+	ORDER BY rank DESC, p.id
+	LIMIT $4 OFFSET $5`
+
+	rows, err := r.db.Query(query, tsquery, text, searchHeadlineOptions, limit, offset,
+		highlightStart+highlightStop)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hits := []*SearchHit{}
+	for rows.Next() {
+		var hit SearchHit
+		hit.Product, err = scanProduct(rows, &hit.Rank, &hit.HighlightedName, &hit.Snippet)
+		if err != nil {
+			return nil, err
+		}
+		hit.HighlightedName = highlightHTML(hit.HighlightedName)
+		hit.Snippet = highlightHTML(hit.Snippet)
+		hits = append(hits, &hit)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	products := make([]*models.Product, 0, len(hits))
+	for _, hit := range hits {
+		products = append(products, hit.Product)
+	}
+	if err := loadDetails(r.db, products...); err != nil {
+		return nil, err
+	}
+
+	return hits, nil
+}
+
+// highlightHTML escapes a ts_headline result and wraps its matches in
+// <mark> tags
+func highlightHTML(headline string) string {
+	return highlightMarkup.Replace(html.EscapeString(headline))
+}
+
+// prefixColumns qualifies each column of a comma separated list
+func prefixColumns(prefix, columns string) string {
+	fields := strings.Split(columns, ",")
+	for i, field := range fields {
+		fields[i] = prefix + strings.TrimSpace(field)
+	}
+	return strings.Join(fields, ", ")
+}
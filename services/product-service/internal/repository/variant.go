@@ -0,0 +1,229 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/lucas/gokafka/product-service/internal/models"
+	sharedModels "github.com/lucas/gokafka/shared/models"
+)
+
+// MaxVariantsPerProduct caps the variants of a single product
+const MaxVariantsPerProduct = 100
+
+// Variant errors
+var (
+	ErrVariantNotFound = errors.New("variant not found")
+	ErrSKUTaken        = errors.New("SKU is already in use")
+	ErrVariantExists   = errors.New("product already has a variant with these attributes")
+	ErrVariantCurrency = errors.New("variant price must be in the product's currency")
+	ErrTooManyVariants = fmt.Errorf("a product cannot have more than %d variants", MaxVariantsPerProduct)
+)
+
+func (r *ProductRepository) createVariantTableIfNotExists() {
+	query := `
+	CREATE TABLE IF NOT EXISTS product_variants (
+		id SERIAL PRIMARY KEY,
+		product_id INTEGER NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+		sku VARCHAR(64) NOT NULL,
+		attributes JSONB NOT NULL DEFAULT '{}',
+		price NUMERIC(10, 2),
+		currency VARCHAR(3),
+		stock INTEGER NOT NULL DEFAULT 0 CHECK (stock >= 0),
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		CONSTRAINT product_variants_sku_key UNIQUE (sku)
+	);
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_product_variants_attributes
+		ON product_variants (product_id, attributes)`
+
+	if _, err := r.db.Exec(query); err != nil {
+		log.Fatalf("failed to create product variants table: %v", err)
+	}
+}
+
+const variantColumns = `id, product_id, sku, attributes, price, currency, stock, created_at, updated_at`
+
+func scanVariant(row rowScanner) (*models.ProductVariant, error) {
+	var variant models.ProductVariant
+	var attributes []byte
+	var price, currency sql.NullString
+	err := row.Scan(&variant.ID, &variant.ProductID, &variant.SKU, &attributes,
+		&price, &currency, &variant.Stock, &variant.CreatedAt, &variant.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrVariantNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(attributes, &variant.Attributes); err != nil {
+		return nil, fmt.Errorf("invalid attributes of variant %d: %w", variant.ID, err)
+	}
+	if price.Valid {
+		money, err := sharedModels.ParseMoney(price.String, currency.String)
+		if err != nil {
+			return nil, fmt.Errorf("invalid price of variant %d: %w", variant.ID, err)
+		}
+		variant.Price = &money
+	}
+	return &variant, nil
+}
+
+// variantWriteError maps unique violations to the variant errors
+func variantWriteError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == pqUniqueViolation {
+		if pqErr.Constraint == "product_variants_sku_key" {
+			return ErrSKUTaken
+		}
+		return ErrVariantExists
+	}
+	return err
+}
+
+// variantPrice returns the price columns of a variant; both are NULL when
+// the variant uses the product price
+func variantPrice(variant *models.ProductVariant) (interface{}, interface{}) {
+	if variant.Price == nil {
+		return nil, nil
+	}
+	return variant.Price.Decimal(), variant.Price.Currency
+}
+
+// CreateVariant adds a variant to a product and records a product.updated
+// event
+func (r *ProductRepository) CreateVariant(variant *models.ProductVariant, actor string) error {
+	_, err := r.changeProduct(variant.ProductID, HistoryActionVariantCreated, actor, func(tx *sql.Tx, product *models.Product) error {
+		if variant.Price != nil && variant.Price.Currency != product.Price.Currency {
+			return ErrVariantCurrency
+		}
+
+		var count int
+		if err := tx.QueryRow(`SELECT COUNT(*) FROM product_variants WHERE product_id = $1`, variant.ProductID).Scan(&count); err != nil {
+			return fmt.Errorf("failed to count product variants: %w", err)
+		}
+		if count >= MaxVariantsPerProduct {
+			return ErrTooManyVariants
+		}
+
+		attributes, err := json.Marshal(variant.Attributes)
+		if err != nil {
+			return fmt.Errorf("failed to serialize attributes: %w", err)
+		}
+		price, currency := variantPrice(variant)
+
+		query := `
+		INSERT INTO product_variants (product_id, sku, attributes, price, currency, stock, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $7)
+		RETURNING id, created_at, updated_at`
+
+		err = tx.QueryRow(query, variant.ProductID, variant.SKU, string(attributes), price, currency,
+			variant.Stock, time.Now()).Scan(&variant.ID, &variant.CreatedAt, &variant.UpdatedAt)
+		if err != nil {
+			return variantWriteError(err)
+		}
+		return nil
+	})
+	return err
+}
+
+// UpdateVariant replaces the SKU, attributes, price and stock of a variant
+func (r *ProductRepository) UpdateVariant(variant *models.ProductVariant, actor string) error {
+	_, err := r.changeProduct(variant.ProductID, HistoryActionVariantUpdated, actor, func(tx *sql.Tx, product *models.Product) error {
+		if variant.Price != nil && variant.Price.Currency != product.Price.Currency {
+			return ErrVariantCurrency
+		}
+
+		attributes, err := json.Marshal(variant.Attributes)
+		if err != nil {
+			return fmt.Errorf("failed to serialize attributes: %w", err)
+		}
+		price, currency := variantPrice(variant)
+
+		query := `
+		UPDATE product_variants
+		SET sku = $1, attributes = $2, price = $3, currency = $4, stock = $5, updated_at = $6
+		WHERE id = $7 AND product_id = $8
+		RETURNING created_at, updated_at`
+
+		err = tx.QueryRow(query, variant.SKU, string(attributes), price, currency, variant.Stock,
+			time.Now(), variant.ID, variant.ProductID).Scan(&variant.CreatedAt, &variant.UpdatedAt)
+		if err == sql.ErrNoRows {
+			return ErrVariantNotFound
+		}
+		if err != nil {
+			return variantWriteError(err)
+		}
+		return nil
+	})
+	return err
+}
+
+// DeleteVariant removes a variant from a product
+func (r *ProductRepository) DeleteVariant(productID, id int, actor string) error {
+	_, err := r.changeProduct(productID, HistoryActionVariantDeleted, actor, func(tx *sql.Tx, product *models.Product) error {
+		result, err := tx.Exec(`DELETE FROM product_variants WHERE id = $1 AND product_id = $2`, id, productID)
+		if err != nil {
+			return fmt.Errorf("failed to delete variant: %w", err)
+		}
+		if rows, _ := result.RowsAffected(); rows == 0 {
+			return ErrVariantNotFound
+		}
+		return nil
+	})
+	return err
+}
+
+// loadVariants fills in the variants of products, ordered by ID
+func loadVariants(q queryer, products ...*models.Product) error {
+	if len(products) == 0 {
+		return nil
+	}
+
+	byID := make(map[int]*models.Product, len(products))
+	ids := make([]int, 0, len(products))
+	for _, product := range products {
+		product.Variants = []models.ProductVariant{}
+		byID[product.ID] = product
+		ids = append(ids, product.ID)
+	}
+
+	rows, err := q.Query(`
+	SELECT `+variantColumns+`
+	FROM product_variants WHERE product_id = ANY($1)
+	ORDER BY product_id, id`, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to load product variants: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		variant, err := scanVariant(rows)
+		if err != nil {
+			return err
+		}
+		product := byID[variant.ProductID]
+		product.Variants = append(product.Variants, *variant)
+	}
+	return rows.Err()
+}
+
+// VariantToVariantData converts a variant to its bus form
+func (r *ProductRepository) VariantToVariantData(variant *models.ProductVariant) *sharedModels.ProductVariantData {
+	return &sharedModels.ProductVariantData{
+		ID:         variant.ID,
+		ProductID:  variant.ProductID,
+		SKU:        variant.SKU,
+		Attributes: variant.Attributes,
+		Price:      variant.Price,
+		Stock:      variant.Stock,
+		CreatedAt:  variant.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:  variant.UpdatedAt.Format(time.RFC3339),
+	}
+}
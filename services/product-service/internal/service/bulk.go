@@ -0,0 +1,385 @@
+package service
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/lucas/gokafka/product-service/internal/models"
+	"github.com/lucas/gokafka/product-service/internal/repository"
+	sharedModels "github.com/lucas/gokafka/shared/models"
+)
+
+// Bulk transfer formats
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+)
+
+// Import limits
+const (
+	ImportBatchSize  = 100
+	MaxImportRows    = 10000
+	maxNDJSONLineLen = 1 << 20
+)
+
+// csvColumns is the CSV layout written by exports. Imports need a header row
+// with at least name and price; list columns are separated by "|".
+var csvColumns = []string{"id", "name", "description", "price", "currency", "stock", "category_ids", "tags"}
+
+// ErrUnsupportedFormat is returned for formats other than CSV and NDJSON
+var ErrUnsupportedFormat = errors.New("format must be csv or ndjson")
+
+// importRecord is one product as read from an import file
+type importRecord struct {
+	ID          int                `json:"id"`
+	Name        string             `json:"name"`
+	Description string             `json:"description"`
+	Price       sharedModels.Money `json:"price"`
+	Stock       *int               `json:"stock"`
+	CategoryIDs []int              `json:"category_ids"`
+	Tags        []string           `json:"tags"`
+	// RegularPrice is set by NDJSON exports taken during a time-limited
+	// price schedule, whose price is exported in Price
+	RegularPrice *sharedModels.Money `json:"regular_price"`
+}
+
+// importer validates records and saves them in batches, collecting the
+// report as it goes
+type importer struct {
+	service *ProductService
+	actor   string
+	// save upserts a batch, normally the repository's ImportProducts
+	save   func(rows []repository.ImportRow, actor string) ([]repository.ImportResult, error)
+	batch  []repository.ImportRow
+	report sharedModels.ImportReport
+}
+
+// ImportProducts reads products from r and upserts them: rows with an id
+// update that product, rows without one create a product. Invalid rows are
+// reported and skipped; a malformed file stops the import, keeping the
+// batches already saved. Changes are recorded in history as made by actor.
+func (s *ProductService) ImportProducts(format string, r io.Reader, actor string) (*sharedModels.ImportReport, error) {
+	imp := &importer{
+		service: s,
+		actor:   actor,
+		save:    s.repo.ImportProducts,
+		report:  sharedModels.ImportReport{Errors: []sharedModels.ImportRowError{}},
+	}
+	return imp.run(format, r)
+}
+
+// run reads the whole file and saves its last batch
+func (imp *importer) run(format string, r io.Reader) (*sharedModels.ImportReport, error) {
+	var err error
+	switch format {
+	case FormatCSV:
+		err = imp.readCSV(r)
+	case FormatNDJSON:
+		err = imp.readNDJSON(r)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+	if err == nil {
+		err = imp.flush()
+	}
+	return &imp.report, err
+}
+
+func (imp *importer) readCSV(r io.Reader) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	// Excel prefixes UTF-8 CSV files with a byte order mark
+	header, err := reader.Read()
+	if err == io.EOF {
+		return fmt.Errorf("CSV file is empty")
+	}
+	if err != nil {
+		return fmt.Errorf("invalid CSV header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if !slices.Contains(csvColumns, name) {
+			return fmt.Errorf("unknown CSV column %q", name)
+		}
+		if _, ok := columns[name]; ok {
+			return fmt.Errorf("duplicate CSV column %q", name)
+		}
+		columns[name] = i
+	}
+	for _, required := range []string{"name", "price"} {
+		if _, ok := columns[required]; !ok {
+			return fmt.Errorf("CSV header must include a %s column", required)
+		}
+	}
+
+	for {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		line, _ := reader.FieldPos(0)
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) && parseErr.Err == csv.ErrFieldCount {
+				imp.fail(line, 0, err)
+				continue
+			}
+			return fmt.Errorf("invalid CSV: %w", err)
+		}
+		if len(fields) != len(header) {
+			imp.fail(line, 0, fmt.Errorf("expected %d fields, got %d", len(header), len(fields)))
+			continue
+		}
+
+		record, err := parseCSVRecord(columns, fields)
+		if err != nil {
+			imp.fail(line, record.ID, err)
+			continue
+		}
+		if err := imp.add(line, record); err != nil {
+			return err
+		}
+	}
+}
+
+// parseCSVRecord converts the fields of one CSV row. The record is returned
+// with its ID even on error so the report can name the product.
+func parseCSVRecord(columns map[string]int, fields []string) (importRecord, error) {
+	var record importRecord
+	field := func(name string) string {
+		if i, ok := columns[name]; ok {
+			return strings.TrimSpace(fields[i])
+		}
+		return ""
+	}
+
+	if value := field("id"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil || id <= 0 {
+			return record, fmt.Errorf("invalid id %q", value)
+		}
+		record.ID = id
+	}
+	record.Name = field("name")
+	record.Description = field("description")
+
+	currency := strings.ToUpper(field("currency"))
+	if currency == "" {
+		currency = sharedModels.DefaultCurrency
+	}
+	price, err := sharedModels.ParseMoney(field("price"), currency)
+	if err != nil {
+		return record, fmt.Errorf("invalid price: %w", err)
+	}
+	record.Price = price
+
+	if value := field("stock"); value != "" {
+		stock, err := strconv.Atoi(value)
+		if err != nil {
+			return record, fmt.Errorf("invalid stock %q", value)
+		}
+		record.Stock = &stock
+	}
+
+	for _, value := range splitList(field("category_ids")) {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			return record, fmt.Errorf("invalid category id %q", value)
+		}
+		record.CategoryIDs = append(record.CategoryIDs, id)
+	}
+	record.Tags = splitList(field("tags"))
+
+	return record, nil
+}
+
+func (imp *importer) readNDJSON(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxNDJSONLineLen)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		var record importRecord
+		if err := json.Unmarshal([]byte(text), &record); err != nil {
+			imp.fail(line, 0, fmt.Errorf("invalid JSON: %w", err))
+			continue
+		}
+		// Importing the scheduled price would keep it after the sale ends
+		if record.RegularPrice != nil {
+			record.Price = *record.RegularPrice
+		}
+		if err := imp.add(line, record); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("invalid NDJSON after line %d: %w", line, err)
+	}
+	return nil
+}
+
+// add validates a record and queues it, saving the batch once it is full
+func (imp *importer) add(line int, record importRecord) error {
+	if imp.report.Total >= MaxImportRows {
+		return fmt.Errorf("imports are limited to %d rows", MaxImportRows)
+	}
+	imp.report.Total++
+
+	product, err := imp.service.importProduct(record)
+	if err != nil {
+		imp.report.Failed++
+		imp.report.Errors = append(imp.report.Errors, sharedModels.ImportRowError{
+			Line: line, ID: record.ID, Error: err.Error(),
+		})
+		return nil
+	}
+
+	imp.batch = append(imp.batch, repository.ImportRow{Line: line, Product: product, Stock: record.Stock})
+	if len(imp.batch) >= ImportBatchSize {
+		return imp.flush()
+	}
+	return nil
+}
+
+// fail records a row that could not be parsed
+func (imp *importer) fail(line, id int, err error) {
+	imp.report.Total++
+	imp.report.Failed++
+	imp.report.Errors = append(imp.report.Errors, sharedModels.ImportRowError{
+		Line: line, ID: id, Error: err.Error(),
+	})
+}
+
+// flush saves the queued rows and adds their outcomes to the report
+func (imp *importer) flush() error {
+	if len(imp.batch) == 0 {
+		return nil
+	}
+
+	results, err := imp.save(imp.batch, imp.actor)
+	if err != nil {
+		return fmt.Errorf("failed to import products: %w", err)
+	}
+	imp.batch = imp.batch[:0]
+
+	for _, result := range results {
+		switch {
+		case result.Err != nil:
+			imp.report.Failed++
+			imp.report.Errors = append(imp.report.Errors, sharedModels.ImportRowError{
+				Line: result.Line, ID: result.ID, Error: result.Err.Error(),
+			})
+		case result.Action == repository.ImportActionCreated:
+			imp.report.Created++
+		default:
+			imp.report.Updated++
+		}
+	}
+	return nil
+}
+
+// importProduct applies the same rules as CreateProduct to an import record
+func (s *ProductService) importProduct(record importRecord) (*models.Product, error) {
+	if record.ID < 0 {
+		return nil, fmt.Errorf("invalid product ID")
+	}
+	if strings.TrimSpace(record.Name) == "" {
+		return nil, fmt.Errorf("product name is required")
+	}
+	if !record.Price.IsPositive() {
+		return nil, fmt.Errorf("product price must be greater than 0")
+	}
+	if !s.converter.Supports(record.Price.Currency) {
+		return nil, fmt.Errorf("price currency %s is not supported", record.Price.Currency)
+	}
+	if record.Stock != nil && *record.Stock < 0 {
+		return nil, fmt.Errorf("product stock cannot be negative")
+	}
+	categoryIDs, err := normalizeCategoryIDs(record.CategoryIDs)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := normalizeTags(record.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.Product{
+		ID:          record.ID,
+		Name:        record.Name,
+		Description: record.Description,
+		Price:       record.Price,
+		CategoryIDs: categoryIDs,
+		Tags:        tags,
+	}, nil
+}
+
+// ExportProducts writes the whole catalogue to w. CSV rows follow
+// csvColumns and hold the regular price; NDJSON lines are full ProductData
+// objects, whose regular_price the importer restores.
+func (s *ProductService) ExportProducts(format string, w io.Writer) error {
+	switch format {
+	case FormatCSV:
+		writer := csv.NewWriter(w)
+		if err := writer.Write(csvColumns); err != nil {
+			return err
+		}
+		err := s.repo.ExportProducts(func(product *models.Product) error {
+			categoryIDs := make([]string, 0, len(product.CategoryIDs))
+			for _, id := range product.CategoryIDs {
+				categoryIDs = append(categoryIDs, strconv.Itoa(id))
+			}
+			return writer.Write([]string{
+				strconv.Itoa(product.ID),
+				product.Name,
+				product.Description,
+				product.Price.Decimal(),
+				product.Price.Currency,
+				strconv.Itoa(product.Stock),
+				strings.Join(categoryIDs, "|"),
+				strings.Join(product.Tags, "|"),
+			})
+		})
+		if err != nil {
+			return err
+		}
+		writer.Flush()
+		return writer.Error()
+
+	case FormatNDJSON:
+		encoder := json.NewEncoder(w)
+		return s.repo.ExportProducts(func(product *models.Product) error {
+			return encoder.Encode(s.productToProductData(product))
+		})
+
+	default:
+		return ErrUnsupportedFormat
+	}
+}
+
+// splitList splits a "|" separated CSV field, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, "|") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
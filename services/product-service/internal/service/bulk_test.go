@@ -0,0 +1,258 @@
+package service
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/lucas/gokafka/product-service/internal/currency"
+	"github.com/lucas/gokafka/product-service/internal/repository"
+	sharedModels "github.com/lucas/gokafka/shared/models"
+)
+
+func TestParseCSVRecord(t *testing.T) {
+	columns := map[string]int{}
+	for i, name := range csvColumns {
+		columns[name] = i
+	}
+	stock := func(n int) *int { return &n }
+
+	tests := []struct {
+		name    string
+		columns map[string]int
+		fields  []string
+		want    importRecord
+		wantErr bool
+	}{
+		{
+			name:   "all columns",
+			fields: []string{"7", "Lamp", "Desk lamp", "19.99", "EUR", "5", "1|2", "desk|light"},
+			want: importRecord{
+				ID: 7, Name: "Lamp", Description: "Desk lamp", Price: sharedModels.NewMoney(1999, "EUR"),
+				Stock: stock(5), CategoryIDs: []int{1, 2}, Tags: []string{"desk", "light"},
+			},
+		},
+		{
+			name:    "name and price only",
+			columns: map[string]int{"name": 0, "price": 1},
+			fields:  []string{"Lamp", "19.99"},
+			want:    importRecord{Name: "Lamp", Price: sharedModels.NewMoney(1999, sharedModels.DefaultCurrency)},
+		},
+		{
+			name:   "empty optional fields",
+			fields: []string{"", "Lamp", "", "19.99", "", "", "", ""},
+			want:   importRecord{Name: "Lamp", Price: sharedModels.NewMoney(1999, sharedModels.DefaultCurrency)},
+		},
+		{
+			name:   "fields are trimmed",
+			fields: []string{" 7 ", " Lamp ", "", " 19.99 ", " eur ", " 5 ", "", ""},
+			want:   importRecord{ID: 7, Name: "Lamp", Price: sharedModels.NewMoney(1999, "EUR"), Stock: stock(5)},
+		},
+		{
+			name:   "lists skip empty entries",
+			fields: []string{"", "Lamp", "", "19.99", "", "", "|1||2|", " desk | | light "},
+			want: importRecord{
+				Name: "Lamp", Price: sharedModels.NewMoney(1999, sharedModels.DefaultCurrency),
+				CategoryIDs: []int{1, 2}, Tags: []string{"desk", "light"},
+			},
+		},
+		{
+			name:   "zero decimal currency",
+			fields: []string{"", "Lamp", "", "1500", "JPY", "", "", ""},
+			want:   importRecord{Name: "Lamp", Price: sharedModels.NewMoney(1500, "JPY")},
+		},
+		{name: "invalid id", fields: []string{"seven", "Lamp", "", "19.99", "", "", "", ""}, wantErr: true},
+		{name: "negative id", fields: []string{"-7", "Lamp", "", "19.99", "", "", "", ""}, wantErr: true},
+		{name: "price not a number", fields: []string{"7", "Lamp", "", "cheap", "", "", "", ""}, want: importRecord{ID: 7, Name: "Lamp"}, wantErr: true},
+		{name: "price too precise", fields: []string{"", "Lamp", "", "19.999", "", "", "", ""}, wantErr: true},
+		{name: "price fraction in zero decimal currency", fields: []string{"", "Lamp", "", "15.5", "JPY", "", "", ""}, wantErr: true},
+		{name: "missing price", fields: []string{"", "Lamp", "", "", "", "", "", ""}, wantErr: true},
+		{name: "invalid currency", fields: []string{"", "Lamp", "", "19.99", "EURO", "", "", ""}, wantErr: true},
+		{name: "invalid stock", fields: []string{"", "Lamp", "", "19.99", "", "many", "", ""}, wantErr: true},
+		{name: "invalid category id", fields: []string{"", "Lamp", "", "19.99", "", "", "1|two", ""}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cols := tt.columns
+			if cols == nil {
+				cols = columns
+			}
+			got, err := parseCSVRecord(cols, tt.fields)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseCSVRecord(%q) = %+v, want error", tt.fields, got)
+				}
+				// The ID is kept so the report can name the product
+				if got.ID != tt.want.ID {
+					t.Errorf("parseCSVRecord(%q) ID = %d, want %d", tt.fields, got.ID, tt.want.ID)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseCSVRecord(%q) returned error: %v", tt.fields, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseCSVRecord(%q) = %+v, want %+v", tt.fields, got, tt.want)
+			}
+		})
+	}
+}
+
+// testImporter returns an importer whose batches are saved by a fake that
+// creates rows without an ID, updates the others and fails product 9.
+// Saved rows are appended to saved.
+func testImporter(t *testing.T, saved *[]repository.ImportRow) *importer {
+	t.Helper()
+	t.Setenv("EXCHANGE_RATES_FILE", filepath.Join(t.TempDir(), "rates.json"))
+
+	return &importer{
+		service: &ProductService{converter: currency.NewConverter()},
+		save: func(rows []repository.ImportRow, actor string) ([]repository.ImportResult, error) {
+			results := make([]repository.ImportResult, 0, len(rows))
+			for _, row := range rows {
+				*saved = append(*saved, row)
+				result := repository.ImportResult{Line: row.Line, ID: row.Product.ID, Action: repository.ImportActionUpdated}
+				switch {
+				case row.Product.ID == 9:
+					result.Err = errors.New("product not found")
+				case row.Product.ID == 0:
+					result.Action = repository.ImportActionCreated
+				}
+				results = append(results, result)
+			}
+			return results, nil
+		},
+		report: sharedModels.ImportReport{Errors: []sharedModels.ImportRowError{}},
+	}
+}
+
+func TestReadCSVHeader(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		wantErr bool
+	}{
+		{name: "export layout", file: "id,name,description,price,currency,stock,category_ids,tags\n"},
+		{name: "required columns only", file: "name,price\n"},
+		{name: "any order", file: "price,name\n"},
+		{name: "byte order mark", file: "\ufeffname,price\n"},
+		{name: "case and space", file: " Name , PRICE \n"},
+		{name: "empty file", file: "", wantErr: true},
+		{name: "unknown column", file: "name,price,colour\n", wantErr: true},
+		{name: "duplicate column", file: "name,price,name\n", wantErr: true},
+		{name: "duplicate column differing in case", file: "name,price,Price\n", wantErr: true},
+		{name: "missing name", file: "price,description\n", wantErr: true},
+		{name: "missing price", file: "name,description\n", wantErr: true},
+		{name: "malformed header", file: "name,\"price\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var saved []repository.ImportRow
+			err := testImporter(t, &saved).readCSV(strings.NewReader(tt.file))
+			if tt.wantErr && err == nil {
+				t.Fatalf("readCSV(%q) returned no error, want error", tt.file)
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatalf("readCSV(%q) returned error: %v", tt.file, err)
+			}
+		})
+	}
+}
+
+// wantRowError is an ImportRowError whose message contains Error
+type wantRowError struct {
+	Line  int
+	ID    int
+	Error string
+}
+
+func checkReport(t *testing.T, got *sharedModels.ImportReport, total, created, updated int, wantErrors []wantRowError) {
+	t.Helper()
+	if got.Total != total || got.Created != created || got.Updated != updated || got.Failed != len(wantErrors) {
+		t.Errorf("report = total %d, created %d, updated %d, failed %d, want %d, %d, %d, %d",
+			got.Total, got.Created, got.Updated, got.Failed, total, created, updated, len(wantErrors))
+	}
+	if len(got.Errors) != len(wantErrors) {
+		t.Fatalf("report errors = %+v, want %d errors", got.Errors, len(wantErrors))
+	}
+	for i, want := range wantErrors {
+		rowErr := got.Errors[i]
+		if rowErr.Line != want.Line || rowErr.ID != want.ID || !strings.Contains(rowErr.Error, want.Error) {
+			t.Errorf("report error %d = %+v, want line %d, id %d, error containing %q", i, rowErr, want.Line, want.ID, want.Error)
+		}
+	}
+}
+
+func TestImportCSVReport(t *testing.T) {
+	file := "\ufeffid,name,price,currency,stock,category_ids,tags\n" +
+		",Lamp,19.99,EUR,5,1|2,desk|light\n" + // 2: created
+		"7,Chair,abc,USD,,,\n" + // 3: invalid price
+		",Table,10.00\n" + // 4: wrong field count
+		"8,Desk,120.00,USD,3,,\n" + // 5: updated
+		",,5.00,USD,,,\n" + // 6: no name
+		",\"Two line\nname\",5.00,USD,,,\n" + // 7-8: created
+		"9,Shelf,5.00,USD,,,\n" + // 9: fails when saved
+		"10,Stool,5.00,USD,-1,,\n" // 10: negative stock
+
+	var saved []repository.ImportRow
+	report, err := testImporter(t, &saved).run(FormatCSV, strings.NewReader(file))
+	if err != nil {
+		t.Fatalf("run returned error: %v", err)
+	}
+
+	checkReport(t, report, 8, 2, 1, []wantRowError{
+		{Line: 3, ID: 7, Error: "invalid price"},
+		{Line: 4, Error: "expected 7 fields, got 3"},
+		{Line: 6, Error: "product name is required"},
+		{Line: 10, ID: 10, Error: "stock cannot be negative"},
+		{Line: 9, ID: 9, Error: "product not found"},
+	})
+
+	var lines []int
+	for _, row := range saved {
+		lines = append(lines, row.Line)
+	}
+	if want := []int{2, 5, 7, 9}; !reflect.DeepEqual(lines, want) {
+		t.Errorf("saved lines = %v, want %v", lines, want)
+	}
+	lamp := saved[0].Product
+	if lamp.Price != sharedModels.NewMoney(1999, "EUR") || !reflect.DeepEqual(lamp.CategoryIDs, []int{1, 2}) ||
+		!reflect.DeepEqual(lamp.Tags, []string{"desk", "light"}) || *saved[0].Stock != 5 {
+		t.Errorf("saved lamp = %+v, stock %v", lamp, saved[0].Stock)
+	}
+}
+
+func TestImportNDJSONReport(t *testing.T) {
+	file := `{"name": "Lamp", "price": {"amount": 1999, "currency": "EUR"}}` + "\n" + // 1: created
+		"\n" + // 2: blank lines are skipped
+		`{"name": "Broken"` + "\n" + // 3: invalid JSON
+		`{"id": 7, "name": "Chair", "price": "0"}` + "\n" + // 4: price not positive
+		`{"id": 8, "name": "Desk", "price": {"amount": 12000, "currency": "USD"}, "regular_price": {"amount": 15000, "currency": "USD"}}` + "\n" + // 5: updated
+		`{"id": 9, "name": "Shelf", "price": "5.00"}` + "\n" + // 6: fails when saved
+		`{"name": "Rug", "price": "5.00 XXX"}` // 7: unsupported currency, no trailing newline
+
+	var saved []repository.ImportRow
+	report, err := testImporter(t, &saved).run(FormatNDJSON, strings.NewReader(file))
+	if err != nil {
+		t.Fatalf("run returned error: %v", err)
+	}
+
+	checkReport(t, report, 6, 1, 1, []wantRowError{
+		{Line: 3, Error: "invalid JSON"},
+		{Line: 4, ID: 7, Error: "price must be greater than 0"},
+		{Line: 7, Error: "currency XXX is not supported"},
+		{Line: 6, ID: 9, Error: "product not found"},
+	})
+
+	if len(saved) != 3 {
+		t.Fatalf("saved %d rows, want 3", len(saved))
+	}
+	// Exports taken during a sale carry the regular price separately
+	if desk := saved[1].Product; desk.Price != sharedModels.NewMoney(15000, "USD") {
+		t.Errorf("saved desk price = %+v, want the regular price", desk.Price)
+	}
+}
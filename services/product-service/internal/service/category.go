@@ -0,0 +1,223 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/lucas/gokafka/product-service/internal/models"
+	"github.com/lucas/gokafka/product-service/internal/repository"
+	sharedModels "github.com/lucas/gokafka/shared/models"
+)
+
+// Taxonomy limits
+const (
+	MaxTagsPerProduct       = 20
+	MaxTagLength            = 50
+	MaxCategoriesPerProduct = 10
+)
+
+var (
+	slugPattern   = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+	slugSeparator = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// slugify derives a URL slug from a category name, e.g. "Men's Shoes"
+// becomes "men-s-shoes"
+func slugify(name string) string {
+	return strings.Trim(slugSeparator.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
+
+// normalizeTags lowercases, trims and deduplicates tags
+func normalizeTags(tags []string) ([]string, error) {
+	seen := make(map[string]bool, len(tags))
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if len(tag) > MaxTagLength {
+			return nil, fmt.Errorf("tags cannot be longer than %d characters", MaxTagLength)
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	if len(normalized) > MaxTagsPerProduct {
+		return nil, fmt.Errorf("a product cannot have more than %d tags", MaxTagsPerProduct)
+	}
+	sort.Strings(normalized)
+	return normalized, nil
+}
+
+// normalizeCategoryIDs validates and deduplicates category IDs
+func normalizeCategoryIDs(categoryIDs []int) ([]int, error) {
+	seen := make(map[int]bool, len(categoryIDs))
+	normalized := make([]int, 0, len(categoryIDs))
+	for _, id := range categoryIDs {
+		if id <= 0 {
+			return nil, fmt.Errorf("invalid category ID")
+		}
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		normalized = append(normalized, id)
+	}
+	if len(normalized) > MaxCategoriesPerProduct {
+		return nil, fmt.Errorf("a product cannot be in more than %d categories", MaxCategoriesPerProduct)
+	}
+	sort.Ints(normalized)
+	return normalized, nil
+}
+
+// categoryError turns repository category errors into client messages
+func categoryError(action string, err error) error {
+	switch {
+	case errors.Is(err, repository.ErrProductNotFound),
+		errors.Is(err, repository.ErrCategoryNotFound),
+		errors.Is(err, repository.ErrCategorySlugTaken),
+		errors.Is(err, repository.ErrCategoryCycle),
+		errors.Is(err, repository.ErrCategoryHasChildren):
+		return err
+	default:
+		return fmt.Errorf("failed to %s: %w", action, err)
+	}
+}
+
+// validateCategory fills in a missing slug and checks the category fields
+func (s *ProductService) validateCategory(category *models.Category) error {
+	category.Name = strings.TrimSpace(category.Name)
+	if category.Name == "" {
+		return fmt.Errorf("category name is required")
+	}
+	if category.Slug == "" {
+		category.Slug = slugify(category.Name)
+	}
+	if !slugPattern.MatchString(category.Slug) {
+		return fmt.Errorf("category slug may only contain lowercase letters, digits and dashes")
+	}
+	if category.ParentID != nil {
+		if *category.ParentID == category.ID {
+			return repository.ErrCategoryCycle
+		}
+		if _, err := s.repo.GetCategoryByID(*category.ParentID); err != nil {
+			if errors.Is(err, repository.ErrCategoryNotFound) {
+				return fmt.Errorf("parent category not found")
+			}
+			return fmt.Errorf("failed to get parent category: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *ProductService) CreateCategory(req sharedModels.CreateCategoryRequest) (*sharedModels.CategoryData, error) {
+	category := &models.Category{
+		Name:     req.Name,
+		Slug:     req.Slug,
+		ParentID: req.ParentID,
+	}
+	if err := s.validateCategory(category); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.CreateCategory(category); err != nil {
+		return nil, categoryError("create category", err)
+	}
+	return s.repo.CategoryToCategoryData(category), nil
+}
+
+func (s *ProductService) GetCategory(id int) (*sharedModels.CategoryData, error) {
+	if id <= 0 {
+		return nil, fmt.Errorf("invalid category ID")
+	}
+
+	category, err := s.repo.GetCategoryByID(id)
+	if err != nil {
+		return nil, categoryError("get category", err)
+	}
+	return s.repo.CategoryToCategoryData(category), nil
+}
+
+func (s *ProductService) ListCategories() ([]sharedModels.CategoryData, error) {
+	categories, err := s.repo.ListCategories()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list categories: %w", err)
+	}
+
+	result := make([]sharedModels.CategoryData, 0, len(categories))
+	for _, category := range categories {
+		result = append(result, *s.repo.CategoryToCategoryData(category))
+	}
+	return result, nil
+}
+
+// UpdateCategory renames or moves a category. A nil ParentID moves it to
+// the top level.
+func (s *ProductService) UpdateCategory(req sharedModels.UpdateCategoryRequest) (*sharedModels.CategoryData, error) {
+	if req.ID <= 0 {
+		return nil, fmt.Errorf("invalid category ID")
+	}
+
+	category := &models.Category{
+		ID:       req.ID,
+		Name:     req.Name,
+		Slug:     req.Slug,
+		ParentID: req.ParentID,
+	}
+	if err := s.validateCategory(category); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UpdateCategory(category); err != nil {
+		return nil, categoryError("update category", err)
+	}
+	return s.repo.CategoryToCategoryData(category), nil
+}
+
+func (s *ProductService) DeleteCategory(id int) error {
+	if id <= 0 {
+		return fmt.Errorf("invalid category ID")
+	}
+
+	if err := s.repo.DeleteCategory(id); err != nil {
+		return categoryError("delete category", err)
+	}
+	return nil
+}
+
+// SetProductCategories replaces the categories of a product
+func (s *ProductService) SetProductCategories(req sharedModels.SetProductCategoriesRequest, actor string) (*sharedModels.ProductData, error) {
+	if req.ID <= 0 {
+		return nil, fmt.Errorf("invalid product ID")
+	}
+	categoryIDs, err := normalizeCategoryIDs(req.CategoryIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	product, err := s.repo.SetProductCategories(req.ID, categoryIDs, actor)
+	if err != nil {
+		return nil, categoryError("set product categories", err)
+	}
+	return s.productToProductData(product), nil
+}
+
+// SetProductTags replaces the tags of a product
+func (s *ProductService) SetProductTags(req sharedModels.SetProductTagsRequest, actor string) (*sharedModels.ProductData, error) {
+	if req.ID <= 0 {
+		return nil, fmt.Errorf("invalid product ID")
+	}
+	tags, err := normalizeTags(req.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	product, err := s.repo.SetProductTags(req.ID, tags, actor)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set product tags: %w", err)
+	}
+	return s.productToProductData(product), nil
+}
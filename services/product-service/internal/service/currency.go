@@ -0,0 +1,58 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	sharedModels "github.com/lucas/gokafka/shared/models"
+)
+
+// validateTargetCurrency checks a requested display currency. Empty means
+// no conversion.
+func (s *ProductService) validateTargetCurrency(currency string) error {
+	if currency == "" {
+		return nil
+	}
+	if !s.converter.Supports(currency) {
+		return fmt.Errorf("unsupported currency %s", strings.ToUpper(currency))
+	}
+	return nil
+}
+
+// convertPrice prices data, its regular price and its variant overrides in
+// currency, keeping the unconverted price as BasePrice. It does nothing when
+// currency is empty or already the price's.
+func (s *ProductService) convertPrice(data *sharedModels.ProductData, currency string) error {
+	if currency == "" || strings.EqualFold(currency, data.Price.Currency) {
+		return nil
+	}
+
+	converted, err := s.converter.Convert(data.Price, currency)
+	if err != nil {
+		return fmt.Errorf("failed to convert price of product %d: %w", data.ID, err)
+	}
+
+	if data.RegularPrice != nil {
+		regular, err := s.converter.Convert(*data.RegularPrice, currency)
+		if err != nil {
+			return fmt.Errorf("failed to convert regular price of product %d: %w", data.ID, err)
+		}
+		data.RegularPrice = &regular
+	}
+
+	for i, variant := range data.Variants {
+		if variant.Price == nil {
+			continue
+		}
+		variantPrice, err := s.converter.Convert(*variant.Price, currency)
+		if err != nil {
+			return fmt.Errorf("failed to convert price of variant %d: %w", variant.ID, err)
+		}
+		data.Variants[i].Price = &variantPrice
+	}
+
+	base := data.Price
+	data.BasePrice = &base
+	data.Price = converted
+	return nil
+}
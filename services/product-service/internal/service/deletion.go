@@ -0,0 +1,69 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	sharedModels "github.com/lucas/gokafka/shared/models"
+	"github.com/lucas/gokafka/shared/utils"
+)
+
+// Purge constants
+const (
+	DefaultDeletedProductRetention = 30 * 24 * time.Hour
+	PurgeInterval                  = time.Hour
+	PurgeBatch                     = 100
+)
+
+// deletedProductRetention reads DELETED_PRODUCT_RETENTION, e.g. "720h"
+func deletedProductRetention() time.Duration {
+	value := utils.GetEnvOrDefault("DELETED_PRODUCT_RETENTION", DefaultDeletedProductRetention.String())
+	retention, err := time.ParseDuration(value)
+	if err != nil || retention <= 0 {
+		log.Printf("invalid DELETED_PRODUCT_RETENTION %q, using %s", value, DefaultDeletedProductRetention)
+		return DefaultDeletedProductRetention
+	}
+	return retention
+}
+
+// RestoreProduct brings back a soft deleted product that has not been
+// purged yet
+func (s *ProductService) RestoreProduct(id int, actor string) (*sharedModels.ProductData, error) {
+	if id <= 0 {
+		return nil, fmt.Errorf("invalid product ID")
+	}
+
+	product, err := s.repo.RestoreProduct(id, actor)
+	if err != nil {
+		return nil, err
+	}
+	return s.productToProductData(product), nil
+}
+
+// RunPurge periodically removes products that have been soft deleted for
+// longer than the retention period, along with their stored images
+func (s *ProductService) RunPurge() {
+	ticker := time.NewTicker(PurgeInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		cutoff := time.Now().Add(-s.retention)
+		for {
+			n, images, err := s.repo.PurgeDeletedProducts(cutoff, PurgeBatch)
+			if err != nil {
+				log.Printf("Failed to purge deleted products: %v", err)
+				break
+			}
+			for i := range images {
+				s.deleteImageFiles(&images[i])
+			}
+			if n > 0 {
+				log.Printf("Purged %d deleted products", n)
+			}
+			if n < PurgeBatch {
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lucas/gokafka/product-service/internal/repository"
+	sharedModels "github.com/lucas/gokafka/shared/models"
+)
+
+// Price history intervals
+const (
+	PriceIntervalDay   = "day"
+	PriceIntervalWeek  = "week"
+	PriceIntervalMonth = "month"
+)
+
+// MaxPriceHistoryBuckets caps the length of a price history
+const MaxPriceHistoryBuckets = 400
+
+// defaultPriceHistorySpan is how far back a price history goes when no start
+// is given, per interval
+var defaultPriceHistorySpan = map[string]func(time.Time) time.Time{
+	PriceIntervalDay:   func(t time.Time) time.Time { return t.AddDate(0, 0, -30) },
+	PriceIntervalWeek:  func(t time.Time) time.Time { return t.AddDate(0, 0, -7*26) },
+	PriceIntervalMonth: func(t time.Time) time.Time { return t.AddDate(-1, 0, 0) },
+}
+
+// GetProductHistory returns a page of a product's changes, newest first,
+// and the cursor of the next page
+func (s *ProductService) GetProductHistory(req sharedModels.ProductHistoryRequest) ([]sharedModels.ProductHistoryEntry, string, error) {
+	if req.ID <= 0 {
+		return nil, "", fmt.Errorf("invalid product ID")
+	}
+
+	page, err := s.repo.GetProductHistory(req.ID, req.Cursor, req.Limit)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrProductNotFound), errors.Is(err, repository.ErrInvalidCursor):
+			return nil, "", err
+		default:
+			return nil, "", fmt.Errorf("failed to get product history: %w", err)
+		}
+	}
+
+	entries := make([]sharedModels.ProductHistoryEntry, 0, len(page.Entries))
+	for _, entry := range page.Entries {
+		entries = append(entries, *s.repo.HistoryEntryToHistoryData(entry))
+	}
+	return entries, page.NextCursor, nil
+}
+
+// GetPriceHistory summarizes a product's price per day, week or month.
+// Buckets are aligned to UTC midnight, Mondays and the first of the month.
+func (s *ProductService) GetPriceHistory(req sharedModels.PriceHistoryRequest) (*sharedModels.PriceHistoryData, error) {
+	if req.ID <= 0 {
+		return nil, fmt.Errorf("invalid product ID")
+	}
+	if req.Interval == "" {
+		req.Interval = PriceIntervalDay
+	}
+	defaultFrom, ok := defaultPriceHistorySpan[req.Interval]
+	if !ok {
+		return nil, fmt.Errorf("interval must be day, week or month")
+	}
+
+	to := time.Now().UTC()
+	if req.To != "" {
+		var err error
+		if to, err = parseHistoryTime(req.To); err != nil {
+			return nil, fmt.Errorf("invalid to: %w", err)
+		}
+	}
+	from := defaultFrom(to)
+	if req.From != "" {
+		var err error
+		if from, err = parseHistoryTime(req.From); err != nil {
+			return nil, fmt.Errorf("invalid from: %w", err)
+		}
+	}
+	if !from.Before(to) {
+		return nil, fmt.Errorf("from must be before to")
+	}
+
+	start := truncateToInterval(from, req.Interval)
+	count := 0
+	for t := start; t.Before(to); t = nextInterval(t, req.Interval) {
+		if count++; count > MaxPriceHistoryBuckets {
+			return nil, fmt.Errorf("price history is limited to %d buckets; use a longer interval or a shorter range", MaxPriceHistoryBuckets)
+		}
+	}
+
+	points, err := s.repo.GetPriceHistory(req.ID, start, to)
+	if err != nil {
+		if errors.Is(err, repository.ErrProductNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to get price history: %w", err)
+	}
+
+	return &sharedModels.PriceHistoryData{
+		ProductID: req.ID,
+		Interval:  req.Interval,
+		From:      start.Format(time.RFC3339),
+		To:        to.Format(time.RFC3339),
+		Buckets:   priceBuckets(points, start, to, req.Interval),
+	}, nil
+}
+
+// priceBuckets folds price points into buckets from start to to. A price
+// holds until the next point, so a bucket without points carries the
+// previous price. Buckets before the first known price are left out.
+func priceBuckets(points []repository.PricePoint, start, to time.Time, interval string) []sharedModels.PriceBucket {
+	buckets := []sharedModels.PriceBucket{}
+	var current *sharedModels.Money
+	i := 0
+	for bucketStart := start; bucketStart.Before(to); bucketStart = nextInterval(bucketStart, interval) {
+		bucketEnd := nextInterval(bucketStart, interval)
+
+		// The last price set by the start of the bucket opens it
+		for i < len(points) && !points[i].At.After(bucketStart) {
+			current = &points[i].Price
+			i++
+		}
+
+		var bucket *sharedModels.PriceBucket
+		if current != nil {
+			bucket = newPriceBucket(bucketStart, *current)
+		}
+		for i < len(points) && points[i].At.Before(bucketEnd) {
+			price := points[i].Price
+			if bucket == nil {
+				bucket = newPriceBucket(bucketStart, price)
+			} else {
+				addToPriceBucket(bucket, price)
+			}
+			current = &points[i].Price
+			i++
+		}
+
+		if bucket != nil {
+			buckets = append(buckets, *bucket)
+		}
+	}
+	return buckets
+}
+
+func newPriceBucket(start time.Time, price sharedModels.Money) *sharedModels.PriceBucket {
+	return &sharedModels.PriceBucket{
+		Start: start.Format(time.RFC3339),
+		Open:  price,
+		Close: price,
+		Low:   price,
+		High:  price,
+	}
+}
+
+// addToPriceBucket records a price change within a bucket. A change of
+// currency restarts the low and high, which cannot compare across
+// currencies.
+func addToPriceBucket(bucket *sharedModels.PriceBucket, price sharedModels.Money) {
+	switch {
+	case price.Currency != bucket.Close.Currency:
+		bucket.Low, bucket.High = price, price
+	case price.Amount < bucket.Low.Amount:
+		bucket.Low = price
+	case price.Amount > bucket.High.Amount:
+		bucket.High = price
+	}
+	bucket.Close = price
+}
+
+// parseHistoryTime accepts an RFC3339 timestamp or a date
+func parseHistoryTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC(), nil
+	}
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is neither an RFC3339 timestamp nor a date", value)
+	}
+	return t, nil
+}
+
+// truncateToInterval returns the start of the bucket containing t
+func truncateToInterval(t time.Time, interval string) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	switch interval {
+	case PriceIntervalWeek:
+		// Weeks start on Monday
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	case PriceIntervalMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return day
+	}
+}
+
+// nextInterval returns the start of the bucket after the one starting at t
+func nextInterval(t time.Time, interval string) time.Time {
+	switch interval {
+	case PriceIntervalWeek:
+		return t.AddDate(0, 0, 7)
+	case PriceIntervalMonth:
+		return t.AddDate(0, 1, 0)
+	default:
+		return t.AddDate(0, 0, 1)
+	}
+}
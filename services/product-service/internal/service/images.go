@@ -0,0 +1,123 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/lucas/gokafka/product-service/internal/media"
+	"github.com/lucas/gokafka/product-service/internal/models"
+	"github.com/lucas/gokafka/product-service/internal/repository"
+	sharedModels "github.com/lucas/gokafka/shared/models"
+	"github.com/lucas/gokafka/shared/utils"
+)
+
+// DefaultMaxImageBytes is the upload limit used when MAX_IMAGE_BYTES is unset
+const DefaultMaxImageBytes = 5 << 20
+
+// ErrImageTooLarge is returned for uploads over the configured size limit
+var ErrImageTooLarge = errors.New("image is too large")
+
+// maxImageBytes reads the upload limit from MAX_IMAGE_BYTES
+func maxImageBytes() int64 {
+	value := utils.GetEnvOrDefault("MAX_IMAGE_BYTES", strconv.Itoa(DefaultMaxImageBytes))
+	limit, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || limit <= 0 {
+		log.Printf("invalid MAX_IMAGE_BYTES %q, using %d", value, DefaultMaxImageBytes)
+		return DefaultMaxImageBytes
+	}
+	return limit
+}
+
+// MaxImageBytes is the largest upload AddProductImage accepts
+func (s *ProductService) MaxImageBytes() int64 {
+	return s.maxImageBytes
+}
+
+// imageError turns repository image errors into client messages
+func imageError(action string, err error) error {
+	switch {
+	case errors.Is(err, repository.ErrProductNotFound),
+		errors.Is(err, repository.ErrImageNotFound),
+		errors.Is(err, repository.ErrTooManyImages):
+		return err
+	default:
+		return fmt.Errorf("failed to %s: %w", action, err)
+	}
+}
+
+// AddProductImage validates an uploaded image, stores it with a thumbnail
+// and appends it to the product's gallery
+func (s *ProductService) AddProductImage(ctx context.Context, productID int, data []byte, actor string) (*sharedModels.ProductData, error) {
+	if productID <= 0 {
+		return nil, fmt.Errorf("invalid product ID")
+	}
+	if int64(len(data)) > s.maxImageBytes {
+		return nil, ErrImageTooLarge
+	}
+
+	processed, err := media.Process(data)
+	if err != nil {
+		return nil, err
+	}
+
+	name := fmt.Sprintf("products/%d/%s", productID, uuid.New().String())
+	image := &models.ProductImage{
+		ProductID:    productID,
+		StorageKey:   name + processed.Extension,
+		ThumbnailKey: name + "_thumb" + processed.ThumbnailExtension,
+		ContentType:  processed.ContentType,
+		Width:        processed.Width,
+		Height:       processed.Height,
+		SizeBytes:    len(data),
+	}
+	image.URL = s.storage.URL(image.StorageKey)
+	image.ThumbnailURL = s.storage.URL(image.ThumbnailKey)
+
+	if err := s.storage.Put(ctx, image.StorageKey, bytes.NewReader(data), image.ContentType); err != nil {
+		return nil, fmt.Errorf("failed to store image: %w", err)
+	}
+	if err := s.storage.Put(ctx, image.ThumbnailKey, bytes.NewReader(processed.Thumbnail), processed.ThumbnailContentType); err != nil {
+		s.deleteImageFiles(image)
+		return nil, fmt.Errorf("failed to store thumbnail: %w", err)
+	}
+
+	product, err := s.repo.AddProductImage(image, actor)
+	if err != nil {
+		s.deleteImageFiles(image)
+		return nil, imageError("add product image", err)
+	}
+	return s.productToProductData(product), nil
+}
+
+// DeleteProductImage removes an image from a product's gallery and deletes
+// its files
+func (s *ProductService) DeleteProductImage(productID int, imageID, actor string) error {
+	if productID <= 0 {
+		return fmt.Errorf("invalid product ID")
+	}
+	if _, err := uuid.Parse(imageID); err != nil {
+		return repository.ErrImageNotFound
+	}
+
+	image, err := s.repo.DeleteProductImage(productID, imageID, actor)
+	if err != nil {
+		return imageError("delete product image", err)
+	}
+	s.deleteImageFiles(image)
+	return nil
+}
+
+// deleteImageFiles removes the stored files of an image. Failures only leave
+// orphaned files behind, so they are logged rather than returned.
+func (s *ProductService) deleteImageFiles(image *models.ProductImage) {
+	for _, key := range []string{image.StorageKey, image.ThumbnailKey} {
+		if err := s.storage.Delete(context.Background(), key); err != nil {
+			log.Printf("failed to delete stored image %s: %v", key, err)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/lucas/gokafka/product-service/internal/models"
+	"github.com/lucas/gokafka/product-service/internal/repository"
+	sharedModels "github.com/lucas/gokafka/shared/models"
+	"github.com/lucas/gokafka/shared/utils"
+)
+
+// Reservation constants
+const (
+	DefaultReservationTTL     = 15 * time.Minute
+	ReservationExpiryInterval = 30 * time.Second
+	ReservationExpiryBatch    = 100
+)
+
+// reservationTTL reads RESERVATION_TTL, e.g. "15m"
+func reservationTTL() time.Duration {
+	value := utils.GetEnvOrDefault("RESERVATION_TTL", DefaultReservationTTL.String())
+	ttl, err := time.ParseDuration(value)
+	if err != nil || ttl <= 0 {
+		log.Printf("invalid RESERVATION_TTL %q, using %s", value, DefaultReservationTTL)
+		return DefaultReservationTTL
+	}
+	return ttl
+}
+
+// ReserveStock holds stock for an order until it is committed, released or
+// the reservation expires
+func (s *ProductService) ReserveStock(req sharedModels.ReserveStockRequest) (*sharedModels.ReservationData, error) {
+	// Validate input
+	if req.OrderID == "" {
+		return nil, fmt.Errorf("order ID is required")
+	}
+	if len(req.Items) == 0 {
+		return nil, fmt.Errorf("at least one item is required")
+	}
+
+	// Merge duplicate lines so each product and variant is reserved once
+	type line struct{ productID, variantID int }
+	quantities := make(map[line]int)
+	var items []models.ReservationItem
+	for _, item := range req.Items {
+		if item.ProductID <= 0 {
+			return nil, fmt.Errorf("invalid product ID")
+		}
+		if item.VariantID < 0 {
+			return nil, fmt.Errorf("invalid variant ID")
+		}
+		if item.Quantity <= 0 {
+			return nil, fmt.Errorf("quantity must be greater than 0")
+		}
+		key := line{item.ProductID, item.VariantID}
+		if _, ok := quantities[key]; !ok {
+			items = append(items, models.ReservationItem{ProductID: item.ProductID, VariantID: item.VariantID})
+		}
+		quantities[key] += item.Quantity
+	}
+	for i := range items {
+		items[i].Quantity = quantities[line{items[i].ProductID, items[i].VariantID}]
+	}
+
+	reservation, err := s.repo.ReserveStock(req.OrderID, items, s.reservationTTL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reserve stock: %w", err)
+	}
+
+	// A retried request returns the order's earlier reservation, which is
+	// only usable if it still holds the stock
+	if reservation.Status != sharedModels.ReservationStatusActive &&
+		reservation.Status != sharedModels.ReservationStatusCommitted {
+		return nil, fmt.Errorf("reservation for order %s is %s", req.OrderID, reservation.Status)
+	}
+
+	return s.repo.ReservationToReservationData(reservation), nil
+}
+
+// CommitReservation turns a reservation into a permanent sale
+func (s *ProductService) CommitReservation(req sharedModels.ReservationRequest) (*sharedModels.ReservationData, error) {
+	if req.ReservationID == "" && req.OrderID == "" {
+		return nil, fmt.Errorf("reservation ID or order ID is required")
+	}
+
+	reservation, err := s.repo.CommitReservation(req.ReservationID, req.OrderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to commit reservation: %w", err)
+	}
+
+	return s.repo.ReservationToReservationData(reservation), nil
+}
+
+// ReleaseReservation returns reserved stock. Releasing by order when the
+// order never got a reservation succeeds, so compensation works even if the
+// reserve reply was lost.
+func (s *ProductService) ReleaseReservation(req sharedModels.ReservationRequest) (*sharedModels.ReservationData, error) {
+	if req.ReservationID == "" && req.OrderID == "" {
+		return nil, fmt.Errorf("reservation ID or order ID is required")
+	}
+
+	reservation, err := s.repo.ReleaseReservation(req.ReservationID, req.OrderID)
+	if errors.Is(err, repository.ErrReservationNotFound) && req.ReservationID == "" {
+		return &sharedModels.ReservationData{
+			OrderID: req.OrderID,
+			Status:  sharedModels.ReservationStatusReleased,
+		}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to release reservation: %w", err)
+	}
+
+	return s.repo.ReservationToReservationData(reservation), nil
+}
+
+// AdjustStock changes a product's stock, e.g. after a delivery or a count
+func (s *ProductService) AdjustStock(req sharedModels.AdjustStockRequest, actor string) (*sharedModels.ProductData, error) {
+	// Validate input
+	if req.ID <= 0 {
+		return nil, fmt.Errorf("invalid product ID")
+	}
+	if req.Quantity != nil && req.Delta != 0 {
+		return nil, fmt.Errorf("specify either delta or quantity, not both")
+	}
+	if req.Quantity == nil && req.Delta == 0 {
+		return nil, fmt.Errorf("delta or quantity is required")
+	}
+	if req.Quantity != nil && *req.Quantity < 0 {
+		return nil, fmt.Errorf("quantity cannot be negative")
+	}
+
+	product, err := s.repo.AdjustStock(req.ID, req.Delta, req.Quantity, actor)
+	if err != nil {
+		return nil, fmt.Errorf("failed to adjust stock: %w", err)
+	}
+
+	log.Printf("Stock of product %d adjusted to %d: %s", product.ID, product.Stock, req.Reason)
+	return s.productToProductData(product), nil
+}
+
+// RunReservationExpiry periodically returns the stock of expired
+// reservations
+func (s *ProductService) RunReservationExpiry() {
+	ticker := time.NewTicker(ReservationExpiryInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		for {
+			n, err := s.repo.ExpireReservations(ReservationExpiryBatch)
+			if err != nil {
+				log.Printf("Failed to expire reservations: %v", err)
+				break
+			}
+			if n > 0 {
+				log.Printf("Expired %d stock reservations", n)
+			}
+			if n < ReservationExpiryBatch {
+				break
+			}
+		}
+	}
+}
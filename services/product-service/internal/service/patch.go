@@ -0,0 +1,115 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/lucas/gokafka/product-service/internal/repository"
+	sharedModels "github.com/lucas/gokafka/shared/models"
+)
+
+// PatchProduct applies a JSON Merge Patch to a product's name, description
+// and price. The patched product goes through the same validation as
+// UpdateProduct. Stock, categories, tags, images and variants have their own
+// requests and cannot be patched.
+func (s *ProductService) PatchProduct(req sharedModels.PatchProductRequest, actor string) (*sharedModels.ProductData, error) {
+	if req.ID <= 0 {
+		return nil, fmt.Errorf("invalid product ID")
+	}
+	if req.Version <= 0 {
+		return nil, fmt.Errorf("product version is required")
+	}
+
+	existingProduct, err := s.repo.GetProductByID(req.ID)
+	if err != nil {
+		return nil, fmt.Errorf("product not found")
+	}
+	if existingProduct.Version != req.Version {
+		return nil, repository.ErrVersionConflict
+	}
+
+	update := sharedModels.UpdateProductRequest{
+		ID:          req.ID,
+		Version:     req.Version,
+		Name:        existingProduct.Name,
+		Description: existingProduct.Description,
+		Price:       existingProduct.Price,
+	}
+	if err := applyProductPatch(&update, req.Patch); err != nil {
+		return nil, err
+	}
+	return s.UpdateProduct(update, actor)
+}
+
+// applyProductPatch merges patch into update. A null member removes the
+// field, which only the description allows.
+func applyProductPatch(update *sharedModels.UpdateProductRequest, patch json.RawMessage) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(patch, &fields); err != nil || fields == nil {
+		return fmt.Errorf("patch must be a JSON object")
+	}
+
+	for name, value := range fields {
+		null := bytes.Equal(bytes.TrimSpace(value), []byte("null"))
+		switch name {
+		case "name":
+			if null {
+				return fmt.Errorf("product name is required")
+			}
+			if err := json.Unmarshal(value, &update.Name); err != nil {
+				return fmt.Errorf("name must be a string")
+			}
+		case "description":
+			if null {
+				update.Description = ""
+			} else if err := json.Unmarshal(value, &update.Description); err != nil {
+				return fmt.Errorf("description must be a string")
+			}
+		case "price":
+			if null {
+				return fmt.Errorf("product price is required")
+			}
+			price, err := patchPrice(update.Price, value)
+			if err != nil {
+				return fmt.Errorf("invalid price: %w", err)
+			}
+			update.Price = price
+		default:
+			return fmt.Errorf("field %q cannot be patched", name)
+		}
+	}
+	return nil
+}
+
+// patchPrice decodes a price member. An object is merged into the current
+// price, so {"amount": 1500} keeps the currency; the legacy number and
+// string forms replace the price, as they do everywhere else.
+func patchPrice(current sharedModels.Money, value json.RawMessage) (sharedModels.Money, error) {
+	var price sharedModels.Money
+	value = bytes.TrimSpace(value)
+	if len(value) == 0 || value[0] != '{' {
+		err := json.Unmarshal(value, &price)
+		return price, err
+	}
+
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(value, &members); err != nil {
+		return price, err
+	}
+	merged := map[string]interface{}{"amount": current.Amount, "currency": current.Currency}
+	for name, member := range members {
+		if bytes.Equal(bytes.TrimSpace(member), []byte("null")) {
+			delete(merged, name)
+		} else {
+			merged[name] = member
+		}
+	}
+
+	data, err := json.Marshal(merged)
+	if err != nil {
+		return price, err
+	}
+	err = json.Unmarshal(data, &price)
+	return price, err
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"encoding/json"
+	"testing"
+
+	sharedModels "github.com/lucas/gokafka/shared/models"
+)
+
+func TestApplyProductPatch(t *testing.T) {
+	current := sharedModels.UpdateProductRequest{
+		ID:          7,
+		Version:     3,
+		Name:        "Lamp",
+		Description: "Desk lamp",
+		Price:       sharedModels.NewMoney(1999, "EUR"),
+	}
+	// want returns the current product with change applied
+	want := func(change func(*sharedModels.UpdateProductRequest)) sharedModels.UpdateProductRequest {
+		update := current
+		change(&update)
+		return update
+	}
+
+	tests := []struct {
+		name    string
+		patch   string
+		want    sharedModels.UpdateProductRequest
+		wantErr bool
+	}{
+		{name: "empty patch", patch: `{}`, want: current},
+		{name: "name", patch: `{"name": "Floor lamp"}`, want: want(func(u *sharedModels.UpdateProductRequest) { u.Name = "Floor lamp" })},
+		{name: "description", patch: `{"description": "Brass"}`, want: want(func(u *sharedModels.UpdateProductRequest) { u.Description = "Brass" })},
+		{name: "null clears description", patch: `{"description": null}`, want: want(func(u *sharedModels.UpdateProductRequest) { u.Description = "" })},
+		{
+			name:  "several fields",
+			patch: `{"name": "Floor lamp", "price": {"amount": 4500}}`,
+			want: want(func(u *sharedModels.UpdateProductRequest) {
+				u.Name = "Floor lamp"
+				u.Price = sharedModels.NewMoney(4500, "EUR")
+			}),
+		},
+		{name: "partial price keeps currency", patch: `{"price": {"amount": 1500}}`, want: want(func(u *sharedModels.UpdateProductRequest) { u.Price = sharedModels.NewMoney(1500, "EUR") })},
+		{name: "partial price keeps amount", patch: `{"price": {"currency": "usd"}}`, want: want(func(u *sharedModels.UpdateProductRequest) { u.Price = sharedModels.NewMoney(1999, "USD") })},
+		{name: "full price object", patch: `{"price": {"amount": 500, "currency": "GBP"}}`, want: want(func(u *sharedModels.UpdateProductRequest) { u.Price = sharedModels.NewMoney(500, "GBP") })},
+		{name: "legacy price replaces", patch: `{"price": "12.50"}`, want: want(func(u *sharedModels.UpdateProductRequest) { u.Price = sharedModels.NewMoney(1250, "USD") })},
+		{name: "null name rejected", patch: `{"name": null}`, wantErr: true},
+		{name: "null price rejected", patch: `{"price": null}`, wantErr: true},
+		{name: "name not a string", patch: `{"name": 42}`, wantErr: true},
+		{name: "description not a string", patch: `{"description": ["a"]}`, wantErr: true},
+		{name: "fractional price amount", patch: `{"price": {"amount": 19.99}}`, wantErr: true},
+		{name: "invalid price currency", patch: `{"price": {"currency": "EURO"}}`, wantErr: true},
+		{name: "stock cannot be patched", patch: `{"stock": 5}`, wantErr: true},
+		{name: "unknown member", patch: `{"colour": "red"}`, wantErr: true},
+		{name: "unknown member with valid ones", patch: `{"name": "Floor lamp", "id": 8}`, wantErr: true},
+		{name: "null patch", patch: `null`, wantErr: true},
+		{name: "array patch", patch: `[]`, wantErr: true},
+		{name: "not JSON", patch: `{"name"`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := current
+			err := applyProductPatch(&got, json.RawMessage(tt.patch))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("applyProductPatch(%s) = %+v, want error", tt.patch, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("applyProductPatch(%s) returned error: %v", tt.patch, err)
+			}
+			if got != tt.want {
+				t.Errorf("applyProductPatch(%s) = %+v, want %+v", tt.patch, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPatchPrice(t *testing.T) {
+	current := sharedModels.NewMoney(1500, "JPY")
+
+	tests := []struct {
+		name    string
+		value   string
+		want    sharedModels.Money
+		wantErr bool
+	}{
+		{name: "amount only", value: `{"amount": 2000}`, want: sharedModels.NewMoney(2000, "JPY")},
+		{name: "currency only", value: `{"currency": "EUR"}`, want: sharedModels.NewMoney(1500, "EUR")},
+		{name: "empty object", value: `{}`, want: current},
+		{name: "null currency falls back to default", value: `{"currency": null}`, want: sharedModels.NewMoney(1500, sharedModels.DefaultCurrency)},
+		{name: "surrounding space", value: ` {"amount": 10} `, want: sharedModels.NewMoney(10, "JPY")},
+		{name: "legacy number", value: `19.99`, want: sharedModels.NewMoney(1999, "USD")},
+		{name: "legacy string with currency", value: `"2500 JPY"`, want: sharedModels.NewMoney(2500, "JPY")},
+		{name: "amount not a number", value: `{"amount": "a lot"}`, wantErr: true},
+		{name: "legacy number with too many decimals", value: `1.999`, wantErr: true},
+		{name: "malformed object", value: `{"amount": }`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := patchPrice(current, json.RawMessage(tt.value))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("patchPrice(%+v, %s) = %+v, want error", current, tt.value, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("patchPrice(%+v, %s) returned error: %v", current, tt.value, err)
+			}
+			if got != tt.want {
+				t.Errorf("patchPrice(%+v, %s) = %+v, want %+v", current, tt.value, got, tt.want)
+			}
+		})
+	}
+}
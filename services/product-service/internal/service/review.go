@@ -0,0 +1,132 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/lucas/gokafka/product-service/internal/models"
+	"github.com/lucas/gokafka/product-service/internal/repository"
+	sharedModels "github.com/lucas/gokafka/shared/models"
+)
+
+// Review limits
+const (
+	MinReviewRating     = 1
+	MaxReviewRating     = 5
+	MaxReviewTextLength = 5000
+)
+
+// reviewError turns repository review errors into client messages
+func reviewError(action string, err error) error {
+	switch {
+	case errors.Is(err, repository.ErrProductNotFound),
+		errors.Is(err, repository.ErrReviewNotFound),
+		errors.Is(err, repository.ErrAlreadyReviewed),
+		errors.Is(err, repository.ErrInvalidCursor):
+		return err
+	default:
+		return fmt.Errorf("failed to %s: %w", action, err)
+	}
+}
+
+// validReviewStatus reports whether status is a review status
+func validReviewStatus(status string) bool {
+	return status == sharedModels.ReviewStatusPublished || status == sharedModels.ReviewStatusHidden
+}
+
+// CreateReview publishes a user's review of a product
+func (s *ProductService) CreateReview(req sharedModels.CreateReviewRequest) (*sharedModels.ReviewData, error) {
+	if req.ProductID <= 0 {
+		return nil, fmt.Errorf("invalid product ID")
+	}
+	if req.UserID == "" {
+		return nil, fmt.Errorf("user ID is required")
+	}
+	if req.Rating < MinReviewRating || req.Rating > MaxReviewRating {
+		return nil, fmt.Errorf("rating must be between %d and %d", MinReviewRating, MaxReviewRating)
+	}
+	text := strings.TrimSpace(req.Text)
+	if text == "" {
+		return nil, fmt.Errorf("review text is required")
+	}
+	if utf8.RuneCountInString(text) > MaxReviewTextLength {
+		return nil, fmt.Errorf("review text is limited to %d characters", MaxReviewTextLength)
+	}
+
+	review := &models.ProductReview{
+		ProductID: req.ProductID,
+		UserID:    req.UserID,
+		Rating:    req.Rating,
+		Text:      text,
+	}
+	if err := s.repo.CreateReview(review); err != nil {
+		return nil, reviewError("create review", err)
+	}
+	return s.repo.ReviewToReviewData(review), nil
+}
+
+// GetReview returns a review in any status
+func (s *ProductService) GetReview(req sharedModels.GetReviewRequest) (*sharedModels.ReviewData, error) {
+	if req.ID <= 0 {
+		return nil, fmt.Errorf("invalid review ID")
+	}
+
+	review, err := s.repo.GetReview(req.ID)
+	if err != nil {
+		return nil, reviewError("get review", err)
+	}
+	return s.repo.ReviewToReviewData(review), nil
+}
+
+// ListReviews returns a page of a product's reviews, newest first, and the
+// cursor of the next page
+func (s *ProductService) ListReviews(req sharedModels.ListReviewsRequest) ([]sharedModels.ReviewData, string, error) {
+	if req.ProductID <= 0 {
+		return nil, "", fmt.Errorf("invalid product ID")
+	}
+	if req.Status != "" && !validReviewStatus(req.Status) {
+		return nil, "", fmt.Errorf("status must be %q or %q", sharedModels.ReviewStatusPublished, sharedModels.ReviewStatusHidden)
+	}
+
+	page, err := s.repo.ListReviews(req.ProductID, req.Status, req.Cursor, req.Limit)
+	if err != nil {
+		return nil, "", reviewError("list reviews", err)
+	}
+
+	reviews := make([]sharedModels.ReviewData, 0, len(page.Reviews))
+	for _, review := range page.Reviews {
+		reviews = append(reviews, *s.repo.ReviewToReviewData(review))
+	}
+	return reviews, page.NextCursor, nil
+}
+
+// ModerateReview hides a review or publishes it again; the product's
+// rating follows
+func (s *ProductService) ModerateReview(req sharedModels.ModerateReviewRequest) (*sharedModels.ReviewData, error) {
+	if req.ID <= 0 {
+		return nil, fmt.Errorf("invalid review ID")
+	}
+	if !validReviewStatus(req.Status) {
+		return nil, fmt.Errorf("status must be %q or %q", sharedModels.ReviewStatusPublished, sharedModels.ReviewStatusHidden)
+	}
+
+	review, err := s.repo.SetReviewStatus(req.ID, req.Status)
+	if err != nil {
+		return nil, reviewError("moderate review", err)
+	}
+	return s.repo.ReviewToReviewData(review), nil
+}
+
+// DeleteReview removes a review, e.g. at its author's request
+func (s *ProductService) DeleteReview(req sharedModels.DeleteReviewRequest) error {
+	if req.ID <= 0 {
+		return fmt.Errorf("invalid review ID")
+	}
+
+	if err := s.repo.DeleteReview(req.ID); err != nil {
+		return reviewError("delete review", err)
+	}
+	return nil
+}
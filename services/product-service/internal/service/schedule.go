@@ -0,0 +1,149 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/lucas/gokafka/product-service/internal/models"
+	"github.com/lucas/gokafka/product-service/internal/repository"
+	sharedModels "github.com/lucas/gokafka/shared/models"
+)
+
+// Price scheduler constants
+const (
+	PriceScheduleInterval = time.Minute
+	PriceScheduleBatch    = 100
+)
+
+// MaxPriceScheduleNameLength limits the label of a price schedule
+const MaxPriceScheduleNameLength = 100
+
+// priceScheduleError turns repository price schedule errors into client
+// messages
+func priceScheduleError(action string, err error) error {
+	switch {
+	case errors.Is(err, repository.ErrProductNotFound),
+		errors.Is(err, repository.ErrPriceScheduleNotFound),
+		errors.Is(err, repository.ErrPriceScheduleOverlap),
+		errors.Is(err, repository.ErrPriceScheduleCurrency),
+		errors.Is(err, repository.ErrPriceScheduleEnded),
+		errors.Is(err, repository.ErrTooManyPriceSchedules):
+		return err
+	default:
+		return fmt.Errorf("failed to %s: %w", action, err)
+	}
+}
+
+// CreatePriceSchedule schedules a price for a product. A schedule starting
+// in the past starts now.
+func (s *ProductService) CreatePriceSchedule(req sharedModels.CreatePriceScheduleRequest, actor string) (*sharedModels.PriceScheduleData, error) {
+	if req.ProductID <= 0 {
+		return nil, fmt.Errorf("invalid product ID")
+	}
+	name := strings.TrimSpace(req.Name)
+	if len(name) > MaxPriceScheduleNameLength {
+		return nil, fmt.Errorf("price schedule name is limited to %d characters", MaxPriceScheduleNameLength)
+	}
+	if !req.Price.IsPositive() {
+		return nil, fmt.Errorf("scheduled price must be greater than 0")
+	}
+	if req.StartsAt == "" {
+		return nil, fmt.Errorf("starts_at is required")
+	}
+
+	now := time.Now()
+	startsAt, err := time.Parse(time.RFC3339, req.StartsAt)
+	if err != nil {
+		return nil, fmt.Errorf("starts_at must be an RFC3339 timestamp")
+	}
+	startsAt = startsAt.UTC()
+	if startsAt.Before(now) {
+		startsAt = now
+	}
+
+	var endsAt *time.Time
+	if req.EndsAt != "" {
+		end, err := time.Parse(time.RFC3339, req.EndsAt)
+		if err != nil {
+			return nil, fmt.Errorf("ends_at must be an RFC3339 timestamp")
+		}
+		if !end.After(startsAt) {
+			return nil, fmt.Errorf("ends_at must be after starts_at and in the future")
+		}
+		end = end.UTC()
+		endsAt = &end
+	}
+
+	schedule := &models.PriceSchedule{
+		ProductID: req.ProductID,
+		Name:      name,
+		Price:     req.Price,
+		StartsAt:  startsAt,
+		EndsAt:    endsAt,
+	}
+	if err := s.repo.CreatePriceSchedule(schedule, actor); err != nil {
+		return nil, priceScheduleError("create price schedule", err)
+	}
+	return s.repo.PriceScheduleToData(schedule, time.Now()), nil
+}
+
+// DeletePriceSchedule cancels a schedule that has not ended yet
+func (s *ProductService) DeletePriceSchedule(req sharedModels.DeletePriceScheduleRequest, actor string) error {
+	if req.ProductID <= 0 {
+		return fmt.Errorf("invalid product ID")
+	}
+	if req.ID <= 0 {
+		return fmt.Errorf("invalid price schedule ID")
+	}
+
+	if err := s.repo.DeletePriceSchedule(req.ProductID, req.ID, actor); err != nil {
+		return priceScheduleError("delete price schedule", err)
+	}
+	return nil
+}
+
+// ListPriceSchedules returns all schedules of a product in start order
+func (s *ProductService) ListPriceSchedules(req sharedModels.ListPriceSchedulesRequest) ([]sharedModels.PriceScheduleData, error) {
+	if req.ProductID <= 0 {
+		return nil, fmt.Errorf("invalid product ID")
+	}
+
+	schedules, err := s.repo.ListPriceSchedules(req.ProductID)
+	if err != nil {
+		return nil, priceScheduleError("list price schedules", err)
+	}
+
+	now := time.Now()
+	result := make([]sharedModels.PriceScheduleData, 0, len(schedules))
+	for i := range schedules {
+		result = append(result, *s.repo.PriceScheduleToData(&schedules[i], now))
+	}
+	return result, nil
+}
+
+// RunPriceScheduler periodically publishes product.price_changed events
+// for schedules that started or ended. Prices are evaluated when read, so
+// a late run only delays the events, not the prices themselves.
+func (s *ProductService) RunPriceScheduler() {
+	ticker := time.NewTicker(PriceScheduleInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		for {
+			n, err := s.repo.AnnouncePriceSchedules(time.Now(), PriceScheduleBatch)
+			if err != nil {
+				log.Printf("Failed to announce price schedules: %v", err)
+				break
+			}
+			if n > 0 {
+				log.Printf("Announced price changes of %d products", n)
+			}
+			if n < PriceScheduleBatch {
+				break
+			}
+		}
+	}
+}
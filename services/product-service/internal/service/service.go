@@ -1,20 +1,35 @@
 package service
 
 import (
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
+	"github.com/lucas/gokafka/product-service/internal/currency"
 	"github.com/lucas/gokafka/product-service/internal/models"
 	"github.com/lucas/gokafka/product-service/internal/repository"
+	"github.com/lucas/gokafka/product-service/internal/storage"
 	sharedModels "github.com/lucas/gokafka/shared/models"
 )
 
 type ProductService struct {
-	repo *repository.ProductRepository
+	repo           *repository.ProductRepository
+	converter      *currency.Converter
+	storage        storage.Storage
+	reservationTTL time.Duration
+	maxImageBytes  int64
+	retention      time.Duration
 }
 
-func NewProductService() *ProductService {
+func NewProductService(repo *repository.ProductRepository, converter *currency.Converter, storage storage.Storage) *ProductService {
 	return &ProductService{
-		repo: repository.NewProductRepository(),
+		repo:           repo,
+		converter:      converter,
+		storage:        storage,
+		reservationTTL: reservationTTL(),
+		maxImageBytes:  maxImageBytes(),
+		retention:      deletedProductRetention(),
 	}
 }
 
@@ -23,35 +38,57 @@ func (s *ProductService) productToProductData(product *models.Product) *sharedMo
 	return s.repo.ProductToProductData(product)
 }
 
-func (s *ProductService) CreateProduct(req sharedModels.CreateProductRequest) (*sharedModels.ProductData, error) {
+func (s *ProductService) CreateProduct(req sharedModels.CreateProductRequest, actor string) (*sharedModels.ProductData, error) {
 	// Validate input
 	if req.Name == "" {
 		return nil, fmt.Errorf("product name is required")
 	}
-	if req.Price <= 0 {
+	if !req.Price.IsPositive() {
 		return nil, fmt.Errorf("product price must be greater than 0")
 	}
+	if !s.converter.Supports(req.Price.Currency) {
+		return nil, fmt.Errorf("price currency %s is not supported", req.Price.Currency)
+	}
+	if req.Stock < 0 {
+		return nil, fmt.Errorf("product stock cannot be negative")
+	}
+	categoryIDs, err := normalizeCategoryIDs(req.CategoryIDs)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := normalizeTags(req.Tags)
+	if err != nil {
+		return nil, err
+	}
 
 	// Create new product
 	product := &models.Product{
 		Name:        req.Name,
 		Description: req.Description,
 		Price:       req.Price,
+		Stock:       req.Stock,
+		CategoryIDs: categoryIDs,
+		Tags:        tags,
 	}
 
 	// Save product to repository
-	if err := s.repo.CreateProduct(product); err != nil {
-		return nil, fmt.Errorf("failed to create product: %w", err)
+	if err := s.repo.CreateProduct(product, actor); err != nil {
+		return nil, categoryError("create product", err)
 	}
 
 	return s.productToProductData(product), nil
 }
 
-func (s *ProductService) GetProductByID(id int) (*sharedModels.ProductData, error) {
+// GetProductByID returns a product priced in currency, or in its own
+// currency when currency is empty
+func (s *ProductService) GetProductByID(id int, currency string) (*sharedModels.ProductData, error) {
 	// Validate input
 	if id <= 0 {
 		return nil, fmt.Errorf("invalid product ID")
 	}
+	if err := s.validateTargetCurrency(currency); err != nil {
+		return nil, err
+	}
 
 	// Get product from repository
 	product, err := s.repo.GetProductByID(id)
@@ -59,28 +96,180 @@ func (s *ProductService) GetProductByID(id int) (*sharedModels.ProductData, erro
 		return nil, fmt.Errorf("product not found")
 	}
 
-	return s.productToProductData(product), nil
+	data := s.productToProductData(product)
+	if err := s.convertPrice(data, currency); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetProductsByIDs returns the products with the given IDs priced like
+// GetProductByID, in the order requested, and the IDs that were not found
+func (s *ProductService) GetProductsByIDs(ids []int, currency string) ([]*sharedModels.ProductData, []int, error) {
+	// Validate input
+	if len(ids) == 0 {
+		return nil, nil, fmt.Errorf("at least one product ID is required")
+	}
+	if len(ids) > sharedModels.MaxProductsPerLookup {
+		return nil, nil, fmt.Errorf("cannot look up more than %d products at once", sharedModels.MaxProductsPerLookup)
+	}
+	for _, id := range ids {
+		if id <= 0 {
+			return nil, nil, fmt.Errorf("invalid product ID")
+		}
+	}
+	if err := s.validateTargetCurrency(currency); err != nil {
+		return nil, nil, err
+	}
+
+	products, err := s.repo.GetProductsByIDs(ids)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data := make([]*sharedModels.ProductData, 0, len(products))
+	var missing []int
+	seen := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		product, ok := products[id]
+		if !ok {
+			missing = append(missing, id)
+			continue
+		}
+		productData := s.productToProductData(product)
+		if err := s.convertPrice(productData, currency); err != nil {
+			return nil, nil, err
+		}
+		data = append(data, productData)
+	}
+	return data, missing, nil
 }
 
-func (s *ProductService) GetAllProducts() ([]*sharedModels.ProductData, error) {
-	// Get all products from repository
-	products, err := s.repo.GetAllProducts()
+// ListProducts returns one page of products priced in req.Currency, or in
+// their own currencies when it is empty, and the cursor of the next page
+func (s *ProductService) ListProducts(req sharedModels.ListProductsRequest) ([]*sharedModels.ProductData, string, error) {
+	if err := s.validateTargetCurrency(req.Currency); err != nil {
+		return nil, "", err
+	}
+
+	filter, err := productFilter(req)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Get one page of products from repository
+	page, err := s.repo.ListProducts(filter)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get products: %w", err)
+		if errors.Is(err, repository.ErrInvalidCursor) || errors.Is(err, repository.ErrInvalidSort) {
+			return nil, "", err
+		}
+		return nil, "", fmt.Errorf("failed to get products: %w", err)
 	}
 
 	// Convert to ProductData
-	productDataList := make([]*sharedModels.ProductData, 0, len(products))
-	for _, product := range products {
+	productDataList := make([]*sharedModels.ProductData, 0, len(page.Products))
+	for _, product := range page.Products {
 		if product != nil {
-			productDataList = append(productDataList, s.productToProductData(product))
+			data := s.productToProductData(product)
+			if err := s.convertPrice(data, req.Currency); err != nil {
+				return nil, "", err
+			}
+			productDataList = append(productDataList, data)
+		}
+	}
+
+	return productDataList, page.NextCursor, nil
+}
+
+// productFilter validates a list request and turns it into a repository filter
+func productFilter(req sharedModels.ListProductsRequest) (repository.ProductFilter, error) {
+	filter := repository.ProductFilter{
+		MinPrice:   req.MinPrice,
+		MaxPrice:   req.MaxPrice,
+		NamePrefix: req.NamePrefix,
+		CategoryID: req.CategoryID,
+		Tag:        strings.ToLower(strings.TrimSpace(req.Tag)),
+		Deleted:    req.Deleted,
+		Sort:       req.Sort,
+		Cursor:     req.Cursor,
+		Limit:      req.Limit,
+	}
+
+	if req.Limit < 0 {
+		return filter, fmt.Errorf("limit cannot be negative")
+	}
+	if req.CategoryID < 0 {
+		return filter, fmt.Errorf("invalid category ID")
+	}
+	if req.MinPrice != nil && req.MaxPrice != nil {
+		if req.MinPrice.Currency != req.MaxPrice.Currency {
+			return filter, fmt.Errorf("price bounds must share a currency")
+		}
+		if req.MinPrice.Amount > req.MaxPrice.Amount {
+			return filter, fmt.Errorf("min_price cannot exceed max_price")
+		}
+	}
+
+	if req.CreatedAfter != "" {
+		after, err := time.Parse(time.RFC3339, req.CreatedAfter)
+		if err != nil {
+			return filter, fmt.Errorf("created_after must be an RFC3339 timestamp")
+		}
+		after = after.UTC()
+		filter.CreatedAfter = &after
+	}
+	if req.CreatedBefore != "" {
+		before, err := time.Parse(time.RFC3339, req.CreatedBefore)
+		if err != nil {
+			return filter, fmt.Errorf("created_before must be an RFC3339 timestamp")
+		}
+		before = before.UTC()
+		filter.CreatedBefore = &before
+	}
+
+	return filter, nil
+}
+
+// SearchProducts runs a full-text search, best matches first
+func (s *ProductService) SearchProducts(req sharedModels.SearchProductsRequest) ([]sharedModels.ProductSearchResult, error) {
+	if strings.TrimSpace(req.Query) == "" {
+		return nil, fmt.Errorf("search query is required")
+	}
+	if req.Limit < 0 || req.Offset < 0 {
+		return nil, fmt.Errorf("limit and offset cannot be negative")
+	}
+	if err := s.validateTargetCurrency(req.Currency); err != nil {
+		return nil, err
+	}
+
+	hits, err := s.repo.SearchProducts(req.Query, req.Limit, req.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search products: %w", err)
+	}
+
+	results := make([]sharedModels.ProductSearchResult, 0, len(hits))
+	for _, hit := range hits {
+		data := s.productToProductData(hit.Product)
+		if err := s.convertPrice(data, req.Currency); err != nil {
+			return nil, err
 		}
+		results = append(results, sharedModels.ProductSearchResult{
+			Product:         *data,
+			Rank:            hit.Rank,
+			HighlightedName: hit.HighlightedName,
+			Snippet:         hit.Snippet,
+		})
 	}
 
-	return productDataList, nil
+	return results, nil
 }
 
-func (s *ProductService) UpdateProduct(req sharedModels.UpdateProductRequest) (*sharedModels.ProductData, error) {
+func (s *ProductService) UpdateProduct(req sharedModels.UpdateProductRequest, actor string) (*sharedModels.ProductData, error) {
 	// Validate input
 	if req.ID <= 0 {
 		return nil, fmt.Errorf("invalid product ID")
@@ -88,37 +277,62 @@ func (s *ProductService) UpdateProduct(req sharedModels.UpdateProductRequest) (*
 	if req.Name == "" {
 		return nil, fmt.Errorf("product name is required")
 	}
-	if req.Price <= 0 {
+	if !req.Price.IsPositive() {
 		return nil, fmt.Errorf("product price must be greater than 0")
 	}
+	if !s.converter.Supports(req.Price.Currency) {
+		return nil, fmt.Errorf("price currency %s is not supported", req.Price.Currency)
+	}
+	if req.Version <= 0 {
+		return nil, fmt.Errorf("product version is required")
+	}
 
 	// Check if product exists
 	existingProduct, err := s.repo.GetProductByID(req.ID)
 	if err != nil {
 		return nil, fmt.Errorf("product not found")
 	}
+	if existingProduct.Version != req.Version {
+		return nil, repository.ErrVersionConflict
+	}
+
+	// Variant price overrides are stored in the product's currency
+	for _, variant := range existingProduct.Variants {
+		if variant.Price != nil && variant.Price.Currency != req.Price.Currency {
+			return nil, fmt.Errorf("cannot change the currency of a product with variant price overrides")
+		}
+	}
+	for _, schedule := range existingProduct.Schedules {
+		if schedule.Price.Currency != req.Price.Currency {
+			return nil, repository.ErrPriceSchedulePending
+		}
+	}
 
 	// Update product fields
 	existingProduct.Name = req.Name
 	existingProduct.Description = req.Description
 	existingProduct.Price = req.Price
 
-	// Update in repository
-	if err := s.repo.UpdateProduct(existingProduct); err != nil {
+	// Update in repository. The version is checked again there, as the
+	// product may change between the read above and the write.
+	if err := s.repo.UpdateProduct(existingProduct, actor); err != nil {
+		if errors.Is(err, repository.ErrVersionConflict) {
+			return nil, err
+		}
 		return nil, fmt.Errorf("failed to update product: %w", err)
 	}
 
 	return s.productToProductData(existingProduct), nil
 }
 
-func (s *ProductService) DeleteProduct(id int) error {
+func (s *ProductService) DeleteProduct(id int, actor string) error {
 	// Validate input
 	if id <= 0 {
 		return fmt.Errorf("invalid product ID")
 	}
 
-	// Delete from repository
-	if err := s.repo.DeleteProduct(id); err != nil {
+	// Soft delete in repository; files stay until the product is purged
+	if err := s.repo.DeleteProduct(id, actor); err != nil {
 		return fmt.Errorf("failed to delete product: %w", err)
 	}
 
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/lucas/gokafka/product-service/internal/models"
+	"github.com/lucas/gokafka/product-service/internal/repository"
+	sharedModels "github.com/lucas/gokafka/shared/models"
+)
+
+// Variant limits
+const (
+	MaxVariantAttributes    = 10
+	MaxAttributeNameLength  = 50
+	MaxAttributeValueLength = 100
+)
+
+// skuPattern accepts SKUs such as "TSHIRT-RED-M" or "4006381.333931"
+var skuPattern = regexp.MustCompile(`^[A-Z0-9][A-Z0-9._-]{0,63}$`)
+
+// normalizeAttributes lowercases and trims attribute names and trims values
+func normalizeAttributes(attributes map[string]string) (map[string]string, error) {
+	normalized := make(map[string]string, len(attributes))
+	for name, value := range attributes {
+		name = strings.ToLower(strings.TrimSpace(name))
+		value = strings.TrimSpace(value)
+		if name == "" || value == "" {
+			return nil, fmt.Errorf("variant attributes need a name and a value")
+		}
+		if len(name) > MaxAttributeNameLength || len(value) > MaxAttributeValueLength {
+			return nil, fmt.Errorf("variant attribute names are limited to %d characters and values to %d",
+				MaxAttributeNameLength, MaxAttributeValueLength)
+		}
+		if _, ok := normalized[name]; ok {
+			return nil, fmt.Errorf("duplicate variant attribute %q", name)
+		}
+		normalized[name] = value
+	}
+	if len(normalized) > MaxVariantAttributes {
+		return nil, fmt.Errorf("a variant cannot have more than %d attributes", MaxVariantAttributes)
+	}
+	return normalized, nil
+}
+
+// validateVariant normalizes the SKU and attributes and checks the price
+// and stock of a variant
+func validateVariant(variant *models.ProductVariant) error {
+	if variant.ProductID <= 0 {
+		return fmt.Errorf("invalid product ID")
+	}
+
+	variant.SKU = strings.ToUpper(strings.TrimSpace(variant.SKU))
+	if variant.SKU == "" {
+		return fmt.Errorf("variant SKU is required")
+	}
+	if !skuPattern.MatchString(variant.SKU) {
+		return fmt.Errorf("variant SKU may only contain letters, digits, dots, dashes and underscores, up to 64 characters")
+	}
+
+	attributes, err := normalizeAttributes(variant.Attributes)
+	if err != nil {
+		return err
+	}
+	variant.Attributes = attributes
+
+	if variant.Price != nil && !variant.Price.IsPositive() {
+		return fmt.Errorf("variant price must be greater than 0")
+	}
+	if variant.Stock < 0 {
+		return fmt.Errorf("variant stock cannot be negative")
+	}
+	return nil
+}
+
+// variantError turns repository variant errors into client messages
+func variantError(action string, err error) error {
+	switch {
+	case errors.Is(err, repository.ErrProductNotFound),
+		errors.Is(err, repository.ErrVariantNotFound),
+		errors.Is(err, repository.ErrSKUTaken),
+		errors.Is(err, repository.ErrVariantExists),
+		errors.Is(err, repository.ErrVariantCurrency),
+		errors.Is(err, repository.ErrTooManyVariants):
+		return err
+	default:
+		return fmt.Errorf("failed to %s: %w", action, err)
+	}
+}
+
+// CreateVariant adds a variant with its own SKU and stock to a product
+func (s *ProductService) CreateVariant(req sharedModels.CreateVariantRequest, actor string) (*sharedModels.ProductVariantData, error) {
+	variant := &models.ProductVariant{
+		ProductID:  req.ProductID,
+		SKU:        req.SKU,
+		Attributes: req.Attributes,
+		Price:      req.Price,
+		Stock:      req.Stock,
+	}
+	if err := validateVariant(variant); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.CreateVariant(variant, actor); err != nil {
+		return nil, variantError("create variant", err)
+	}
+	return s.repo.VariantToVariantData(variant), nil
+}
+
+// UpdateVariant replaces a variant. A nil Price makes the variant use the
+// product price again.
+func (s *ProductService) UpdateVariant(req sharedModels.UpdateVariantRequest, actor string) (*sharedModels.ProductVariantData, error) {
+	if req.ID <= 0 {
+		return nil, fmt.Errorf("invalid variant ID")
+	}
+
+	variant := &models.ProductVariant{
+		ID:         req.ID,
+		ProductID:  req.ProductID,
+		SKU:        req.SKU,
+		Attributes: req.Attributes,
+		Price:      req.Price,
+		Stock:      req.Stock,
+	}
+	if err := validateVariant(variant); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UpdateVariant(variant, actor); err != nil {
+		return nil, variantError("update variant", err)
+	}
+	return s.repo.VariantToVariantData(variant), nil
+}
+
+func (s *ProductService) DeleteVariant(req sharedModels.DeleteVariantRequest, actor string) error {
+	if req.ProductID <= 0 {
+		return fmt.Errorf("invalid product ID")
+	}
+	if req.ID <= 0 {
+		return fmt.Errorf("invalid variant ID")
+	}
+
+	if err := s.repo.DeleteVariant(req.ProductID, req.ID, actor); err != nil {
+		return variantError("delete variant", err)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/lucas/gokafka/shared/utils"
+)
+
+// Local storage defaults. Objects are served by the service's own media
+// route, which the gateway exposes at the same path.
+const (
+	DefaultMediaRoot    = "/var/lib/product-service/media"
+	DefaultMediaBaseURL = "/media"
+)
+
+// LocalStorage keeps objects as files below a root directory
+type LocalStorage struct {
+	root    string
+	baseURL string
+}
+
+func NewLocalStorage() *LocalStorage {
+	root := utils.GetEnvOrDefault("MEDIA_ROOT", DefaultMediaRoot)
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		log.Fatalf("failed to create media root %s: %v", root, err)
+	}
+
+	return &LocalStorage{
+		root:    root,
+		baseURL: strings.TrimSuffix(utils.GetEnvOrDefault("MEDIA_BASE_URL", DefaultMediaBaseURL), "/"),
+	}
+}
+
+// path maps a key to a file below the root, rejecting keys that would
+// escape it
+func (s *LocalStorage) path(key string) (string, error) {
+	clean := path.Clean("/" + key)
+	if clean == "/" || clean != "/"+key {
+		return "", fmt.Errorf("invalid storage key %q", key)
+	}
+	return filepath.Join(s.root, filepath.FromSlash(clean)), nil
+}
+
+// Put writes the object to a temporary file first so readers never see a
+// partial object
+func (s *LocalStorage) Put(ctx context.Context, key string, data io.Reader, contentType string) error {
+	target, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	return os.Rename(tmp.Name(), target)
+}
+
+func (s *LocalStorage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	target, err := s.path(key)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+	info, err := os.Stat(target)
+	if os.IsNotExist(err) || (err == nil && info.IsDir()) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(target)
+}
+
+// Delete removes the object; deleting a missing object is not an error
+func (s *LocalStorage) Delete(ctx context.Context, key string) error {
+	target, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(target); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (s *LocalStorage) URL(key string) string {
+	return s.baseURL + "/" + key
+}
@@ -0,0 +1,41 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+
+	"github.com/lucas/gokafka/shared/utils"
+)
+
+// Storage backends
+const (
+	BackendLocal = "local"
+)
+
+// ErrNotFound is returned when no object exists under a key
+var ErrNotFound = errors.New("object not found")
+
+// Storage keeps uploaded media. Keys are slash separated relative paths such
+// as "products/12/3f1c.jpg".
+type Storage interface {
+	Put(ctx context.Context, key string, data io.Reader, contentType string) error
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	// URL returns the public URL clients fetch the object from
+	URL(key string) string
+}
+
+// NewStorage returns the backend selected by STORAGE_BACKEND. Only the local
+// filesystem is implemented; an S3-compatible backend can be added here.
+func NewStorage() Storage {
+	backend := utils.GetEnvOrDefault("STORAGE_BACKEND", BackendLocal)
+	switch backend {
+	case BackendLocal:
+		return NewLocalStorage()
+	default:
+		log.Fatalf("unknown STORAGE_BACKEND %q", backend)
+		return nil
+	}
+}
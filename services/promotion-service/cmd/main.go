@@ -0,0 +1,59 @@
+package main
+
+import (
+	"log"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lucas/gokafka/promotion-service/internal/handlers"
+	"github.com/lucas/gokafka/promotion-service/internal/repository"
+	"github.com/lucas/gokafka/promotion-service/internal/service"
+	"github.com/lucas/gokafka/shared/bus"
+	"github.com/lucas/gokafka/shared/utils"
+)
+
+const (
+	DefaultPort = "8088"
+	// ReplyTopic receives replies to the requests promotion-service sends
+	// to other services when pricing items
+	ReplyTopic = "promotion-service-replies"
+)
+
+func main() {
+	log.Println("Starting promotion-service...")
+
+	// Initialize dependencies
+	repo := repository.NewPromotionRepository()
+	client := bus.NewClient(ReplyTopic)
+	service := service.NewPromotionService(repo, client)
+	handler := handlers.NewPromotionHandler(service)
+
+	log.Println("Promotion-service started, waiting for requests...")
+
+	// Start Kafka message listener in background
+	go handler.ListenMessages()
+
+	// Start HTTP server
+	startHTTPServer()
+}
+
+func startHTTPServer() {
+	router := gin.Default()
+
+	// Health endpoints
+	router.GET("/health", healthHandler)
+	router.GET("/ready", readyHandler)
+
+	port := utils.GetEnvOrDefault("PORT", DefaultPort)
+	log.Printf("Starting HTTP server on port %s", port)
+	if err := router.Run(":" + port); err != nil {
+		log.Fatalf("Failed to start HTTP server: %v", err)
+	}
+}
+
+func healthHandler(c *gin.Context) {
+	c.JSON(200, gin.H{"status": "healthy"})
+}
+
+func readyHandler(c *gin.Context) {
+	c.JSON(200, gin.H{"status": "ready"})
+}
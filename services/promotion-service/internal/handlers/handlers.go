@@ -0,0 +1,295 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/lucas/gokafka/promotion-service/internal/service"
+	sharedModels "github.com/lucas/gokafka/shared/models"
+	"github.com/lucas/gokafka/shared/utils"
+	"github.com/segmentio/kafka-go"
+)
+
+// ReplyTopic is the topic the gateway listens on for this service's replies
+const ReplyTopic = "promotion-service-topic"
+
+// Request type constants
+const (
+	RequestTypeHealth           = "health"
+	RequestTypeCreateCoupon     = "create-coupon"
+	RequestTypeGetCoupon        = "get-coupon"
+	RequestTypeListCoupons      = "list-coupons"
+	RequestTypeDeactivateCoupon = "deactivate-coupon"
+	RequestTypeEvaluateCoupon   = "evaluate-coupon"
+	RequestTypeRedeemCoupon     = "redeem-coupon"
+	RequestTypeReleaseCoupon    = "release-coupon"
+)
+
+type PromotionHandler struct {
+	writer  *kafka.Writer
+	reader  *kafka.Reader
+	service *service.PromotionService
+}
+
+func NewPromotionHandler(service *service.PromotionService) *PromotionHandler {
+	broker := utils.GetEnvOrDefault("KAFKA_BROKERS", "localhost:9092")
+
+	return &PromotionHandler{
+		service: service,
+		writer: kafka.NewWriter(kafka.WriterConfig{
+			Brokers: []string{broker},
+		}),
+		reader: kafka.NewReader(kafka.ReaderConfig{
+			Brokers: []string{broker},
+			Topic:   "api-gateway-topic",
+			GroupID: "promotion-service-group",
+		}),
+	}
+}
+
+// Helper method to create error responses
+func (h *PromotionHandler) createErrorResponse(correlationID, errorMsg string) sharedModels.Response {
+	return sharedModels.Response{
+		CorrelationID: correlationID,
+		Success:       false,
+		Error:         errorMsg,
+	}
+}
+
+// Helper method to create success responses
+func (h *PromotionHandler) createSuccessResponse(correlationID string, data interface{}) sharedModels.Response {
+	dataBytes, _ := json.Marshal(data)
+	return sharedModels.Response{
+		CorrelationID: correlationID,
+		Success:       true,
+		Data:          string(dataBytes),
+	}
+}
+
+// Helper method to unmarshal request payload
+func (h *PromotionHandler) unmarshalPayload(payload string, target interface{}) error {
+	return json.Unmarshal([]byte(payload), target)
+}
+
+func (h *PromotionHandler) ListenMessages() {
+	for {
+		m, err := h.reader.ReadMessage(context.Background())
+		if err != nil {
+			log.Println("read error:", err)
+			continue
+		}
+
+		var req sharedModels.Request
+		if err := json.Unmarshal(m.Value, &req); err != nil {
+			log.Println("unmarshal error:", err)
+			continue
+		}
+
+		resp, ok := h.handleRequest(req)
+		if !ok {
+			continue
+		}
+
+		respBytes, _ := json.Marshal(resp)
+		err = h.writer.WriteMessages(context.Background(),
+			kafka.Message{
+				Topic: req.ReplyTo,
+				Value: respBytes,
+			},
+		)
+		if err != nil {
+			log.Println("write error:", err)
+		} else {
+			log.Printf("responded to %s with correlation_id %s", req.ReplyTo, req.CorrelationID)
+		}
+	}
+}
+
+// handleRequest processes different request types. It returns false for
+// requests owned by other services, which share the same request topic.
+func (h *PromotionHandler) handleRequest(req sharedModels.Request) (sharedModels.Response, bool) {
+	switch req.Type {
+	case RequestTypeHealth:
+		// Every service receives health checks; answer only our own
+		if req.ReplyTo != ReplyTopic {
+			return sharedModels.Response{}, false
+		}
+		return h.handleHealth(req.CorrelationID), true
+	case RequestTypeCreateCoupon:
+		return h.handleCreateCoupon(req), true
+	case RequestTypeGetCoupon:
+		return h.handleGetCoupon(req), true
+	case RequestTypeListCoupons:
+		return h.handleListCoupons(req), true
+	case RequestTypeDeactivateCoupon:
+		return h.handleDeactivateCoupon(req), true
+	case RequestTypeEvaluateCoupon:
+		return h.handleEvaluateCoupon(req), true
+	case RequestTypeRedeemCoupon:
+		return h.handleRedeemCoupon(req), true
+	case RequestTypeReleaseCoupon:
+		return h.handleReleaseCoupon(req), true
+	default:
+		return sharedModels.Response{}, false
+	}
+}
+
+// handleHealth returns health status
+func (h *PromotionHandler) handleHealth(correlationID string) sharedModels.Response {
+	healthResponse := map[string]interface{}{
+		"service":   "promotion-service",
+		"status":    "healthy",
+		"timestamp": time.Now().UTC().Format(time.RFC3339Nano),
+	}
+	return h.createSuccessResponse(correlationID, healthResponse)
+}
+
+// handleCreateCoupon processes create coupon requests
+func (h *PromotionHandler) handleCreateCoupon(req sharedModels.Request) sharedModels.Response {
+	var createReq sharedModels.CreateCouponRequest
+	if err := h.unmarshalPayload(req.Payload, &createReq); err != nil {
+		log.Printf("Failed to parse create coupon request: %v", err)
+		return h.createErrorResponse(req.CorrelationID, "Invalid create coupon request format")
+	}
+
+	result, err := h.service.CreateCoupon(createReq)
+	if err != nil {
+		log.Printf("Failed to create coupon: %v", err)
+		return h.createErrorResponse(req.CorrelationID, err.Error())
+	}
+
+	response := sharedModels.CouponResponse{
+		Status: "success",
+		Data:   *result,
+	}
+	return h.createSuccessResponse(req.CorrelationID, response)
+}
+
+// handleGetCoupon processes get coupon by ID requests
+func (h *PromotionHandler) handleGetCoupon(req sharedModels.Request) sharedModels.Response {
+	var getReq sharedModels.GetCouponRequest
+	if err := h.unmarshalPayload(req.Payload, &getReq); err != nil {
+		log.Printf("Failed to parse get coupon request: %v", err)
+		return h.createErrorResponse(req.CorrelationID, "Invalid get coupon request format")
+	}
+
+	result, err := h.service.GetCoupon(getReq)
+	if err != nil {
+		log.Printf("Failed to get coupon: %v", err)
+		return h.createErrorResponse(req.CorrelationID, err.Error())
+	}
+
+	response := sharedModels.CouponResponse{
+		Status: "success",
+		Data:   *result,
+	}
+	return h.createSuccessResponse(req.CorrelationID, response)
+}
+
+// handleListCoupons processes list coupons requests
+func (h *PromotionHandler) handleListCoupons(req sharedModels.Request) sharedModels.Response {
+	var listReq sharedModels.ListCouponsRequest
+	if err := h.unmarshalPayload(req.Payload, &listReq); err != nil {
+		log.Printf("Failed to parse list coupons request: %v", err)
+		return h.createErrorResponse(req.CorrelationID, "Invalid list coupons request format")
+	}
+
+	result, err := h.service.ListCoupons(listReq)
+	if err != nil {
+		log.Printf("Failed to list coupons: %v", err)
+		return h.createErrorResponse(req.CorrelationID, err.Error())
+	}
+
+	response := sharedModels.ListCouponsResponse{
+		Status: "success",
+		Data:   result,
+	}
+	return h.createSuccessResponse(req.CorrelationID, response)
+}
+
+// handleDeactivateCoupon processes deactivate coupon requests
+func (h *PromotionHandler) handleDeactivateCoupon(req sharedModels.Request) sharedModels.Response {
+	var deactivateReq sharedModels.DeactivateCouponRequest
+	if err := h.unmarshalPayload(req.Payload, &deactivateReq); err != nil {
+		log.Printf("Failed to parse deactivate coupon request: %v", err)
+		return h.createErrorResponse(req.CorrelationID, "Invalid deactivate coupon request format")
+	}
+
+	result, err := h.service.DeactivateCoupon(deactivateReq)
+	if err != nil {
+		log.Printf("Failed to deactivate coupon: %v", err)
+		return h.createErrorResponse(req.CorrelationID, err.Error())
+	}
+
+	response := sharedModels.CouponResponse{
+		Status: "success",
+		Data:   *result,
+	}
+	return h.createSuccessResponse(req.CorrelationID, response)
+}
+
+// handleEvaluateCoupon processes coupon evaluation requests
+func (h *PromotionHandler) handleEvaluateCoupon(req sharedModels.Request) sharedModels.Response {
+	var evaluateReq sharedModels.EvaluateCouponRequest
+	if err := h.unmarshalPayload(req.Payload, &evaluateReq); err != nil {
+		log.Printf("Failed to parse evaluate coupon request: %v", err)
+		return h.createErrorResponse(req.CorrelationID, "Invalid evaluate coupon request format")
+	}
+
+	result, err := h.service.Evaluate(evaluateReq)
+	if err != nil {
+		log.Printf("Failed to evaluate coupon: %v", err)
+		return h.createErrorResponse(req.CorrelationID, err.Error())
+	}
+
+	response := sharedModels.CouponEvaluationResponse{
+		Status: "success",
+		Data:   *result,
+	}
+	return h.createSuccessResponse(req.CorrelationID, response)
+}
+
+// handleRedeemCoupon processes redeem requests from checkout
+func (h *PromotionHandler) handleRedeemCoupon(req sharedModels.Request) sharedModels.Response {
+	var redeemReq sharedModels.RedeemCouponRequest
+	if err := h.unmarshalPayload(req.Payload, &redeemReq); err != nil {
+		log.Printf("Failed to parse redeem coupon request: %v", err)
+		return h.createErrorResponse(req.CorrelationID, "Invalid redeem coupon request format")
+	}
+
+	result, err := h.service.Redeem(redeemReq)
+	if err != nil {
+		log.Printf("Failed to redeem coupon: %v", err)
+		return h.createErrorResponse(req.CorrelationID, err.Error())
+	}
+
+	return h.createSuccessResponse(req.CorrelationID, result)
+}
+
+// handleReleaseCoupon processes release requests from checkout compensation
+func (h *PromotionHandler) handleReleaseCoupon(req sharedModels.Request) sharedModels.Response {
+	var releaseReq sharedModels.ReleaseCouponRequest
+	if err := h.unmarshalPayload(req.Payload, &releaseReq); err != nil {
+		log.Printf("Failed to parse release coupon request: %v", err)
+		return h.createErrorResponse(req.CorrelationID, "Invalid release coupon request format")
+	}
+
+	result, err := h.service.Release(releaseReq)
+	if err != nil {
+		log.Printf("Failed to release coupon: %v", err)
+		return h.createErrorResponse(req.CorrelationID, err.Error())
+	}
+
+	if result == nil {
+		response := map[string]interface{}{
+			"status":   "success",
+			"message":  "No coupon to release",
+			"order_id": releaseReq.OrderID,
+		}
+		return h.createSuccessResponse(req.CorrelationID, response)
+	}
+
+	return h.createSuccessResponse(req.CorrelationID, result)
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	sharedModels "github.com/lucas/gokafka/shared/models"
+)
+
+// Coupon is a discount code. AmountOff and MinOrderValue share the
+// coupon's currency; zero limits mean unlimited.
+type Coupon struct {
+	ID                    int                 `json:"id" db:"id"`
+	Code                  string              `json:"code" db:"code"`
+	Description           string              `json:"description" db:"description"`
+	Type                  string              `json:"type" db:"type"`
+	PercentOff            int                 `json:"percent_off" db:"percent_off"`
+	AmountOff             *sharedModels.Money `json:"amount_off" db:"amount_off"`
+	MinOrderValue         *sharedModels.Money `json:"min_order_value" db:"min_order_value"`
+	MaxRedemptions        int                 `json:"max_redemptions" db:"max_redemptions"`
+	MaxRedemptionsPerUser int                 `json:"max_redemptions_per_user" db:"max_redemptions_per_user"`
+	Redemptions           int                 `json:"redemptions" db:"redemptions"` // redeemed and not released
+	StartsAt              *time.Time          `json:"starts_at" db:"starts_at"`
+	ExpiresAt             *time.Time          `json:"expires_at" db:"expires_at"`
+	Active                bool                `json:"active" db:"active"`
+	CreatedAt             time.Time           `json:"created_at" db:"created_at"`
+	UpdatedAt             time.Time           `json:"updated_at" db:"updated_at"`
+}
+
+// CouponRedemption records a coupon applied to an order. There is at most
+// one per order; a released redemption no longer counts against the
+// coupon's limits.
+type CouponRedemption struct {
+	ID        string             `json:"id" db:"id"`
+	CouponID  int                `json:"coupon_id" db:"coupon_id"`
+	Code      string             `json:"code" db:"code"`
+	OrderID   string             `json:"order_id" db:"order_id"`
+	UserID    string             `json:"user_id" db:"user_id"`
+	Subtotal  sharedModels.Money `json:"subtotal" db:"subtotal"`
+	Discount  sharedModels.Money `json:"discount" db:"discount"`
+	Status    string             `json:"status" db:"status"`
+	CreatedAt time.Time          `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time          `json:"updated_at" db:"updated_at"`
+}
@@ -0,0 +1,456 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/lucas/gokafka/promotion-service/internal/models"
+	sharedModels "github.com/lucas/gokafka/shared/models"
+	"github.com/lucas/gokafka/shared/utils"
+)
+
+// Database constants
+const (
+	DefaultPostgresHost     = "localhost"
+	DefaultPostgresPort     = "5432"
+	DefaultPostgresDB       = "gokafka"
+	DefaultPostgresUser     = "postgres"
+	DefaultPostgresPassword = "postgres"
+)
+
+// pqUniqueViolation is the Postgres error code for unique constraint errors
+const pqUniqueViolation = "23505"
+
+var (
+	ErrCouponNotFound  = errors.New("coupon not found")
+	ErrCouponCodeTaken = errors.New("coupon code is already in use")
+)
+
+// DiscountFunc checks that a coupon applies and returns its discount.
+// userRedemptions is how often the user has redeemed the coupon already.
+type DiscountFunc func(coupon *models.Coupon, userRedemptions int) (sharedModels.Money, error)
+
+type PromotionRepository struct {
+	db *sql.DB
+}
+
+func NewPromotionRepository() *PromotionRepository {
+	db := initDatabase()
+	repo := &PromotionRepository{db: db}
+	repo.createTablesIfNotExists()
+	return repo
+}
+
+// initDatabase initializes the database connection
+func initDatabase() *sql.DB {
+	host := utils.GetEnvOrDefault("POSTGRES_HOST", DefaultPostgresHost)
+	port := utils.GetEnvOrDefault("POSTGRES_PORT", DefaultPostgresPort)
+	dbname := utils.GetEnvOrDefault("POSTGRES_DB", DefaultPostgresDB)
+	user := utils.GetEnvOrDefault("POSTGRES_USER", DefaultPostgresUser)
+	password := utils.GetEnvOrDefault("POSTGRES_PASSWORD", DefaultPostgresPassword)
+
+	connStr := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable",
+		user, password, host, port, dbname)
+
+	log.Printf("Connecting to PostgreSQL at %s:%s", host, port)
+	db, err := sql.Open("postgres", connStr)
+	if err != nil {
+		log.Fatalf("failed to connect to postgres: %v", err)
+	}
+
+	if err := db.Ping(); err != nil {
+		log.Fatalf("failed to ping postgres: %v", err)
+	}
+
+	log.Println("Connected to PostgreSQL database")
+	return db
+}
+
+func (r *PromotionRepository) createTablesIfNotExists() {
+	query := `
+	CREATE TABLE IF NOT EXISTS coupons (
+		id SERIAL PRIMARY KEY,
+		code VARCHAR(50) NOT NULL UNIQUE,
+		description VARCHAR(255) NOT NULL DEFAULT '',
+		type VARCHAR(20) NOT NULL,
+		percent_off INTEGER NOT NULL DEFAULT 0 CHECK (percent_off BETWEEN 0 AND 100),
+		amount_off NUMERIC(12, 2),
+		min_order_value NUMERIC(12, 2),
+		currency VARCHAR(3),
+		max_redemptions INTEGER NOT NULL DEFAULT 0,
+		max_redemptions_per_user INTEGER NOT NULL DEFAULT 0,
+		redemptions INTEGER NOT NULL DEFAULT 0 CHECK (redemptions >= 0),
+		starts_at TIMESTAMP,
+		expires_at TIMESTAMP,
+		active BOOLEAN NOT NULL DEFAULT TRUE,
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE TABLE IF NOT EXISTS coupon_redemptions (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		coupon_id INTEGER NOT NULL REFERENCES coupons (id),
+		order_id VARCHAR(255) NOT NULL UNIQUE,
+		user_id VARCHAR(255) NOT NULL,
+		subtotal NUMERIC(12, 2) NOT NULL,
+		discount NUMERIC(12, 2) NOT NULL,
+		currency VARCHAR(3) NOT NULL,
+		status VARCHAR(20) NOT NULL,
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE INDEX IF NOT EXISTS idx_coupon_redemptions_user ON coupon_redemptions (coupon_id, user_id)
+		WHERE status = 'redeemed'`
+
+	if _, err := r.db.Exec(query); err != nil {
+		log.Fatalf("failed to create promotion tables: %v", err)
+	}
+
+	log.Println("Promotion tables are ready")
+}
+
+const couponColumns = `id, code, description, type, percent_off, amount_off, min_order_value, currency,
+	max_redemptions, max_redemptions_per_user, redemptions, starts_at, expires_at, active,
+	created_at, updated_at`
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanCoupon reads a coupon row. NUMERIC amounts are scanned as text so
+// they convert to Money without passing through float64.
+func scanCoupon(row rowScanner) (*models.Coupon, error) {
+	var coupon models.Coupon
+	var amountOff, minOrderValue, currency sql.NullString
+	var startsAt, expiresAt sql.NullTime
+	err := row.Scan(
+		&coupon.ID, &coupon.Code, &coupon.Description, &coupon.Type, &coupon.PercentOff,
+		&amountOff, &minOrderValue, &currency, &coupon.MaxRedemptions,
+		&coupon.MaxRedemptionsPerUser, &coupon.Redemptions, &startsAt, &expiresAt,
+		&coupon.Active, &coupon.CreatedAt, &coupon.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrCouponNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if startsAt.Valid {
+		coupon.StartsAt = &startsAt.Time
+	}
+	if expiresAt.Valid {
+		coupon.ExpiresAt = &expiresAt.Time
+	}
+	if amountOff.Valid {
+		money, err := sharedModels.ParseMoney(amountOff.String, currency.String)
+		if err != nil {
+			return nil, fmt.Errorf("invalid amount off of coupon %d: %w", coupon.ID, err)
+		}
+		coupon.AmountOff = &money
+	}
+	if minOrderValue.Valid {
+		money, err := sharedModels.ParseMoney(minOrderValue.String, currency.String)
+		if err != nil {
+			return nil, fmt.Errorf("invalid minimum order value of coupon %d: %w", coupon.ID, err)
+		}
+		coupon.MinOrderValue = &money
+	}
+
+	return &coupon, nil
+}
+
+// moneyColumns returns the amount off, minimum order value and currency
+// columns of a coupon; unset values are NULL
+func moneyColumns(coupon *models.Coupon) (interface{}, interface{}, interface{}) {
+	var amountOff, minOrderValue, currency interface{}
+	if coupon.AmountOff != nil {
+		amountOff, currency = coupon.AmountOff.Decimal(), coupon.AmountOff.Currency
+	}
+	if coupon.MinOrderValue != nil {
+		minOrderValue, currency = coupon.MinOrderValue.Decimal(), coupon.MinOrderValue.Currency
+	}
+	return amountOff, minOrderValue, currency
+}
+
+func (r *PromotionRepository) CreateCoupon(coupon *models.Coupon) error {
+	query := `
+	INSERT INTO coupons (code, description, type, percent_off, amount_off, min_order_value, currency,
+		max_redemptions, max_redemptions_per_user, starts_at, expires_at, active, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, TRUE, $12, $12)
+	RETURNING id, active, created_at, updated_at`
+
+	amountOff, minOrderValue, currency := moneyColumns(coupon)
+	err := r.db.QueryRow(query, coupon.Code, coupon.Description, coupon.Type, coupon.PercentOff,
+		amountOff, minOrderValue, currency, coupon.MaxRedemptions, coupon.MaxRedemptionsPerUser,
+		coupon.StartsAt, coupon.ExpiresAt, time.Now()).
+		Scan(&coupon.ID, &coupon.Active, &coupon.CreatedAt, &coupon.UpdatedAt)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == pqUniqueViolation {
+			return ErrCouponCodeTaken
+		}
+		return fmt.Errorf("failed to create coupon: %w", err)
+	}
+	return nil
+}
+
+func (r *PromotionRepository) GetCouponByID(id int) (*models.Coupon, error) {
+	return scanCoupon(r.db.QueryRow(`SELECT `+couponColumns+` FROM coupons WHERE id = $1`, id))
+}
+
+func (r *PromotionRepository) GetCouponByCode(code string) (*models.Coupon, error) {
+	return scanCoupon(r.db.QueryRow(`SELECT `+couponColumns+` FROM coupons WHERE code = $1`, code))
+}
+
+// ListCoupons returns coupons, newest first
+func (r *PromotionRepository) ListCoupons(activeOnly bool) ([]*models.Coupon, error) {
+	rows, err := r.db.Query(`
+	SELECT `+couponColumns+` FROM coupons
+	WHERE active OR NOT $1
+	ORDER BY created_at DESC, id DESC`, activeOnly)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	coupons := []*models.Coupon{}
+	for rows.Next() {
+		coupon, err := scanCoupon(rows)
+		if err != nil {
+			return nil, err
+		}
+		coupons = append(coupons, coupon)
+	}
+	return coupons, rows.Err()
+}
+
+// DeactivateCoupon stops a coupon from being redeemed
+func (r *PromotionRepository) DeactivateCoupon(id int) (*models.Coupon, error) {
+	return scanCoupon(r.db.QueryRow(`
+	UPDATE coupons SET active = FALSE, updated_at = $1
+	WHERE id = $2
+	RETURNING `+couponColumns, time.Now(), id))
+}
+
+// CountUserRedemptions returns how often a user has redeemed a coupon,
+// not counting released redemptions
+func (r *PromotionRepository) CountUserRedemptions(couponID int, userID string) (int, error) {
+	return countUserRedemptions(r.db, couponID, userID)
+}
+
+// queryer is implemented by both *sql.DB and *sql.Tx
+type queryer interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+func countUserRedemptions(q queryer, couponID int, userID string) (int, error) {
+	var count int
+	err := q.QueryRow(`
+	SELECT COUNT(*) FROM coupon_redemptions
+	WHERE coupon_id = $1 AND user_id = $2 AND status = $3`,
+		couponID, userID, sharedModels.CouponRedemptionStatusRedeemed).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count redemptions: %w", err)
+	}
+	return count, nil
+}
+
+const redemptionColumns = `r.id, r.coupon_id, c.code, r.order_id, r.user_id, r.subtotal, r.discount,
+	r.currency, r.status, r.created_at, r.updated_at`
+
+// scanRedemption reads a redemption row joined with its coupon
+func scanRedemption(row rowScanner) (*models.CouponRedemption, error) {
+	var redemption models.CouponRedemption
+	var subtotal, discount, currency string
+	err := row.Scan(
+		&redemption.ID, &redemption.CouponID, &redemption.Code, &redemption.OrderID,
+		&redemption.UserID, &subtotal, &discount, &currency, &redemption.Status,
+		&redemption.CreatedAt, &redemption.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if redemption.Subtotal, err = sharedModels.ParseMoney(subtotal, currency); err != nil {
+		return nil, fmt.Errorf("invalid subtotal of redemption %s: %w", redemption.ID, err)
+	}
+	if redemption.Discount, err = sharedModels.ParseMoney(discount, currency); err != nil {
+		return nil, fmt.Errorf("invalid discount of redemption %s: %w", redemption.ID, err)
+	}
+	return &redemption, nil
+}
+
+// getRedemptionForOrder returns the redemption of an order, locking it when
+// lock is set
+func getRedemptionForOrder(q queryer, orderID string, lock bool) (*models.CouponRedemption, error) {
+	query := `
+	SELECT ` + redemptionColumns + `
+	FROM coupon_redemptions r JOIN coupons c ON c.id = r.coupon_id
+	WHERE r.order_id = $1`
+	if lock {
+		query += ` FOR UPDATE OF r`
+	}
+	return scanRedemption(q.QueryRow(query, orderID))
+}
+
+// RedeemCoupon applies the coupon with code to an order, once. The coupon
+// is locked while discount checks its limits, so concurrent redemptions
+// cannot exceed them. If the order already has a redemption it is returned
+// instead, with created false.
+func (r *PromotionRepository) RedeemCoupon(code, orderID, userID string, subtotal sharedModels.Money, discount DiscountFunc) (*models.CouponRedemption, bool, error) {
+	existing, err := getRedemptionForOrder(r.db, orderID, false)
+	if err == nil {
+		return existing, false, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, false, fmt.Errorf("failed to get redemption: %w", err)
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	coupon, err := scanCoupon(tx.QueryRow(`SELECT `+couponColumns+` FROM coupons WHERE code = $1 FOR UPDATE`, code))
+	if err != nil {
+		return nil, false, err
+	}
+
+	userRedemptions, err := countUserRedemptions(tx, coupon.ID, userID)
+	if err != nil {
+		return nil, false, err
+	}
+	amount, err := discount(coupon, userRedemptions)
+	if err != nil {
+		return nil, false, err
+	}
+
+	now := time.Now()
+	redemption := &models.CouponRedemption{
+		CouponID: coupon.ID,
+		Code:     coupon.Code,
+		OrderID:  orderID,
+		UserID:   userID,
+		Subtotal: subtotal,
+		Discount: amount,
+		Status:   sharedModels.CouponRedemptionStatusRedeemed,
+	}
+	query := `
+	INSERT INTO coupon_redemptions (coupon_id, order_id, user_id, subtotal, discount, currency, status, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $8)
+	ON CONFLICT (order_id) DO NOTHING
+	RETURNING id, created_at, updated_at`
+
+	err = tx.QueryRow(query, coupon.ID, orderID, userID, subtotal.Decimal(), amount.Decimal(),
+		subtotal.Currency, redemption.Status, now).
+		Scan(&redemption.ID, &redemption.CreatedAt, &redemption.UpdatedAt)
+	if err == sql.ErrNoRows {
+		// A concurrent request for the same order got there first
+		tx.Rollback()
+		existing, err := getRedemptionForOrder(r.db, orderID, false)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to get redemption: %w", err)
+		}
+		return existing, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to redeem coupon: %w", err)
+	}
+
+	if _, err := tx.Exec(`UPDATE coupons SET redemptions = redemptions + 1, updated_at = $1 WHERE id = $2`,
+		now, coupon.ID); err != nil {
+		return nil, false, fmt.Errorf("failed to count redemption: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, false, fmt.Errorf("failed to commit: %w", err)
+	}
+	return redemption, true, nil
+}
+
+// ReleaseRedemption releases the redemption of an order. It returns nil
+// when the order redeemed no coupon; releasing twice is harmless.
+func (r *PromotionRepository) ReleaseRedemption(orderID string) (*models.CouponRedemption, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	redemption, err := getRedemptionForOrder(tx, orderID, true)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get redemption: %w", err)
+	}
+	if redemption.Status == sharedModels.CouponRedemptionStatusReleased {
+		return redemption, nil
+	}
+
+	now := time.Now()
+	_, err = tx.Exec(`UPDATE coupon_redemptions SET status = $1, updated_at = $2 WHERE id = $3`,
+		sharedModels.CouponRedemptionStatusReleased, now, redemption.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to release redemption: %w", err)
+	}
+	if _, err := tx.Exec(`UPDATE coupons SET redemptions = redemptions - 1, updated_at = $1 WHERE id = $2`,
+		now, redemption.CouponID); err != nil {
+		return nil, fmt.Errorf("failed to uncount redemption: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit: %w", err)
+	}
+	redemption.Status = sharedModels.CouponRedemptionStatusReleased
+	redemption.UpdatedAt = now
+	return redemption, nil
+}
+
+// CouponToCouponData converts a coupon to its bus form
+func (r *PromotionRepository) CouponToCouponData(coupon *models.Coupon) *sharedModels.CouponData {
+	data := &sharedModels.CouponData{
+		ID:                    coupon.ID,
+		Code:                  coupon.Code,
+		Description:           coupon.Description,
+		Type:                  coupon.Type,
+		PercentOff:            coupon.PercentOff,
+		AmountOff:             coupon.AmountOff,
+		MinOrderValue:         coupon.MinOrderValue,
+		MaxRedemptions:        coupon.MaxRedemptions,
+		MaxRedemptionsPerUser: coupon.MaxRedemptionsPerUser,
+		Redemptions:           coupon.Redemptions,
+		Active:                coupon.Active,
+		CreatedAt:             coupon.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:             coupon.UpdatedAt.Format(time.RFC3339),
+	}
+	if coupon.StartsAt != nil {
+		data.StartsAt = coupon.StartsAt.Format(time.RFC3339)
+	}
+	if coupon.ExpiresAt != nil {
+		data.ExpiresAt = coupon.ExpiresAt.Format(time.RFC3339)
+	}
+	return data
+}
+
+// RedemptionToRedemptionData converts a redemption to its bus form
+func (r *PromotionRepository) RedemptionToRedemptionData(redemption *models.CouponRedemption) *sharedModels.CouponRedemptionData {
+	return &sharedModels.CouponRedemptionData{
+		ID:        redemption.ID,
+		CouponID:  redemption.CouponID,
+		Code:      redemption.Code,
+		OrderID:   redemption.OrderID,
+		UserID:    redemption.UserID,
+		Subtotal:  redemption.Subtotal,
+		Discount:  redemption.Discount,
+		Status:    redemption.Status,
+		CreatedAt: redemption.CreatedAt.Format(time.RFC3339),
+		UpdatedAt: redemption.UpdatedAt.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,382 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/lucas/gokafka/promotion-service/internal/models"
+	"github.com/lucas/gokafka/promotion-service/internal/repository"
+	"github.com/lucas/gokafka/shared/bus"
+	sharedModels "github.com/lucas/gokafka/shared/models"
+)
+
+const MaxCouponDescriptionLength = 255
+
+// codePattern accepts codes such as "SUMMER-25" or "WELCOME_10"
+var codePattern = regexp.MustCompile(`^[A-Z0-9_-]{3,50}$`)
+
+type PromotionService struct {
+	repo   *repository.PromotionRepository
+	client *bus.Client
+}
+
+func NewPromotionService(repo *repository.PromotionRepository, client *bus.Client) *PromotionService {
+	return &PromotionService{
+		repo:   repo,
+		client: client,
+	}
+}
+
+// normalizeCode makes coupon codes case-insensitive
+func normalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// couponError maps repository errors to messages for the caller
+func couponError(action string, err error) error {
+	switch {
+	case errors.Is(err, repository.ErrCouponNotFound):
+		return fmt.Errorf("coupon not found")
+	case errors.Is(err, repository.ErrCouponCodeTaken):
+		return err
+	default:
+		return fmt.Errorf("failed to %s: %w", action, err)
+	}
+}
+
+// CreateCoupon validates and stores a new coupon
+func (s *PromotionService) CreateCoupon(req sharedModels.CreateCouponRequest) (*sharedModels.CouponData, error) {
+	coupon := &models.Coupon{
+		Code:                  normalizeCode(req.Code),
+		Description:           strings.TrimSpace(req.Description),
+		Type:                  req.Type,
+		MaxRedemptions:        req.MaxRedemptions,
+		MaxRedemptionsPerUser: req.MaxRedemptionsPerUser,
+	}
+	if !codePattern.MatchString(coupon.Code) {
+		return nil, fmt.Errorf("coupon code must be 3 to 50 letters, digits, '-' or '_'")
+	}
+	if len(coupon.Description) > MaxCouponDescriptionLength {
+		return nil, fmt.Errorf("coupon description is limited to %d characters", MaxCouponDescriptionLength)
+	}
+
+	switch req.Type {
+	case sharedModels.CouponTypePercentage:
+		if req.PercentOff < 1 || req.PercentOff > 100 {
+			return nil, fmt.Errorf("percent_off must be between 1 and 100")
+		}
+		if req.AmountOff != nil {
+			return nil, fmt.Errorf("percentage coupons take percent_off, not amount_off")
+		}
+		coupon.PercentOff = req.PercentOff
+	case sharedModels.CouponTypeFixed:
+		if req.AmountOff == nil || !req.AmountOff.IsPositive() {
+			return nil, fmt.Errorf("amount_off must be greater than 0")
+		}
+		if req.PercentOff != 0 {
+			return nil, fmt.Errorf("fixed coupons take amount_off, not percent_off")
+		}
+		if err := sharedModels.ValidateCurrency(req.AmountOff.Currency); err != nil {
+			return nil, err
+		}
+		amountOff := *req.AmountOff
+		coupon.AmountOff = &amountOff
+	default:
+		return nil, fmt.Errorf("coupon type must be %q or %q", sharedModels.CouponTypePercentage, sharedModels.CouponTypeFixed)
+	}
+
+	if req.MinOrderValue != nil {
+		if !req.MinOrderValue.IsPositive() {
+			return nil, fmt.Errorf("min_order_value must be greater than 0")
+		}
+		if err := sharedModels.ValidateCurrency(req.MinOrderValue.Currency); err != nil {
+			return nil, err
+		}
+		if coupon.AmountOff != nil && coupon.AmountOff.Currency != req.MinOrderValue.Currency {
+			return nil, fmt.Errorf("amount_off and min_order_value must share a currency")
+		}
+		minOrderValue := *req.MinOrderValue
+		coupon.MinOrderValue = &minOrderValue
+	}
+
+	if req.MaxRedemptions < 0 || req.MaxRedemptionsPerUser < 0 {
+		return nil, fmt.Errorf("redemption limits cannot be negative")
+	}
+
+	if req.StartsAt != "" {
+		startsAt, err := time.Parse(time.RFC3339, req.StartsAt)
+		if err != nil {
+			return nil, fmt.Errorf("starts_at must be an RFC3339 timestamp")
+		}
+		startsAt = startsAt.UTC()
+		coupon.StartsAt = &startsAt
+	}
+	if req.ExpiresAt != "" {
+		expiresAt, err := time.Parse(time.RFC3339, req.ExpiresAt)
+		if err != nil {
+			return nil, fmt.Errorf("expires_at must be an RFC3339 timestamp")
+		}
+		expiresAt = expiresAt.UTC()
+		if !expiresAt.After(time.Now()) || (coupon.StartsAt != nil && !expiresAt.After(*coupon.StartsAt)) {
+			return nil, fmt.Errorf("expires_at must be after starts_at and in the future")
+		}
+		coupon.ExpiresAt = &expiresAt
+	}
+
+	if err := s.repo.CreateCoupon(coupon); err != nil {
+		return nil, couponError("create coupon", err)
+	}
+	return s.repo.CouponToCouponData(coupon), nil
+}
+
+// GetCoupon returns a coupon by ID
+func (s *PromotionService) GetCoupon(req sharedModels.GetCouponRequest) (*sharedModels.CouponData, error) {
+	if req.ID <= 0 {
+		return nil, fmt.Errorf("invalid coupon ID")
+	}
+
+	coupon, err := s.repo.GetCouponByID(req.ID)
+	if err != nil {
+		return nil, couponError("get coupon", err)
+	}
+	return s.repo.CouponToCouponData(coupon), nil
+}
+
+// ListCoupons returns all coupons, or only active ones, newest first
+func (s *PromotionService) ListCoupons(req sharedModels.ListCouponsRequest) ([]sharedModels.CouponData, error) {
+	coupons, err := s.repo.ListCoupons(req.ActiveOnly)
+	if err != nil {
+		return nil, couponError("list coupons", err)
+	}
+
+	result := make([]sharedModels.CouponData, 0, len(coupons))
+	for _, coupon := range coupons {
+		result = append(result, *s.repo.CouponToCouponData(coupon))
+	}
+	return result, nil
+}
+
+// DeactivateCoupon stops a coupon from being redeemed. Orders that already
+// redeemed it keep their discount.
+func (s *PromotionService) DeactivateCoupon(req sharedModels.DeactivateCouponRequest) (*sharedModels.CouponData, error) {
+	if req.ID <= 0 {
+		return nil, fmt.Errorf("invalid coupon ID")
+	}
+
+	coupon, err := s.repo.DeactivateCoupon(req.ID)
+	if err != nil {
+		return nil, couponError("deactivate coupon", err)
+	}
+	return s.repo.CouponToCouponData(coupon), nil
+}
+
+// couponDiscount checks that a coupon applies to an order of subtotal for a
+// user who redeemed it userRedemptions times, and returns the discount.
+// The errors are shown to shoppers, so they say why the coupon was refused.
+func couponDiscount(coupon *models.Coupon, userRedemptions int, subtotal sharedModels.Money, now time.Time) (sharedModels.Money, error) {
+	switch {
+	case !coupon.Active:
+		return sharedModels.Money{}, fmt.Errorf("coupon %s is no longer active", coupon.Code)
+	case coupon.StartsAt != nil && now.Before(*coupon.StartsAt):
+		return sharedModels.Money{}, fmt.Errorf("coupon %s is not valid yet", coupon.Code)
+	case coupon.ExpiresAt != nil && !now.Before(*coupon.ExpiresAt):
+		return sharedModels.Money{}, fmt.Errorf("coupon %s has expired", coupon.Code)
+	case coupon.MaxRedemptions > 0 && coupon.Redemptions >= coupon.MaxRedemptions:
+		return sharedModels.Money{}, fmt.Errorf("coupon %s has been fully redeemed", coupon.Code)
+	case coupon.MaxRedemptionsPerUser > 0 && userRedemptions >= coupon.MaxRedemptionsPerUser:
+		return sharedModels.Money{}, fmt.Errorf("coupon %s has already been used the maximum number of times", coupon.Code)
+	}
+
+	if coupon.MinOrderValue != nil {
+		if coupon.MinOrderValue.Currency != subtotal.Currency {
+			return sharedModels.Money{}, fmt.Errorf("coupon %s is not valid for %s orders", coupon.Code, subtotal.Currency)
+		}
+		if subtotal.Amount < coupon.MinOrderValue.Amount {
+			return sharedModels.Money{}, fmt.Errorf("coupon %s requires an order of at least %s", coupon.Code, coupon.MinOrderValue)
+		}
+	}
+
+	if coupon.Type == sharedModels.CouponTypePercentage {
+		// Round half up to the minor unit
+		return sharedModels.NewMoney((subtotal.Amount*int64(coupon.PercentOff)+50)/100, subtotal.Currency), nil
+	}
+
+	if coupon.AmountOff.Currency != subtotal.Currency {
+		return sharedModels.Money{}, fmt.Errorf("coupon %s is not valid for %s orders", coupon.Code, subtotal.Currency)
+	}
+	// A fixed discount never makes the order negative
+	if coupon.AmountOff.Amount > subtotal.Amount {
+		return subtotal, nil
+	}
+	return *coupon.AmountOff, nil
+}
+
+// Evaluate prices items at the current product prices and shows what a
+// coupon would take off, without redeeming it
+func (s *PromotionService) Evaluate(req sharedModels.EvaluateCouponRequest) (*sharedModels.CouponEvaluationData, error) {
+	code := normalizeCode(req.Code)
+	if code == "" {
+		return nil, fmt.Errorf("coupon code is required")
+	}
+	if req.UserID == "" {
+		return nil, fmt.Errorf("user ID is required")
+	}
+	if len(req.Items) == 0 {
+		return nil, fmt.Errorf("at least one item is required")
+	}
+
+	// Merge duplicate lines so each product and variant appears once
+	type line struct{ productID, variantID int }
+	quantities := make(map[line]int)
+	var lines []line
+	var productIDs []int
+	seen := make(map[int]bool)
+	for _, item := range req.Items {
+		if item.ProductID <= 0 {
+			return nil, fmt.Errorf("invalid product ID")
+		}
+		if item.VariantID < 0 {
+			return nil, fmt.Errorf("invalid variant ID")
+		}
+		if item.Quantity <= 0 {
+			return nil, fmt.Errorf("quantity must be greater than 0")
+		}
+		key := line{item.ProductID, item.VariantID}
+		if _, ok := quantities[key]; !ok {
+			lines = append(lines, key)
+		}
+		quantities[key] += item.Quantity
+		if !seen[item.ProductID] {
+			seen[item.ProductID] = true
+			productIDs = append(productIDs, item.ProductID)
+		}
+	}
+	if len(productIDs) > sharedModels.MaxProductsPerLookup {
+		return nil, fmt.Errorf("cannot evaluate more than %d products at once", sharedModels.MaxProductsPerLookup)
+	}
+
+	var products sharedModels.GetProductsResponse
+	if err := s.client.Call("get-products-by-ids", sharedModels.GetProductsRequest{IDs: productIDs}, &products); err != nil {
+		return nil, fmt.Errorf("failed to price products: %w", err)
+	}
+	if len(products.Missing) > 0 {
+		return nil, fmt.Errorf("product %d not found", products.Missing[0])
+	}
+
+	byID := make(map[int]*sharedModels.ProductData, len(products.Data))
+	for i := range products.Data {
+		byID[products.Data[i].ID] = &products.Data[i]
+	}
+
+	result := &sharedModels.CouponEvaluationData{Code: code}
+	for _, key := range lines {
+		product := byID[key.productID]
+		if product == nil {
+			return nil, fmt.Errorf("product %d not found", key.productID)
+		}
+		saleItem, err := product.SaleItem(key.variantID)
+		if err != nil {
+			return nil, err
+		}
+		lineTotal, err := saleItem.UnitPrice.Mul(quantities[key])
+		if err != nil {
+			return nil, fmt.Errorf("invalid quantity of product %d: %w", product.ID, err)
+		}
+		item := sharedModels.OrderItemData{
+			ProductID: product.ID,
+			VariantID: saleItem.VariantID,
+			SKU:       saleItem.SKU,
+			Name:      product.Name,
+			Quantity:  quantities[key],
+			UnitPrice: saleItem.UnitPrice,
+			LineTotal: lineTotal,
+		}
+		result.Items = append(result.Items, item)
+
+		subtotal, err := result.Subtotal.Add(item.LineTotal)
+		if err != nil {
+			return nil, fmt.Errorf("products in one order must share a currency")
+		}
+		result.Subtotal = subtotal
+	}
+
+	coupon, err := s.repo.GetCouponByCode(code)
+	if err != nil {
+		return nil, couponError("get coupon", err)
+	}
+	userRedemptions, err := s.repo.CountUserRedemptions(coupon.ID, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	result.Discount, err = couponDiscount(coupon, userRedemptions, result.Subtotal, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	result.Total, err = result.Subtotal.Sub(result.Discount)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Redeem applies a coupon to an order once. Repeating the request returns
+// the stored redemption, so a retried checkout step is not counted twice.
+func (s *PromotionService) Redeem(req sharedModels.RedeemCouponRequest) (*sharedModels.CouponRedemptionData, error) {
+	code := normalizeCode(req.Code)
+	if code == "" {
+		return nil, fmt.Errorf("coupon code is required")
+	}
+	if req.OrderID == "" || req.UserID == "" {
+		return nil, fmt.Errorf("order ID and user ID are required")
+	}
+	if !req.Subtotal.IsPositive() {
+		return nil, fmt.Errorf("subtotal must be greater than 0")
+	}
+
+	var refusal error
+	redemption, created, err := s.repo.RedeemCoupon(code, req.OrderID, req.UserID, req.Subtotal,
+		func(coupon *models.Coupon, userRedemptions int) (sharedModels.Money, error) {
+			discount, err := couponDiscount(coupon, userRedemptions, req.Subtotal, time.Now())
+			refusal = err
+			return discount, err
+		})
+	if err != nil {
+		if refusal != nil {
+			return nil, refusal
+		}
+		return nil, couponError("redeem coupon", err)
+	}
+
+	if !created {
+		if redemption.Code != code || redemption.UserID != req.UserID || redemption.Subtotal != req.Subtotal {
+			return nil, fmt.Errorf("order %s already redeemed a different coupon", req.OrderID)
+		}
+		if redemption.Status == sharedModels.CouponRedemptionStatusReleased {
+			return nil, fmt.Errorf("coupon redemption of order %s was released", req.OrderID)
+		}
+	}
+
+	return s.repo.RedemptionToRedemptionData(redemption), nil
+}
+
+// Release gives back the redemption of an order. Releasing an order that
+// redeemed nothing succeeds with no redemption, so checkout compensation
+// works even if the redeem reply was lost.
+func (s *PromotionService) Release(req sharedModels.ReleaseCouponRequest) (*sharedModels.CouponRedemptionData, error) {
+	if req.OrderID == "" {
+		return nil, fmt.Errorf("order ID is required")
+	}
+
+	redemption, err := s.repo.ReleaseRedemption(req.OrderID)
+	if err != nil {
+		return nil, err
+	}
+	if redemption == nil {
+		return nil, nil
+	}
+	return s.repo.RedemptionToRedemptionData(redemption), nil
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lucas/gokafka/promotion-service/internal/models"
+	sharedModels "github.com/lucas/gokafka/shared/models"
+)
+
+func TestCouponDiscount(t *testing.T) {
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+	usd := func(amount int64) *sharedModels.Money {
+		money := sharedModels.NewMoney(amount, "USD")
+		return &money
+	}
+
+	percent := func(off int) models.Coupon {
+		return models.Coupon{Code: "SAVE", Type: sharedModels.CouponTypePercentage, PercentOff: off, Active: true}
+	}
+	fixed := func(off *sharedModels.Money) models.Coupon {
+		return models.Coupon{Code: "SAVE", Type: sharedModels.CouponTypeFixed, AmountOff: off, Active: true}
+	}
+	with := func(coupon models.Coupon, change func(*models.Coupon)) models.Coupon {
+		change(&coupon)
+		return coupon
+	}
+
+	tests := []struct {
+		name            string
+		coupon          models.Coupon
+		userRedemptions int
+		subtotal        sharedModels.Money
+		want            sharedModels.Money
+		wantErr         bool
+	}{
+		{name: "percentage", coupon: percent(10), subtotal: *usd(5000), want: *usd(500)},
+		{name: "percentage rounds half up", coupon: percent(10), subtotal: *usd(1995), want: *usd(200)},
+		{name: "percentage rounds down", coupon: percent(10), subtotal: *usd(1994), want: *usd(199)},
+		{name: "percentage rounds fraction up", coupon: percent(15), subtotal: *usd(1999), want: *usd(300)},
+		{name: "percentage of zero decimal currency", coupon: percent(15), subtotal: sharedModels.NewMoney(999, "JPY"), want: sharedModels.NewMoney(150, "JPY")},
+		{name: "fixed", coupon: fixed(usd(500)), subtotal: *usd(5000), want: *usd(500)},
+		{name: "fixed equal to subtotal", coupon: fixed(usd(500)), subtotal: *usd(500), want: *usd(500)},
+		{name: "fixed capped at subtotal", coupon: fixed(usd(500)), subtotal: *usd(300), want: *usd(300)},
+		{name: "fixed currency mismatch", coupon: fixed(usd(500)), subtotal: sharedModels.NewMoney(5000, "EUR"), wantErr: true},
+		{name: "inactive", coupon: with(percent(10), func(c *models.Coupon) { c.Active = false }), subtotal: *usd(5000), wantErr: true},
+		{name: "not started", coupon: with(percent(10), func(c *models.Coupon) { c.StartsAt = &future }), subtotal: *usd(5000), wantErr: true},
+		{name: "started", coupon: with(percent(10), func(c *models.Coupon) { c.StartsAt = &past }), subtotal: *usd(5000), want: *usd(500)},
+		{name: "not expired", coupon: with(percent(10), func(c *models.Coupon) { c.ExpiresAt = &future }), subtotal: *usd(5000), want: *usd(500)},
+		{name: "expired", coupon: with(percent(10), func(c *models.Coupon) { c.ExpiresAt = &past }), subtotal: *usd(5000), wantErr: true},
+		{name: "expires now", coupon: with(percent(10), func(c *models.Coupon) { c.ExpiresAt = &now }), subtotal: *usd(5000), wantErr: true},
+		{
+			name:     "global limit not reached",
+			coupon:   with(percent(10), func(c *models.Coupon) { c.MaxRedemptions, c.Redemptions = 3, 2 }),
+			subtotal: *usd(5000), want: *usd(500),
+		},
+		{
+			name:     "global limit reached",
+			coupon:   with(percent(10), func(c *models.Coupon) { c.MaxRedemptions, c.Redemptions = 3, 3 }),
+			subtotal: *usd(5000), wantErr: true,
+		},
+		{
+			name:     "no global limit",
+			coupon:   with(percent(10), func(c *models.Coupon) { c.Redemptions = 1000 }),
+			subtotal: *usd(5000), want: *usd(500),
+		},
+		{
+			name:            "per user limit not reached",
+			coupon:          with(percent(10), func(c *models.Coupon) { c.MaxRedemptionsPerUser = 2 }),
+			userRedemptions: 1, subtotal: *usd(5000), want: *usd(500),
+		},
+		{
+			name:            "per user limit reached",
+			coupon:          with(percent(10), func(c *models.Coupon) { c.MaxRedemptionsPerUser = 2 }),
+			userRedemptions: 2, subtotal: *usd(5000), wantErr: true,
+		},
+		{
+			name:     "min order value met",
+			coupon:   with(percent(10), func(c *models.Coupon) { c.MinOrderValue = usd(5000) }),
+			subtotal: *usd(5000), want: *usd(500),
+		},
+		{
+			name:     "min order value not met",
+			coupon:   with(percent(10), func(c *models.Coupon) { c.MinOrderValue = usd(5000) }),
+			subtotal: *usd(4999), wantErr: true,
+		},
+		{
+			name:     "min order value currency mismatch",
+			coupon:   with(percent(10), func(c *models.Coupon) { c.MinOrderValue = usd(1000) }),
+			subtotal: sharedModels.NewMoney(5000, "EUR"), wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := couponDiscount(&tt.coupon, tt.userRedemptions, tt.subtotal, now)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("couponDiscount(%+v, %d, %+v) = %+v, want error", tt.coupon, tt.userRedemptions, tt.subtotal, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("couponDiscount(%+v, %d, %+v) returned error: %v", tt.coupon, tt.userRedemptions, tt.subtotal, err)
+			}
+			if got != tt.want {
+				t.Errorf("couponDiscount(%+v, %d, %+v) = %+v, want %+v", tt.coupon, tt.userRedemptions, tt.subtotal, got, tt.want)
+			}
+		})
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"log"
 
 	"github.com/gin-gonic/gin"
+	sharedModels "github.com/lucas/gokafka/shared/models"
+	"github.com/lucas/gokafka/shared/outbox"
 	"github.com/lucas/gokafka/shared/utils"
 	"github.com/lucas/gokafka/user-service/internal/handlers"
 	"github.com/lucas/gokafka/user-service/internal/repository"
@@ -19,11 +21,15 @@ func main() {
 
 	// Initialize dependencies
 	repo := repository.NewUserRepository()
+	relay := outbox.NewRelay(repo.Outbox(), sharedModels.UserEventsTopic)
 	service := services.NewUserService(repo)
 	handler := handlers.NewUserServiceHandler(service)
 
 	log.Println("User-service started, waiting for requests...")
 
+	// Start outbox relay publishing user events in background
+	go relay.Run()
+
 	// Start Kafka message listener in background
 	go handler.ListenMessages()
 
@@ -12,6 +12,9 @@ import (
 	"github.com/segmentio/kafka-go"
 )
 
+// ReplyTopic is the topic the gateway listens on for this service's replies
+const ReplyTopic = "user-service-topic"
+
 // Request type constants
 const (
 	RequestTypeHealth           = "health"
@@ -21,6 +24,8 @@ const (
 	RequestTypeLogout           = "logout"
 	RequestTypeGetByID          = "get-by-id"
 	RequestTypeListUserProfiles = "list-user-profiles"
+	RequestTypeUpdateProfile    = "update-user-profile"
+	RequestTypeDeleteUser       = "delete-user"
 )
 
 type UserServiceHandler struct {
@@ -84,7 +89,10 @@ func (h *UserServiceHandler) ListenMessages() {
 			continue
 		}
 
-		resp := h.handleRequest(req)
+		resp, ok := h.handleRequest(req)
+		if !ok {
+			continue
+		}
 
 		respBytes, _ := json.Marshal(resp)
 		err = h.writer.WriteMessages(context.Background(),
@@ -101,28 +109,34 @@ func (h *UserServiceHandler) ListenMessages() {
 	}
 }
 
-// handleRequest processes different request types
-func (h *UserServiceHandler) handleRequest(req models.Request) models.Response {
+// handleRequest processes different request types. It returns false for
+// requests owned by other services, which share the same request topic.
+func (h *UserServiceHandler) handleRequest(req models.Request) (models.Response, bool) {
 	switch req.Type {
 	case RequestTypeHealth:
-		return h.handleHealth(req.CorrelationID)
+		// Every service receives health checks; answer only our own
+		if req.ReplyTo != ReplyTopic {
+			return models.Response{}, false
+		}
+		return h.handleHealth(req.CorrelationID), true
 	case RequestTypeRegister:
-		return h.handleRegister(req)
+		return h.handleRegister(req), true
 	case RequestTypeLogin:
-		return h.handleLogin(req)
+		return h.handleLogin(req), true
 	case RequestTypeGetUserProfile:
-		return h.handleGetUserProfile(req)
+		return h.handleGetUserProfile(req), true
 	case RequestTypeLogout:
-		return h.handleLogout(req)
+		return h.handleLogout(req), true
 	case RequestTypeGetByID:
-		return h.handleGetById(req)
+		return h.handleGetById(req), true
 	case RequestTypeListUserProfiles:
-		return h.handleListUserProfiles(req.CorrelationID)
+		return h.handleListUserProfiles(req), true
+	case RequestTypeUpdateProfile:
+		return h.handleUpdateProfile(req), true
+	case RequestTypeDeleteUser:
+		return h.handleDeleteUser(req), true
 	default:
-		return models.Response{
-			CorrelationID: req.CorrelationID,
-			Data:          "Unknown request type: " + req.Type,
-		}
+		return models.Response{}, false
 	}
 }
 
@@ -216,25 +230,64 @@ func (h *UserServiceHandler) handleGetById(req models.Request) models.Response {
 	return h.createSuccessResponse(req.CorrelationID, result)
 }
 
-// handleListUserProfiles processes list all user profiles request
-func (h *UserServiceHandler) handleListUserProfiles(correlationID string) models.Response {
-	result, err := h.service.GetAllUserProfile()
+// handleListUserProfiles processes a list user profiles request
+func (h *UserServiceHandler) handleListUserProfiles(req models.Request) models.Response {
+	// Older gateways send an empty payload
+	var listReq models.ListUsersRequest
+	if req.Payload != "" && req.Payload != `""` {
+		if err := h.unmarshalPayload(req.Payload, &listReq); err != nil {
+			log.Printf("Failed to parse list user profiles request: %v", err)
+			return h.createErrorResponse(req.CorrelationID, "Invalid request format")
+		}
+	}
+
+	result, err := h.service.ListUserProfiles(listReq)
 	if err != nil {
 		log.Printf("Failed to list user profiles: %v", err)
-		return h.createErrorResponse(correlationID, err.Error())
+		return h.createErrorResponse(req.CorrelationID, err.Error())
 	}
 
-	// Convert []*models.UserData to []models.UserData
-	userDataVals := make([]models.UserData, len(result))
-	for i, u := range result {
-		if u != nil {
-			userDataVals[i] = *u
-		}
+	return h.createSuccessResponse(req.CorrelationID, result)
+}
+
+// handleUpdateProfile processes user profile update request
+func (h *UserServiceHandler) handleUpdateProfile(req models.Request) models.Response {
+	var updateReq models.UpdateProfileRequest
+	if err := h.unmarshalPayload(req.Payload, &updateReq); err != nil {
+		log.Printf("Failed to parse update profile request: %v", err)
+		return h.createErrorResponse(req.CorrelationID, "Invalid update profile request format")
+	}
+
+	result, err := h.service.UpdateUserProfile(updateReq)
+	if err != nil {
+		log.Printf("Failed to update user profile: %v", err)
+		return h.createErrorResponse(req.CorrelationID, err.Error())
 	}
-	profileListResponse := models.ListProfileResponse{
+
+	profileResponse := models.GetProfileResponse{
 		Status: "success",
-		Data:   userDataVals,
+		Data:   *result,
+	}
+	return h.createSuccessResponse(req.CorrelationID, profileResponse)
+}
+
+// handleDeleteUser processes user deletion request
+func (h *UserServiceHandler) handleDeleteUser(req models.Request) models.Response {
+	var deleteReq models.DeleteUserRequest
+	if err := h.unmarshalPayload(req.Payload, &deleteReq); err != nil {
+		log.Printf("Failed to parse delete user request: %v", err)
+		return h.createErrorResponse(req.CorrelationID, "Invalid delete user request format")
 	}
 
-	return h.createSuccessResponse(correlationID, profileListResponse)
+	if err := h.service.DeleteUser(deleteReq.ID); err != nil {
+		log.Printf("Failed to delete user: %v", err)
+		return h.createErrorResponse(req.CorrelationID, err.Error())
+	}
+
+	response := map[string]interface{}{
+		"status":  "success",
+		"message": "User deleted successfully",
+		"id":      deleteReq.ID,
+	}
+	return h.createSuccessResponse(req.CorrelationID, response)
 }
@@ -9,4 +9,4 @@ type User struct {
 	CreatedAt string `json:"created_at"`
 	UpdatedAt string `json:"updated_at"`
 	Role      string `json:"role"`
-}
\ No newline at end of file
+}
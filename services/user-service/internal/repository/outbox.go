@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	sharedModels "github.com/lucas/gokafka/shared/models"
+)
+
+// insertOutboxEvent records a user event inside the caller's transaction
+func (r *UserRepository) insertOutboxEvent(tx *sql.Tx, eventType string, user *sharedModels.UserData) error {
+	// Copy only public profile fields so credentials can never leak
+	return r.outbox.Insert(tx, eventType, user.ID, sharedModels.UserData{
+		ID:        user.ID,
+		Email:     user.Email,
+		FirstName: user.FirstName,
+		LastName:  user.LastName,
+		CreatedAt: user.CreatedAt,
+		UpdatedAt: user.UpdatedAt,
+	})
+}
+
+// RecordUserEvent records an event that is not tied to a change of the
+// users table, such as a login
+func (r *UserRepository) RecordUserEvent(eventType string, user *sharedModels.UserData) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := r.insertOutboxEvent(tx, eventType, user); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lucas/gokafka/user-service/internal/models"
+)
+
+// Pagination limits for ListUsers
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// UserFilter selects one page of users, newest first. Search matches a
+// substring of the email, first name, last name or full name.
+type UserFilter struct {
+	Search        string
+	Role          string
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	Cursor        string
+	Limit         int
+}
+
+// UserPage is one page of users. Total counts every user matching the
+// filter across all pages; NextCursor is empty on the last page.
+type UserPage struct {
+	Users      []*models.User
+	NextCursor string
+	Total      int
+}
+
+// userCursor is the position after the last user of a page
+type userCursor struct {
+	CreatedAt string `json:"c"`
+	ID        string `json:"id"`
+}
+
+func encodeCursor(cursor userCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(value string) (*userCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var cursor userCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID == "" {
+		return nil, ErrInvalidCursor
+	}
+	if _, err := time.Parse(time.RFC3339Nano, cursor.CreatedAt); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &cursor, nil
+}
+
+// ListUsers returns a page of users without their passwords, using keyset
+// pagination on (created_at, id)
+func (r *UserRepository) ListUsers(filter UserFilter) (*UserPage, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultPageSize
+	}
+	if filter.Limit > MaxPageSize {
+		filter.Limit = MaxPageSize
+	}
+
+	var conditions []string
+	var args []interface{}
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if filter.Search != "" {
+		pattern := arg("%" + escapeLike(filter.Search) + "%")
+		conditions = append(conditions, fmt.Sprintf(
+			`(email ILIKE %[1]s ESCAPE '\' OR first_name ILIKE %[1]s ESCAPE '\' OR last_name ILIKE %[1]s ESCAPE '\'`+
+				` OR first_name || ' ' || last_name ILIKE %[1]s ESCAPE '\')`, pattern))
+	}
+	if filter.Role != "" {
+		conditions = append(conditions, "role = "+arg(filter.Role))
+	}
+	if filter.CreatedAfter != nil {
+		conditions = append(conditions, "created_at >= "+arg(*filter.CreatedAfter))
+	}
+	if filter.CreatedBefore != nil {
+		conditions = append(conditions, "created_at < "+arg(*filter.CreatedBefore))
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = ` WHERE ` + strings.Join(conditions, " AND ")
+	}
+
+	// The total ignores the cursor so it stays the same on every page
+	page := &UserPage{Users: []*models.User{}}
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM users`+where, args...).Scan(&page.Total); err != nil {
+		return nil, err
+	}
+
+	if filter.Cursor != "" {
+		cursor, err := decodeCursor(filter.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, fmt.Sprintf("(created_at, id) < (%s::timestamp, %s)",
+			arg(cursor.CreatedAt), arg(cursor.ID)))
+		where = ` WHERE ` + strings.Join(conditions, " AND ")
+	}
+
+	// Fetch one extra row to learn whether another page follows
+	query := `
+		SELECT id, email, first_name, last_name, created_at, updated_at, role
+		FROM users` + where + `
+		ORDER BY created_at DESC, id DESC
+		LIMIT ` + arg(filter.Limit+1)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var user models.User
+		err := rows.Scan(
+			&user.ID, &user.Email, &user.FirstName, &user.LastName,
+			&user.CreatedAt, &user.UpdatedAt, &user.Role,
+		)
+		if err != nil {
+			return nil, err
+		}
+		page.Users = append(page.Users, &user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(page.Users) > filter.Limit {
+		page.Users = page.Users[:filter.Limit]
+		last := page.Users[len(page.Users)-1]
+		page.NextCursor = encodeCursor(userCursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+
+	return page, nil
+}
+
+// escapeLike escapes the LIKE wildcards in a literal search term
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
@@ -8,7 +8,9 @@ import (
 
 	"github.com/google/uuid"
 	_ "github.com/lib/pq" // PostgreSQL driver
+	"github.com/lucas/gokafka/shared/locks"
 	sharedModels "github.com/lucas/gokafka/shared/models"
+	"github.com/lucas/gokafka/shared/outbox"
 	"github.com/lucas/gokafka/shared/utils"
 	"github.com/lucas/gokafka/user-service/internal/auth"
 	"github.com/lucas/gokafka/user-service/internal/models"
@@ -25,16 +27,28 @@ const (
 )
 
 type UserRepository struct {
-	db *sql.DB
+	db     *sql.DB
+	outbox *outbox.Outbox
 }
 
 func NewUserRepository() *UserRepository {
 	db := initDatabase()
-	repo := &UserRepository{db: db}
+	repo := &UserRepository{
+		db:     db,
+		outbox: outbox.New(db, "user_outbox", locks.UserOutboxRelay),
+	}
 	repo.createTableIfNotExists()
+	if err := repo.outbox.CreateTableIfNotExists(); err != nil {
+		log.Fatal(err)
+	}
 	return repo
 }
 
+// Outbox returns the outbox user events are recorded in
+func (r *UserRepository) Outbox() *outbox.Outbox {
+	return r.outbox
+}
+
 // initDatabase initializes the database connection
 func initDatabase() *sql.DB {
 	// Build connection string from environment variables
@@ -73,7 +87,8 @@ func (r *UserRepository) createTableIfNotExists() {
 		created_at TIMESTAMP NOT NULL,
 		updated_at TIMESTAMP NOT NULL,
 		role VARCHAR(50) NOT NULL DEFAULT 'user'
-	)`
+	);
+	CREATE INDEX IF NOT EXISTS idx_users_created_at ON users (created_at DESC, id DESC)`
 
 	if _, err := r.db.Exec(query); err != nil {
 		log.Fatalf("failed to create users table: %v", err)
@@ -148,18 +163,39 @@ func (r *UserRepository) GetUserByEmail(email string) (*models.User, error) {
 	return &user, nil
 }
 
+// CreateUser inserts a user and records a user.registered event in the
+// same transaction
 func (r *UserRepository) CreateUser(user *models.User) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
 	query := `
 		INSERT INTO users (id, email, password, first_name, last_name, created_at, updated_at, role)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 	`
 
-	_, err := r.db.Exec(query,
+	if _, err := tx.Exec(query,
 		user.ID, user.Email, user.Password, user.FirstName,
 		user.LastName, user.CreatedAt, user.UpdatedAt, user.Role,
-	)
+	); err != nil {
+		return err
+	}
+
+	if err := r.insertOutboxEvent(tx, sharedModels.UserEventRegistered, &sharedModels.UserData{
+		ID:        user.ID,
+		Email:     user.Email,
+		FirstName: user.FirstName,
+		LastName:  user.LastName,
+		CreatedAt: user.CreatedAt,
+		UpdatedAt: user.UpdatedAt,
+	}); err != nil {
+		return err
+	}
 
-	return err
+	return tx.Commit()
 }
 
 func (r *UserRepository) GetUserByID(id string) (*sharedModels.UserData, error) {
@@ -181,32 +217,73 @@ func (r *UserRepository) GetUserByID(id string) (*sharedModels.UserData, error)
 	return &user, nil
 }
 
-func (r *UserRepository) GetAllUsers() ([]*models.User, error) {
+// UpdateUser changes a user's name and records a user.updated event in the
+// same transaction
+func (r *UserRepository) UpdateUser(id, firstName, lastName string) (*sharedModels.UserData, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
 	query := `
-		SELECT id, email, password, first_name, last_name, created_at, updated_at, role 
-		FROM users ORDER BY created_at DESC
+		UPDATE users SET first_name = $1, last_name = $2, updated_at = $3
+		WHERE id = $4
+		RETURNING id, email, first_name, last_name, created_at, updated_at
 	`
 
-	rows, err := r.db.Query(query)
+	var user sharedModels.UserData
+	err = tx.QueryRow(query, firstName, lastName, time.Now().Format(time.RFC3339), id).Scan(
+		&user.ID, &user.Email, &user.FirstName,
+		&user.LastName, &user.CreatedAt, &user.UpdatedAt,
+	)
+
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
 
-	var users []*models.User
-	for rows.Next() {
-		var user models.User
-		err := rows.Scan(
-			&user.ID, &user.Email, &user.Password, &user.FirstName,
-			&user.LastName, &user.CreatedAt, &user.UpdatedAt, &user.Role,
-		)
-		if err != nil {
-			return nil, err
-		}
-		// Don't return passwords
-		user.Password = ""
-		users = append(users, &user)
+	if err := r.insertOutboxEvent(tx, sharedModels.UserEventUpdated, &user); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
 	}
 
-	return users, nil
+	return &user, nil
+}
+
+// DeleteUser removes a user and records a user.deleted event in the same
+// transaction
+func (r *UserRepository) DeleteUser(id string) (*sharedModels.UserData, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		DELETE FROM users WHERE id = $1
+		RETURNING id, email, first_name, last_name, created_at, updated_at
+	`
+
+	var user sharedModels.UserData
+	err = tx.QueryRow(query, id).Scan(
+		&user.ID, &user.Email, &user.FirstName,
+		&user.LastName, &user.CreatedAt, &user.UpdatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.insertOutboxEvent(tx, sharedModels.UserEventDeleted, &user); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &user, nil
 }
@@ -1,7 +1,10 @@
 package services
 
 import (
+	"errors"
 	"fmt"
+	"log"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -33,6 +36,7 @@ func (s *UserService) userToUserData(user *userModels.User) *sharedModels.UserDa
 		Email:     user.Email,
 		FirstName: user.FirstName,
 		LastName:  user.LastName,
+		Role:      user.Role,
 		CreatedAt: user.CreatedAt,
 		UpdatedAt: user.UpdatedAt,
 	}
@@ -109,6 +113,11 @@ func (s *UserService) LoginUser(req sharedModels.LoginRequest) (*sharedModels.Lo
 		return nil, fmt.Errorf("failed to generate token: %w", err)
 	}
 
+	// A lost login event is not worth failing the login for
+	if err := s.repo.RecordUserEvent(sharedModels.UserEventLoggedIn, s.userToUserData(user)); err != nil {
+		log.Printf("Failed to record %s event for user %s: %v", sharedModels.UserEventLoggedIn, user.ID, err)
+	}
+
 	// Prepare response
 	loginResponse := &sharedModels.LoginResponse{
 		Token: token,
@@ -138,19 +147,85 @@ func (s *UserService) GetUserProfile(userID string) (*sharedModels.UserData, err
 	return user, nil
 }
 
-func (s *UserService) GetAllUserProfile() ([]*sharedModels.UserData, error) {
-	// Get all users
-	users, err := s.repo.GetAllUsers()
-	if err != nil || len(users) == 0 {
-		return nil, fmt.Errorf("no users found")
+// ListUserProfiles returns one page of users matching the request. No
+// matches is an empty page, not an error.
+func (s *UserService) ListUserProfiles(req sharedModels.ListUsersRequest) (*sharedModels.ListProfileResponse, error) {
+	if req.Limit < 0 {
+		return nil, fmt.Errorf("limit cannot be negative")
 	}
 
-	userListResponse := make([]*sharedModels.UserData, 0, len(users))
-	for _, user := range users {
-		if user != nil {
-			userListResponse = append(userListResponse, s.userToUserData(user))
+	filter := repository.UserFilter{
+		Search: strings.TrimSpace(req.Search),
+		Role:   req.Role,
+		Cursor: req.Cursor,
+		Limit:  req.Limit,
+	}
+	if req.CreatedAfter != "" {
+		after, err := time.Parse(time.RFC3339, req.CreatedAfter)
+		if err != nil {
+			return nil, fmt.Errorf("created_after must be an RFC3339 timestamp")
 		}
+		after = after.UTC()
+		filter.CreatedAfter = &after
+	}
+	if req.CreatedBefore != "" {
+		before, err := time.Parse(time.RFC3339, req.CreatedBefore)
+		if err != nil {
+			return nil, fmt.Errorf("created_before must be an RFC3339 timestamp")
+		}
+		before = before.UTC()
+		filter.CreatedBefore = &before
+	}
+
+	page, err := s.repo.ListUsers(filter)
+	if err != nil {
+		if errors.Is(err, repository.ErrInvalidCursor) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+
+	users := make([]sharedModels.UserData, 0, len(page.Users))
+	for _, user := range page.Users {
+		users = append(users, *s.userToUserData(user))
+	}
+
+	return &sharedModels.ListProfileResponse{
+		Status:     "success",
+		Data:       users,
+		NextCursor: page.NextCursor,
+		Total:      page.Total,
+	}, nil
+}
+
+func (s *UserService) UpdateUserProfile(req sharedModels.UpdateProfileRequest) (*sharedModels.UserData, error) {
+	// Validate input
+	if req.ID == "" {
+		return nil, fmt.Errorf("user ID is required")
+	}
+	if req.FirstName == "" || req.LastName == "" {
+		return nil, fmt.Errorf("first name and last name are required")
+	}
+
+	// Update user
+	user, err := s.repo.UpdateUser(req.ID, req.FirstName, req.LastName)
+	if err != nil {
+		return nil, fmt.Errorf("user not found")
+	}
+
+	return user, nil
+}
+
+func (s *UserService) DeleteUser(userID string) error {
+	// Validate input
+	if userID == "" {
+		return fmt.Errorf("user ID is required")
+	}
+
+	// Delete user
+	if _, err := s.repo.DeleteUser(userID); err != nil {
+		return fmt.Errorf("user not found")
 	}
 
-	return userListResponse, nil
+	return nil
 }
@@ -0,0 +1,147 @@
+package bus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	sharedModels "github.com/lucas/gokafka/shared/models"
+	"github.com/lucas/gokafka/shared/utils"
+	"github.com/segmentio/kafka-go"
+)
+
+// Bus constants
+const (
+	RequestTopic   = "api-gateway-topic"
+	DefaultTimeout = 10 * time.Second
+)
+
+// Client sends requests to other services over the request/reply bus and
+// waits for their replies, like the gateway's messaging service
+type Client struct {
+	writer        *kafka.Writer
+	reader        *kafka.Reader
+	replyTopic    string
+	responseChans map[string]chan sharedModels.Response
+	mu            sync.Mutex
+}
+
+// NewClient creates a client receiving replies on replyTopic. Every instance
+// joins its own consumer group so each replica sees the replies to its own
+// requests.
+func NewClient(replyTopic string) *Client {
+	broker := utils.GetEnvOrDefault("KAFKA_BROKERS", "localhost:9092")
+
+	c := &Client{
+		writer: kafka.NewWriter(kafka.WriterConfig{
+			Brokers:      []string{broker},
+			Topic:        RequestTopic,
+			RequiredAcks: int(kafka.RequireOne),
+		}),
+		reader: kafka.NewReader(kafka.ReaderConfig{
+			Brokers:     []string{broker},
+			Topic:       replyTopic,
+			GroupID:     replyTopic + "-" + uuid.NewString(),
+			StartOffset: kafka.LastOffset,
+		}),
+		replyTopic:    replyTopic,
+		responseChans: make(map[string]chan sharedModels.Response),
+	}
+	go c.listenResponses()
+	return c
+}
+
+func (c *Client) listenResponses() {
+	for {
+		m, err := c.reader.ReadMessage(context.Background())
+		if err != nil {
+			log.Println("bus read error:", err)
+			continue
+		}
+		var resp sharedModels.Response
+		if err := json.Unmarshal(m.Value, &resp); err != nil {
+			continue
+		}
+		c.mu.Lock()
+		ch, ok := c.responseChans[resp.CorrelationID]
+		c.mu.Unlock()
+		if ok {
+			select {
+			case ch <- resp:
+			default:
+			}
+		}
+	}
+}
+
+// Call sends a request and decodes a successful reply into result, which may
+// be nil. A reply with Success false is returned as an error.
+func (c *Client) Call(requestType string, payload interface{}, result interface{}) error {
+	return c.CallWithTimeout(requestType, payload, result, DefaultTimeout)
+}
+
+// CallWithTimeout is Call for requests that need longer than DefaultTimeout
+func (c *Client) CallWithTimeout(requestType string, payload interface{}, result interface{}, timeout time.Duration) error {
+	correlationID := uuid.NewString()
+	replyChan := make(chan sharedModels.Response, 1)
+
+	c.mu.Lock()
+	c.responseChans[correlationID] = replyChan
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.responseChans, correlationID)
+		c.mu.Unlock()
+	}()
+
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to serialize request: %w", err)
+	}
+
+	reqBytes, _ := json.Marshal(sharedModels.Request{
+		Type:          requestType,
+		CorrelationID: correlationID,
+		ReplyTo:       c.replyTopic,
+		Payload:       string(payloadBytes),
+	})
+
+	if err := c.writer.WriteMessages(context.Background(), kafka.Message{
+		Key:   []byte(requestType),
+		Value: reqBytes,
+	}); err != nil {
+		return fmt.Errorf("failed to send %s: %w", requestType, err)
+	}
+
+	select {
+	case resp := <-replyChan:
+		if !resp.Success {
+			return &ServiceError{RequestType: requestType, Message: resp.Error}
+		}
+		if result == nil {
+			return nil
+		}
+		if err := json.Unmarshal([]byte(resp.Data), result); err != nil {
+			return fmt.Errorf("invalid %s response format: %w", requestType, err)
+		}
+		return nil
+
+	case <-time.After(timeout):
+		return fmt.Errorf("timeout waiting for %s response", requestType)
+	}
+}
+
+// ServiceError is returned when a service replied with a failure, as opposed
+// to the request getting lost
+type ServiceError struct {
+	RequestType string
+	Message     string
+}
+
+func (e *ServiceError) Error() string {
+	return fmt.Sprintf("%s failed: %s", e.RequestType, e.Message)
+}
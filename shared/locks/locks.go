@@ -0,0 +1,17 @@
+// Package locks is the registry of Postgres advisory lock keys. Every
+// service shares the gokafka database, so a key taken by two unrelated jobs
+// would make them block or skip each other; new keys are added here only.
+package locks
+
+// Advisory lock keys
+const (
+	// ProductOutboxRelay is held while product events are relayed
+	ProductOutboxRelay int64 = 727001
+	// PaymentOutboxRelay is held while payment events are relayed
+	PaymentOutboxRelay int64 = 727002
+	// UserOutboxRelay is held while user events are relayed
+	UserOutboxRelay int64 = 727003
+	// ProductHistoryBackfill serializes the product history baseline
+	// backfill between replicas starting at the same time
+	ProductHistoryBackfill int64 = 727004
+)
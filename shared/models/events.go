@@ -0,0 +1,56 @@
+package models
+
+// Domain event topics
+const (
+	ProductEventsTopic = "products.events"
+	UserEventsTopic    = "users.events"
+	AuditEventsTopic   = "audit.events"
+	PaymentEventsTopic = "payments.events"
+)
+
+// Product event types
+const (
+	ProductEventCreated = "product.created"
+	ProductEventUpdated = "product.updated"
+	ProductEventDeleted = "product.deleted"
+	// ProductEventRestored fires when a soft deleted product is restored
+	ProductEventRestored = "product.restored"
+	// ProductEventLowStock fires when stock drops below the low stock
+	// threshold; its payload is ProductData like the other product events
+	ProductEventLowStock = "product.low_stock"
+	// ProductEventPriceChanged fires when a price schedule starts or ends
+	// and so changes the effective price; its payload is ProductData
+	ProductEventPriceChanged = "product.price_changed"
+)
+
+// User event types. Their payload is UserData, which never carries
+// passwords or hashes.
+const (
+	UserEventRegistered = "user.registered"
+	UserEventLoggedIn   = "user.logged_in"
+	UserEventUpdated    = "user.updated"
+	UserEventDeleted    = "user.deleted"
+)
+
+// Payment event types. They play the role of provider webhooks: every
+// change of a payment's status is announced with PaymentData as payload.
+const (
+	PaymentEventSucceeded = "payment.succeeded"
+	PaymentEventDeclined  = "payment.declined"
+	PaymentEventRefunded  = "payment.refunded"
+)
+
+// Audit event types. Their payload is AuditRecord.
+const (
+	AuditEventAdminAction = "audit.admin_action"
+)
+
+// Event is the envelope published on domain event topics. Consumers should
+// deduplicate on ID since delivery is at-least-once.
+type Event struct {
+	ID          string `json:"id"`
+	Type        string `json:"type"`         // e.g., "product.created"
+	AggregateID string `json:"aggregate_id"` // also used as the Kafka message key
+	OccurredAt  string `json:"occurred_at"`
+	Payload     string `json:"payload"` // JSON-encoded event data, e.g. ProductData
+}
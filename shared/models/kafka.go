@@ -5,6 +5,7 @@ type Request struct {
 	CorrelationID string `json:"correlation_id"`
 	ReplyTo       string `json:"reply_to"`
 	Payload       string `json:"payload"` // You can use a string or json.RawMessage for more complex payloads
+	Actor         string `json:"actor,omitempty"` // ID of the user the request is made for, set by the gateway
 }
 
 type Response struct {
@@ -12,4 +13,10 @@ type Response struct {
     Success       bool   `json:"success"`
     Data          string `json:"data"`
     Error         string `json:"error,omitempty"`
-}
\ No newline at end of file
+    Code          string `json:"code,omitempty"` // machine readable reason for a failure
+}
+
+// Response codes let callers tell failures apart without parsing messages
+const (
+    ErrorCodeVersionConflict = "version_conflict"
+)
\ No newline at end of file
@@ -1,10 +1,13 @@
 package models
 
+import "encoding/json"
+
 type UserData struct {
 	ID        string `json:"id"`
 	Email     string `json:"email"`
 	FirstName string `json:"first_name"`
 	LastName  string `json:"last_name"`
+	Role      string `json:"role,omitempty"`
 	CreatedAt string `json:"created_at"`
 	UpdatedAt string `json:"updated_at"`
 }
@@ -30,55 +33,496 @@ type GetProfileRequest struct {
 	ID string `json:"id"`
 }
 
+type UpdateProfileRequest struct {
+	ID        string `json:"id"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+}
+
+type DeleteUserRequest struct {
+	ID string `json:"id"`
+}
+
 type GetProfileResponse struct {
 	Status string   `json:"status"`
 	Data   UserData `json:"data"`
 }
 
+// ListUsersRequest selects one page of users, newest first. Search matches
+// part of the email or name; Cursor is the next_cursor of the previous page.
+type ListUsersRequest struct {
+	Search        string `json:"search,omitempty"`
+	Role          string `json:"role,omitempty"`
+	CreatedAfter  string `json:"created_after,omitempty"`  // RFC3339, inclusive
+	CreatedBefore string `json:"created_before,omitempty"` // RFC3339, exclusive
+	Cursor        string `json:"cursor,omitempty"`
+	Limit         int    `json:"limit,omitempty"`
+}
+
 type ListProfileResponse struct {
-	Status string     `json:"status"`
-	Data   []UserData `json:"data"`
+	Status     string     `json:"status"`
+	Data       []UserData `json:"data"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+	Total      int        `json:"total"`
 }
 
 // Product-related models
 type ProductData struct {
-	ID          int     `json:"id"`
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price"`
-	CreatedAt   string  `json:"created_at"`
-	UpdatedAt   string  `json:"updated_at"`
+	ID          int                  `json:"id"`
+	Name        string               `json:"name"`
+	Description string               `json:"description"`
+	Price       Money                `json:"price"`                // effective price, including an active price schedule
+	BasePrice   *Money               `json:"base_price,omitempty"` // price before conversion, set when Price was converted
+	Stock       int                  `json:"stock"`                // units available, excluding active reservations
+	Version     int                  `json:"version"`              // sent back as the ETag
+	CategoryIDs []int                `json:"category_ids"`
+	Tags        []string             `json:"tags"`
+	Images      []ProductImageData   `json:"images"`
+	Variants    []ProductVariantData `json:"variants"`
+	CreatedAt   string               `json:"created_at"`
+	UpdatedAt   string               `json:"updated_at"`
+	DeletedAt   string               `json:"deleted_at,omitempty"` // set on soft deleted products
+	// RegularPrice is the price outside an active, time-limited price
+	// schedule, which sets Price until ScheduledPriceEndsAt
+	RegularPrice         *Money `json:"regular_price,omitempty"`
+	ScheduledPriceEndsAt string `json:"scheduled_price_ends_at,omitempty"`
+	// Ratings summarize the published reviews of the product
+	RatingAverage float64 `json:"rating_average"` // rounded to two decimals, 0 without reviews
+	ReviewCount   int     `json:"review_count"`
+}
+
+// ProductImageData is a product photo; images are uploaded over HTTP, not
+// through Kafka
+type ProductImageData struct {
+	ID           string `json:"id"`
+	URL          string `json:"url"`
+	ThumbnailURL string `json:"thumbnail_url"`
+	ContentType  string `json:"content_type"`
+	Width        int    `json:"width"`
+	Height       int    `json:"height"`
+	Position     int    `json:"position"`
+}
+
+// ProductVariantData is a sellable version of a product with its own SKU
+// and stock. Price is omitted when the variant uses the product price. A
+// product with variants is bought as one of them, never as itself.
+type ProductVariantData struct {
+	ID         int               `json:"id"`
+	ProductID  int               `json:"product_id"`
+	SKU        string            `json:"sku"`
+	Attributes map[string]string `json:"attributes"` // e.g. {"size": "m", "colour": "red"}
+	Price      *Money            `json:"price,omitempty"`
+	Stock      int               `json:"stock"`
+	CreatedAt  string            `json:"created_at"`
+	UpdatedAt  string            `json:"updated_at"`
+}
+
+// CreateVariantRequest adds a variant to a product. A nil Price keeps the
+// product price; a set Price must be in the product's currency.
+type CreateVariantRequest struct {
+	ProductID  int               `json:"product_id"`
+	SKU        string            `json:"sku"`
+	Attributes map[string]string `json:"attributes"`
+	Price      *Money            `json:"price,omitempty"`
+	Stock      int               `json:"stock"`
+}
+
+type UpdateVariantRequest struct {
+	ID         int               `json:"id"`
+	ProductID  int               `json:"product_id"`
+	SKU        string            `json:"sku"`
+	Attributes map[string]string `json:"attributes"`
+	Price      *Money            `json:"price,omitempty"`
+	Stock      int               `json:"stock"`
+}
+
+type DeleteVariantRequest struct {
+	ID        int `json:"id"`
+	ProductID int `json:"product_id"`
+}
+
+type VariantResponse struct {
+	Status string             `json:"status"`
+	Data   ProductVariantData `json:"data"`
+}
+
+// ImportReport summarizes a bulk product import. Rows that fail are listed
+// in Errors by their line in the uploaded file; all other rows are saved.
+type ImportReport struct {
+	Total   int              `json:"total"`
+	Created int              `json:"created"`
+	Updated int              `json:"updated"`
+	Failed  int              `json:"failed"`
+	Errors  []ImportRowError `json:"errors"`
+}
+
+type ImportRowError struct {
+	Line  int    `json:"line"`
+	ID    int    `json:"id,omitempty"`
+	Error string `json:"error"`
 }
 
 type CreateProductRequest struct {
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Price       Money    `json:"price"`
+	Stock       int      `json:"stock"`
+	CategoryIDs []int    `json:"category_ids,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
 }
 
+// UpdateProductRequest replaces a product's fields. Version is the version
+// the update is based on; the update fails if the product has changed since.
 type UpdateProductRequest struct {
-	ID          int     `json:"id"`
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price"`
+	ID          int    `json:"id"`
+	Version     int    `json:"version"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Price       Money  `json:"price"`
 }
 
-type GetProductRequest struct {
+// PatchProductRequest changes some of a product's fields. Patch is a JSON
+// Merge Patch (RFC 7396) of the name, description and price; Version works
+// as in UpdateProductRequest.
+type PatchProductRequest struct {
+	ID      int             `json:"id"`
+	Version int             `json:"version"`
+	Patch   json.RawMessage `json:"patch"`
+}
+
+// ProductHistoryRequest asks for a page of a product's change history,
+// newest first. Cursor is the next_cursor of the previous page.
+type ProductHistoryRequest struct {
+	ID     int    `json:"id"`
+	Limit  int    `json:"limit,omitempty"`
+	Cursor string `json:"cursor,omitempty"`
+}
+
+// FieldChange is the value of a product field before and after a change.
+// From is null for fields set when the product was created.
+type FieldChange struct {
+	From json.RawMessage `json:"from"`
+	To   json.RawMessage `json:"to"`
+}
+
+// ProductHistoryEntry is one recorded product mutation. Actor is the ID of
+// the user who made it.
+type ProductHistoryEntry struct {
+	ID        int64                  `json:"id"`
+	ProductID int                    `json:"product_id"`
+	Version   int                    `json:"version"`
+	Action    string                 `json:"action"`
+	Actor     string                 `json:"actor"`
+	Changes   map[string]FieldChange `json:"changes"`
+	CreatedAt string                 `json:"created_at"`
+}
+
+type ProductHistoryResponse struct {
+	Status     string                `json:"status"`
+	Data       []ProductHistoryEntry `json:"data"`
+	NextCursor string                `json:"next_cursor,omitempty"`
+}
+
+// PriceHistoryRequest asks for a product's price over time in buckets of
+// Interval: "day", "week" or "month". From and To are RFC3339 timestamps
+// or dates.
+type PriceHistoryRequest struct {
+	ID       int    `json:"id"`
+	Interval string `json:"interval,omitempty"`
+	From     string `json:"from,omitempty"`
+	To       string `json:"to,omitempty"`
+}
+
+// PriceBucket summarizes the price during one bucket: the price at its
+// start and end, and the lowest and highest price in between. Low and High
+// only consider prices in the currency of Close.
+type PriceBucket struct {
+	Start string `json:"start"`
+	Open  Money  `json:"open"`
+	Close Money  `json:"close"`
+	Low   Money  `json:"low"`
+	High  Money  `json:"high"`
+}
+
+// PriceHistoryData lists the buckets from the first one with a known price
+type PriceHistoryData struct {
+	ProductID int           `json:"product_id"`
+	Interval  string        `json:"interval"`
+	From      string        `json:"from"`
+	To        string        `json:"to"`
+	Buckets   []PriceBucket `json:"buckets"`
+}
+
+type PriceHistoryResponse struct {
+	Status string           `json:"status"`
+	Data   PriceHistoryData `json:"data"`
+}
+
+// Price schedule statuses
+const (
+	PriceScheduleStatusScheduled = "scheduled"
+	PriceScheduleStatusActive    = "active"
+	PriceScheduleStatusEnded     = "ended"
+	// PriceScheduleStatusApplied marks a schedule without an end whose
+	// price has become the product's regular price
+	PriceScheduleStatusApplied = "applied"
+)
+
+// PriceScheduleData is a price that replaces a product's price from
+// StartsAt until EndsAt. Without EndsAt it is a scheduled price change
+// that becomes the regular price once it starts. Variant price overrides
+// are not affected.
+type PriceScheduleData struct {
+	ID        int    `json:"id"`
+	ProductID int    `json:"product_id"`
+	Name      string `json:"name,omitempty"` // e.g. "Weekend sale"
+	Price     Money  `json:"price"`
+	StartsAt  string `json:"starts_at"`
+	EndsAt    string `json:"ends_at,omitempty"`
+	Status    string `json:"status"`
+	CreatedAt string `json:"created_at"`
+}
+
+// CreatePriceScheduleRequest schedules a price. StartsAt and EndsAt are
+// RFC3339 timestamps; schedules of a product may not overlap.
+type CreatePriceScheduleRequest struct {
+	ProductID int    `json:"product_id"`
+	Name      string `json:"name,omitempty"`
+	Price     Money  `json:"price"`
+	StartsAt  string `json:"starts_at"`
+	EndsAt    string `json:"ends_at,omitempty"`
+}
+
+type DeletePriceScheduleRequest struct {
+	ProductID int `json:"product_id"`
+	ID        int `json:"id"`
+}
+
+type ListPriceSchedulesRequest struct {
+	ProductID int `json:"product_id"`
+}
+
+type PriceScheduleResponse struct {
+	Status string            `json:"status"`
+	Data   PriceScheduleData `json:"data"`
+}
+
+type ListPriceSchedulesResponse struct {
+	Status string              `json:"status"`
+	Data   []PriceScheduleData `json:"data"`
+}
+
+// Review statuses. Hidden reviews are kept for moderators but not shown or
+// counted in a product's rating.
+const (
+	ReviewStatusPublished = "published"
+	ReviewStatusHidden    = "hidden"
+)
+
+// ReviewData is a user's rating of a product from 1 to 5 stars with a text.
+// A user reviews a product at most once.
+type ReviewData struct {
+	ID        int    `json:"id"`
+	ProductID int    `json:"product_id"`
+	UserID    string `json:"user_id"`
+	Rating    int    `json:"rating"`
+	Text      string `json:"text"`
+	Status    string `json:"status"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+type CreateReviewRequest struct {
+	ProductID int    `json:"product_id"`
+	UserID    string `json:"user_id"`
+	Rating    int    `json:"rating"`
+	Text      string `json:"text"`
+}
+
+type GetReviewRequest struct {
+	ID int `json:"id"`
+}
+
+// ListReviewsRequest asks for a page of a product's reviews, newest first.
+// Status filters by review status; empty lists all of them. Cursor is the
+// next_cursor of the previous page.
+type ListReviewsRequest struct {
+	ProductID int    `json:"product_id"`
+	Status    string `json:"status,omitempty"`
+	Limit     int    `json:"limit,omitempty"`
+	Cursor    string `json:"cursor,omitempty"`
+}
+
+// ModerateReviewRequest hides a review or publishes it again
+type ModerateReviewRequest struct {
+	ID     int    `json:"id"`
+	Status string `json:"status"`
+}
+
+type DeleteReviewRequest struct {
 	ID int `json:"id"`
 }
 
+type ReviewResponse struct {
+	Status string     `json:"status"`
+	Data   ReviewData `json:"data"`
+}
+
+type ListReviewsResponse struct {
+	Status     string       `json:"status"`
+	Data       []ReviewData `json:"data"`
+	NextCursor string       `json:"next_cursor,omitempty"`
+}
+
+// GetProductRequest may ask for the price in another currency; by default
+// the product's own currency is used
+type GetProductRequest struct {
+	ID       int    `json:"id"`
+	Currency string `json:"currency,omitempty"`
+}
+
+// ListProductsRequest selects one page of products. Sort is one of id, name,
+// price, created_at or updated_at, prefixed with "-" for descending order;
+// the default is "-created_at". Cursor is the next_cursor of the previous
+// page. Price bounds only match products priced in the bound's currency.
+type ListProductsRequest struct {
+	Currency      string `json:"currency,omitempty"`
+	Limit         int    `json:"limit,omitempty"`
+	Cursor        string `json:"cursor,omitempty"`
+	Sort          string `json:"sort,omitempty"`
+	MinPrice      *Money `json:"min_price,omitempty"`
+	MaxPrice      *Money `json:"max_price,omitempty"`
+	NamePrefix    string `json:"name_prefix,omitempty"`
+	CategoryID    int    `json:"category_id,omitempty"` // includes subcategories
+	Tag           string `json:"tag,omitempty"`
+	CreatedAfter  string `json:"created_after,omitempty"`  // RFC3339
+	CreatedBefore string `json:"created_before,omitempty"` // RFC3339
+	Deleted       bool   `json:"deleted,omitempty"`        // list soft deleted products instead
+}
+
 type DeleteProductRequest struct {
 	ID int `json:"id"`
 }
 
+// RestoreProductRequest undoes the soft delete of a product
+type RestoreProductRequest struct {
+	ID int `json:"id"`
+}
+
+// SetProductCategoriesRequest replaces the categories of a product
+type SetProductCategoriesRequest struct {
+	ID          int   `json:"id"`
+	CategoryIDs []int `json:"category_ids"`
+}
+
+// SetProductTagsRequest replaces the tags of a product
+type SetProductTagsRequest struct {
+	ID   int      `json:"id"`
+	Tags []string `json:"tags"`
+}
+
+// Category-related models
+type CategoryData struct {
+	ID        int    `json:"id"`
+	Name      string `json:"name"`
+	Slug      string `json:"slug"`
+	ParentID  *int   `json:"parent_id"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+// CreateCategoryRequest creates a category; Slug is derived from Name when
+// empty
+type CreateCategoryRequest struct {
+	Name     string `json:"name"`
+	Slug     string `json:"slug"`
+	ParentID *int   `json:"parent_id"`
+}
+
+type UpdateCategoryRequest struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	Slug     string `json:"slug"`
+	ParentID *int   `json:"parent_id"`
+}
+
+type GetCategoryRequest struct {
+	ID int `json:"id"`
+}
+
+type DeleteCategoryRequest struct {
+	ID int `json:"id"`
+}
+
+type CategoryResponse struct {
+	Status string       `json:"status"`
+	Data   CategoryData `json:"data"`
+}
+
+type ListCategoriesResponse struct {
+	Status string         `json:"status"`
+	Data   []CategoryData `json:"data"`
+}
+
+// AdjustStockRequest changes a product's stock either by Delta or, when
+// Quantity is set, to an absolute count
+type AdjustStockRequest struct {
+	ID       int    `json:"id"`
+	Delta    int    `json:"delta"`
+	Quantity *int   `json:"quantity,omitempty"`
+	Reason   string `json:"reason"`
+}
+
 type GetProductResponse struct {
 	Status string      `json:"status"`
 	Data   ProductData `json:"data"`
 }
 
+// MaxProductsPerLookup caps the IDs of a single GetProductsRequest
+const MaxProductsPerLookup = 100
+
+// GetProductsRequest looks up several products in one round trip; Currency
+// works as in GetProductRequest
+type GetProductsRequest struct {
+	IDs      []int  `json:"ids"`
+	Currency string `json:"currency,omitempty"`
+}
+
+// GetProductsResponse lists the products found, in the requested order.
+// Missing holds the IDs that do not exist or are deleted.
+type GetProductsResponse struct {
+	Status  string        `json:"status"`
+	Data    []ProductData `json:"data"`
+	Missing []int         `json:"missing,omitempty"`
+}
+
 type ListProductResponse struct {
-	Status string        `json:"status"`
-	Data   []ProductData `json:"data"`
+	Status     string        `json:"status"`
+	Data       []ProductData `json:"data"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+}
+
+// SearchProductsRequest is a full-text product search. Every word of Query
+// matches as a prefix; close misspellings of product names also match.
+type SearchProductsRequest struct {
+	Query    string `json:"query"`
+	Currency string `json:"currency,omitempty"`
+	Limit    int    `json:"limit,omitempty"`
+	Offset   int    `json:"offset,omitempty"`
+}
+
+// ProductSearchResult is a search hit. HighlightedName and Snippet are HTML
+// escaped and wrap matched terms in <mark> tags.
+type ProductSearchResult struct {
+	Product         ProductData `json:"product"`
+	Rank            float64     `json:"rank"`
+	HighlightedName string      `json:"highlighted_name"`
+	Snippet         string      `json:"snippet"`
+}
+
+type SearchProductsResponse struct {
+	Status string                `json:"status"`
+	Data   []ProductSearchResult `json:"data"`
 }
 
 type ProductResponse struct {
@@ -86,3 +530,374 @@ type ProductResponse struct {
 	Message string      `json:"message"`
 	Data    ProductData `json:"data"`
 }
+
+// Notification-related models
+type NotificationPreferences struct {
+	UserID            string   `json:"user_id"`
+	EmailEnabled      bool     `json:"email_enabled"`
+	DisabledTemplates []string `json:"disabled_templates"`
+	UpdatedAt         string   `json:"updated_at"`
+}
+
+type GetNotificationPreferencesRequest struct {
+	UserID string `json:"user_id"`
+}
+
+type UpdateNotificationPreferencesRequest struct {
+	UserID            string   `json:"user_id"`
+	EmailEnabled      *bool    `json:"email_enabled"`
+	DisabledTemplates []string `json:"disabled_templates"`
+}
+
+type NotificationPreferencesResponse struct {
+	Status string                  `json:"status"`
+	Data   NotificationPreferences `json:"data"`
+}
+
+// Audit-related models
+type AuditRecord struct {
+	ID         string `json:"id"`
+	OccurredAt string `json:"occurred_at"`
+	ActorID    string `json:"actor_id"`
+	ActorEmail string `json:"actor_email"`
+	ActorRole  string `json:"actor_role"`
+	Action     string `json:"action"` // e.g., "products.update"
+	Method     string `json:"method"`
+	Path       string `json:"path"`
+	TargetType string `json:"target_type"`
+	TargetID   string `json:"target_id"`
+	Before     string `json:"before,omitempty"` // JSON snapshot before the change
+	After      string `json:"after,omitempty"`  // JSON snapshot returned by the change
+	Outcome    string `json:"outcome"`          // "success" or "failure"
+	StatusCode int    `json:"status_code"`
+	ClientIP   string `json:"client_ip"`
+}
+
+type ListAuditEventsRequest struct {
+	ActorID    string `json:"actor_id"`
+	Action     string `json:"action"`
+	TargetType string `json:"target_type"`
+	TargetID   string `json:"target_id"`
+	Outcome    string `json:"outcome"`
+	From       string `json:"from"` // RFC3339, inclusive
+	To         string `json:"to"`   // RFC3339, exclusive
+	Cursor     string `json:"cursor"`
+	Limit      int    `json:"limit"`
+}
+
+type ListAuditEventsResponse struct {
+	Status     string        `json:"status"`
+	Data       []AuditRecord `json:"data"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+}
+
+// Order-related models. VariantID is 0 for products without variants.
+type OrderItemRequest struct {
+	ProductID int `json:"product_id"`
+	VariantID int `json:"variant_id,omitempty"`
+	Quantity  int `json:"quantity"`
+}
+
+type CreateOrderRequest struct {
+	UserID       string             `json:"user_id"`
+	Items        []OrderItemRequest `json:"items"`
+	PaymentToken string             `json:"payment_token"`
+	CouponCode   string             `json:"coupon_code,omitempty"`
+}
+
+type OrderItemData struct {
+	ProductID int    `json:"product_id"`
+	VariantID int    `json:"variant_id,omitempty"`
+	SKU       string `json:"sku,omitempty"`
+	Name      string `json:"name"`
+	Quantity  int    `json:"quantity"`
+	UnitPrice Money  `json:"unit_price"`
+	LineTotal Money  `json:"line_total"`
+}
+
+type OrderData struct {
+	ID            string          `json:"id"`
+	UserID        string          `json:"user_id"`
+	Status        string          `json:"status"`
+	Items         []OrderItemData `json:"items"`
+	CouponCode    string          `json:"coupon_code,omitempty"`
+	Discount      *Money          `json:"discount,omitempty"` // set once the coupon is redeemed
+	Total         Money           `json:"total"`
+	FailureReason string          `json:"failure_reason,omitempty"`
+	CreatedAt     string          `json:"created_at"`
+	UpdatedAt     string          `json:"updated_at"`
+}
+
+type GetOrderRequest struct {
+	ID     string `json:"id"`
+	UserID string `json:"user_id"`
+}
+
+type ListOrdersRequest struct {
+	UserID string `json:"user_id"`
+}
+
+type OrderResponse struct {
+	Status  string    `json:"status"`
+	Message string    `json:"message"`
+	Data    OrderData `json:"data"`
+}
+
+type ListOrdersResponse struct {
+	Status string      `json:"status"`
+	Data   []OrderData `json:"data"`
+}
+
+// Cart-related models. A cart belongs to a user or, before login, to an
+// anonymous session; UserID wins when both are set.
+type CartRequest struct {
+	UserID    string `json:"user_id,omitempty"`
+	SessionID string `json:"session_id,omitempty"`
+}
+
+// CartItemRequest changes a cart line; the line of a variant is identified
+// by both IDs
+type CartItemRequest struct {
+	UserID    string `json:"user_id,omitempty"`
+	SessionID string `json:"session_id,omitempty"`
+	ProductID int    `json:"product_id"`
+	VariantID int    `json:"variant_id,omitempty"`
+	Quantity  int    `json:"quantity"`
+}
+
+// MergeCartRequest moves an anonymous session's cart into the user's cart
+type MergeCartRequest struct {
+	UserID    string `json:"user_id"`
+	SessionID string `json:"session_id"`
+}
+
+type CheckoutCartRequest struct {
+	UserID       string `json:"user_id"`
+	PaymentToken string `json:"payment_token"`
+	CouponCode   string `json:"coupon_code,omitempty"`
+}
+
+// CartItemData shows an item at the product's current price. AddedPrice is
+// the price when the item was added, so clients can flag price changes.
+type CartItemData struct {
+	ProductID    int    `json:"product_id"`
+	VariantID    int    `json:"variant_id,omitempty"`
+	SKU          string `json:"sku,omitempty"`
+	Name         string `json:"name"`
+	Quantity     int    `json:"quantity"`
+	UnitPrice    Money  `json:"unit_price"`
+	AddedPrice   Money  `json:"added_price"`
+	PriceChanged bool   `json:"price_changed"`
+	Available    bool   `json:"available"` // product exists and has enough stock
+	LineTotal    Money  `json:"line_total"`
+}
+
+type CartData struct {
+	UserID    string         `json:"user_id,omitempty"`
+	SessionID string         `json:"session_id,omitempty"`
+	Items     []CartItemData `json:"items"`
+	Total     Money          `json:"total"`
+	Warnings  []string       `json:"warnings,omitempty"`
+}
+
+type CartResponse struct {
+	Status  string   `json:"status"`
+	Message string   `json:"message"`
+	Data    CartData `json:"data"`
+}
+
+// Inventory messages used by the checkout saga
+const (
+	ReservationStatusActive    = "active"
+	ReservationStatusCommitted = "committed"
+	ReservationStatusReleased  = "released"
+	ReservationStatusExpired   = "expired"
+)
+
+// StockItem takes stock from a variant when VariantID is set, otherwise
+// from the product
+type StockItem struct {
+	ProductID int `json:"product_id"`
+	VariantID int `json:"variant_id,omitempty"`
+	Quantity  int `json:"quantity"`
+}
+
+type ReserveStockRequest struct {
+	OrderID string      `json:"order_id"` // idempotency key: one reservation per order
+	Items   []StockItem `json:"items"`
+}
+
+type ReservationData struct {
+	ID        string      `json:"id"`
+	OrderID   string      `json:"order_id"`
+	Status    string      `json:"status"`
+	Items     []StockItem `json:"items"`
+	ExpiresAt string      `json:"expires_at"`
+}
+
+// ReservationRequest identifies a reservation by ID, or by order when the
+// reserve reply was lost
+type ReservationRequest struct {
+	ReservationID string `json:"reservation_id"`
+	OrderID       string `json:"order_id"`
+}
+
+// Payment messages used by the checkout saga
+const (
+	PaymentStatusPending   = "pending"
+	PaymentStatusSucceeded = "succeeded"
+	PaymentStatusDeclined  = "declined"
+	PaymentStatusRefunded  = "refunded"
+)
+
+type ChargePaymentRequest struct {
+	IdempotencyKey string `json:"idempotency_key"`
+	OrderID        string `json:"order_id"`
+	UserID         string `json:"user_id"`
+	Amount         Money  `json:"amount"`
+	PaymentToken   string `json:"payment_token"`
+}
+
+// RefundPaymentRequest identifies a payment by ID, or by order when the
+// charge reply was lost
+type RefundPaymentRequest struct {
+	IdempotencyKey string `json:"idempotency_key"`
+	PaymentID      string `json:"payment_id"`
+	OrderID        string `json:"order_id"`
+}
+
+type GetPaymentRequest struct {
+	ID string `json:"id"`
+}
+
+type PaymentData struct {
+	ID            string `json:"id"`
+	OrderID       string `json:"order_id"`
+	UserID        string `json:"user_id"`
+	Amount        Money  `json:"amount"`
+	Status        string `json:"status"`
+	FailureReason string `json:"failure_reason,omitempty"`
+	ProviderRef   string `json:"provider_ref,omitempty"`
+	CreatedAt     string `json:"created_at"`
+	UpdatedAt     string `json:"updated_at"`
+}
+
+// Promotion-related models
+const (
+	CouponTypePercentage = "percentage"
+	CouponTypeFixed      = "fixed"
+)
+
+const (
+	CouponRedemptionStatusRedeemed = "redeemed"
+	CouponRedemptionStatusReleased = "released"
+)
+
+// CouponData is a discount code. A percentage coupon takes PercentOff
+// percent off the order; a fixed coupon takes AmountOff, but never more
+// than the order's value. Zero limits mean unlimited.
+type CouponData struct {
+	ID                    int    `json:"id"`
+	Code                  string `json:"code"`
+	Description           string `json:"description,omitempty"`
+	Type                  string `json:"type"`
+	PercentOff            int    `json:"percent_off,omitempty"`
+	AmountOff             *Money `json:"amount_off,omitempty"`
+	MinOrderValue         *Money `json:"min_order_value,omitempty"`
+	MaxRedemptions        int    `json:"max_redemptions,omitempty"`
+	MaxRedemptionsPerUser int    `json:"max_redemptions_per_user,omitempty"`
+	Redemptions           int    `json:"redemptions"`
+	StartsAt              string `json:"starts_at,omitempty"`
+	ExpiresAt             string `json:"expires_at,omitempty"`
+	Active                bool   `json:"active"`
+	CreatedAt             string `json:"created_at"`
+	UpdatedAt             string `json:"updated_at"`
+}
+
+// CreateCouponRequest creates a coupon. StartsAt and ExpiresAt are
+// optional RFC3339 timestamps.
+type CreateCouponRequest struct {
+	Code                  string `json:"code"`
+	Description           string `json:"description,omitempty"`
+	Type                  string `json:"type"`
+	PercentOff            int    `json:"percent_off,omitempty"`
+	AmountOff             *Money `json:"amount_off,omitempty"`
+	MinOrderValue         *Money `json:"min_order_value,omitempty"`
+	MaxRedemptions        int    `json:"max_redemptions,omitempty"`
+	MaxRedemptionsPerUser int    `json:"max_redemptions_per_user,omitempty"`
+	StartsAt              string `json:"starts_at,omitempty"`
+	ExpiresAt             string `json:"expires_at,omitempty"`
+}
+
+type GetCouponRequest struct {
+	ID int `json:"id"`
+}
+
+type ListCouponsRequest struct {
+	ActiveOnly bool `json:"active_only"`
+}
+
+// DeactivateCouponRequest stops a coupon from being redeemed; past
+// redemptions are kept
+type DeactivateCouponRequest struct {
+	ID int `json:"id"`
+}
+
+type CouponResponse struct {
+	Status string     `json:"status"`
+	Data   CouponData `json:"data"`
+}
+
+type ListCouponsResponse struct {
+	Status string       `json:"status"`
+	Data   []CouponData `json:"data"`
+}
+
+// EvaluateCouponRequest prices items at the current product prices and
+// applies a coupon for a user, without redeeming it
+type EvaluateCouponRequest struct {
+	Code   string             `json:"code"`
+	UserID string             `json:"user_id"`
+	Items  []OrderItemRequest `json:"items"`
+}
+
+type CouponEvaluationData struct {
+	Code     string          `json:"code"`
+	Items    []OrderItemData `json:"items"`
+	Subtotal Money           `json:"subtotal"`
+	Discount Money           `json:"discount"`
+	Total    Money           `json:"total"`
+}
+
+type CouponEvaluationResponse struct {
+	Status string               `json:"status"`
+	Data   CouponEvaluationData `json:"data"`
+}
+
+// RedeemCouponRequest applies a coupon to an order. OrderID is the
+// idempotency key: an order redeems one coupon, once.
+type RedeemCouponRequest struct {
+	Code     string `json:"code"`
+	OrderID  string `json:"order_id"`
+	UserID   string `json:"user_id"`
+	Subtotal Money  `json:"subtotal"`
+}
+
+// ReleaseCouponRequest gives back the redemption of an order whose
+// checkout failed, so it no longer counts against the coupon's limits
+type ReleaseCouponRequest struct {
+	OrderID string `json:"order_id"`
+}
+
+type CouponRedemptionData struct {
+	ID        string `json:"id"`
+	CouponID  int    `json:"coupon_id"`
+	Code      string `json:"code"`
+	OrderID   string `json:"order_id"`
+	UserID    string `json:"user_id"`
+	Subtotal  Money  `json:"subtotal"`
+	Discount  Money  `json:"discount"`
+	Status    string `json:"status"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
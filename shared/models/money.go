@@ -0,0 +1,214 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency is assumed when a payload carries a bare number, as all
+// prices did before Money existed
+const DefaultCurrency = "USD"
+
+// zeroDecimalCurrencies have no minor unit. Every other currency is assumed
+// to use cents; three-decimal currencies are not supported.
+var zeroDecimalCurrencies = map[string]bool{
+	"CLP": true,
+	"ISK": true,
+	"JPY": true,
+	"KRW": true,
+	"VND": true,
+}
+
+// Money is an exact amount in minor units (e.g. cents) of an ISO 4217
+// currency. It is encoded as {"amount": 1999, "currency": "USD"} but also
+// decodes the legacy forms 19.99 and "19.99", which are taken as
+// DefaultCurrency.
+type Money struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// NewMoney returns amount minor units of currency
+func NewMoney(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: strings.ToUpper(currency)}
+}
+
+// CurrencyExponent returns the number of decimal places of a currency
+func CurrencyExponent(currency string) int {
+	if zeroDecimalCurrencies[strings.ToUpper(currency)] {
+		return 0
+	}
+	return 2
+}
+
+// ValidateCurrency checks that currency looks like an ISO 4217 code
+func ValidateCurrency(currency string) error {
+	if len(currency) != 3 {
+		return fmt.Errorf("invalid currency %q", currency)
+	}
+	for _, c := range currency {
+		if c < 'A' || c > 'Z' {
+			return fmt.Errorf("invalid currency %q", currency)
+		}
+	}
+	return nil
+}
+
+// ParseMoney parses a decimal amount such as "19.99" exactly. The value may
+// carry its own currency, as in "19.99 EUR"; otherwise currency is used.
+func ParseMoney(value, currency string) (Money, error) {
+	value = strings.TrimSpace(value)
+	if amount, code, ok := strings.Cut(value, " "); ok {
+		value, currency = amount, strings.TrimSpace(code)
+	}
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+	currency = strings.ToUpper(currency)
+	if err := ValidateCurrency(currency); err != nil {
+		return Money{}, err
+	}
+
+	amount, ok := new(big.Rat).SetString(value)
+	if !ok {
+		return Money{}, fmt.Errorf("invalid amount %q", value)
+	}
+
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(CurrencyExponent(currency))), nil)
+	amount.Mul(amount, new(big.Rat).SetInt(scale))
+	if !amount.IsInt() {
+		return Money{}, fmt.Errorf("amount %q has too many decimal places for %s", value, currency)
+	}
+	if !amount.Num().IsInt64() {
+		return Money{}, fmt.Errorf("amount %q is out of range", value)
+	}
+
+	return Money{Amount: amount.Num().Int64(), Currency: currency}, nil
+}
+
+// Decimal formats the amount without currency, e.g. "19.99", as stored in
+// NUMERIC columns
+func (m Money) Decimal() string {
+	exponent := CurrencyExponent(m.Currency)
+	if exponent == 0 {
+		return strconv.FormatInt(m.Amount, 10)
+	}
+
+	sign := ""
+	amount := m.Amount
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	scale := int64(math.Pow10(exponent))
+	return fmt.Sprintf("%s%d.%0*d", sign, amount/scale, exponent, amount%scale)
+}
+
+// String formats the amount with its currency, e.g. "19.99 USD"
+func (m Money) String() string {
+	return m.Decimal() + " " + m.Currency
+}
+
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+func (m Money) IsPositive() bool {
+	return m.Amount > 0
+}
+
+func (m Money) IsNegative() bool {
+	return m.Amount < 0
+}
+
+// Add returns m + other. A zero Money without currency adopts the other
+// currency, so sums can start from Money{}.
+func (m Money) Add(other Money) (Money, error) {
+	if m.Currency == "" {
+		m.Currency = other.Currency
+	}
+	if other.Currency != "" && other.Currency != m.Currency {
+		return Money{}, fmt.Errorf("cannot add %s to %s", other.Currency, m.Currency)
+	}
+	sum := m.Amount + other.Amount
+	if (other.Amount > 0 && sum < m.Amount) || (other.Amount < 0 && sum > m.Amount) {
+		return Money{}, fmt.Errorf("%s plus %s is out of range", m, other)
+	}
+	m.Amount = sum
+	return m, nil
+}
+
+// Sub returns m - other
+func (m Money) Sub(other Money) (Money, error) {
+	other.Amount = -other.Amount
+	return m.Add(other)
+}
+
+// Mul returns m times quantity, or an error when the result does not fit
+// in an int64
+func (m Money) Mul(quantity int) (Money, error) {
+	q := int64(quantity)
+	product := m.Amount * q
+	if q != 0 && (product/q != m.Amount || (m.Amount == math.MinInt64 && q == -1)) {
+		return Money{}, fmt.Errorf("%s times %d is out of range", m, quantity)
+	}
+	m.Amount = product
+	return m, nil
+}
+
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || string(data) == "null" {
+		return nil
+	}
+
+	switch data[0] {
+	case '{':
+		var raw struct {
+			Amount   json.Number `json:"amount"`
+			Currency string      `json:"currency"`
+		}
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return err
+		}
+		currency := strings.ToUpper(raw.Currency)
+		if currency == "" {
+			currency = DefaultCurrency
+		}
+		if err := ValidateCurrency(currency); err != nil {
+			return err
+		}
+		amount, err := strconv.ParseInt(raw.Amount.String(), 10, 64)
+		if err != nil {
+			return fmt.Errorf("money amount must be an integer in minor units: %q", raw.Amount)
+		}
+		*m = Money{Amount: amount, Currency: currency}
+		return nil
+
+	case '"':
+		var value string
+		if err := json.Unmarshal(data, &value); err != nil {
+			return err
+		}
+		parsed, err := ParseMoney(value, DefaultCurrency)
+		if err != nil {
+			return err
+		}
+		*m = parsed
+		return nil
+
+	default:
+		// Legacy float payload; parse its text so 19.99 stays exact
+		parsed, err := ParseMoney(string(data), DefaultCurrency)
+		if err != nil {
+			return err
+		}
+		*m = parsed
+		return nil
+	}
+}
@@ -0,0 +1,204 @@
+package models
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		currency string
+		want     Money
+		wantErr  bool
+	}{
+		{name: "cents", value: "19.99", currency: "USD", want: Money{Amount: 1999, Currency: "USD"}},
+		{name: "whole amount", value: "20", currency: "EUR", want: Money{Amount: 2000, Currency: "EUR"}},
+		{name: "one decimal", value: "0.5", currency: "USD", want: Money{Amount: 50, Currency: "USD"}},
+		{name: "default currency", value: "1.25", currency: "", want: Money{Amount: 125, Currency: "USD"}},
+		{name: "lower case currency", value: "1.25", currency: "gbp", want: Money{Amount: 125, Currency: "GBP"}},
+		{name: "inline currency", value: "19.99 EUR", currency: "USD", want: Money{Amount: 1999, Currency: "EUR"}},
+		{name: "surrounding space", value: " 3.10 ", currency: "USD", want: Money{Amount: 310, Currency: "USD"}},
+		{name: "zero", value: "0", currency: "USD", want: Money{Amount: 0, Currency: "USD"}},
+		{name: "negative", value: "-4.05", currency: "USD", want: Money{Amount: -405, Currency: "USD"}},
+		{name: "zero decimal currency", value: "1500", currency: "JPY", want: Money{Amount: 1500, Currency: "JPY"}},
+		{name: "zero decimal currency with zero fraction", value: "1500.00", currency: "JPY", want: Money{Amount: 1500, Currency: "JPY"}},
+		{name: "zero decimal currency with fraction", value: "1500.5", currency: "JPY", wantErr: true},
+		{name: "too many decimals", value: "19.999", currency: "USD", wantErr: true},
+		{name: "largest amount", value: "92233720368547758.07", currency: "USD", want: Money{Amount: math.MaxInt64, Currency: "USD"}},
+		{name: "out of range", value: "92233720368547758.08", currency: "USD", wantErr: true},
+		{name: "negative out of range", value: "-92233720368547758.09", currency: "USD", wantErr: true},
+		{name: "not a number", value: "abc", currency: "USD", wantErr: true},
+		{name: "empty", value: "", currency: "USD", wantErr: true},
+		{name: "invalid currency", value: "1.00", currency: "US", wantErr: true},
+		{name: "invalid inline currency", value: "1.00 E1R", currency: "USD", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseMoney(tt.value, tt.currency)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseMoney(%q, %q) = %v, want error", tt.value, tt.currency, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseMoney(%q, %q) returned error: %v", tt.value, tt.currency, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseMoney(%q, %q) = %+v, want %+v", tt.value, tt.currency, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoneyUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    Money
+		wantErr bool
+	}{
+		{name: "object", data: `{"amount": 1999, "currency": "EUR"}`, want: Money{Amount: 1999, Currency: "EUR"}},
+		{name: "object lower case currency", data: `{"amount": 5, "currency": "eur"}`, want: Money{Amount: 5, Currency: "EUR"}},
+		{name: "object without currency", data: `{"amount": 1999}`, want: Money{Amount: 1999, Currency: "USD"}},
+		{name: "object negative", data: `{"amount": -250, "currency": "USD"}`, want: Money{Amount: -250, Currency: "USD"}},
+		{name: "object zero decimal currency", data: `{"amount": 1500, "currency": "JPY"}`, want: Money{Amount: 1500, Currency: "JPY"}},
+		{name: "object fractional amount", data: `{"amount": 19.99, "currency": "USD"}`, wantErr: true},
+		{name: "object out of range", data: `{"amount": 9223372036854775808, "currency": "USD"}`, wantErr: true},
+		{name: "object invalid currency", data: `{"amount": 1, "currency": "DOLLARS"}`, wantErr: true},
+		{name: "legacy float", data: `19.99`, want: Money{Amount: 1999, Currency: "USD"}},
+		{name: "legacy float stays exact", data: `0.29`, want: Money{Amount: 29, Currency: "USD"}},
+		{name: "legacy integer", data: `20`, want: Money{Amount: 2000, Currency: "USD"}},
+		{name: "legacy negative float", data: `-1.5`, want: Money{Amount: -150, Currency: "USD"}},
+		{name: "legacy float with too many decimals", data: `19.999`, wantErr: true},
+		{name: "legacy float out of range", data: `1e20`, wantErr: true},
+		{name: "legacy string", data: `"19.99"`, want: Money{Amount: 1999, Currency: "USD"}},
+		{name: "legacy string with currency", data: `"1500 JPY"`, want: Money{Amount: 1500, Currency: "JPY"}},
+		{name: "legacy string with too many decimals", data: `"1500.5 JPY"`, wantErr: true},
+		{name: "legacy string not a number", data: `"free"`, wantErr: true},
+		{name: "null", data: `null`, want: Money{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Money
+			err := json.Unmarshal([]byte(tt.data), &got)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Unmarshal(%s) = %+v, want error", tt.data, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unmarshal(%s) returned error: %v", tt.data, err)
+			}
+			if got != tt.want {
+				t.Errorf("Unmarshal(%s) = %+v, want %+v", tt.data, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoneyDecimal(t *testing.T) {
+	tests := []struct {
+		money Money
+		want  string
+	}{
+		{money: Money{Amount: 1999, Currency: "USD"}, want: "19.99"},
+		{money: Money{Amount: 5, Currency: "USD"}, want: "0.05"},
+		{money: Money{Amount: 2000, Currency: "EUR"}, want: "20.00"},
+		{money: Money{Amount: 0, Currency: "USD"}, want: "0.00"},
+		{money: Money{Amount: -405, Currency: "USD"}, want: "-4.05"},
+		{money: Money{Amount: -5, Currency: "USD"}, want: "-0.05"},
+		{money: Money{Amount: 1500, Currency: "JPY"}, want: "1500"},
+		{money: Money{Amount: -1500, Currency: "JPY"}, want: "-1500"},
+		{money: Money{Amount: math.MaxInt64, Currency: "USD"}, want: "92233720368547758.07"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.money.Decimal(); got != tt.want {
+			t.Errorf("%+v.Decimal() = %q, want %q", tt.money, got, tt.want)
+		}
+
+		// Decimal is what gets stored, so it must parse back to the same value
+		parsed, err := ParseMoney(tt.money.Decimal(), tt.money.Currency)
+		if err != nil {
+			t.Errorf("ParseMoney(%q) returned error: %v", tt.money.Decimal(), err)
+		} else if parsed != tt.money {
+			t.Errorf("ParseMoney(%q) = %+v, want %+v", tt.money.Decimal(), parsed, tt.money)
+		}
+	}
+}
+
+func TestMoneyMul(t *testing.T) {
+	tests := []struct {
+		name     string
+		money    Money
+		quantity int
+		want     Money
+		wantErr  bool
+	}{
+		{name: "quantity", money: Money{Amount: 1999, Currency: "USD"}, quantity: 3, want: Money{Amount: 5997, Currency: "USD"}},
+		{name: "zero quantity", money: Money{Amount: 1999, Currency: "USD"}, quantity: 0, want: Money{Amount: 0, Currency: "USD"}},
+		{name: "negative amount", money: Money{Amount: -100, Currency: "USD"}, quantity: 2, want: Money{Amount: -200, Currency: "USD"}},
+		{name: "largest result", money: Money{Amount: math.MaxInt64, Currency: "USD"}, quantity: 1, want: Money{Amount: math.MaxInt64, Currency: "USD"}},
+		{name: "overflow", money: Money{Amount: math.MaxInt64 / 2, Currency: "USD"}, quantity: 3, wantErr: true},
+		{name: "negative overflow", money: Money{Amount: math.MinInt64 / 2, Currency: "USD"}, quantity: 3, wantErr: true},
+		{name: "negating the minimum", money: Money{Amount: math.MinInt64, Currency: "USD"}, quantity: -1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.money.Mul(tt.quantity)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("%+v.Mul(%d) = %+v, want error", tt.money, tt.quantity, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("%+v.Mul(%d) returned error: %v", tt.money, tt.quantity, err)
+			}
+			if got != tt.want {
+				t.Errorf("%+v.Mul(%d) = %+v, want %+v", tt.money, tt.quantity, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoneyAdd(t *testing.T) {
+	tests := []struct {
+		name    string
+		a, b    Money
+		want    Money
+		wantErr bool
+	}{
+		{name: "same currency", a: Money{Amount: 100, Currency: "USD"}, b: Money{Amount: 250, Currency: "USD"}, want: Money{Amount: 350, Currency: "USD"}},
+		{name: "from zero value", a: Money{}, b: Money{Amount: 250, Currency: "EUR"}, want: Money{Amount: 250, Currency: "EUR"}},
+		{name: "mixed currencies", a: Money{Amount: 100, Currency: "USD"}, b: Money{Amount: 100, Currency: "EUR"}, wantErr: true},
+		{name: "overflow", a: Money{Amount: math.MaxInt64, Currency: "USD"}, b: Money{Amount: 1, Currency: "USD"}, wantErr: true},
+		{name: "negative overflow", a: Money{Amount: math.MinInt64, Currency: "USD"}, b: Money{Amount: -1, Currency: "USD"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.a.Add(tt.b)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("%+v.Add(%+v) = %+v, want error", tt.a, tt.b, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("%+v.Add(%+v) returned error: %v", tt.a, tt.b, err)
+			}
+			if got != tt.want {
+				t.Errorf("%+v.Add(%+v) = %+v, want %+v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package models
+
+import "fmt"
+
+// SaleItem is what a buyer gets for a product line: the product itself, or
+// one of its variants
+type SaleItem struct {
+	VariantID int
+	SKU       string
+	UnitPrice Money
+	Stock     int
+}
+
+// SaleItem resolves a product line. A variantID of 0 buys the product
+// itself, which is only possible for products without variants; a variant
+// sells at its price override, or at the product price without one.
+func (p *ProductData) SaleItem(variantID int) (SaleItem, error) {
+	if variantID == 0 {
+		if len(p.Variants) > 0 {
+			return SaleItem{}, fmt.Errorf("%s must be bought as one of its variants", p.Name)
+		}
+		return SaleItem{UnitPrice: p.Price, Stock: p.Stock}, nil
+	}
+
+	for _, variant := range p.Variants {
+		if variant.ID != variantID {
+			continue
+		}
+		item := SaleItem{VariantID: variant.ID, SKU: variant.SKU, UnitPrice: p.Price, Stock: variant.Stock}
+		if variant.Price != nil {
+			item.UnitPrice = *variant.Price
+		}
+		return item, nil
+	}
+	return SaleItem{}, fmt.Errorf("%s has no variant %d", p.Name, variantID)
+}
@@ -0,0 +1,51 @@
+package models
+
+import "testing"
+
+func TestProductDataSaleItem(t *testing.T) {
+	override := Money{Amount: 2500, Currency: "EUR"}
+	simple := ProductData{ID: 1, Name: "Mug", Price: Money{Amount: 1000, Currency: "EUR"}, Stock: 7}
+	shirt := ProductData{
+		ID:    2,
+		Name:  "Shirt",
+		Price: Money{Amount: 2000, Currency: "EUR"},
+		Stock: 0,
+		Variants: []ProductVariantData{
+			{ID: 10, ProductID: 2, SKU: "SHIRT-S", Stock: 3},
+			{ID: 11, ProductID: 2, SKU: "SHIRT-XL", Price: &override, Stock: 1},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		product   ProductData
+		variantID int
+		want      SaleItem
+		wantErr   bool
+	}{
+		{name: "product without variants", product: simple, want: SaleItem{UnitPrice: simple.Price, Stock: 7}},
+		{name: "variant at product price", product: shirt, variantID: 10, want: SaleItem{VariantID: 10, SKU: "SHIRT-S", UnitPrice: shirt.Price, Stock: 3}},
+		{name: "variant with price override", product: shirt, variantID: 11, want: SaleItem{VariantID: 11, SKU: "SHIRT-XL", UnitPrice: override, Stock: 1}},
+		{name: "product with variants", product: shirt, wantErr: true},
+		{name: "unknown variant", product: shirt, variantID: 12, wantErr: true},
+		{name: "variant of product without variants", product: simple, variantID: 10, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.product.SaleItem(tt.variantID)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("SaleItem(%d) = %+v, want error", tt.variantID, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SaleItem(%d) returned error: %v", tt.variantID, err)
+			}
+			if got != tt.want {
+				t.Errorf("SaleItem(%d) = %+v, want %+v", tt.variantID, got, tt.want)
+			}
+		})
+	}
+}
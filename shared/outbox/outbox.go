@@ -0,0 +1,157 @@
+// Package outbox implements the transactional outbox. Services record events
+// in the same transaction as the change they describe and a Relay publishes
+// them to Kafka afterwards, so an event is sent if and only if its change
+// committed.
+package outbox
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Event is an event waiting in an outbox table
+type Event struct {
+	ID          int64
+	EventID     string
+	AggregateID string
+	EventType   string
+	Payload     string
+	CreatedAt   time.Time
+}
+
+// Outbox is a service's outbox table. lockKey is the advisory lock held
+// while the table is relayed, taken from the shared locks registry.
+type Outbox struct {
+	db      *sql.DB
+	table   string
+	lockKey int64
+}
+
+func New(db *sql.DB, table string, lockKey int64) *Outbox {
+	return &Outbox{
+		db:      db,
+		table:   table,
+		lockKey: lockKey,
+	}
+}
+
+// Table returns the name of the outbox table
+func (o *Outbox) Table() string {
+	return o.table
+}
+
+func (o *Outbox) CreateTableIfNotExists() error {
+	query := fmt.Sprintf(`
+	CREATE TABLE IF NOT EXISTS %[1]s (
+		id BIGSERIAL PRIMARY KEY,
+		event_id UUID NOT NULL DEFAULT gen_random_uuid(),
+		aggregate_id VARCHAR(255) NOT NULL,
+		event_type VARCHAR(100) NOT NULL,
+		payload TEXT NOT NULL,
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		published_at TIMESTAMP
+	);
+	CREATE INDEX IF NOT EXISTS idx_%[1]s_unpublished
+		ON %[1]s (id) WHERE published_at IS NULL`, o.table)
+
+	if _, err := o.db.Exec(query); err != nil {
+		return fmt.Errorf("failed to create %s table: %w", o.table, err)
+	}
+
+	return nil
+}
+
+// Insert records an event inside the caller's transaction. payload is
+// serialized to JSON.
+func (o *Outbox) Insert(tx *sql.Tx, eventType, aggregateID string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to serialize %s event: %w", eventType, err)
+	}
+
+	query := fmt.Sprintf(`
+	INSERT INTO %s (aggregate_id, event_type, payload, created_at)
+	VALUES ($1, $2, $3, $4)`, o.table)
+
+	if _, err := tx.Exec(query, aggregateID, eventType, string(data), time.Now()); err != nil {
+		return fmt.Errorf("failed to record %s event: %w", eventType, err)
+	}
+
+	return nil
+}
+
+// Publish hands the oldest unpublished events to publish and marks them
+// published once it succeeds. It returns the number of events relayed, or 0
+// when another replica currently holds the relay lock.
+func (o *Outbox) Publish(limit int, publish func([]*Event) error) (int, error) {
+	tx, err := o.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Only one replica relays at a time, which preserves per-aggregate order
+	var locked bool
+	if err := tx.QueryRow(`SELECT pg_try_advisory_xact_lock($1)`, o.lockKey).Scan(&locked); err != nil {
+		return 0, fmt.Errorf("failed to acquire outbox lock: %w", err)
+	}
+	if !locked {
+		return 0, nil
+	}
+
+	query := fmt.Sprintf(`
+	SELECT id, event_id, aggregate_id, event_type, payload, created_at
+	FROM %s
+	WHERE published_at IS NULL
+	ORDER BY id
+	LIMIT $1`, o.table)
+
+	rows, err := tx.Query(query, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load outbox events: %w", err)
+	}
+
+	var events []*Event
+	var ids []int64
+	for rows.Next() {
+		var event Event
+		if err := rows.Scan(
+			&event.ID, &event.EventID, &event.AggregateID,
+			&event.EventType, &event.Payload, &event.CreatedAt,
+		); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan outbox event: %w", err)
+		}
+		events = append(events, &event)
+		ids = append(ids, event.ID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to load outbox events: %w", err)
+	}
+
+	if len(events) == 0 {
+		return 0, tx.Commit()
+	}
+
+	if err := publish(events); err != nil {
+		return 0, err
+	}
+
+	if _, err := tx.Exec(
+		fmt.Sprintf(`UPDATE %s SET published_at = $1 WHERE id = ANY($2)`, o.table),
+		time.Now(), pq.Array(ids),
+	); err != nil {
+		return 0, fmt.Errorf("failed to mark outbox events published: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit outbox batch: %w", err)
+	}
+
+	return len(events), nil
+}
@@ -0,0 +1,99 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	sharedModels "github.com/lucas/gokafka/shared/models"
+	"github.com/lucas/gokafka/shared/utils"
+	"github.com/segmentio/kafka-go"
+)
+
+// Relay constants
+const (
+	DefaultRelayInterval  = time.Second
+	DefaultRelayBatchSize = 100
+)
+
+// Relay publishes an outbox to a Kafka topic. Events are keyed by aggregate
+// ID so every event of an aggregate lands on the same partition, in order.
+// Delivery is at-least-once: a crash between publishing and marking a batch
+// published causes it to be sent again.
+type Relay struct {
+	outbox    *Outbox
+	writer    *kafka.Writer
+	topic     string
+	interval  time.Duration
+	batchSize int
+}
+
+func NewRelay(outbox *Outbox, topic string) *Relay {
+	broker := utils.GetEnvOrDefault("KAFKA_BROKERS", "localhost:9092")
+
+	return &Relay{
+		outbox: outbox,
+		writer: kafka.NewWriter(kafka.WriterConfig{
+			Brokers:      []string{broker},
+			Topic:        topic,
+			Balancer:     &kafka.Hash{},
+			RequiredAcks: int(kafka.RequireAll),
+		}),
+		topic:     topic,
+		interval:  DefaultRelayInterval,
+		batchSize: DefaultRelayBatchSize,
+	}
+}
+
+// Run polls the outbox until the process exits
+func (r *Relay) Run() {
+	log.Printf("Outbox relay publishing %s to %s", r.outbox.Table(), r.topic)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		// Drain full batches without waiting for the next tick
+		for {
+			published, err := r.outbox.Publish(r.batchSize, r.publish)
+			if err != nil {
+				log.Printf("outbox relay error: %v", err)
+				break
+			}
+			if published < r.batchSize {
+				break
+			}
+		}
+	}
+}
+
+// publish writes a batch of outbox events to Kafka
+func (r *Relay) publish(events []*Event) error {
+	messages := make([]kafka.Message, 0, len(events))
+	for _, event := range events {
+		value, err := json.Marshal(sharedModels.Event{
+			ID:          event.EventID,
+			Type:        event.EventType,
+			AggregateID: event.AggregateID,
+			OccurredAt:  event.CreatedAt.UTC().Format(time.RFC3339Nano),
+			Payload:     event.Payload,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to serialize event %s: %w", event.EventID, err)
+		}
+
+		messages = append(messages, kafka.Message{
+			Key:   []byte(event.AggregateID),
+			Value: value,
+		})
+	}
+
+	if err := r.writer.WriteMessages(context.Background(), messages...); err != nil {
+		return fmt.Errorf("failed to publish outbox events: %w", err)
+	}
+
+	log.Printf("published %d events to %s", len(events), r.topic)
+	return nil
+}